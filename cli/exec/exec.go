@@ -186,6 +186,26 @@ func execWithAxis(c *cli.Context, file, repoPath string, axis matrix.Axis) error
 		return err
 	}
 
+	if c.Bool("emit-yaml") {
+		emitted, err := yaml.EmitString(conf)
+		if err != nil {
+			return err
+		}
+		fmt.Print(emitted)
+		return nil
+	}
+
+	stepSelector := make(map[string]string)
+	for _, label := range c.StringSlice("step-selector") {
+		key, value, _ := strings.Cut(label, "=")
+		stepSelector[key] = value
+	}
+
+	var secretFallback func(name string) (string, bool)
+	if c.Bool("secrets-from-env") {
+		secretFallback = secretFromEnv
+	}
+
 	// compiles the yaml file
 	compiled, err := compiler.New(
 		compiler.WithEscalated(
@@ -217,12 +237,18 @@ func execWithAxis(c *cli.Context, file, repoPath string, axis matrix.Axis) error
 		),
 		compiler.WithMetadata(metadata),
 		compiler.WithSecret(secrets...),
+		compiler.WithSecretFallback(secretFallback),
 		compiler.WithEnviron(pipelineEnv),
+		compiler.WithStepSelector(stepSelector),
 	).Compile(conf)
 	if err != nil {
 		return err
 	}
 
+	if stepName := c.String("explain-env"); stepName != "" {
+		return explainEnv(compiled, stepName, metadata.Environ(), pipelineEnv)
+	}
+
 	backendCtx := context.WithValue(c.Context, backendTypes.CliContext, c)
 	backends := []backendTypes.Backend{
 		kubernetes.New(),
@@ -255,6 +281,27 @@ func execWithAxis(c *cli.Context, file, repoPath string, axis matrix.Axis) error
 	).Run(c.Context)
 }
 
+// explainEnv prints the environment of a compiled step with the source of each value.
+func explainEnv(compiled *backendTypes.Config, stepName string, metadataEnv, pipelineEnv map[string]string) error {
+	for _, stage := range compiled.Stages {
+		for _, step := range stage.Steps {
+			if step.Name != stepName {
+				continue
+			}
+
+			// same order as the environment is passed to the compiler
+			for _, env := range compiler.ExplainEnv(step,
+				compiler.EnvLayer{Source: compiler.EnvSourceMetadata, Env: metadataEnv},
+				compiler.EnvLayer{Source: compiler.EnvSourceGlobal, Env: pipelineEnv},
+			) {
+				fmt.Printf("%s=%s (%s)\n", env.Key, env.Value, env.Source)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("step '%s' not found", stepName)
+}
+
 // convertPathForWindows converts a path to use slash separators
 // for Windows. If the path is a Windows volume name like C:, it
 // converts it to an absolute root path starting with slash (e.g.
@@ -274,6 +321,15 @@ func convertPathForWindows(path string) string {
 	return filepath.ToSlash(path)
 }
 
+// secretFromEnv looks up a secret in the environment of the host, secret names are lower case
+// while environment variables mostly are upper case so both are tried.
+func secretFromEnv(name string) (string, bool) {
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true
+	}
+	return os.LookupEnv(strings.ToUpper(name))
+}
+
 const maxLogLineLength = 1024 * 1024 // 1mb
 var defaultLogger = pipeline.Logger(func(step *backendTypes.Step, rc io.Reader) error {
 	logWriter := NewLineWriter(step.Name, step.UUID)
@@ -68,6 +68,22 @@ var flags = []cli.Flag{
 		Usage:   "backend engine to run pipelines on",
 		Value:   "auto-detect",
 	},
+	&cli.BoolFlag{
+		Name:  "emit-yaml",
+		Usage: "print the workflow after substitution as normalized yaml instead of running the pipeline",
+	},
+	&cli.StringSliceFlag{
+		Name:  "step-selector",
+		Usage: "only run steps with the given labels and skip all others, e.g. speed=fast",
+	},
+	&cli.BoolFlag{
+		Name:  "secrets-from-env",
+		Usage: "use environment variables of the host with the same name for secrets which are not set",
+	},
+	&cli.StringFlag{
+		Name:  "explain-env",
+		Usage: "print the environment of the given step and where each value comes from instead of running the pipeline",
+	},
 
 	//
 	// backend options for pipeline compiler
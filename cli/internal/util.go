@@ -149,6 +149,35 @@ func ParseRepo(client woodpecker.Client, str string) (repoID int64, err error) {
 	return strconv.ParseInt(str, 10, 64)
 }
 
+// ParseStep parses the step id from a string, a step name is looked up in the steps of the pipeline.
+func ParseStep(client woodpecker.Client, repoID, number int64, stepIDOrName string) (stepID int64, err error) {
+	if stepID, err := strconv.ParseInt(stepIDOrName, 10, 64); err == nil {
+		return stepID, nil
+	}
+
+	pipeline, err := client.Pipeline(repoID, number)
+	if err != nil {
+		return 0, err
+	}
+
+	var found *woodpecker.Step
+	for _, workflow := range pipeline.Workflows {
+		for _, step := range workflow.Children {
+			if step.Name != stepIDOrName {
+				continue
+			}
+			if found != nil {
+				return 0, fmt.Errorf("step name '%s' is ambiguous in pipeline %d, use the step id instead", stepIDOrName, number)
+			}
+			found = step
+		}
+	}
+	if found == nil {
+		return 0, fmt.Errorf("no step with name '%s' found in pipeline %d", stepIDOrName, number)
+	}
+	return found.ID, nil
+}
+
 // ParseKeyPair parses a key=value pair.
 func ParseKeyPair(p []string) map[string]string {
 	params := map[string]string{}
@@ -18,6 +18,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"go.woodpecker-ci.org/woodpecker/v2/woodpecker-go/woodpecker"
+	"go.woodpecker-ci.org/woodpecker/v2/woodpecker-go/woodpecker/mocks"
 )
 
 func TestParseKeyPair(t *testing.T) {
@@ -31,3 +34,27 @@ func TestParseKeyPair(t *testing.T) {
 	_, exists = p["INVALID"]
 	assert.False(t, exists, "keys without an equal sign suffix are invalid")
 }
+
+func TestParseStep(t *testing.T) {
+	mockClient := mocks.NewClient(t)
+	mockClient.On("Pipeline", int64(1), int64(42)).Return(&woodpecker.Pipeline{
+		Workflows: []*woodpecker.Workflow{
+			{Children: []*woodpecker.Step{{ID: 10, Name: "clone"}, {ID: 11, Name: "build"}}},
+			{Children: []*woodpecker.Step{{ID: 12, Name: "clone"}, {ID: 13, Name: "deploy"}}},
+		},
+	}, nil)
+
+	stepID, err := ParseStep(mockClient, 1, 42, "7")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 7, stepID)
+
+	stepID, err = ParseStep(mockClient, 1, 42, "build")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 11, stepID)
+
+	_, err = ParseStep(mockClient, 1, 42, "clone")
+	assert.EqualError(t, err, "step name 'clone' is ambiguous in pipeline 42, use the step id instead")
+
+	_, err = ParseStep(mockClient, 1, 42, "test")
+	assert.EqualError(t, err, "no step with name 'test' found in pipeline 42")
+}
@@ -16,18 +16,43 @@ package log
 
 import (
 	"fmt"
+	"io"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/urfave/cli/v2"
 
 	"go.woodpecker-ci.org/woodpecker/v2/cli/internal"
+	"go.woodpecker-ci.org/woodpecker/v2/cli/setup/ui"
+	"go.woodpecker-ci.org/woodpecker/v2/shared/utils"
+	"go.woodpecker-ci.org/woodpecker/v2/woodpecker-go/woodpecker"
 )
 
 var logPurgeCmd = &cli.Command{
 	Name:      "purge",
 	Usage:     "purge a log",
-	ArgsUsage: "<repo-id|repo-full-name> <pipeline> [step]",
+	ArgsUsage: "<repo-id|repo-full-name> [pipeline] [step-id|step-name]",
 	Action:    logPurge,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "older-than",
+			Usage: "purge the logs of all finished pipelines created before the given age instead of a single pipeline, e.g. 30d or 12h",
+		},
+		&cli.BoolFlag{
+			Name:  "all",
+			Usage: "purge the logs of all finished pipelines instead of a single pipeline",
+		},
+		&cli.BoolFlag{
+			Name:    "force",
+			Aliases: []string{"yes"},
+			Usage:   "don't ask for confirmation before purging the logs of all pipelines",
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "only list the pipelines whose logs would be purged",
+		},
+	},
 }
 
 func logPurge(c *cli.Context) (err error) {
@@ -40,16 +65,37 @@ func logPurge(c *cli.Context) (err error) {
 	if err != nil {
 		return err
 	}
+
+	if olderThan := c.String("older-than"); olderThan != "" {
+		age, err := parseAge(olderThan)
+		if err != nil {
+			return err
+		}
+		return purgeOldLogs(c.App.Writer, client, repoID, time.Now().Add(-age), c.Bool("dry-run"))
+	}
+
+	if c.Bool("all") {
+		if !c.Bool("force") && !c.Bool("dry-run") {
+			confirmed, err := ui.Confirm(fmt.Sprintf("Do you really want to purge the logs of all pipelines of %s?", repoIDOrFullName))
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				return fmt.Errorf("purging the logs was aborted")
+			}
+		}
+		return purgeOldLogs(c.App.Writer, client, repoID, time.Time{}, c.Bool("dry-run"))
+	}
+
 	number, err := strconv.ParseInt(c.Args().Get(1), 10, 64)
 	if err != nil {
 		return err
 	}
 
 	stepArg := c.Args().Get(2) //nolint:mnd
-	// TODO: Add lookup by name: stepID, err := internal.ParseStep(client, repoID, stepIDOrName)
 	var stepID int64
 	if len(stepArg) != 0 {
-		stepID, err = strconv.ParseInt(stepArg, 10, 64)
+		stepID, err = internal.ParseStep(client, repoID, number, stepArg)
 		if err != nil {
 			return err
 		}
@@ -67,3 +113,57 @@ func logPurge(c *cli.Context) (err error) {
 	fmt.Printf("Purging logs for pipeline %s#%d\n", repoIDOrFullName, number)
 	return nil
 }
+
+// purgeOldLogs purges the logs of the finished pipelines of the repo created before the cutoff,
+// or of all of them if the cutoff is zero, and reports the progress.
+func purgeOldLogs(w io.Writer, client woodpecker.Client, repoID int64, cutoff time.Time, dryRun bool) error {
+	// the server returns the pipelines page by page
+	pipelines, err := utils.Paginate(func(page int) ([]*woodpecker.Pipeline, error) {
+		return client.PipelineListOpts(repoID, woodpecker.PipelineListOptions{Page: page, Before: cutoff})
+	})
+	if err != nil {
+		return err
+	}
+
+	var purge []*woodpecker.Pipeline
+	for _, pipeline := range pipelines {
+		// logs of running pipelines can't be purged
+		if pipeline.Finished != 0 {
+			purge = append(purge, pipeline)
+		}
+	}
+
+	for i, pipeline := range purge {
+		if dryRun {
+			fmt.Fprintf(w, "Would purge logs for pipeline #%d\n", pipeline.Number)
+			continue
+		}
+		if err := client.LogsPurge(repoID, pipeline.Number); err != nil {
+			return fmt.Errorf("could not purge logs for pipeline #%d: %w", pipeline.Number, err)
+		}
+		fmt.Fprintf(w, "Purged logs for pipeline #%d (%d/%d)\n", pipeline.Number, i+1, len(purge))
+	}
+
+	if dryRun {
+		fmt.Fprintf(w, "Would purge logs for %d pipelines\n", len(purge))
+	} else {
+		fmt.Fprintf(w, "Purged logs for %d pipelines\n", len(purge))
+	}
+	return nil
+}
+
+// parseAge parses a duration which additionally supports days, e.g. "30d".
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age '%s'", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	age, err := time.ParseDuration(s)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age '%s'", s)
+	}
+	return age, nil
+}
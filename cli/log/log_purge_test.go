@@ -0,0 +1,100 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.woodpecker-ci.org/woodpecker/v2/woodpecker-go/woodpecker"
+	"go.woodpecker-ci.org/woodpecker/v2/woodpecker-go/woodpecker/mocks"
+)
+
+func TestPurgeOldLogs(t *testing.T) {
+	cutoff := time.Unix(300, 0)
+	// the server filters by the cutoff and returns the pipelines page by page
+	firstPage := []*woodpecker.Pipeline{
+		{Number: 4, Created: 250},
+		{Number: 3, Created: 240, Finished: 260},
+	}
+	secondPage := []*woodpecker.Pipeline{
+		{Number: 2, Created: 200, Finished: 210},
+		{Number: 1, Created: 100, Finished: 110},
+	}
+	onPages := func(mockClient *mocks.Client) {
+		mockClient.On("PipelineListOpts", int64(1), woodpecker.PipelineListOptions{Page: 1, Before: cutoff}).Return(firstPage, nil).Once()
+		mockClient.On("PipelineListOpts", int64(1), woodpecker.PipelineListOptions{Page: 2, Before: cutoff}).Return(secondPage, nil).Once()
+		mockClient.On("PipelineListOpts", int64(1), woodpecker.PipelineListOptions{Page: 3, Before: cutoff}).Return([]*woodpecker.Pipeline{}, nil).Once()
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		mockClient := mocks.NewClient(t)
+		onPages(mockClient)
+
+		var out bytes.Buffer
+		assert.NoError(t, purgeOldLogs(&out, mockClient, 1, cutoff, true))
+		assert.Equal(t, "Would purge logs for pipeline #3\nWould purge logs for pipeline #2\nWould purge logs for pipeline #1\nWould purge logs for 3 pipelines\n", out.String())
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		mockClient := mocks.NewClient(t)
+		onPages(mockClient)
+		mockClient.On("LogsPurge", int64(1), int64(3)).Return(nil).Once()
+		mockClient.On("LogsPurge", int64(1), int64(2)).Return(nil).Once()
+		mockClient.On("LogsPurge", int64(1), int64(1)).Return(nil).Once()
+
+		var out bytes.Buffer
+		assert.NoError(t, purgeOldLogs(&out, mockClient, 1, cutoff, false))
+		assert.Equal(t, "Purged logs for pipeline #3 (1/3)\nPurged logs for pipeline #2 (2/3)\nPurged logs for pipeline #1 (3/3)\nPurged logs for 3 pipelines\n", out.String())
+	})
+
+	t.Run("all", func(t *testing.T) {
+		// without cutoff all pages are requested without time filter
+		mockClient := mocks.NewClient(t)
+		mockClient.On("PipelineListOpts", int64(1), woodpecker.PipelineListOptions{Page: 1}).Return([]*woodpecker.Pipeline{
+			{Number: 4, Created: 400},
+			{Number: 3, Created: 300, Finished: 310},
+		}, nil).Once()
+		mockClient.On("PipelineListOpts", int64(1), woodpecker.PipelineListOptions{Page: 2}).Return([]*woodpecker.Pipeline{
+			{Number: 2, Created: 200, Finished: 210},
+			{Number: 1, Created: 100, Finished: 110},
+		}, nil).Once()
+		mockClient.On("PipelineListOpts", int64(1), woodpecker.PipelineListOptions{Page: 3}).Return([]*woodpecker.Pipeline{}, nil).Once()
+		mockClient.On("LogsPurge", int64(1), int64(3)).Return(nil).Once()
+		mockClient.On("LogsPurge", int64(1), int64(2)).Return(nil).Once()
+		mockClient.On("LogsPurge", int64(1), int64(1)).Return(errors.New("forbidden")).Once()
+
+		var out bytes.Buffer
+		assert.EqualError(t, purgeOldLogs(&out, mockClient, 1, time.Time{}, false), "could not purge logs for pipeline #1: forbidden")
+		assert.Equal(t, "Purged logs for pipeline #3 (1/3)\nPurged logs for pipeline #2 (2/3)\n", out.String())
+	})
+}
+
+func TestParseAge(t *testing.T) {
+	age, err := parseAge("30d")
+	assert.NoError(t, err)
+	assert.Equal(t, 30*24*time.Hour, age)
+
+	age, err = parseAge("12h")
+	assert.NoError(t, err)
+	assert.Equal(t, 12*time.Hour, age)
+
+	_, err = parseAge("a month")
+	assert.EqualError(t, err, "invalid age 'a month'")
+}
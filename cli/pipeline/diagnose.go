@@ -0,0 +1,138 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	pipeline_errors "go.woodpecker-ci.org/woodpecker/v2/pipeline/errors"
+	forge_types "go.woodpecker-ci.org/woodpecker/v2/server/forge/types"
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+	"go.woodpecker-ci.org/woodpecker/v2/server/pipeline/stepbuilder"
+)
+
+var pipelineDiagnoseCmd = &cli.Command{
+	Name:      "diagnose",
+	Usage:     "explain which workflow files run for a pipeline and why the others are skipped",
+	ArgsUsage: "<path/to/workflow.yaml>...",
+	Action:    pipelineDiagnose,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "event",
+			Usage: "pipeline event",
+			Value: "push",
+		},
+		&cli.StringFlag{
+			Name:  "branch",
+			Usage: "branch of the commit",
+			Value: "main",
+		},
+		&cli.StringFlag{
+			Name:  "tag",
+			Usage: "tag of the commit, used by tag events",
+		},
+		&cli.StringFlag{
+			Name:  "target",
+			Usage: "deployment target, used by deployment events",
+		},
+		&cli.StringFlag{
+			Name:  "cron",
+			Usage: "name of the cron job, used by cron events",
+		},
+		&cli.StringFlag{
+			Name:  "repo",
+			Usage: "full name of the repository like owner/name",
+		},
+		&cli.StringSliceFlag{
+			Name:  "changed-file",
+			Usage: "file changed by the commit, used by the path filter",
+		},
+		&cli.StringFlag{
+			Name:  "commit-message",
+			Usage: "message of the commit",
+		},
+	},
+}
+
+func pipelineDiagnose(c *cli.Context) error {
+	if c.NArg() == 0 {
+		return fmt.Errorf("missing workflow file")
+	}
+
+	repo := &model.Repo{FullName: c.String("repo")}
+	pipeline := &model.Pipeline{
+		Event:        model.WebhookEvent(c.String("event")),
+		Branch:       c.String("branch"),
+		Ref:          "refs/heads/" + c.String("branch"),
+		Message:      c.String("commit-message"),
+		ChangedFiles: c.StringSlice("changed-file"),
+		Deploy:       c.String("target"),
+		Sender:       c.String("cron"),
+	}
+	if tag := c.String("tag"); tag != "" {
+		pipeline.Ref = "refs/tags/" + tag
+	}
+
+	var files []*forge_types.FileMeta
+	for _, file := range c.Args().Slice() {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		files = append(files, &forge_types.FileMeta{Name: file, Data: data})
+	}
+
+	results, err := diagnoseWorkflows(files, repo, pipeline)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		if _, err := fmt.Fprintln(c.App.Writer, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diagnoseWorkflows returns whether the workflows run for the pipeline by a dry run of the step builder,
+// like "workflow deploy skipped: branch 'feature/x' does not match [main, release/*]".
+func diagnoseWorkflows(files []*forge_types.FileMeta, repo *model.Repo, pipeline *model.Pipeline) ([]string, error) {
+	b := stepbuilder.StepBuilder{
+		Repo:   repo,
+		Curr:   pipeline,
+		Last:   &model.Pipeline{},
+		Netrc:  &model.Netrc{},
+		Yamls:  files,
+		DryRun: true,
+	}
+	items, err := b.Build()
+	if pipeline_errors.HasBlockingErrors(err) {
+		return nil, err
+	}
+
+	results := make([]string, 0, len(items))
+	for _, item := range items {
+		if item.Workflow.State == model.StatusSkipped {
+			results = append(results, fmt.Sprintf("workflow %s skipped: %s", item.Workflow.Name, strings.Join(item.SkipReasons, "; ")))
+			continue
+		}
+		results = append(results, fmt.Sprintf("workflow %s runs", item.Workflow.Name))
+	}
+	return results, nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	forge_types "go.woodpecker-ci.org/woodpecker/v2/server/forge/types"
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+)
+
+const diagnoseSample = `
+when:
+  - event: push
+    branch: [ main, release/* ]
+  - event: tag
+steps:
+  deploy:
+    image: alpine
+    commands: echo deploy
+`
+
+func TestDiagnoseWorkflows(t *testing.T) {
+	files := []*forge_types.FileMeta{
+		{Name: ".woodpecker/deploy.yaml", Data: []byte(diagnoseSample)},
+		{Name: ".woodpecker/test.yaml", Data: []byte("when:\n  event: push\nsteps:\n  test:\n    image: alpine\n    commands: echo test\n")},
+	}
+	push := func(branch string) *model.Pipeline {
+		return &model.Pipeline{Event: model.EventPush, Branch: branch, Ref: "refs/heads/" + branch}
+	}
+
+	results, err := diagnoseWorkflows(files, &model.Repo{}, push("release/1.0"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"workflow deploy runs", "workflow test runs"}, results)
+
+	results, err = diagnoseWorkflows(files, &model.Repo{}, push("feature/x"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"workflow test runs",
+		"workflow deploy skipped: constraint 1: branch 'feature/x' does not match [main, release/*]; " +
+			"constraint 2: event 'push' does not match [tag]",
+	}, results)
+
+	_, err = diagnoseWorkflows([]*forge_types.FileMeta{{Name: "broken.yaml", Data: []byte("steps: [")}}, &model.Repo{}, push("main"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/metadata"
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml"
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/matrix"
+)
+
+var pipelineExpandCmd = &cli.Command{
+	Name:      "expand",
+	Usage:     "print a workflow file after the matrix and variable substitution",
+	ArgsUsage: "<path/to/.woodpecker.yaml>",
+	Action:    pipelineExpand,
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "matrix",
+			Usage: "pin the matrix axis by key=value",
+		},
+		&cli.StringFlag{
+			Name:  "event",
+			Usage: "pipeline event used for the substitution",
+			Value: "push",
+		},
+	},
+}
+
+func pipelineExpand(c *cli.Context) error {
+	file := c.Args().First()
+	if file == "" {
+		return fmt.Errorf("missing workflow file")
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	pins := make(map[string]string)
+	for _, pin := range c.StringSlice("matrix") {
+		key, value, _ := strings.Cut(pin, "=")
+		pins[key] = value
+	}
+
+	expanded, err := expandWorkflow(filepath.Base(file), string(data), pins, c.String("event"))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(c.App.Writer, expanded)
+	return err
+}
+
+// expandWorkflow selects the matrix axis matching all pins and returns the workflow substituted
+// for it and the event. Neither secrets nor the compiler are involved, so it's safe for any input.
+func expandWorkflow(name, data string, pins map[string]string, event string) (string, error) {
+	axes, err := matrix.ParseString(data)
+	if err != nil {
+		return "", err
+	}
+	if len(axes) == 0 {
+		axes = append(axes, matrix.Axis{})
+	}
+
+	var matching []matrix.Axis
+	for _, axis := range axes {
+		if axisMatches(axis, pins) {
+			matching = append(matching, axis)
+		}
+	}
+	switch len(matching) {
+	case 0:
+		return "", fmt.Errorf("no matrix axis matches %v", pins)
+	case 1:
+	default:
+		labels := make([]string, 0, len(matching))
+		for _, axis := range matching {
+			labels = append(labels, axis.Label())
+		}
+		return "", fmt.Errorf("%d matrix axes match, pin one of them with --matrix: %s", len(matching), strings.Join(labels, "; "))
+	}
+	axis := matching[0]
+
+	m := metadata.Metadata{
+		Curr:     metadata.Pipeline{Event: event},
+		Workflow: metadata.Workflow{Name: strings.TrimSuffix(name, filepath.Ext(name)), Matrix: axis},
+	}
+	environ := m.Environ()
+	for k, v := range axis {
+		environ[k] = v
+	}
+
+	substituted, err := metadata.EnvVarSubst(data, environ)
+	if err != nil {
+		return "", err
+	}
+	parsed, err := yaml.ParseString(substituted)
+	if err != nil {
+		return "", err
+	}
+	return yaml.EmitString(parsed)
+}
+
+func axisMatches(axis matrix.Axis, pins map[string]string) bool {
+	for key, value := range pins {
+		if v, ok := axis[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const expandSample = `
+matrix:
+  GO_VERSION:
+    - "1.21"
+    - "1.22"
+  DATABASE:
+    - mysql
+    - postgres
+steps:
+  test:
+    image: golang:${GO_VERSION}
+    commands:
+      - go test -tags ${DATABASE} ./...
+      - echo ${CI_PIPELINE_EVENT} ${CI_WORKFLOW_NAME}
+services:
+  database:
+    image: ${DATABASE}
+`
+
+func TestExpandWorkflow(t *testing.T) {
+	expanded, err := expandWorkflow("test.yaml", expandSample, map[string]string{"GO_VERSION": "1.22", "DATABASE": "postgres"}, "tag")
+	assert.NoError(t, err)
+	assert.Contains(t, expanded, "image: golang:1.22\n")
+	assert.Contains(t, expanded, "go test -tags postgres ./...")
+	assert.Contains(t, expanded, "echo tag test")
+	assert.Contains(t, expanded, "image: postgres\n")
+	assert.NotContains(t, expanded, "matrix")
+
+	_, err = expandWorkflow("test.yaml", expandSample, map[string]string{"GO_VERSION": "1.22"}, "push")
+	assert.ErrorContains(t, err, "2 matrix axes match, pin one of them with --matrix")
+	assert.ErrorContains(t, err, "DATABASE=mysql, GO_VERSION=1.22")
+	assert.ErrorContains(t, err, "DATABASE=postgres, GO_VERSION=1.22")
+
+	_, err = expandWorkflow("test.yaml", expandSample, map[string]string{"GO_VERSION": "1.20"}, "push")
+	assert.ErrorContains(t, err, "no matrix axis matches")
+
+	// workflows without a matrix are expanded as they are
+	expanded, err = expandWorkflow("build.yaml", "steps:\n  build:\n    image: golang\n    commands: echo ${CI_PIPELINE_EVENT}\n", nil, "push")
+	assert.NoError(t, err)
+	assert.Contains(t, expanded, "echo push")
+}
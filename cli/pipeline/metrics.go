@@ -0,0 +1,176 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"go.woodpecker-ci.org/woodpecker/v2/cli/internal"
+	"go.woodpecker-ci.org/woodpecker/v2/shared/utils"
+	"go.woodpecker-ci.org/woodpecker/v2/woodpecker-go/woodpecker"
+)
+
+// durationBuckets are the upper bounds in seconds of the duration histograms.
+var durationBuckets = []float64{30, 60, 120, 300, 600, 1800, 3600}
+
+var pipelineMetricsCmd = &cli.Command{
+	Name:      "metrics",
+	Usage:     "export metrics of finished pipelines in the OpenMetrics format",
+	ArgsUsage: "<repo-id|repo-full-name>",
+	Action:    pipelineMetrics,
+	Flags: []cli.Flag{
+		&cli.DurationFlag{
+			Name:  "since",
+			Usage: "only include pipelines created within the duration, e.g. 24h",
+		},
+	},
+}
+
+func pipelineMetrics(c *cli.Context) error {
+	repoIDOrFullName := c.Args().First()
+	client, err := internal.NewClient(c)
+	if err != nil {
+		return err
+	}
+	repoID, err := internal.ParseRepo(client, repoIDOrFullName)
+	if err != nil {
+		return err
+	}
+
+	opt := woodpecker.PipelineListOptions{}
+	if d := c.Duration("since"); d > 0 {
+		opt.After = time.Now().Add(-d)
+	}
+
+	// the server returns the pipelines page by page
+	pipelines, err := utils.Paginate(func(page int) ([]*woodpecker.Pipeline, error) {
+		opt.Page = page
+		return client.PipelineListOpts(repoID, opt)
+	})
+	if err != nil {
+		return err
+	}
+
+	finished := make([]*woodpecker.Pipeline, 0, len(pipelines))
+	for _, pipeline := range pipelines {
+		if pipeline.Finished == 0 {
+			continue
+		}
+		// the list doesn't contain the workflows
+		pipeline, err = client.Pipeline(repoID, pipeline.Number)
+		if err != nil {
+			return err
+		}
+		finished = append(finished, pipeline)
+	}
+
+	return writeMetrics(c.App.Writer, repoIDOrFullName, finished)
+}
+
+// writeMetrics writes the status counts, the success ratio and the durations of the
+// pipelines and their workflows in the OpenMetrics text format.
+func writeMetrics(w io.Writer, repo string, pipelines []*woodpecker.Pipeline) error {
+	repoLabel := fmt.Sprintf(`repo="%s"`, escapeLabel(repo))
+
+	statuses := make(map[string]int)
+	var pipelineDurations []float64
+	workflowDurations := make(map[string][]float64)
+	for _, pipeline := range pipelines {
+		statuses[pipeline.Status]++
+		if pipeline.Started > 0 && pipeline.Finished >= pipeline.Started {
+			pipelineDurations = append(pipelineDurations, float64(pipeline.Finished-pipeline.Started))
+		}
+		for _, workflow := range pipeline.Workflows {
+			if workflow.Started > 0 && workflow.Stopped >= workflow.Started {
+				workflowDurations[workflow.Name] = append(workflowDurations[workflow.Name], float64(workflow.Stopped-workflow.Started))
+			}
+		}
+	}
+
+	var b strings.Builder
+
+	b.WriteString("# TYPE woodpecker_pipeline_runs counter\n")
+	b.WriteString("# HELP woodpecker_pipeline_runs Finished pipelines by status.\n")
+	for _, status := range sortedKeys(statuses) {
+		fmt.Fprintf(&b, "woodpecker_pipeline_runs_total{%s,status=\"%s\"} %d\n", repoLabel, escapeLabel(status), statuses[status])
+	}
+
+	b.WriteString("# TYPE woodpecker_pipeline_success_ratio gauge\n")
+	b.WriteString("# HELP woodpecker_pipeline_success_ratio Ratio of successful finished pipelines.\n")
+	ratio := 0.0
+	if len(pipelines) > 0 {
+		ratio = float64(statuses["success"]) / float64(len(pipelines))
+	}
+	fmt.Fprintf(&b, "woodpecker_pipeline_success_ratio{%s} %s\n", repoLabel, formatFloat(ratio))
+
+	b.WriteString("# TYPE woodpecker_pipeline_duration_seconds histogram\n")
+	b.WriteString("# HELP woodpecker_pipeline_duration_seconds Duration of finished pipelines.\n")
+	writeHistogram(&b, "woodpecker_pipeline_duration_seconds", repoLabel, pipelineDurations)
+
+	b.WriteString("# TYPE woodpecker_workflow_duration_seconds histogram\n")
+	b.WriteString("# HELP woodpecker_workflow_duration_seconds Duration of the workflows of finished pipelines.\n")
+	for _, name := range sortedKeys(workflowDurations) {
+		labels := fmt.Sprintf(`%s,workflow="%s"`, repoLabel, escapeLabel(name))
+		writeHistogram(&b, "woodpecker_workflow_duration_seconds", labels, workflowDurations[name])
+	}
+
+	b.WriteString("# EOF\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeHistogram(b *strings.Builder, name, labels string, values []float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	for _, bucket := range durationBuckets {
+		count := 0
+		for _, v := range values {
+			if v <= bucket {
+				count++
+			}
+		}
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, formatFloat(bucket), count)
+	}
+	fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, len(values))
+	fmt.Fprintf(b, "%s_sum{%s} %s\n", name, labels, formatFloat(sum))
+	fmt.Fprintf(b, "%s_count{%s} %d\n", name, labels, len(values))
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.woodpecker-ci.org/woodpecker/v2/woodpecker-go/woodpecker"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	pipelines := []*woodpecker.Pipeline{
+		{
+			Number: 1, Status: "success", Started: 100, Finished: 145,
+			Workflows: []*woodpecker.Workflow{
+				{Name: "build", Started: 100, Stopped: 125},
+				{Name: "test", Started: 100, Stopped: 145},
+			},
+		},
+		{
+			Number: 2, Status: "failure", Started: 200, Finished: 400,
+			Workflows: []*woodpecker.Workflow{
+				{Name: "build", Started: 200, Stopped: 400},
+			},
+		},
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, writeMetrics(&out, "org/repo", pipelines))
+	assert.Equal(t, `# TYPE woodpecker_pipeline_runs counter
+# HELP woodpecker_pipeline_runs Finished pipelines by status.
+woodpecker_pipeline_runs_total{repo="org/repo",status="failure"} 1
+woodpecker_pipeline_runs_total{repo="org/repo",status="success"} 1
+# TYPE woodpecker_pipeline_success_ratio gauge
+# HELP woodpecker_pipeline_success_ratio Ratio of successful finished pipelines.
+woodpecker_pipeline_success_ratio{repo="org/repo"} 0.5
+# TYPE woodpecker_pipeline_duration_seconds histogram
+# HELP woodpecker_pipeline_duration_seconds Duration of finished pipelines.
+woodpecker_pipeline_duration_seconds_bucket{repo="org/repo",le="30"} 0
+woodpecker_pipeline_duration_seconds_bucket{repo="org/repo",le="60"} 1
+woodpecker_pipeline_duration_seconds_bucket{repo="org/repo",le="120"} 1
+woodpecker_pipeline_duration_seconds_bucket{repo="org/repo",le="300"} 2
+woodpecker_pipeline_duration_seconds_bucket{repo="org/repo",le="600"} 2
+woodpecker_pipeline_duration_seconds_bucket{repo="org/repo",le="1800"} 2
+woodpecker_pipeline_duration_seconds_bucket{repo="org/repo",le="3600"} 2
+woodpecker_pipeline_duration_seconds_bucket{repo="org/repo",le="+Inf"} 2
+woodpecker_pipeline_duration_seconds_sum{repo="org/repo"} 245
+woodpecker_pipeline_duration_seconds_count{repo="org/repo"} 2
+# TYPE woodpecker_workflow_duration_seconds histogram
+# HELP woodpecker_workflow_duration_seconds Duration of the workflows of finished pipelines.
+woodpecker_workflow_duration_seconds_bucket{repo="org/repo",workflow="build",le="30"} 1
+woodpecker_workflow_duration_seconds_bucket{repo="org/repo",workflow="build",le="60"} 1
+woodpecker_workflow_duration_seconds_bucket{repo="org/repo",workflow="build",le="120"} 1
+woodpecker_workflow_duration_seconds_bucket{repo="org/repo",workflow="build",le="300"} 2
+woodpecker_workflow_duration_seconds_bucket{repo="org/repo",workflow="build",le="600"} 2
+woodpecker_workflow_duration_seconds_bucket{repo="org/repo",workflow="build",le="1800"} 2
+woodpecker_workflow_duration_seconds_bucket{repo="org/repo",workflow="build",le="3600"} 2
+woodpecker_workflow_duration_seconds_bucket{repo="org/repo",workflow="build",le="+Inf"} 2
+woodpecker_workflow_duration_seconds_sum{repo="org/repo",workflow="build"} 225
+woodpecker_workflow_duration_seconds_count{repo="org/repo",workflow="build"} 2
+woodpecker_workflow_duration_seconds_bucket{repo="org/repo",workflow="test",le="30"} 0
+woodpecker_workflow_duration_seconds_bucket{repo="org/repo",workflow="test",le="60"} 1
+woodpecker_workflow_duration_seconds_bucket{repo="org/repo",workflow="test",le="120"} 1
+woodpecker_workflow_duration_seconds_bucket{repo="org/repo",workflow="test",le="300"} 1
+woodpecker_workflow_duration_seconds_bucket{repo="org/repo",workflow="test",le="600"} 1
+woodpecker_workflow_duration_seconds_bucket{repo="org/repo",workflow="test",le="1800"} 1
+woodpecker_workflow_duration_seconds_bucket{repo="org/repo",workflow="test",le="3600"} 1
+woodpecker_workflow_duration_seconds_bucket{repo="org/repo",workflow="test",le="+Inf"} 1
+woodpecker_workflow_duration_seconds_sum{repo="org/repo",workflow="test"} 45
+woodpecker_workflow_duration_seconds_count{repo="org/repo",workflow="test"} 1
+# EOF
+`, out.String())
+}
@@ -43,6 +43,9 @@ var Command = &cli.Command{
 		pipelineKillCmd,
 		pipelinePsCmd,
 		pipelineCreateCmd,
+		pipelineMetricsCmd,
+		pipelineExpandCmd,
+		pipelineDiagnoseCmd,
 	},
 }
 
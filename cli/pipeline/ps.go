@@ -87,4 +87,7 @@ func pipelinePs(c *cli.Context) error {
 var tmplPipelinePs = "\x1b[33mStep #{{ .PID }} \x1b[0m" + `
 Step: {{ .Name }}
 State: {{ .State }}
+{{- if and .FailureMessage (eq .State "failure") }}
+Hint: {{ .FailureMessage }}
+{{- end }}
 `
@@ -45,6 +45,14 @@ var registryCreateCmd = &cli.Command{
 			Name:  "password",
 			Usage: "registry password",
 		},
+		&cli.StringSliceFlag{
+			Name:  "environment",
+			Usage: "registry limited to these deploy environments",
+		},
+		&cli.BoolFlag{
+			Name:  "token",
+			Usage: "the password is a short-lived token the server refreshes for each pipeline",
+		},
 	},
 }
 
@@ -67,9 +75,14 @@ func registryCreate(c *cli.Context) error {
 		return err
 	}
 	registry := &woodpecker.Registry{
-		Address:  hostname,
-		Username: username,
-		Password: password,
+		Address:      hostname,
+		Username:     username,
+		Password:     password,
+		Environments: c.StringSlice("environment"),
+	}
+	if c.Bool("token") {
+		tokenAuth := true
+		registry.TokenAuth = &tokenAuth
 	}
 	if strings.HasPrefix(registry.Password, "@") {
 		path := strings.TrimPrefix(registry.Password, "@")
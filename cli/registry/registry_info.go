@@ -36,6 +36,10 @@ var registryInfoCmd = &cli.Command{
 			Usage: "registry hostname",
 			Value: "docker.io",
 		},
+		&cli.StringSliceFlag{
+			Name:  "environment",
+			Usage: "deploy environments the registry is limited to",
+		},
 		common.FormatFlag(tmplRegistryList, true),
 	},
 }
@@ -57,7 +61,7 @@ func registryInfo(c *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	registry, err := client.Registry(repoID, hostname)
+	registry, err := client.Registry(repoID, hostname, c.StringSlice("environment")...)
 	if err != nil {
 		return err
 	}
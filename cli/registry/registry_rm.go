@@ -33,6 +33,10 @@ var registryDeleteCmd = &cli.Command{
 			Usage: "registry hostname",
 			Value: "docker.io",
 		},
+		&cli.StringSliceFlag{
+			Name:  "environment",
+			Usage: "deploy environments the registry is limited to",
+		},
 	},
 }
 
@@ -52,5 +56,5 @@ func registryDelete(c *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	return client.RegistryDelete(repoID, hostname)
+	return client.RegistryDelete(repoID, hostname, c.StringSlice("environment")...)
 }
@@ -50,6 +50,10 @@ var repoUpdateCmd = &cli.Command{
 			Name:  "config",
 			Usage: "repository configuration path (e.g. .woodpecker.yml)",
 		},
+		&cli.StringFlag{
+			Name:  "clone-image",
+			Usage: "image of the default clone step, overrides the default of the server",
+		},
 		&cli.IntFlag{
 			Name:  "pipeline-counter",
 			Usage: "repository starting pipeline number",
@@ -75,6 +79,7 @@ func repoUpdate(c *cli.Context) error {
 	var (
 		visibility      = c.String("visibility")
 		config          = c.String("config")
+		cloneImage      = c.String("clone-image")
 		timeout         = c.Duration("timeout")
 		trusted         = c.Bool("trusted")
 		gated           = c.Bool("gated")
@@ -96,6 +101,9 @@ func repoUpdate(c *cli.Context) error {
 	if c.IsSet("config") {
 		patch.Config = &config
 	}
+	if c.IsSet("clone-image") {
+		patch.CloneImage = &cloneImage
+	}
 	if c.IsSet("visibility") {
 		switch visibility {
 		case "public", "private", "internal":
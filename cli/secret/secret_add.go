@@ -53,6 +53,14 @@ var secretCreateCmd = &cli.Command{
 			Name:  "image",
 			Usage: "secret limited to these images",
 		},
+		&cli.BoolFlag{
+			Name:  "required",
+			Usage: "fail pipelines for events the secret is not available for",
+		},
+		&cli.BoolFlag{
+			Name:  "file",
+			Usage: "write the secret to a file in the step container and only pass its path",
+		},
 	},
 }
 
@@ -62,11 +70,15 @@ func secretCreate(c *cli.Context) error {
 		return err
 	}
 
+	required := c.Bool("required")
+	file := c.Bool("file")
 	secret := &woodpecker.Secret{
-		Name:   strings.ToLower(c.String("name")),
-		Value:  c.String("value"),
-		Images: c.StringSlice("image"),
-		Events: c.StringSlice("event"),
+		Name:     strings.ToLower(c.String("name")),
+		Value:    c.String("value"),
+		Images:   c.StringSlice("image"),
+		Events:   c.StringSlice("event"),
+		Required: &required,
+		File:     &file,
 	}
 	if len(secret.Events) == 0 {
 		secret.Events = defaultSecretEvents
@@ -53,6 +53,14 @@ var secretUpdateCmd = &cli.Command{
 			Name:  "image",
 			Usage: "secret limited to these images",
 		},
+		&cli.BoolFlag{
+			Name:  "required",
+			Usage: "fail pipelines for events the secret is not available for",
+		},
+		&cli.BoolFlag{
+			Name:  "file",
+			Usage: "write the secret to a file in the step container and only pass its path",
+		},
 	},
 }
 
@@ -68,6 +76,14 @@ func secretUpdate(c *cli.Context) error {
 		Images: c.StringSlice("image"),
 		Events: c.StringSlice("event"),
 	}
+	if c.IsSet("required") {
+		required := c.Bool("required")
+		secret.Required = &required
+	}
+	if c.IsSet("file") {
+		file := c.Bool("file")
+		secret.File = &file
+	}
 	if strings.HasPrefix(secret.Value, "@") {
 		path := strings.TrimPrefix(secret.Value, "@")
 		out, err := os.ReadFile(path)
@@ -1336,7 +1336,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/Secret"
+                            "$ref": "#/definitions/SecretPatch"
                         }
                     }
                 ],
@@ -2926,6 +2926,16 @@ const docTemplate = `{
                         "name": "registry",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "the environments the registry is limited to",
+                        "name": "environments",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -2967,6 +2977,16 @@ const docTemplate = `{
                         "name": "registry",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "the environments the registry is limited to",
+                        "name": "environments",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -3006,13 +3026,23 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "the environments the registry is limited to",
+                        "name": "environments",
+                        "in": "query"
+                    },
                     {
                         "description": "the attributes for the registry",
                         "name": "registryData",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/Registry"
+                            "$ref": "#/definitions/RegistryPatch"
                         }
                     }
                 ],
@@ -3272,7 +3302,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/Secret"
+                            "$ref": "#/definitions/SecretPatch"
                         }
                     }
                 ],
@@ -3464,7 +3494,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/Secret"
+                            "$ref": "#/definitions/SecretPatch"
                         }
                     }
                 ],
@@ -4236,6 +4266,9 @@ const docTemplate = `{
                         "type": "string"
                     }
                 },
+                "changed_files_unknown": {
+                    "type": "boolean"
+                },
                 "commit": {
                     "type": "string"
                 },
@@ -4335,6 +4368,9 @@ const docTemplate = `{
                 "branch": {
                     "type": "string"
                 },
+                "trace_id": {
+                    "type": "string"
+                },
                 "variables": {
                     "type": "object",
                     "additionalProperties": {
@@ -4371,6 +4407,26 @@ const docTemplate = `{
                 }
             }
         },
+        "RegistryPatch": {
+            "type": "object",
+            "properties": {
+                "environments": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "password": {
+                    "type": "string"
+                },
+                "token_auth": {
+                    "type": "boolean"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
         "Repo": {
             "type": "object",
             "properties": {
@@ -4545,6 +4601,35 @@ const docTemplate = `{
                 "repo_id": {
                     "type": "integer"
                 },
+                "required": {
+                    "type": "boolean"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "SecretPatch": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/WebhookEvent"
+                    }
+                },
+                "file": {
+                    "type": "boolean"
+                },
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "required": {
+                    "type": "boolean"
+                },
                 "value": {
                     "type": "string"
                 }
@@ -4659,6 +4744,13 @@ const docTemplate = `{
                         "type": "integer"
                     }
                 },
+                "dep_run_on": {
+                    "description": "status of a dependency the task runs on (failure or always), other dependencies follow RunOn",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
                 "dep_status": {
                     "type": "object",
                     "additionalProperties": {
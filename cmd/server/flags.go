@@ -138,6 +138,11 @@ var flags = append([]cli.Flag{
 		Usage:   "The default docker image to be used when cloning the repo",
 		Value:   constant.DefaultCloneImage,
 	},
+	&cli.StringFlag{
+		EnvVars: []string{"WOODPECKER_ARTIFACT_IMAGE"},
+		Name:    "artifact-image",
+		Usage:   "The docker image of the plugin saving and restoring workflow artifacts, artifacts can't be used without it",
+	},
 	&cli.Int64Flag{
 		EnvVars: []string{"WOODPECKER_DEFAULT_PIPELINE_TIMEOUT"},
 		Name:    "default-pipeline-timeout",
@@ -150,6 +155,27 @@ var flags = append([]cli.Flag{
 		Usage:   "The maximum time in minutes you can set in the repo settings before a pipeline gets killed",
 		Value:   120,
 	},
+	&cli.IntFlag{
+		EnvVars: []string{"WOODPECKER_MAX_MATRIX_AXES"},
+		Name:    "max-matrix-axes",
+		Usage:   "The maximum number of workflows a matrix can expand to, pipelines with larger matrices fail",
+		Value:   50,
+	},
+	&cli.IntFlag{
+		EnvVars: []string{"WOODPECKER_MAX_PARALLEL_STEPS"},
+		Name:    "max-parallel-steps",
+		Usage:   "The maximum number of steps of a pipeline of an untrusted repository running at the same time, only recorded with the pipeline for now",
+	},
+	&cli.BoolFlag{
+		EnvVars: []string{"WOODPECKER_AUTO_SKIP_CLONE"},
+		Name:    "auto-skip-clone",
+		Usage:   "Skip the clone step of workflows which only run plugins and don't save artifacts",
+	},
+	&cli.BoolFlag{
+		EnvVars: []string{"WOODPECKER_AUTO_DISAMBIGUATE_WORKFLOW_NAMES"},
+		Name:    "auto-disambiguate-workflow-names",
+		Usage:   "Append the parent folder to workflows which have the same name instead of failing the pipeline",
+	},
 	&cli.DurationFlag{
 		EnvVars: []string{"WOODPECKER_SESSION_EXPIRES"},
 		Name:    "session-expires",
@@ -162,6 +188,26 @@ var flags = append([]cli.Flag{
 		Usage:   "images to run in privileged mode",
 		Value:   cli.NewStringSlice(constant.PrivilegedPlugins...),
 	},
+	&cli.StringSliceFlag{
+		EnvVars: []string{"WOODPECKER_PRIVILEGED_EVENTS"},
+		Name:    "privileged-events",
+		Usage:   "events pipelines can use privileged mode for, all events if empty",
+	},
+	&cli.BoolFlag{
+		EnvVars: []string{"WOODPECKER_PRIVILEGED_EVENTS_DOWNGRADE"},
+		Name:    "privileged-events-downgrade",
+		Usage:   "run privileged steps of other events than privileged-events unprivileged instead of failing the pipeline",
+	},
+	&cli.StringSliceFlag{
+		EnvVars: []string{"WOODPECKER_ALLOWED_CAPABILITIES"},
+		Name:    "allowed-capabilities",
+		Usage:   "linux capabilities steps of trusted repositories are allowed to add",
+	},
+	&cli.Int64Flag{
+		EnvVars: []string{"WOODPECKER_MAX_TMPFS_SIZE"},
+		Name:    "max-tmpfs-size",
+		Usage:   "maximum total size in bytes of the tmpfs mounts of a step of untrusted repositories, untrusted repositories can't use tmpfs if not set",
+	},
 	&cli.StringSliceFlag{
 		EnvVars: []string{"WOODPECKER_VOLUME"},
 		Name:    "volume",
@@ -194,6 +240,11 @@ var flags = append([]cli.Flag{
 		Name:    "config-service-endpoint",
 		Usage:   "url used for calling configuration service endpoint",
 	},
+	&cli.StringFlag{
+		EnvVars: []string{"WOODPECKER_REGISTRY_CREDENTIALS_ENDPOINT"},
+		Name:    "registry-credentials-endpoint",
+		Usage:   "url used for requesting fresh credentials of registries flagged as token",
+	},
 	&cli.StringFlag{
 		EnvVars: []string{"WOODPECKER_DATABASE_DRIVER"},
 		Name:    "driver",
@@ -300,6 +351,11 @@ var flags = append([]cli.Flag{
 		Name:    "limit-shm-size",
 		Usage:   "docker compose /dev/shm allowed in bytes",
 	},
+	&cli.Int64Flag{
+		EnvVars: []string{"WOODPECKER_LIMIT_MAX_SHM_SIZE"},
+		Name:    "limit-max-shm-size",
+		Usage:   "max /dev/shm in bytes steps of untrusted repos can request with shm_size",
+	},
 	&cli.Int64Flag{
 		EnvVars: []string{"WOODPECKER_LIMIT_CPU_QUOTA"},
 		Name:    "limit-cpu-quota",
@@ -315,6 +371,16 @@ var flags = append([]cli.Flag{
 		Name:    "limit-cpu-set",
 		Usage:   "set the cpus allowed to execute containers",
 	},
+	&cli.BoolFlag{
+		EnvVars: []string{"WOODPECKER_UNTRUSTED_READ_ONLY_ROOTFS"},
+		Name:    "untrusted-read-only-rootfs",
+		Usage:   "run steps of untrusted repos with a read-only root filesystem",
+	},
+	&cli.StringFlag{
+		EnvVars: []string{"WOODPECKER_UNTRUSTED_USER"},
+		Name:    "untrusted-user",
+		Usage:   "run steps of untrusted repos but the clone step as this uid and optional gid, e.g. 1000:1000",
+	},
 	//
 	&cli.StringFlag{
 		Name:    "forge-url",
@@ -42,6 +42,7 @@ import (
 	woodpeckerGrpcServer "go.woodpecker-ci.org/woodpecker/v2/server/grpc"
 	"go.woodpecker-ci.org/woodpecker/v2/server/logging"
 	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+	"go.woodpecker-ci.org/woodpecker/v2/server/pipeline"
 	"go.woodpecker-ci.org/woodpecker/v2/server/pubsub"
 	"go.woodpecker-ci.org/woodpecker/v2/server/router"
 	"go.woodpecker-ci.org/woodpecker/v2/server/router/middleware"
@@ -97,6 +98,10 @@ func run(c *cli.Context) error {
 		return fmt.Errorf("can't setup globals: %w", err)
 	}
 
+	if err := pipeline.CancelPipelinesAfterTimeout(c.Context, _store); err != nil {
+		log.Error().Err(err).Msg("failed to restore the timeouts of active pipelines")
+	}
+
 	var g errgroup.Group
 
 	setupMetrics(&g, _store)
@@ -288,6 +293,9 @@ func setupEvilGlobals(c *cli.Context, s store.Store) error {
 	server.Config.Pipeline.DefaultCloneImage = c.String("default-clone-image")
 	constant.TrustedCloneImages = append(constant.TrustedCloneImages, server.Config.Pipeline.DefaultCloneImage)
 
+	// Artifacts
+	server.Config.Pipeline.ArtifactImage = c.String("artifact-image")
+
 	// Execution
 	_events := c.StringSlice("default-cancel-previous-pipeline-events")
 	events := make([]model.WebhookEvent, 0, len(_events))
@@ -297,14 +305,18 @@ func setupEvilGlobals(c *cli.Context, s store.Store) error {
 	server.Config.Pipeline.DefaultCancelPreviousPipelineEvents = events
 	server.Config.Pipeline.DefaultTimeout = c.Int64("default-pipeline-timeout")
 	server.Config.Pipeline.MaxTimeout = c.Int64("max-pipeline-timeout")
+	server.Config.Pipeline.MaxMatrixAxes = c.Int("max-matrix-axes")
 
 	// limits
 	server.Config.Pipeline.Limits.MemSwapLimit = c.Int64("limit-mem-swap")
 	server.Config.Pipeline.Limits.MemLimit = c.Int64("limit-mem")
 	server.Config.Pipeline.Limits.ShmSize = c.Int64("limit-shm-size")
+	server.Config.Pipeline.Limits.MaxShmSize = c.Int64("limit-max-shm-size")
 	server.Config.Pipeline.Limits.CPUQuota = c.Int64("limit-cpu-quota")
 	server.Config.Pipeline.Limits.CPUShares = c.Int64("limit-cpu-shares")
 	server.Config.Pipeline.Limits.CPUSet = c.String("limit-cpu-set")
+	server.Config.Pipeline.UntrustedReadOnlyRootfs = c.Bool("untrusted-read-only-rootfs")
+	server.Config.Pipeline.UntrustedUser = c.String("untrusted-user")
 
 	// backend options for pipeline compiler
 	server.Config.Pipeline.Proxy.No = c.String("backend-no-proxy")
@@ -343,6 +355,15 @@ func setupEvilGlobals(c *cli.Context, s store.Store) error {
 	server.Config.Pipeline.Networks = c.StringSlice("network")
 	server.Config.Pipeline.Volumes = c.StringSlice("volume")
 	server.Config.Pipeline.Privileged = c.StringSlice("escalate")
+	for _, event := range c.StringSlice("privileged-events") {
+		server.Config.Pipeline.PrivilegedEvents = append(server.Config.Pipeline.PrivilegedEvents, model.WebhookEvent(event))
+	}
+	server.Config.Pipeline.PrivilegedEventsDowngrade = c.Bool("privileged-events-downgrade")
+	server.Config.Pipeline.AllowedCapabilities = c.StringSlice("allowed-capabilities")
+	server.Config.Pipeline.MaxTmpfsSize = c.Int64("max-tmpfs-size")
+	server.Config.Pipeline.MaxParallelSteps = c.Int("max-parallel-steps")
+	server.Config.Pipeline.AutoSkipClone = c.Bool("auto-skip-clone")
+	server.Config.Pipeline.AutoDisambiguateNames = c.Bool("auto-disambiguate-workflow-names")
 	server.Config.WebUI.EnableSwagger = c.Bool("enable-swagger")
 	server.Config.WebUI.SkipVersionCheck = c.Bool("skip-version-check")
 
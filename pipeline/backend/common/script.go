@@ -16,6 +16,8 @@ package common
 
 import (
 	"encoding/base64"
+	"path"
+	"slices"
 )
 
 func GenerateContainerConf(commands []string, goos string) (env map[string]string, entry []string) {
@@ -35,3 +37,26 @@ func GenerateContainerConf(commands []string, goos string) (env map[string]strin
 
 	return env, entry
 }
+
+// GenerateFilesConf returns the environment and the entrypoint writing the files to their absolute
+// paths before the given entrypoint is executed. The files are kept in a tmpfs, which can only be
+// written from inside of the running container.
+func GenerateFilesConf(files map[string]string, entry []string) (env map[string]string, filesEntry []string) {
+	env = map[string]string{
+		"CI_FILES": base64.StdEncoding.EncodeToString([]byte(generateFilesScriptPosix(files))),
+	}
+	filesEntry = []string{"/bin/sh", "-c", `echo $CI_FILES | base64 -d | /bin/sh -e && unset CI_FILES && exec "$@"`, "sh"}
+	return env, append(filesEntry, entry...)
+}
+
+// FileDirs returns the sorted folders of the files written to the container.
+func FileDirs(files map[string]string) []string {
+	var dirs []string
+	for file := range files {
+		if dir := path.Dir(file); !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	slices.Sort(dirs)
+	return dirs
+}
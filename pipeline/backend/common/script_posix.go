@@ -16,7 +16,10 @@ package common
 
 import (
 	"bytes"
+	"encoding/base64"
 	"fmt"
+	"path"
+	"slices"
 
 	"github.com/alessio/shellescape"
 )
@@ -39,6 +42,29 @@ func generateScriptPosix(commands []string) string {
 	return buf.String()
 }
 
+// generateFilesScriptPosix is a helper function that generates a script
+// writing the files to their absolute paths.
+func generateFilesScriptPosix(files map[string]string) string {
+	var buf bytes.Buffer
+
+	paths := make([]string, 0, len(files))
+	for file := range files {
+		paths = append(paths, file)
+	}
+	slices.Sort(paths)
+
+	for _, file := range paths {
+		buf.WriteString(fmt.Sprintf(
+			fileScript,
+			shellescape.Quote(path.Dir(file)),
+			base64.StdEncoding.EncodeToString([]byte(files[file])),
+			shellescape.Quote(file),
+		))
+	}
+
+	return buf.String()
+}
+
 // setupScript is a helper script this is added to the step script to ensure
 // a minimum set of environment variables are set correctly.
 const setupScript = `
@@ -61,3 +87,10 @@ const traceScript = `
 echo + %s
 %s
 `
+
+// fileScript is a helper script that is added to the files script
+// to write a file.
+const fileScript = `
+mkdir -p %s
+echo %s | base64 -d > %s
+`
@@ -56,3 +56,18 @@ go test
 		assert.EqualValues(t, test.want, script, "Want encoded script for %s", test.from)
 	}
 }
+
+func TestGenerateFilesScriptPosix(t *testing.T) {
+	got := generateFilesScriptPosix(map[string]string{
+		"/run/woodpecker/secrets/kubeconfig": "apiVersion: v1",
+		"/run/woodpecker/secrets/cert":       "-----BEGIN CERTIFICATE-----",
+	})
+	want := `
+mkdir -p /run/woodpecker/secrets
+echo LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0t | base64 -d > /run/woodpecker/secrets/cert
+
+mkdir -p /run/woodpecker/secrets
+echo YXBpVmVyc2lvbjogdjE= | base64 -d > /run/woodpecker/secrets/kubeconfig
+`
+	assert.Equal(t, want, got)
+}
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"encoding/base64"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -23,3 +24,18 @@ func TestGenerateContainerConf(t *testing.T) {
 	assert.Equal(t, "/bin/sh", gotEnv["SHELL"])
 	assert.Equal(t, []string{"/bin/sh", "-c", "echo $CI_SCRIPT | base64 -d | /bin/sh -e"}, gotEntry)
 }
+
+func TestGenerateFilesConf(t *testing.T) {
+	files := map[string]string{"/run/woodpecker/secrets/kubeconfig": "apiVersion: v1"}
+	gotEnv, gotEntry := GenerateFilesConf(files, []string{"/bin/plugin", "--verbose"})
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte(generateFilesScriptPosix(files))), gotEnv["CI_FILES"])
+	assert.Equal(t, []string{"/bin/sh", "-c", `echo $CI_FILES | base64 -d | /bin/sh -e && unset CI_FILES && exec "$@"`, "sh", "/bin/plugin", "--verbose"}, gotEntry)
+}
+
+func TestFileDirs(t *testing.T) {
+	assert.Equal(t, []string{"/etc/ssl", "/run/woodpecker/secrets"}, FileDirs(map[string]string{
+		"/run/woodpecker/secrets/kubeconfig": "apiVersion: v1",
+		"/run/woodpecker/secrets/cert":       "-----BEGIN CERTIFICATE-----",
+		"/etc/ssl/ca.pem":                    "-----BEGIN CERTIFICATE-----",
+	}))
+}
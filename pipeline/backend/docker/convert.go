@@ -39,9 +39,15 @@ func (e *docker) toConfig(step *types.Step) *container.Config {
 			"wp_step": step.Name,
 		},
 		WorkingDir:   step.WorkingDir,
+		User:         step.User,
+		StopSignal:   step.StopSignal,
 		AttachStdout: true,
 		AttachStderr: true,
 	}
+	if step.StopGrace > 0 {
+		stopTimeout := int(step.StopGrace.Seconds())
+		config.StopTimeout = &stopTimeout
+	}
 	configEnv := make(map[string]string)
 	maps.Copy(configEnv, step.Environment)
 
@@ -82,8 +88,9 @@ func toHostConfig(step *types.Step) *container.HostConfig {
 		LogConfig: container.LogConfig{
 			Type: "json-file",
 		},
-		Privileged: step.Privileged,
-		ShmSize:    step.ShmSize,
+		Privileged:     step.Privileged,
+		ReadonlyRootfs: step.ReadOnlyRootfs,
+		ShmSize:        step.ShmSize,
 	}
 
 	if len(step.NetworkMode) != 0 {
@@ -105,6 +112,12 @@ func toHostConfig(step *types.Step) *container.HostConfig {
 	if len(step.Devices) != 0 {
 		config.Devices = toDev(step.Devices)
 	}
+	if len(step.CapAdd) != 0 {
+		config.CapAdd = step.CapAdd
+	}
+	if len(step.CapDrop) != 0 {
+		config.CapDrop = step.CapDrop
+	}
 	if len(step.Volumes) != 0 {
 		config.Binds = step.Volumes
 	}
@@ -120,6 +133,12 @@ func toHostConfig(step *types.Step) *container.HostConfig {
 		}
 		config.Tmpfs[parts[0]] = parts[1]
 	}
+	// files are kept in memory only, so they are never written to the disk of the host
+	for _, dir := range common.FileDirs(step.Files) {
+		if _, ok := config.Tmpfs[dir]; !ok {
+			config.Tmpfs[dir] = ""
+		}
+	}
 
 	return config
 }
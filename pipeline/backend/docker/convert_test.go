@@ -18,6 +18,7 @@ import (
 	"reflect"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
@@ -123,6 +124,7 @@ func TestToConfigSmall(t *testing.T) {
 
 func TestToConfigFull(t *testing.T) {
 	engine := docker{info: types.Info{OSType: "linux/riscv64"}}
+	stopTimeout := 30
 
 	conf := engine.toConfig(&backend.Step{
 		Name:         "test",
@@ -133,6 +135,9 @@ func TestToConfigFull(t *testing.T) {
 		Detached:     true,
 		Privileged:   true,
 		WorkingDir:   "/src/abc",
+		User:         "1000:1000",
+		StopSignal:   "SIGINT",
+		StopGrace:    30 * time.Second,
 		Environment:  map[string]string{"TAGS": "sqlite"},
 		Commands:     []string{"go test", "go vet ./..."},
 		ExtraHosts:   []backend.HostAlias{{Name: "t", IP: "1.2.3.4"}},
@@ -160,6 +165,9 @@ func TestToConfigFull(t *testing.T) {
 	assert.EqualValues(t, &container.Config{
 		Image:        "golang:1.2.3",
 		WorkingDir:   "/src/abc",
+		User:         "1000:1000",
+		StopSignal:   "SIGINT",
+		StopTimeout:  &stopTimeout,
 		AttachStdout: true,
 		AttachStderr: true,
 		Entrypoint:   []string{"/bin/sh", "-c", "echo $CI_SCRIPT | base64 -d | /bin/sh -e"},
@@ -182,3 +190,24 @@ func TestToConfigFull(t *testing.T) {
 		},
 	}, conf)
 }
+
+func TestToHostConfigFiles(t *testing.T) {
+	conf := toHostConfig(&backend.Step{
+		Name:           "test",
+		UUID:           "09238932",
+		Image:          "alpine",
+		ReadOnlyRootfs: true,
+		Tmpfs:          []string{"/etc/ssl:size=1m"},
+		Files: map[string]string{
+			"/run/woodpecker/secrets/kubeconfig": "apiVersion: v1",
+			"/run/woodpecker/secrets/cert":       "-----BEGIN CERTIFICATE-----",
+			"/etc/ssl/ca.pem":                    "-----BEGIN CERTIFICATE-----",
+		},
+	})
+
+	// the folders of the files are kept in memory, the options of existing tmpfs mounts are kept
+	assert.Equal(t, map[string]string{
+		"/etc/ssl":                "size=1m",
+		"/run/woodpecker/secrets": "",
+	}, conf.Tmpfs)
+}
@@ -23,6 +23,7 @@ import (
 	"strings"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/api/types/volume"
 	tls_config "github.com/docker/go-connections/tlsconfig"
@@ -33,6 +34,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v2"
 
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/backend/common"
 	backend "go.woodpecker-ci.org/woodpecker/v2/pipeline/backend/types"
 	"go.woodpecker-ci.org/woodpecker/v2/shared/utils"
 )
@@ -214,6 +216,21 @@ func (e *docker) StartStep(ctx context.Context, step *backend.Step, taskUUID str
 		}
 	}
 
+	// the files are written by a wrapper of the entrypoint, as the tmpfs they are kept in
+	// can't be written to before the container started
+	if len(step.Files) != 0 {
+		entrypoint := config.Entrypoint
+		if len(entrypoint) == 0 {
+			var err error
+			if entrypoint, err = e.imageEntrypoint(ctx, config.Image, pullOpts); err != nil {
+				return err
+			}
+		}
+		env, entry := common.GenerateFilesConf(step.Files, entrypoint)
+		config.Env = append(config.Env, toEnv(env)...)
+		config.Entrypoint = entry
+	}
+
 	// add default volumes to the host configuration
 	hostConfig.Binds = utils.DeduplicateStrings(append(hostConfig.Binds, e.volumes...))
 
@@ -221,16 +238,9 @@ func (e *docker) StartStep(ctx context.Context, step *backend.Step, taskUUID str
 	if client.IsErrNotFound(err) {
 		// automatically pull and try to re-create the image if the
 		// failure is caused because the image does not exist.
-		responseBody, pErr := e.client.ImagePull(ctx, config.Image, pullOpts)
-		if pErr != nil {
+		if pErr := e.pullImage(ctx, config.Image, pullOpts); pErr != nil {
 			return pErr
 		}
-		// TODO(1936): show image pull progress in web-ui
-		fd, isTerminal := term.GetFdInfo(os.Stdout)
-		if err := json_message.DisplayJSONMessagesStream(responseBody, os.Stdout, fd, isTerminal, nil); err != nil {
-			log.Error().Err(err).Msg("DisplayJSONMessagesStream")
-		}
-		responseBody.Close()
 
 		_, err = e.client.ContainerCreate(ctx, config, hostConfig, nil, nil, containerName)
 	}
@@ -260,6 +270,39 @@ func (e *docker) StartStep(ctx context.Context, step *backend.Step, taskUUID str
 	return e.client.ContainerStart(ctx, containerName, startOpts)
 }
 
+// pullImage pulls the image and shows the progress.
+func (e *docker) pullImage(ctx context.Context, image string, pullOpts types.ImagePullOptions) error {
+	responseBody, err := e.client.ImagePull(ctx, image, pullOpts)
+	if err != nil {
+		return err
+	}
+	defer responseBody.Close()
+	// TODO(1936): show image pull progress in web-ui
+	fd, isTerminal := term.GetFdInfo(os.Stdout)
+	if err := json_message.DisplayJSONMessagesStream(responseBody, os.Stdout, fd, isTerminal, nil); err != nil {
+		log.Error().Err(err).Msg("DisplayJSONMessagesStream")
+	}
+	return nil
+}
+
+// imageEntrypoint returns the entrypoint and the command of the image, it's pulled if it doesn't exist yet.
+func (e *docker) imageEntrypoint(ctx context.Context, image string, pullOpts types.ImagePullOptions) ([]string, error) {
+	info, _, err := e.client.ImageInspectWithRaw(ctx, image)
+	if client.IsErrNotFound(err) {
+		if err := e.pullImage(ctx, image, pullOpts); err != nil {
+			return nil, err
+		}
+		info, _, err = e.client.ImageInspectWithRaw(ctx, image)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.Config == nil {
+		return nil, nil
+	}
+	return append(info.Config.Entrypoint, info.Config.Cmd...), nil
+}
+
 func (e *docker) WaitStep(ctx context.Context, step *backend.Step, taskUUID string) (*backend.State, error) {
 	log.Trace().Str("taskUUID", taskUUID).Msgf("wait for step %s", step.Name)
 
@@ -306,6 +349,13 @@ func (e *docker) DestroyStep(ctx context.Context, step *backend.Step, taskUUID s
 
 	containerName := toContainerName(step)
 
+	// let the step shut down cleanly with its stop signal and grace period
+	if step.StopSignal != "" || step.StopGrace > 0 {
+		if err := e.client.ContainerStop(ctx, containerName, container.StopOptions{}); err != nil && !isErrContainerNotFoundOrNotRunning(err) {
+			return err
+		}
+	}
+
 	if err := e.client.ContainerKill(ctx, containerName, "9"); err != nil && !isErrContainerNotFoundOrNotRunning(err) {
 		return err
 	}
@@ -81,6 +81,19 @@ func newDefaultDeleteOptions() meta_v1.DeleteOptions {
 	}
 }
 
+// stepDeleteOptions gives the pod of the step its stop grace period to shut down,
+// steps without one are killed immediately.
+func stepDeleteOptions(step *types.Step) meta_v1.DeleteOptions {
+	if step.StopGrace <= 0 {
+		return defaultDeleteOptions
+	}
+
+	deleteOptions := newDefaultDeleteOptions()
+	gracePeriodSeconds := int64(step.StopGrace.Seconds())
+	deleteOptions.GracePeriodSeconds = &gracePeriodSeconds
+	return deleteOptions
+}
+
 func configFromCliContext(ctx context.Context) (*config, error) {
 	if ctx != nil {
 		if c, ok := ctx.Value(types.CliContext).(*cli.Context); ok {
@@ -229,6 +242,11 @@ func (e *kube) StartStep(ctx context.Context, step *types.Step, taskUUID string)
 		log.Error().Err(err).Msg("could not parse backend options")
 	}
 
+	// pods are always stopped with SIGTERM
+	if step.StopSignal != "" && step.StopSignal != "SIGTERM" {
+		return fmt.Errorf("step %s uses the stop signal %s, which the kubernetes backend doesn't support", step.Name, step.StopSignal)
+	}
+
 	log.Trace().Str("taskUUID", taskUUID).Msgf("starting step: %s", step.Name)
 	_, err = startPod(ctx, e, step, options)
 	return err
@@ -387,7 +405,7 @@ func (e *kube) TailStep(ctx context.Context, step *types.Step, taskUUID string)
 
 func (e *kube) DestroyStep(ctx context.Context, step *types.Step, taskUUID string) error {
 	log.Trace().Str("taskUUID", taskUUID).Msgf("Stopping step: %s", step.Name)
-	err := stopPod(ctx, e, step, defaultDeleteOptions)
+	err := stopPod(ctx, e, step, stepDeleteOptions(step))
 	return err
 }
 
@@ -398,7 +416,7 @@ func (e *kube) DestroyWorkflow(ctx context.Context, conf *types.Config, taskUUID
 	// Use noContext because the ctx sent to this function will be canceled/done in case of error or canceled by user.
 	for _, stage := range conf.Stages {
 		for _, step := range stage.Steps {
-			err := stopPod(ctx, e, step, defaultDeleteOptions)
+			err := stopPod(ctx, e, step, stepDeleteOptions(step))
 			if err != nil {
 				return err
 			}
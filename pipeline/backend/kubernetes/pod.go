@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"maps"
+	"strconv"
 	"strings"
 
 	"github.com/rs/zerolog/log"
@@ -33,6 +34,11 @@ import (
 const (
 	StepLabel = "step"
 	podPrefix = "wp-"
+	// scratchVolumePrefix names the emptyDir volumes keeping the tmpfs paths of read-only steps writable
+	scratchVolumePrefix = "scratch-"
+	// filesVolumePrefix names the in-memory emptyDir volumes the files of a step are written to
+	filesVolumePrefix  = "files-"
+	filesContainerName = "write-files"
 )
 
 func mkPod(step *types.Step, config *config, podName, goos string, options BackendOptions) (*v1.Pod, error) {
@@ -59,6 +65,9 @@ func mkPod(step *types.Step, config *config, podName, goos string, options Backe
 		return nil, err
 	}
 	spec.Containers = append(spec.Containers, container)
+	if len(step.Files) != 0 {
+		spec.InitContainers = append(spec.InitContainers, filesContainer(step, container))
+	}
 
 	pod := &v1.Pod{
 		ObjectMeta: meta,
@@ -167,6 +176,12 @@ func podSpec(step *types.Step, config *config, options BackendOptions, nsp nativ
 	if err != nil {
 		return spec, err
 	}
+	spec.Volumes = append(spec.Volumes, scratchVolumes(step)...)
+	spec.Volumes = append(spec.Volumes, filesVolumes(step)...)
+
+	if step.StopGrace > 0 {
+		spec.TerminationGracePeriodSeconds = newInt64(int64(step.StopGrace.Seconds()))
+	}
 
 	log.Trace().Msgf("using the image pull secrets: %v", config.ImagePullSecretNames)
 	spec.ImagePullSecrets = secretsReferences(config.ImagePullSecretNames)
@@ -179,11 +194,15 @@ func podSpec(step *types.Step, config *config, options BackendOptions, nsp nativ
 func podContainer(step *types.Step, podName, goos string, options BackendOptions, nsp nativeSecretsProcessor) (v1.Container, error) {
 	var err error
 	container := v1.Container{
-		Name:            podName,
-		Image:           step.Image,
-		WorkingDir:      step.WorkingDir,
-		Ports:           containerPorts(step.Ports),
-		SecurityContext: containerSecurityContext(options.SecurityContext, step.Privileged),
+		Name:       podName,
+		Image:      step.Image,
+		WorkingDir: step.WorkingDir,
+		Ports:      containerPorts(step.Ports),
+	}
+
+	container.SecurityContext, err = containerSecurityContext(options.SecurityContext, step)
+	if err != nil {
+		return container, err
 	}
 
 	if step.Pull {
@@ -210,6 +229,8 @@ func podContainer(step *types.Step, podName, goos string, options BackendOptions
 	if err != nil {
 		return container, err
 	}
+	container.VolumeMounts = append(container.VolumeMounts, scratchVolumeMounts(step)...)
+	container.VolumeMounts = append(container.VolumeMounts, filesVolumeMounts(step)...)
 
 	container.EnvFrom = append(container.EnvFrom, nsp.envFromSources...)
 	container.Env = append(container.Env, nsp.envVars...)
@@ -245,6 +266,77 @@ func pvcVolume(name string) v1.Volume {
 	}
 }
 
+// scratchVolumes returns the emptyDir volumes of the tmpfs paths of a step with a read-only root
+// filesystem, the kubernetes backend doesn't support tmpfs mounts otherwise.
+func scratchVolumes(step *types.Step) []v1.Volume {
+	if !step.ReadOnlyRootfs {
+		return nil
+	}
+
+	var vols []v1.Volume
+	for i := range step.Tmpfs {
+		vols = append(vols, v1.Volume{
+			Name: scratchVolumePrefix + strconv.Itoa(i),
+			VolumeSource: v1.VolumeSource{
+				EmptyDir: &v1.EmptyDirVolumeSource{},
+			},
+		})
+	}
+	return vols
+}
+
+func scratchVolumeMounts(step *types.Step) []v1.VolumeMount {
+	if !step.ReadOnlyRootfs {
+		return nil
+	}
+
+	var mounts []v1.VolumeMount
+	for i, path := range step.Tmpfs {
+		path, _, _ = strings.Cut(path, ":")
+		mounts = append(mounts, volumeMount(scratchVolumePrefix+strconv.Itoa(i), path))
+	}
+	return mounts
+}
+
+// filesVolumes returns the in-memory emptyDir volumes of the folders of the files of a step.
+func filesVolumes(step *types.Step) []v1.Volume {
+	var vols []v1.Volume
+	for i := range common.FileDirs(step.Files) {
+		vols = append(vols, v1.Volume{
+			Name: filesVolumePrefix + strconv.Itoa(i),
+			VolumeSource: v1.VolumeSource{
+				EmptyDir: &v1.EmptyDirVolumeSource{
+					Medium: v1.StorageMediumMemory,
+				},
+			},
+		})
+	}
+	return vols
+}
+
+func filesVolumeMounts(step *types.Step) []v1.VolumeMount {
+	var mounts []v1.VolumeMount
+	for i, dir := range common.FileDirs(step.Files) {
+		mounts = append(mounts, volumeMount(filesVolumePrefix+strconv.Itoa(i), dir))
+	}
+	return mounts
+}
+
+// filesContainer returns the init container writing the files of a step to their volumes
+// with the image and the security context of the step container.
+func filesContainer(step *types.Step, container v1.Container) v1.Container {
+	env, command := common.GenerateFilesConf(step.Files, nil)
+	return v1.Container{
+		Name:            filesContainerName,
+		Image:           container.Image,
+		ImagePullPolicy: container.ImagePullPolicy,
+		Command:         command,
+		Env:             mapToEnvVars(env),
+		SecurityContext: container.SecurityContext,
+		VolumeMounts:    filesVolumeMounts(step),
+	}
+}
+
 func volumeMounts(volumes []string) ([]v1.VolumeMount, error) {
 	var mounts []v1.VolumeMount
 
@@ -443,32 +535,59 @@ func seccompProfile(scp *SecProfile) *v1.SeccompProfile {
 	return seccompProfile
 }
 
-func containerSecurityContext(sc *SecurityContext, stepPrivileged bool) *v1.SecurityContext {
-	if !stepPrivileged {
-		return nil
-	}
+func containerSecurityContext(sc *SecurityContext, step *types.Step) (*v1.SecurityContext, error) {
+	securityContext := &v1.SecurityContext{}
+	empty := true
 
-	privileged := false
+	// privileged steps run privileged unless the security context disables it explicitly
+	if step.Privileged && (sc == nil || sc.Privileged == nil || *sc.Privileged) {
+		securityContext.Privileged = newBool(true)
+		empty = false
+	}
 
-	// if security context privileged is set explicitly
-	if sc != nil && sc.Privileged != nil && *sc.Privileged {
-		privileged = true
+	if step.ReadOnlyRootfs {
+		securityContext.ReadOnlyRootFilesystem = newBool(true)
+		empty = false
 	}
 
-	// if security context privileged is not set explicitly, but step is privileged
-	if (sc == nil || sc.Privileged == nil) && stepPrivileged {
-		privileged = true
+	if step.User != "" {
+		uid, gid, hasGroup := strings.Cut(step.User, ":")
+		user, err := strconv.ParseInt(uid, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user '%s' of step %s: %w", step.User, step.Name, err)
+		}
+		securityContext.RunAsUser = newInt64(user)
+		if hasGroup {
+			group, err := strconv.ParseInt(gid, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid user '%s' of step %s: %w", step.User, step.Name, err)
+			}
+			securityContext.RunAsGroup = newInt64(group)
+		}
+		empty = false
 	}
 
-	if privileged {
-		securityContext := &v1.SecurityContext{
-			Privileged: newBool(true),
+	if len(step.CapAdd) != 0 || len(step.CapDrop) != 0 {
+		securityContext.Capabilities = &v1.Capabilities{
+			Add:  capabilities(step.CapAdd),
+			Drop: capabilities(step.CapDrop),
 		}
-		log.Trace().Msgf("container security context that will be used: %v", securityContext)
-		return securityContext
+		empty = false
 	}
 
-	return nil
+	if empty {
+		return nil, nil
+	}
+	log.Trace().Msgf("container security context that will be used: %v", securityContext)
+	return securityContext, nil
+}
+
+func capabilities(names []string) []v1.Capability {
+	var caps []v1.Capability
+	for _, name := range names {
+		caps = append(caps, v1.Capability(strings.TrimPrefix(name, "CAP_")))
+	}
+	return caps
 }
 
 func apparmorAnnotation(containerName string, scp *SecProfile) (*string, *string) {
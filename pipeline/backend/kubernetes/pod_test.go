@@ -17,6 +17,7 @@ package kubernetes
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/kinbiko/jsonassert"
 	"github.com/stretchr/testify/assert"
@@ -588,3 +589,77 @@ func TestSecrets(t *testing.T) {
 	ja := jsonassert.New(t)
 	ja.Assertf(string(podJSON), expected)
 }
+
+func TestPodStepSecurityOptions(t *testing.T) {
+	pod, err := mkPod(&types.Step{
+		Name:           "go-test",
+		Image:          "golang:1.16",
+		ReadOnlyRootfs: true,
+		Tmpfs:          []string{"/tmp", "/cache:size=64m"},
+		User:           "1000:2000",
+		CapAdd:         []string{"NET_ADMIN"},
+		CapDrop:        []string{"ALL"},
+		StopGrace:      30 * time.Second,
+	}, &config{
+		Namespace: "woodpecker",
+	}, "wp-01he8bebctabr3kgk0qj36d2me-0", "linux/amd64", BackendOptions{})
+	assert.NoError(t, err)
+
+	securityContext := pod.Spec.Containers[0].SecurityContext
+	assert.True(t, *securityContext.ReadOnlyRootFilesystem)
+	assert.Equal(t, int64(1000), *securityContext.RunAsUser)
+	assert.Equal(t, int64(2000), *securityContext.RunAsGroup)
+	assert.Equal(t, []v1.Capability{"NET_ADMIN"}, securityContext.Capabilities.Add)
+	assert.Equal(t, []v1.Capability{"ALL"}, securityContext.Capabilities.Drop)
+	assert.Nil(t, securityContext.Privileged)
+	assert.Equal(t, int64(30), *pod.Spec.TerminationGracePeriodSeconds)
+
+	// the tmpfs paths stay writable
+	assert.Equal(t, []v1.VolumeMount{
+		{Name: "scratch-0", MountPath: "/tmp"},
+		{Name: "scratch-1", MountPath: "/cache"},
+	}, pod.Spec.Containers[0].VolumeMounts)
+	assert.Len(t, pod.Spec.Volumes, 2)
+
+	_, err = mkPod(&types.Step{
+		Name:  "go-test",
+		Image: "golang:1.16",
+		User:  "nobody",
+	}, &config{
+		Namespace: "woodpecker",
+	}, "wp-01he8bebctabr3kgk0qj36d2me-0", "linux/amd64", BackendOptions{})
+	assert.ErrorContains(t, err, "invalid user 'nobody' of step go-test")
+}
+
+func TestPodFiles(t *testing.T) {
+	pod, err := mkPod(&types.Step{
+		Name:  "deploy",
+		Image: "bitnami/kubectl",
+		User:  "1000",
+		Files: map[string]string{
+			"/run/woodpecker/secrets/kubeconfig": "apiVersion: v1",
+		},
+	}, &config{
+		Namespace: "woodpecker",
+	}, "wp-01he8bebctabr3kgk0qj36d2me-0", "linux/amd64", BackendOptions{})
+	assert.NoError(t, err)
+
+	// the files are kept in memory
+	assert.Equal(t, []v1.Volume{{
+		Name: "files-0",
+		VolumeSource: v1.VolumeSource{
+			EmptyDir: &v1.EmptyDirVolumeSource{Medium: v1.StorageMediumMemory},
+		},
+	}}, pod.Spec.Volumes)
+	mounts := []v1.VolumeMount{{Name: "files-0", MountPath: "/run/woodpecker/secrets"}}
+	assert.Equal(t, mounts, pod.Spec.Containers[0].VolumeMounts)
+
+	// and written by an init container running as the step
+	if assert.Len(t, pod.Spec.InitContainers, 1) {
+		initContainer := pod.Spec.InitContainers[0]
+		assert.Equal(t, "bitnami/kubectl", initContainer.Image)
+		assert.Equal(t, mounts, initContainer.VolumeMounts)
+		assert.Equal(t, int64(1000), *initContainer.SecurityContext.RunAsUser)
+		assert.Equal(t, "CI_FILES", initContainer.Env[0].Name)
+	}
+}
@@ -121,9 +121,18 @@ func (e *local) StartStep(ctx context.Context, step *types.Step, taskUUID string
 		return err
 	}
 
+	files, err := writeFiles(step, state)
+	if err != nil {
+		return err
+	}
+
 	// Get environment variables
 	env := os.Environ()
 	for a, b := range step.Environment {
+		// paths of files point to where they were written on the host
+		if file, ok := files[b]; ok {
+			b = file
+		}
 		// append allowed env vars to command env
 		if !slices.Contains(notAllowedEnvVarOverwrites, a) {
 			env = append(env, a+"="+b)
@@ -147,6 +156,23 @@ func (e *local) StartStep(ctx context.Context, step *types.Step, taskUUID string
 	}
 }
 
+// writeFiles writes the files of the step to its folder in the workflow folder, as there is no
+// container to write them to. It returns the paths on the host by the paths in the container.
+func writeFiles(step *types.Step, state *workflowState) (map[string]string, error) {
+	files := make(map[string]string, len(step.Files))
+	for file, content := range step.Files {
+		hostFile := filepath.Join(state.baseDir, "files", step.UUID, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(hostFile), 0o700); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(hostFile, []byte(content), 0o600); err != nil {
+			return nil, err
+		}
+		files[file] = hostFile
+	}
+	return files, nil
+}
+
 // execCommands use step.Image as shell and run the commands in it.
 func (e *local) execCommands(ctx context.Context, step *types.Step, state *workflowState, env []string) error {
 	// Prepare commands
@@ -14,6 +14,8 @@
 
 package types
 
+import "time"
+
 // Step defines a container process.
 type Step struct {
 	Name           string            `json:"name"`
@@ -23,13 +25,18 @@ type Step struct {
 	Pull           bool              `json:"pull,omitempty"`
 	Detached       bool              `json:"detach,omitempty"`
 	Privileged     bool              `json:"privileged,omitempty"`
+	CapAdd         []string          `json:"cap_add,omitempty"`
+	CapDrop        []string          `json:"cap_drop,omitempty"`
+	ReadOnlyRootfs bool              `json:"read_only_rootfs,omitempty"`
 	WorkingDir     string            `json:"working_dir,omitempty"`
+	User           string            `json:"user,omitempty"`
 	Environment    map[string]string `json:"environment,omitempty"`
 	Entrypoint     []string          `json:"entrypoint,omitempty"`
 	Commands       []string          `json:"commands,omitempty"`
 	ExtraHosts     []HostAlias       `json:"extra_hosts,omitempty"`
 	Volumes        []string          `json:"volumes,omitempty"`
 	Tmpfs          []string          `json:"tmpfs,omitempty"`
+	Files          map[string]string `json:"files,omitempty"` // content of files written to the container before the step runs, by absolute path
 	Devices        []string          `json:"devices,omitempty"`
 	Networks       []Conn            `json:"networks,omitempty"`
 	DNS            []string          `json:"dns,omitempty"`
@@ -43,10 +50,16 @@ type Step struct {
 	OnFailure      bool              `json:"on_failure,omitempty"`
 	OnSuccess      bool              `json:"on_success,omitempty"`
 	Failure        string            `json:"failure,omitempty"`
+	FailureMessage string            `json:"failure_message,omitempty"`
 	AuthConfig     Auth              `json:"auth_config,omitempty"`
 	NetworkMode    string            `json:"network_mode,omitempty"`
 	Ports          []Port            `json:"ports,omitempty"`
 	BackendOptions map[string]any    `json:"backend_options,omitempty"`
+	Cache          *StepCache        `json:"cache,omitempty"`
+	StopSignal     string            `json:"stop_signal,omitempty"`
+	StopGrace      time.Duration     `json:"stop_grace_period,omitempty"`
+	Outputs        []string          `json:"outputs,omitempty"`   // keys of the outputs the step sets in its log
+	Condition      string            `json:"condition,omitempty"` // expression on outputs of other steps, the step is skipped if it is false
 }
 
 // StepType identifies the type of step.
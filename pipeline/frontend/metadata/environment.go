@@ -28,6 +28,8 @@ import (
 var (
 	pullRegexp      = regexp.MustCompile(`\d+`)
 	maxChangedFiles = 500
+	// invalidEnvChars are replaced in matrix keys to use them in names of environment variables
+	invalidEnvChars = regexp.MustCompile(`[^A-Z0-9_]`)
 )
 
 // Environ returns the metadata as a map of environment variables.
@@ -74,6 +76,8 @@ func (m *Metadata) Environ() map[string]string {
 		"CI_PIPELINE_NUMBER":        strconv.FormatInt(m.Curr.Number, 10),
 		"CI_PIPELINE_PARENT":        strconv.FormatInt(m.Curr.Parent, 10),
 		"CI_PIPELINE_EVENT":         m.Curr.Event,
+		"CI_PIPELINE_EVENT_HASH":    m.Curr.EventHash,
+		"CI_PIPELINE_TRACE_ID":      m.Curr.TraceID,
 		"CI_PIPELINE_URL":           m.getPipelineWebURL(m.Curr, 0),
 		"CI_PIPELINE_FORGE_URL":     m.Curr.ForgeURL,
 		"CI_PIPELINE_DEPLOY_TARGET": m.Curr.Target,
@@ -121,12 +125,23 @@ func (m *Metadata) Environ() map[string]string {
 		"CI_SYSTEM_PLATFORM": m.Sys.Platform, // will be set by pipeline platform option or by agent
 		"CI_SYSTEM_VERSION":  m.Sys.Version,
 
-		"CI_FORGE_TYPE": m.Forge.Type,
-		"CI_FORGE_URL":  m.Forge.URL,
+		"CI_FORGE_TYPE":                m.Forge.Type,
+		"CI_FORGE_URL":                 m.Forge.URL,
+		"CI_FORGE_RATELIMIT_REMAINING": m.Forge.RateLimitRemaining,
 
 		// TODO: Deprecated, remove in 3.x
 		"CI_COMMIT_URL": m.Curr.ForgeURL,
 	}
+	// the config is loaded from the triggering commit if no other source is known
+	params["CI_CONFIG_SOURCE_REPO"] = m.ConfigSource.Repo
+	if params["CI_CONFIG_SOURCE_REPO"] == "" {
+		params["CI_CONFIG_SOURCE_REPO"] = params["CI_REPO"]
+	}
+	params["CI_CONFIG_SOURCE_SHA"] = m.ConfigSource.Sha
+	if params["CI_CONFIG_SOURCE_SHA"] == "" {
+		params["CI_CONFIG_SOURCE_SHA"] = m.Curr.Commit.Sha
+	}
+
 	if m.Curr.Event == EventTag || m.Curr.Event == EventRelease || strings.HasPrefix(m.Curr.Commit.Ref, "refs/tags/") {
 		params["CI_COMMIT_TAG"] = strings.TrimPrefix(m.Curr.Commit.Ref, "refs/tags/")
 	}
@@ -138,6 +153,18 @@ func (m *Metadata) Environ() map[string]string {
 		params["CI_COMMIT_PULL_REQUEST_LABELS"] = strings.Join(m.Curr.Commit.PullRequestLabels, ",")
 	}
 
+	// the matrix keys are set as variables by themselves too, these tell which of them come from the matrix
+	if len(m.Workflow.Matrix) != 0 {
+		matrix, err := json.Marshal(m.Workflow.Matrix)
+		if err != nil {
+			log.Error().Err(err).Msg("marshal workflow matrix")
+		}
+		params["CI_WORKFLOW_MATRIX"] = string(matrix)
+		for key, value := range m.Workflow.Matrix {
+			params["CI_WORKFLOW_MATRIX_"+invalidEnvChars.ReplaceAllString(strings.ToUpper(key), "_")] = value
+		}
+	}
+
 	// Only export changed files if maxChangedFiles is not exceeded
 	if len(m.Curr.Commit.ChangedFiles) == 0 {
 		params["CI_PIPELINE_FILES"] = "[]"
@@ -15,13 +15,66 @@
 package metadata
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/drone/envsubst"
+	"github.com/drone/envsubst/parse"
 )
 
+// variableReference matches a reference like ${GO_VERSION} or ${GO_VERSION:-1.22} which isn't escaped by a second $.
+var variableReference = regexp.MustCompile(`(^|[^$])\$\{([A-Za-z_][A-Za-z0-9_]*)([^}]*)\}`)
+
+// ErrUndefinedVariable is returned if a variable required with ${VAR:?} is not set.
+var ErrUndefinedVariable = errors.New("variable is not set")
+
+// SubstitutionError is returned if the variables of a config can't be substituted.
+type SubstitutionError struct {
+	// File is the config file, it is set by callers knowing the file.
+	File string `json:"file,omitempty"`
+	// Variable is the name of an undefined variable or the malformed reference like "${FOO".
+	Variable string `json:"variable"`
+	// Line is the line of a malformed reference, 0 if unknown.
+	Line int   `json:"line,omitempty"`
+	Err  error `json:"-"`
+}
+
+func (e *SubstitutionError) Error() string {
+	var msg string
+	if errors.Is(e.Err, ErrUndefinedVariable) {
+		msg = fmt.Sprintf("variable '%s' is not set", e.Variable)
+		if s := strings.TrimPrefix(e.Err.Error(), ErrUndefinedVariable.Error()); s != "" {
+			msg += s
+		}
+	} else if e.Line > 0 {
+		msg = fmt.Sprintf("invalid variable reference '%s' in line %d: %v", e.Variable, e.Line, e.Err)
+	} else {
+		msg = fmt.Sprintf("invalid variable reference: %v", e.Err)
+	}
+
+	if e.File != "" {
+		return e.File + ": " + msg
+	}
+	return msg
+}
+
+func (e *SubstitutionError) Unwrap() error {
+	return e.Err
+}
+
 func EnvVarSubst(yaml string, environ map[string]string) (string, error) {
+	tree, err := parse.Parse(yaml)
+	if err != nil {
+		reference, line := invalidReference(yaml)
+		return "", &SubstitutionError{Variable: reference, Line: line, Err: err}
+	}
+	if err := checkRequiredVariables(tree.Root, environ); err != nil {
+		return "", err
+	}
+
 	return envsubst.Eval(yaml, func(name string) string {
 		env := environ[name]
 		if strings.Contains(env, "\n") {
@@ -30,3 +83,111 @@ func EnvVarSubst(yaml string, environ map[string]string) (string, error) {
 		return env
 	})
 }
+
+// UnknownVariables returns the names of the variables referenced by s which are not in environ and
+// have no default, the substitution silently replaces them by an empty string. Names are returned once.
+func UnknownVariables(s string, environ map[string]string) []string {
+	var unknown []string
+	for _, match := range variableReference.FindAllStringSubmatch(s, -1) {
+		name, operator := match[2], match[3]
+		if _, ok := environ[name]; ok || providesDefault(operator) || slices.Contains(unknown, name) {
+			continue
+		}
+		unknown = append(unknown, name)
+	}
+	return unknown
+}
+
+// providesDefault reports whether the substitution operator provides a value for unset variables.
+func providesDefault(operator string) bool {
+	return strings.HasPrefix(operator, ":-") || strings.HasPrefix(operator, "-") ||
+		strings.HasPrefix(operator, ":=") || strings.HasPrefix(operator, "=")
+}
+
+// checkRequiredVariables returns an error for the first variable required with ${VAR:?}
+// which is not set or empty, like a shell does. Defaults like ${VAR:-${OTHER:?}} are only
+// checked if they are used.
+func checkRequiredVariables(node parse.Node, environ map[string]string) error {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		for _, n := range node.Nodes {
+			if err := checkRequiredVariables(n, environ); err != nil {
+				return err
+			}
+		}
+	case *parse.FuncNode:
+		if isDefaultFunc(node.Name) && isParamSet(node.Name, node.Param, environ) {
+			// like a shell the default isn't evaluated if the parameter is set
+			return nil
+		}
+		for _, n := range node.Args {
+			if err := checkRequiredVariables(n, environ); err != nil {
+				return err
+			}
+		}
+		if node.Name == ":?" && environ[node.Param] == "" {
+			err := ErrUndefinedVariable
+			if msg := requiredMessage(node.Args); msg != "" {
+				err = fmt.Errorf("%w: %s", ErrUndefinedVariable, msg)
+			}
+			return &SubstitutionError{Variable: node.Param, Err: err}
+		}
+	}
+	return nil
+}
+
+// isDefaultFunc returns true for the operators substituting their argument if the parameter is unset.
+func isDefaultFunc(name string) bool {
+	switch name {
+	case ":-", "-", ":=", "=":
+		return true
+	}
+	return false
+}
+
+// isParamSet returns true if the parameter is set for the default operator, like a shell the
+// operators with a colon also need it to be non-empty.
+func isParamSet(name, param string, environ map[string]string) bool {
+	value, ok := environ[param]
+	if strings.HasPrefix(name, ":") {
+		return value != ""
+	}
+	return ok
+}
+
+func requiredMessage(args []parse.Node) string {
+	var msg strings.Builder
+	for _, arg := range args {
+		if text, ok := arg.(*parse.TextNode); ok {
+			msg.WriteString(text.Value)
+		}
+	}
+	return msg.String()
+}
+
+// invalidReference returns the first variable reference which can't be parsed and its line.
+func invalidReference(yaml string) (string, int) {
+	for i, line := range strings.Split(yaml, "\n") {
+		for offset := 0; ; {
+			start := strings.Index(line[offset:], "${")
+			if start < 0 {
+				break
+			}
+			start += offset
+			offset = start + 2
+			// escaped with $${
+			if start > 0 && line[start-1] == '$' {
+				continue
+			}
+
+			reference := line[start:]
+			if end := strings.Index(reference, "}"); end >= 0 {
+				reference = reference[:end+1]
+			}
+			if _, err := parse.Parse(reference); err != nil {
+				return reference, i + 1
+			}
+		}
+	}
+	return "", 0
+}
@@ -35,6 +35,41 @@ func TestEnvVarSubst(t *testing.T) {
 		want: `steps:
 		step1:
 			image: hello-world`,
+	}, {
+		name:    "default for unset variable",
+		yaml:    "image: golang:${GO_VERSION:-1.22}",
+		environ: map[string]string{},
+		want:    "image: golang:1.22",
+	}, {
+		name:    "default for empty variable",
+		yaml:    "image: golang:${GO_VERSION:-1.22}",
+		environ: map[string]string{"GO_VERSION": ""},
+		want:    "image: golang:1.22",
+	}, {
+		name:    "default not used for set variable",
+		yaml:    "image: golang:${GO_VERSION:-1.22}",
+		environ: map[string]string{"GO_VERSION": "1.21"},
+		want:    "image: golang:1.21",
+	}, {
+		name:    "nested default",
+		yaml:    "image: golang:${GO_VERSION:-${DEFAULT_GO_VERSION:-1.22}}",
+		environ: map[string]string{},
+		want:    "image: golang:1.22",
+	}, {
+		name:    "nested variable in default",
+		yaml:    "tags: ${CI_COMMIT_TAG:-dev-${CI_COMMIT_BRANCH}}",
+		environ: map[string]string{"CI_COMMIT_BRANCH": "main"},
+		want:    "tags: dev-main",
+	}, {
+		name:    "escaped default",
+		yaml:    "commands: echo $${GO_VERSION:-1.22}",
+		environ: map[string]string{"GO_VERSION": "1.21"},
+		want:    "commands: echo ${GO_VERSION:-1.22}",
+	}, {
+		name:    "undefined variable without default",
+		yaml:    "image: golang:${GO_VERSION}",
+		environ: map[string]string{},
+		want:    "image: golang:",
 	}}
 
 	for _, testCase := range testCases {
@@ -45,3 +80,57 @@ func TestEnvVarSubst(t *testing.T) {
 		})
 	}
 }
+
+func TestEnvVarSubstErrors(t *testing.T) {
+	_, err := EnvVarSubst("steps:\n  build:\n    image: golang:${GO_VERSION:?use a matrix}", map[string]string{})
+	var substErr *SubstitutionError
+	if assert.ErrorAs(t, err, &substErr) {
+		assert.Equal(t, "GO_VERSION", substErr.Variable)
+		assert.ErrorIs(t, err, ErrUndefinedVariable)
+		assert.EqualError(t, err, "variable 'GO_VERSION' is not set: use a matrix")
+	}
+
+	_, err = EnvVarSubst("image: golang:${GO_VERSION:?}", map[string]string{"GO_VERSION": ""})
+	assert.EqualError(t, err, "variable 'GO_VERSION' is not set")
+
+	result, err := EnvVarSubst("image: golang:${GO_VERSION:?}", map[string]string{"GO_VERSION": "1.22"})
+	assert.NoError(t, err)
+	assert.Equal(t, "image: golang:1.22", result)
+
+	// the required variable is only checked if the default is used
+	result, err = EnvVarSubst("image: golang:${GO_VERSION:-${DEFAULT_GO_VERSION:?no go version}}", map[string]string{"GO_VERSION": "1.22"})
+	assert.NoError(t, err)
+	assert.Equal(t, "image: golang:1.22", result)
+
+	_, err = EnvVarSubst("image: golang:${GO_VERSION:-${DEFAULT_GO_VERSION:?no go version}}", map[string]string{})
+	assert.EqualError(t, err, "variable 'DEFAULT_GO_VERSION' is not set: no go version")
+
+	// without colon an empty parameter is set, with colon it isn't
+	_, err = EnvVarSubst("image: golang:${GO_VERSION=${DEFAULT_GO_VERSION:?no go version}}", map[string]string{"GO_VERSION": ""})
+	assert.NoError(t, err)
+	_, err = EnvVarSubst("image: golang:${GO_VERSION=${DEFAULT_GO_VERSION:?no go version}}", map[string]string{})
+	assert.EqualError(t, err, "variable 'DEFAULT_GO_VERSION' is not set: no go version")
+	_, err = EnvVarSubst("image: golang:${GO_VERSION:-${DEFAULT_GO_VERSION:?no go version}}", map[string]string{"GO_VERSION": ""})
+	assert.EqualError(t, err, "variable 'DEFAULT_GO_VERSION' is not set: no go version")
+
+	// escaped references are never required
+	result, err = EnvVarSubst("commands: echo $${GO_VERSION:?}", map[string]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "commands: echo ${GO_VERSION:?}", result)
+
+	_, err = EnvVarSubst("steps:\n  build:\n    image: golang\n    commands: echo $${HOME} ${CI_COMMIT_SHA", map[string]string{})
+	if assert.ErrorAs(t, err, &substErr) {
+		assert.Equal(t, "${CI_COMMIT_SHA", substErr.Variable)
+		assert.Equal(t, 4, substErr.Line)
+		assert.NotErrorIs(t, err, ErrUndefinedVariable)
+		assert.EqualError(t, err, "invalid variable reference '${CI_COMMIT_SHA' in line 4: missing closing brace")
+	}
+}
+
+func TestUnknownVariables(t *testing.T) {
+	environ := map[string]string{"GO_VERSION": "1.22"}
+
+	assert.Empty(t, UnknownVariables("golang:${GO_VERSION}", environ))
+	assert.Empty(t, UnknownVariables("golang:${GO:-1.22} ${GO=1.21} $${ESCAPED}", environ))
+	assert.Equal(t, []string{"GO", "OS"}, UnknownVariables("golang:${GO}-${OS} ${GO}", environ))
+}
@@ -17,14 +17,15 @@ package metadata
 type (
 	// Metadata defines runtime m.
 	Metadata struct {
-		ID       string   `json:"id,omitempty"`
-		Repo     Repo     `json:"repo,omitempty"`
-		Curr     Pipeline `json:"curr,omitempty"`
-		Prev     Pipeline `json:"prev,omitempty"`
-		Workflow Workflow `json:"workflow,omitempty"`
-		Step     Step     `json:"step,omitempty"`
-		Sys      System   `json:"sys,omitempty"`
-		Forge    Forge    `json:"forge,omitempty"`
+		ID           string       `json:"id,omitempty"`
+		Repo         Repo         `json:"repo,omitempty"`
+		Curr         Pipeline     `json:"curr,omitempty"`
+		Prev         Pipeline     `json:"prev,omitempty"`
+		Workflow     Workflow     `json:"workflow,omitempty"`
+		Step         Step         `json:"step,omitempty"`
+		Sys          System       `json:"sys,omitempty"`
+		Forge        Forge        `json:"forge,omitempty"`
+		ConfigSource ConfigSource `json:"config_source,omitempty"`
 	}
 
 	// Repo defines runtime metadata for a repository.
@@ -58,19 +59,31 @@ type (
 		Commit   Commit `json:"commit,omitempty"`
 		Parent   int64  `json:"parent,omitempty"`
 		Cron     string `json:"cron,omitempty"`
+		// EventHash is the hash of the webhook payload, empty if the pipeline wasn't created by a webhook
+		EventHash string `json:"event_hash,omitempty"`
+		// TraceID correlates the spans emitted by the steps of the pipeline
+		TraceID string `json:"trace_id,omitempty"`
 	}
 
 	// Commit defines runtime metadata for a commit.
 	Commit struct {
-		Sha               string   `json:"sha,omitempty"`
-		Ref               string   `json:"ref,omitempty"`
-		Refspec           string   `json:"refspec,omitempty"`
-		Branch            string   `json:"branch,omitempty"`
-		Message           string   `json:"message,omitempty"`
-		Author            Author   `json:"author,omitempty"`
-		ChangedFiles      []string `json:"changed_files,omitempty"`
-		PullRequestLabels []string `json:"labels,omitempty"`
-		IsPrerelease      bool     `json:"is_prerelease,omitempty"`
+		Sha          string   `json:"sha,omitempty"`
+		Ref          string   `json:"ref,omitempty"`
+		Refspec      string   `json:"refspec,omitempty"`
+		Branch       string   `json:"branch,omitempty"`
+		Message      string   `json:"message,omitempty"`
+		Author       Author   `json:"author,omitempty"`
+		ChangedFiles []string `json:"changed_files,omitempty"`
+		// ChangedFilesUnknown is set if the forge can't provide the changed files, path conditions match then
+		ChangedFilesUnknown bool     `json:"changed_files_unknown,omitempty"`
+		PullRequestLabels   []string `json:"labels,omitempty"`
+		IsPrerelease        bool     `json:"is_prerelease,omitempty"`
+	}
+
+	// ConfigSource defines the repository and commit the pipeline config was loaded from.
+	ConfigSource struct {
+		Repo string `json:"repo,omitempty"`
+		Sha  string `json:"sha,omitempty"`
 	}
 
 	// Author defines runtime metadata for a commit author.
@@ -114,6 +127,8 @@ type (
 	Forge struct {
 		Type string `json:"type,omitempty"`
 		URL  string `json:"url,omitempty"`
+		// RateLimitRemaining is the remaining API quota of the forge, empty if unknown.
+		RateLimitRemaining string `json:"ratelimit_remaining,omitempty"`
 	}
 
 	// ServerForge represent the needed func of a server forge to get its metadata.
@@ -123,4 +138,10 @@ type (
 		// URL returns the root url of a configured forge
 		URL() string
 	}
+
+	// RateLimitForge is implemented by server forges that know the remaining API quota of a token.
+	RateLimitForge interface {
+		// RateLimitRemaining returns the remaining API quota of the token reported by the last response for it
+		RateLimitRemaining(token string) (int, bool)
+	}
 )
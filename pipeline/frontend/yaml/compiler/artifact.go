@@ -0,0 +1,76 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"fmt"
+
+	backend_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/backend/types"
+	yaml_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/types"
+)
+
+const (
+	artifactRestoreName = "restore-artifacts"
+	artifactSaveName    = "save-artifacts"
+)
+
+// artifactRestoreStage creates a stage with one step per consumed workflow
+// copying its artifacts into the workspace.
+func (c *Compiler) artifactRestoreStage(consumes []*yaml_types.ArtifactDependency) (*backend_types.Stage, error) {
+	if c.artifactImage == "" {
+		return nil, &ErrArtifactImageMissing{}
+	}
+	stage := new(backend_types.Stage)
+	for _, dep := range consumes {
+		container := &yaml_types.Container{
+			Name:  fmt.Sprintf("%s-%s", artifactRestoreName, dep.Workflow),
+			Image: c.artifactImage,
+			Settings: map[string]any{
+				"action":   "restore",
+				"workflow": dep.Workflow,
+				"paths":    []string(dep.Paths),
+			},
+			Environment: make(map[string]any),
+		}
+		step, err := c.createProcess(container, backend_types.StepTypePlugin)
+		if err != nil {
+			return nil, err
+		}
+		stage.Steps = append(stage.Steps, step)
+	}
+	return stage, nil
+}
+
+// artifactSaveStage creates a stage saving the artifact paths of the workflow
+// after all steps succeeded.
+func (c *Compiler) artifactSaveStage(paths []string) (*backend_types.Stage, error) {
+	if c.artifactImage == "" {
+		return nil, &ErrArtifactImageMissing{}
+	}
+	container := &yaml_types.Container{
+		Name:  artifactSaveName,
+		Image: c.artifactImage,
+		Settings: map[string]any{
+			"action": "save",
+			"paths":  paths,
+		},
+		Environment: make(map[string]any),
+	}
+	step, err := c.createProcess(container, backend_types.StepTypePlugin)
+	if err != nil {
+		return nil, err
+	}
+	return &backend_types.Stage{Steps: []*backend_types.Step{step}}, nil
+}
@@ -16,6 +16,8 @@ package compiler
 
 import (
 	"fmt"
+	"slices"
+	"strconv"
 
 	backend_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/backend/types"
 	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/metadata"
@@ -40,6 +42,8 @@ type Secret struct {
 	Value          string
 	AllowedPlugins []string
 	Events         []string
+	// File secrets are written to a file in the step container, variables referencing them get the path
+	File bool
 }
 
 func (s *Secret) Available(event string, container *yaml_types.Container) error {
@@ -48,7 +52,7 @@ func (s *Secret) Available(event string, container *yaml_types.Container) error
 		return fmt.Errorf("secret %q only allowed to be used by plugins by step %q", s.Name, container.Name)
 	}
 
-	if onlyAllowSecretForPlugins && !utils.MatchImage(container.Image, s.AllowedPlugins...) {
+	if onlyAllowSecretForPlugins && !utils.MatchImageGlob(container.Image, s.AllowedPlugins...) {
 		return fmt.Errorf("secret %q is not allowed to be used with image %q by step %q", s.Name, container.Image, container.Name)
 	}
 
@@ -91,22 +95,32 @@ type ResourceLimit struct {
 
 // Compiler compiles the yaml.
 type Compiler struct {
-	local             bool
-	escalated         []string
-	prefix            string
-	volumes           []string
-	networks          []string
-	env               map[string]string
-	cloneEnv          map[string]string
-	base              string
-	path              string
-	metadata          metadata.Metadata
-	registries        []Registry
-	secrets           map[string]Secret
-	reslimit          ResourceLimit
-	defaultCloneImage string
-	trustedPipeline   bool
-	netrcOnlyTrusted  bool
+	local              bool
+	escalated          []string
+	privilegedDisabled bool
+	prefix             string
+	volumes            []string
+	networks           []string
+	env                map[string]string
+	cloneEnv           map[string]string
+	base               string
+	path               string
+	metadata           metadata.Metadata
+	registries         []Registry
+	secrets            map[string]Secret
+	secretFallback     func(name string) (string, bool)
+	reslimit           ResourceLimit
+	maxShmSize         int64
+	defaultCloneImage  string
+	artifactImage      string
+	trustedPipeline    bool
+	netrcOnlyTrusted   bool
+	readOnlyRootfs     bool
+	forcedUser         string
+	pull               bool
+	stepSelector       map[string]string
+	skippedSteps       []string
+	autoSkipClone      bool
 }
 
 // New creates a new Compiler with options.
@@ -140,19 +154,18 @@ func (c *Compiler) Compile(conf *yaml_types.Workflow) (*backend_types.Config, er
 		Name: fmt.Sprintf("%s_default", c.prefix),
 	})
 
+	// create a temp volume not shared with other workflows
+	if !c.local {
+		config.Volumes = append(config.Volumes, &backend_types.Volume{
+			Name: fmt.Sprintf("%s_tmp", c.prefix),
+		})
+	}
+
 	// create a default network
 	config.Networks = append(config.Networks, &backend_types.Network{
 		Name: fmt.Sprintf("%s_default", c.prefix),
 	})
 
-	// create secrets for mask
-	for _, sec := range c.secrets {
-		config.Secrets = append(config.Secrets, &backend_types.Secret{
-			Name:  sec.Name,
-			Value: sec.Value,
-		})
-	}
-
 	// overrides the default workspace paths when specified
 	// in the YAML file.
 	if len(conf.Workspace.Base) != 0 {
@@ -162,14 +175,21 @@ func (c *Compiler) Compile(conf *yaml_types.Workflow) (*backend_types.Config, er
 		c.path = conf.Workspace.Path
 	}
 
+	// steps inherit the pull setting of the workflow if they don't set it
+	c.pull = conf.Pull
+
 	cloneImage := constant.DefaultCloneImage
 	if len(c.defaultCloneImage) > 0 {
 		cloneImage = c.defaultCloneImage
 	}
 
+	// the workspace volume is created anyway, so steps have a working directory without clone
+	skipClone := conf.SkipClone || conf.Clone.Disable ||
+		(c.autoSkipClone && len(conf.Clone.ContainerList) == 0 && !usesSource(conf))
+
 	// add default clone step
-	if !c.local && len(conf.Clone.ContainerList) == 0 && !conf.SkipClone {
-		cloneSettings := map[string]any{"depth": "0"}
+	if !c.local && len(conf.Clone.ContainerList) == 0 && !skipClone {
+		cloneSettings := map[string]any{"depth": strconv.Itoa(conf.Clone.Depth)}
 		if c.metadata.Curr.Event == metadata.EventTag {
 			cloneSettings["tags"] = "true"
 		}
@@ -191,7 +211,7 @@ func (c *Compiler) Compile(conf *yaml_types.Workflow) (*backend_types.Config, er
 		stage.Steps = append(stage.Steps, step)
 
 		config.Stages = append(config.Stages, stage)
-	} else if !c.local && !conf.SkipClone {
+	} else if !c.local && !skipClone {
 		for _, container := range conf.Clone.ContainerList {
 			if match, err := container.When.Match(c.metadata, false, c.env); !match && err == nil {
 				continue
@@ -219,6 +239,15 @@ func (c *Compiler) Compile(conf *yaml_types.Workflow) (*backend_types.Config, er
 		}
 	}
 
+	// restore artifacts of the workflows this one depends on
+	if !c.local && len(conf.Consumes) != 0 {
+		stage, err := c.artifactRestoreStage(conf.Consumes)
+		if err != nil {
+			return nil, err
+		}
+		config.Stages = append(config.Stages, stage)
+	}
+
 	// add services steps
 	if len(conf.Services.ContainerList) != 0 {
 		stage := new(backend_types.Stage)
@@ -242,6 +271,7 @@ func (c *Compiler) Compile(conf *yaml_types.Workflow) (*backend_types.Config, er
 
 	// add pipeline steps
 	steps := make([]*dagCompilerStep, 0, len(conf.Steps.ContainerList))
+	initChains := make(map[*dagCompilerStep][]*dagCompilerStep)
 	for pos, container := range conf.Steps.ContainerList {
 		// Skip if local and should not run local
 		if c.local && !container.When.IsLocal() {
@@ -270,13 +300,42 @@ func (c *Compiler) Compile(conf *yaml_types.Workflow) (*backend_types.Config, er
 			}
 		}
 
-		steps = append(steps, &dagCompilerStep{
+		initSteps, err := c.createInitSteps(container, pos)
+		if err != nil {
+			return nil, err
+		}
+
+		// skipped steps and steps not matching the step selector are kept, but never run
+		if !c.matchStepSelector(container.Labels) || slices.Contains(c.skippedSteps, container.Name) {
+			step.Condition = "false"
+			for _, initStep := range initSteps {
+				initStep.step.Condition = "false"
+			}
+		}
+		steps = append(steps, initSteps...)
+
+		mainStep := &dagCompilerStep{
 			step:      step,
 			position:  pos,
 			name:      container.Name,
 			group:     container.Group,
 			dependsOn: container.DependsOn,
-		})
+		}
+		steps = append(steps, mainStep)
+		if len(initSteps) > 0 {
+			initChains[mainStep] = initSteps
+		}
+	}
+
+	// init steps run in sequence before their step, by depends_on they have to be chained explicitly
+	if newDAGCompiler(steps).isDAG() {
+		for mainStep, initSteps := range initChains {
+			initSteps[0].dependsOn = mainStep.dependsOn
+			for i := 1; i < len(initSteps); i++ {
+				initSteps[i].dependsOn = []string{initSteps[i-1].name}
+			}
+			mainStep.dependsOn = []string{initSteps[len(initSteps)-1].name}
+		}
 	}
 
 	// generate stages out of steps
@@ -287,5 +346,72 @@ func (c *Compiler) Compile(conf *yaml_types.Workflow) (*backend_types.Config, er
 
 	config.Stages = append(config.Stages, stepStages...)
 
+	// save artifacts for dependent workflows
+	if !c.local && len(conf.Artifacts) != 0 {
+		stage, err := c.artifactSaveStage(conf.Artifacts)
+		if err != nil {
+			return nil, err
+		}
+		config.Stages = append(config.Stages, stage)
+	}
+
+	// create secrets for mask, after the steps as those can add secrets from the fallback
+	for _, sec := range c.secrets {
+		config.Secrets = append(config.Secrets, &backend_types.Secret{
+			Name:  sec.Name,
+			Value: sec.Value,
+		})
+	}
+
 	return config, nil
 }
+
+// createInitSteps creates the init steps of a step. Init steps without a name are named after the step.
+func (c *Compiler) createInitSteps(container *yaml_types.Container, pos int) ([]*dagCompilerStep, error) {
+	steps := make([]*dagCompilerStep, 0, len(container.Init))
+	for i, init := range container.Init {
+		initContainer := *init
+		if initContainer.Name == "" {
+			initContainer.Name = fmt.Sprintf("%s-init-%d", container.Name, i+1)
+		}
+		step, err := c.createProcess(&initContainer, backend_types.StepTypeCommands)
+		if err != nil {
+			return nil, err
+		}
+		if c.trustedPipeline {
+			for k, v := range c.cloneEnv {
+				step.Environment[k] = v
+			}
+		}
+		steps = append(steps, &dagCompilerStep{
+			step:     step,
+			position: pos,
+			name:     initContainer.Name,
+		})
+	}
+	return steps, nil
+}
+
+// matchStepSelector checks if the step labels contain all labels of the step selector.
+func (c *Compiler) matchStepSelector(labels map[string]string) bool {
+	for k, v := range c.stepSelector {
+		if value, ok := labels[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+// usesSource returns true if the workflow might use the cloned source, it saves
+// artifacts or any of its steps or services runs commands.
+func usesSource(conf *yaml_types.Workflow) bool {
+	if len(conf.Artifacts) != 0 {
+		return true
+	}
+	for _, container := range slices.Concat(conf.Steps.ContainerList, conf.Services.ContainerList) {
+		if !container.IsPlugin() {
+			return true
+		}
+	}
+	return false
+}
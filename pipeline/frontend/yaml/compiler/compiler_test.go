@@ -15,7 +15,10 @@
 package compiler
 
 import (
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -58,6 +61,24 @@ func TestSecretAvailable(t *testing.T) {
 	assert.ErrorContains(t, secret.Available("pull_request", &yaml_types.Container{
 		Image: "golang",
 	}), "not allowed to be used with pipeline event ")
+
+	// secret available for all plugins of a registry path
+	secret = Secret{
+		Name:           "foo",
+		AllowedPlugins: []string{"registry.example.com/ci/*"},
+		Events:         []string{"push"},
+	}
+	assert.NoError(t, secret.Available("push", &yaml_types.Container{
+		Name:  "step",
+		Image: "registry.example.com/ci/deploy:1.0",
+	}))
+	assert.ErrorContains(t, secret.Available("push", &yaml_types.Container{
+		Image:    "registry.example.com/ci/deploy:1.0",
+		Commands: yaml_base_types.StringOrSlice{"env"},
+	}), "only allowed to be used by plugins by step")
+	assert.ErrorContains(t, secret.Available("push", &yaml_types.Container{
+		Image: "registry.example.com/other/deploy",
+	}), "not allowed to be used with image ")
 }
 
 func TestCompilerCompile(t *testing.T) {
@@ -83,6 +104,8 @@ func TestCompilerCompile(t *testing.T) {
 	}}
 	defaultVolumes := []*backend_types.Volume{{
 		Name: "test_default",
+	}, {
+		Name: "test_tmp",
 	}}
 
 	defaultCloneStage := &backend_types.Stage{
@@ -92,7 +115,7 @@ func TestCompilerCompile(t *testing.T) {
 			Image:      constant.DefaultCloneImage,
 			OnSuccess:  true,
 			Failure:    "fail",
-			Volumes:    []string{defaultVolumes[0].Name + ":"},
+			Volumes:    []string{defaultVolumes[0].Name + ":", defaultVolumes[1].Name + ":/woodpecker-tmp"},
 			Networks:   []backend_types.Conn{{Name: "test_default", Aliases: []string{"clone"}}},
 			ExtraHosts: []backend_types.HostAlias{},
 		}},
@@ -112,6 +135,14 @@ func TestCompilerCompile(t *testing.T) {
 				Volumes:  defaultVolumes,
 			},
 		},
+		{
+			name:     "empty workflow, clone disabled",
+			fronConf: &yaml_types.Workflow{Clone: yaml_types.WorkflowClone{Disable: true}},
+			backConf: &backend_types.Config{
+				Networks: defaultNetworks,
+				Volumes:  defaultVolumes,
+			},
+		},
 		{
 			name:     "empty workflow, default clone",
 			fronConf: &yaml_types.Workflow{},
@@ -137,7 +168,7 @@ func TestCompilerCompile(t *testing.T) {
 						Image:      "dummy_img",
 						OnSuccess:  true,
 						Failure:    "fail",
-						Volumes:    []string{defaultVolumes[0].Name + ":"},
+						Volumes:    []string{defaultVolumes[0].Name + ":", defaultVolumes[1].Name + ":/woodpecker-tmp"},
 						Networks:   []backend_types.Conn{{Name: "test_default", Aliases: []string{"dummy"}}},
 						ExtraHosts: []backend_types.HostAlias{},
 					}},
@@ -172,7 +203,7 @@ func TestCompilerCompile(t *testing.T) {
 						Commands:   []string{"env"},
 						OnSuccess:  true,
 						Failure:    "fail",
-						Volumes:    []string{defaultVolumes[0].Name + ":"},
+						Volumes:    []string{defaultVolumes[0].Name + ":", defaultVolumes[1].Name + ":/woodpecker-tmp"},
 						Networks:   []backend_types.Conn{{Name: "test_default", Aliases: []string{"echo env"}}},
 						ExtraHosts: []backend_types.HostAlias{},
 					}},
@@ -184,7 +215,7 @@ func TestCompilerCompile(t *testing.T) {
 						Commands:   []string{"echo 1"},
 						OnSuccess:  true,
 						Failure:    "fail",
-						Volumes:    []string{defaultVolumes[0].Name + ":"},
+						Volumes:    []string{defaultVolumes[0].Name + ":", defaultVolumes[1].Name + ":/woodpecker-tmp"},
 						Networks:   []backend_types.Conn{{Name: "test_default", Aliases: []string{"parallel echo 1"}}},
 						ExtraHosts: []backend_types.HostAlias{},
 					}, {
@@ -194,7 +225,7 @@ func TestCompilerCompile(t *testing.T) {
 						Commands:   []string{"echo 2"},
 						OnSuccess:  true,
 						Failure:    "fail",
-						Volumes:    []string{defaultVolumes[0].Name + ":"},
+						Volumes:    []string{defaultVolumes[0].Name + ":", defaultVolumes[1].Name + ":/woodpecker-tmp"},
 						Networks:   []backend_types.Conn{{Name: "test_default", Aliases: []string{"parallel echo 2"}}},
 						ExtraHosts: []backend_types.HostAlias{},
 					}},
@@ -228,7 +259,7 @@ func TestCompilerCompile(t *testing.T) {
 						Commands:   []string{"env"},
 						OnSuccess:  true,
 						Failure:    "fail",
-						Volumes:    []string{defaultVolumes[0].Name + ":"},
+						Volumes:    []string{defaultVolumes[0].Name + ":", defaultVolumes[1].Name + ":/woodpecker-tmp"},
 						Networks:   []backend_types.Conn{{Name: "test_default", Aliases: []string{"echo env"}}},
 						ExtraHosts: []backend_types.HostAlias{},
 					}, {
@@ -238,7 +269,7 @@ func TestCompilerCompile(t *testing.T) {
 						Commands:   []string{"echo 2"},
 						OnSuccess:  true,
 						Failure:    "fail",
-						Volumes:    []string{defaultVolumes[0].Name + ":"},
+						Volumes:    []string{defaultVolumes[0].Name + ":", defaultVolumes[1].Name + ":/woodpecker-tmp"},
 						Networks:   []backend_types.Conn{{Name: "test_default", Aliases: []string{"echo 2"}}},
 						ExtraHosts: []backend_types.HostAlias{},
 					}},
@@ -250,7 +281,7 @@ func TestCompilerCompile(t *testing.T) {
 						Commands:   []string{"echo 1"},
 						OnSuccess:  true,
 						Failure:    "fail",
-						Volumes:    []string{defaultVolumes[0].Name + ":"},
+						Volumes:    []string{defaultVolumes[0].Name + ":", defaultVolumes[1].Name + ":/woodpecker-tmp"},
 						Networks:   []backend_types.Conn{{Name: "test_default", Aliases: []string{"echo 1"}}},
 						ExtraHosts: []backend_types.HostAlias{},
 					}},
@@ -379,3 +410,706 @@ func TestCompilerCompilePrivileged(t *testing.T) {
 	assert.False(t, backConf.Stages[0].Steps[1].Privileged)
 	assert.False(t, backConf.Stages[0].Steps[2].Privileged)
 }
+
+func TestCompilerCompileDiamondDependsOn(t *testing.T) {
+	compiler := New()
+
+	fronConf := &yaml_types.Workflow{
+		SkipClone: true,
+		Steps: yaml_types.ContainerList{
+			ContainerList: []*yaml_types.Container{
+				{
+					Name:      "deploy",
+					Image:     "bash",
+					Commands:  []string{"echo deploy"},
+					DependsOn: []string{"test", "lint"},
+				},
+				{
+					Name:      "test",
+					Image:     "bash",
+					Commands:  []string{"echo test"},
+					DependsOn: []string{"build"},
+				},
+				{
+					Name:      "lint",
+					Image:     "bash",
+					Commands:  []string{"echo lint"},
+					DependsOn: []string{"build"},
+				},
+				{
+					Name:     "build",
+					Image:    "bash",
+					Commands: []string{"echo build"},
+				},
+			},
+		},
+	}
+
+	backConf, err := compiler.Compile(fronConf)
+	assert.NoError(t, err)
+
+	var stageNames [][]string
+	for _, stage := range backConf.Stages {
+		var names []string
+		for _, step := range stage.Steps {
+			names = append(names, step.Name)
+		}
+		stageNames = append(stageNames, names)
+	}
+	assert.Equal(t, [][]string{{"build"}, {"test", "lint"}, {"deploy"}}, stageNames)
+
+	fronConf.Steps.ContainerList[3].DependsOn = []string{"deploy"}
+	_, err = compiler.Compile(fronConf)
+	assert.ErrorIs(t, err, &ErrStepDependencyCycle{})
+}
+
+func TestCompilerCompileFailureMessage(t *testing.T) {
+	compiler := New()
+
+	backConf, err := compiler.Compile(&yaml_types.Workflow{
+		SkipClone: true,
+		Steps: yaml_types.ContainerList{
+			ContainerList: []*yaml_types.Container{{
+				Name:           "migrate",
+				Image:          "golang",
+				Commands:       []string{"go run ./cmd/migrate"},
+				FailureMessage: "check your DB migration",
+			}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "check your DB migration", backConf.Stages[0].Steps[0].FailureMessage)
+}
+
+func TestCompilerCompileReadOnlyRootfs(t *testing.T) {
+	fronConf := &yaml_types.Workflow{
+		Steps: yaml_types.ContainerList{
+			ContainerList: []*yaml_types.Container{{
+				Name:     "read-only",
+				Image:    "golang",
+				Commands: []string{"go build"},
+				ReadOnly: true,
+			}, {
+				Name:     "writable",
+				Image:    "golang",
+				Commands: []string{"go test"},
+			}},
+		},
+	}
+
+	backConf, err := New().Compile(fronConf)
+	assert.NoError(t, err)
+	cloneStep := backConf.Stages[0].Steps[0]
+	readOnlyStep := backConf.Stages[1].Steps[0]
+	writableStep := backConf.Stages[2].Steps[0]
+	assert.False(t, cloneStep.ReadOnlyRootfs)
+	assert.True(t, readOnlyStep.ReadOnlyRootfs)
+	assert.Equal(t, []string{"/tmp"}, readOnlyStep.Tmpfs)
+	assert.False(t, writableStep.ReadOnlyRootfs)
+	assert.Empty(t, writableStep.Tmpfs)
+
+	// untrusted repos can be forced to use a read-only root filesystem
+	backConf, err = New(WithReadOnlyRootfs(true)).Compile(fronConf)
+	assert.NoError(t, err)
+	cloneStep = backConf.Stages[0].Steps[0]
+	writableStep = backConf.Stages[2].Steps[0]
+	assert.False(t, cloneStep.ReadOnlyRootfs)
+	assert.True(t, writableStep.ReadOnlyRootfs)
+	assert.Equal(t, []string{"/tmp"}, writableStep.Tmpfs)
+}
+
+func TestCompilerCompileTmpfs(t *testing.T) {
+	backConf, err := New().Compile(&yaml_types.Workflow{
+		SkipClone: true,
+		Steps: yaml_types.ContainerList{
+			ContainerList: []*yaml_types.Container{{
+				Name:     "build",
+				Image:    "golang",
+				Commands: []string{"go build"},
+				Tmpfs:    []string{"/tmp/fast:size=64m", "/cache"},
+			}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/tmp/fast:size=64m", "/cache"}, backConf.Stages[0].Steps[0].Tmpfs)
+}
+
+func TestCompilerCompileResources(t *testing.T) {
+	backConf, err := New(WithResourceLimit(0, 1<<30, 0, 100000, 0, "")).Compile(&yaml_types.Workflow{
+		SkipClone: true,
+		Steps: yaml_types.ContainerList{
+			ContainerList: []*yaml_types.Container{{
+				Name:     "build",
+				Image:    "golang",
+				Commands: []string{"go build"},
+				Resources: &yaml_types.Resources{
+					MemLimit: 512 << 20,
+					CPUSet:   "0-1",
+				},
+			}, {
+				Name:     "notify",
+				Image:    "alpine",
+				Commands: []string{"./notify.sh"},
+			}},
+		},
+	})
+	assert.NoError(t, err)
+	build := backConf.Stages[0].Steps[0]
+	assert.EqualValues(t, 512<<20, build.MemLimit)
+	assert.EqualValues(t, 100000, build.CPUQuota)
+	assert.Equal(t, "0-1", build.CPUSet)
+	notify := backConf.Stages[1].Steps[0]
+	assert.EqualValues(t, 1<<30, notify.MemLimit)
+	assert.EqualValues(t, 100000, notify.CPUQuota)
+	assert.Empty(t, notify.CPUSet)
+
+	// the step can't use CPUs outside of the cpuset of the server
+	for cpuSet, want := range map[string]string{"1": "1", "2-5": "0-3"} {
+		backConf, err = New(WithResourceLimit(0, 0, 0, 0, 0, "0-3")).Compile(&yaml_types.Workflow{
+			SkipClone: true,
+			Steps: yaml_types.ContainerList{
+				ContainerList: []*yaml_types.Container{{
+					Name:      "build",
+					Image:     "golang",
+					Commands:  []string{"go build"},
+					Resources: &yaml_types.Resources{CPUSet: cpuSet},
+				}},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, want, backConf.Stages[0].Steps[0].CPUSet)
+	}
+}
+
+func TestCompilerCompileFileSecret(t *testing.T) {
+	backConf, err := New(WithSecret(Secret{
+		Name:  "kubeconfig",
+		Value: "apiVersion: v1",
+		File:  true,
+	}, Secret{
+		Name:  "token",
+		Value: "s3cr3t",
+	})).Compile(&yaml_types.Workflow{
+		SkipClone: true,
+		Steps: yaml_types.ContainerList{
+			ContainerList: []*yaml_types.Container{{
+				Name:     "deploy",
+				Image:    "bitnami/kubectl",
+				Commands: []string{"kubectl apply -f deploy.yaml"},
+				Secrets: yaml_types.Secrets{Secrets: []*yaml_types.Secret{
+					{Source: "kubeconfig", Target: "kubeconfig"},
+					{Source: "token", Target: "token"},
+				}},
+			}, {
+				Name:     "test",
+				Image:    "golang",
+				Commands: []string{"go test"},
+			}},
+		},
+	})
+	assert.NoError(t, err)
+	deploy := backConf.Stages[0].Steps[0]
+	assert.Equal(t, "/run/woodpecker/secrets/kubeconfig", deploy.Environment["KUBECONFIG"])
+	assert.Equal(t, "s3cr3t", deploy.Environment["TOKEN"])
+	assert.Equal(t, map[string]string{"/run/woodpecker/secrets/kubeconfig": "apiVersion: v1"}, deploy.Files)
+	assert.Nil(t, backConf.Stages[1].Steps[0].Files)
+}
+
+func TestCompilerCompileCloneDepth(t *testing.T) {
+	backConf, err := New().Compile(&yaml_types.Workflow{
+		Clone: yaml_types.WorkflowClone{Depth: 1},
+		Steps: yaml_types.ContainerList{ContainerList: []*yaml_types.Container{{
+			Name:     "build",
+			Image:    "golang",
+			Commands: []string{"go build"},
+		}}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", backConf.Stages[0].Steps[0].Environment["PLUGIN_DEPTH"])
+
+	backConf, err = New().Compile(&yaml_types.Workflow{
+		Steps: yaml_types.ContainerList{ContainerList: []*yaml_types.Container{{
+			Name:     "build",
+			Image:    "golang",
+			Commands: []string{"go build"},
+		}}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "0", backConf.Stages[0].Steps[0].Environment["PLUGIN_DEPTH"])
+}
+
+func TestCompilerCompileAutoSkipClone(t *testing.T) {
+	notify := &yaml_types.Container{
+		Name:     "notify",
+		Image:    "plugins/webhook",
+		Settings: map[string]any{"urls": "https://example.com/hook"},
+	}
+	build := &yaml_types.Container{
+		Name:     "build",
+		Image:    "golang",
+		Commands: []string{"go build"},
+	}
+	hasClone := func(conf *backend_types.Config) bool {
+		return len(conf.Stages) != 0 && conf.Stages[0].Steps[0].Type == backend_types.StepTypeClone
+	}
+
+	// plugins only
+	conf := &yaml_types.Workflow{Steps: yaml_types.ContainerList{ContainerList: []*yaml_types.Container{notify}}}
+	backConf, err := New(WithAutoSkipClone()).Compile(conf)
+	assert.NoError(t, err)
+	assert.False(t, hasClone(backConf))
+
+	// the option is opt-in
+	backConf, err = New().Compile(conf)
+	assert.NoError(t, err)
+	assert.True(t, hasClone(backConf))
+
+	// steps with commands
+	backConf, err = New(WithAutoSkipClone()).Compile(&yaml_types.Workflow{Steps: yaml_types.ContainerList{ContainerList: []*yaml_types.Container{notify, build}}})
+	assert.NoError(t, err)
+	assert.True(t, hasClone(backConf))
+
+	// saved artifacts
+	backConf, err = New(WithAutoSkipClone(), WithArtifactImage("artifact-plugin")).Compile(&yaml_types.Workflow{
+		Steps:     yaml_types.ContainerList{ContainerList: []*yaml_types.Container{notify}},
+		Artifacts: []string{"dist"},
+	})
+	assert.NoError(t, err)
+	assert.True(t, hasClone(backConf))
+}
+
+func TestCompilerCompileCache(t *testing.T) {
+	backConf, err := New().Compile(&yaml_types.Workflow{
+		SkipClone: true,
+		Steps: yaml_types.ContainerList{
+			ContainerList: []*yaml_types.Container{{
+				Name:     "build",
+				Image:    "golang",
+				Commands: []string{"go build"},
+				Cache: &yaml_types.Cache{
+					Key:   "go-main",
+					Paths: []string{".cache/go-build"},
+				},
+			}, {
+				Name:     "test",
+				Image:    "golang",
+				Commands: []string{"go test"},
+			}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &backend_types.StepCache{Key: "go-main", Paths: []string{".cache/go-build"}}, backConf.Stages[0].Steps[0].Cache)
+	assert.Nil(t, backConf.Stages[1].Steps[0].Cache)
+}
+
+func TestCompilerCompileDNSAndExtraHosts(t *testing.T) {
+	newWorkflow := func(dns, extraHosts []string) *yaml_types.Workflow {
+		return &yaml_types.Workflow{
+			SkipClone: true,
+			Steps: yaml_types.ContainerList{
+				ContainerList: []*yaml_types.Container{{
+					Name:       "integration",
+					Image:      "golang",
+					Commands:   []string{"go test"},
+					DNS:        dns,
+					ExtraHosts: extraHosts,
+				}},
+			},
+		}
+	}
+
+	backConf, err := New().Compile(newWorkflow(
+		[]string{"10.0.0.53", "2001:db8::53"},
+		[]string{"db.internal:10.0.0.10", "host.docker.internal:host-gateway", "v6.internal:2001:db8::1"},
+	))
+	assert.NoError(t, err)
+	step := backConf.Stages[0].Steps[0]
+	assert.Equal(t, []string{"10.0.0.53", "2001:db8::53"}, step.DNS)
+	assert.Equal(t, []backend_types.HostAlias{
+		{Name: "db.internal", IP: "10.0.0.10"},
+		{Name: "host.docker.internal", IP: "host-gateway"},
+		{Name: "v6.internal", IP: "2001:db8::1"},
+	}, step.ExtraHosts)
+
+	_, err = New().Compile(newWorkflow(nil, []string{"db.internal"}))
+	assert.ErrorIs(t, err, &ErrExtraHostFormat{})
+	_, err = New().Compile(newWorkflow(nil, []string{"db.internal:not-an-ip"}))
+	assert.ErrorIs(t, err, &ErrExtraHostFormat{})
+	_, err = New().Compile(newWorkflow([]string{"dns.internal"}, nil))
+	assert.ErrorIs(t, err, &ErrDNSFormat{})
+}
+
+func TestCompilerCompileArtifacts(t *testing.T) {
+	backConf, err := New(
+		WithArtifactImage("artifact-plugin"),
+	).Compile(&yaml_types.Workflow{
+		SkipClone: true,
+		Steps: yaml_types.ContainerList{
+			ContainerList: []*yaml_types.Container{{
+				Name:     "package",
+				Image:    "golang",
+				Commands: []string{"tar -czf release.tar.gz dist"},
+			}},
+		},
+		Artifacts: []string{"release.tar.gz"},
+		Consumes: []*yaml_types.ArtifactDependency{
+			{Workflow: "build", Paths: []string{"dist"}},
+			{Workflow: "docs", Paths: []string{"public", "man"}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, backConf.Stages, 3)
+
+	restore := backConf.Stages[0].Steps
+	assert.Len(t, restore, 2)
+	assert.Equal(t, "restore-artifacts-build", restore[0].Name)
+	assert.Equal(t, "artifact-plugin", restore[0].Image)
+	assert.Equal(t, backend_types.StepTypePlugin, restore[0].Type)
+	assert.Equal(t, "restore", restore[0].Environment["PLUGIN_ACTION"])
+	assert.Equal(t, "build", restore[0].Environment["PLUGIN_WORKFLOW"])
+	assert.Equal(t, "dist", restore[0].Environment["PLUGIN_PATHS"])
+	assert.Equal(t, "public,man", restore[1].Environment["PLUGIN_PATHS"])
+
+	assert.Equal(t, "package", backConf.Stages[1].Steps[0].Name)
+
+	save := backConf.Stages[2].Steps
+	assert.Len(t, save, 1)
+	assert.Equal(t, "save-artifacts", save[0].Name)
+	assert.Equal(t, "save", save[0].Environment["PLUGIN_ACTION"])
+	assert.Equal(t, "release.tar.gz", save[0].Environment["PLUGIN_PATHS"])
+
+	// nothing to save or restore when running locally
+	backConf, err = New(WithLocal(true)).Compile(&yaml_types.Workflow{
+		SkipClone: true,
+		Steps: yaml_types.ContainerList{
+			ContainerList: []*yaml_types.Container{{
+				Name:     "package",
+				Image:    "golang",
+				Commands: []string{"tar -czf release.tar.gz dist"},
+			}},
+		},
+		Artifacts: []string{"release.tar.gz"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, backConf.Stages, 1)
+
+	// artifacts require the artifact image
+	_, err = New().Compile(&yaml_types.Workflow{
+		SkipClone: true,
+		Steps: yaml_types.ContainerList{
+			ContainerList: []*yaml_types.Container{{
+				Name:     "package",
+				Image:    "golang",
+				Commands: []string{"tar -czf release.tar.gz dist"},
+			}},
+		},
+		Artifacts: []string{"release.tar.gz"},
+	})
+	assert.ErrorIs(t, err, &ErrArtifactImageMissing{})
+}
+
+func TestCompilerCompileStepSelector(t *testing.T) {
+	workflow := &yaml_types.Workflow{
+		SkipClone: true,
+		Steps: yaml_types.ContainerList{
+			ContainerList: []*yaml_types.Container{{
+				Name:     "lint",
+				Image:    "golang",
+				Commands: []string{"go vet"},
+				Labels:   map[string]string{"speed": "fast"},
+			}, {
+				Name:     "test",
+				Image:    "golang",
+				Commands: []string{"go test -short"},
+				Labels:   map[string]string{"speed": "fast", "kind": "test"},
+			}, {
+				Name:     "integration",
+				Image:    "golang",
+				Commands: []string{"go test"},
+				Labels:   map[string]string{"speed": "slow", "kind": "test"},
+			}, {
+				Name:     "build",
+				Image:    "golang",
+				Commands: []string{"go build"},
+			}},
+		},
+	}
+
+	stepNames := func(config *backend_types.Config) (run, skipped []string) {
+		for _, stage := range config.Stages {
+			for _, step := range stage.Steps {
+				if step.Condition == "false" {
+					skipped = append(skipped, step.Name)
+				} else {
+					run = append(run, step.Name)
+				}
+			}
+		}
+		return run, skipped
+	}
+
+	backConf, err := New().Compile(workflow)
+	assert.NoError(t, err)
+	run, skipped := stepNames(backConf)
+	assert.Equal(t, []string{"lint", "test", "integration", "build"}, run)
+	assert.Empty(t, skipped)
+
+	backConf, err = New(WithStepSelector(map[string]string{"speed": "fast"})).Compile(workflow)
+	assert.NoError(t, err)
+	run, skipped = stepNames(backConf)
+	assert.Equal(t, []string{"lint", "test"}, run)
+	assert.Equal(t, []string{"integration", "build"}, skipped)
+
+	backConf, err = New(WithStepSelector(map[string]string{"speed": "fast", "kind": "test"})).Compile(workflow)
+	assert.NoError(t, err)
+	run, skipped = stepNames(backConf)
+	assert.Equal(t, []string{"test"}, run)
+	assert.Equal(t, []string{"lint", "integration", "build"}, skipped)
+}
+
+func TestCompilerCompileWorkspaceTmp(t *testing.T) {
+	workflow := &yaml_types.Workflow{
+		SkipClone: true,
+		Steps: yaml_types.ContainerList{
+			ContainerList: []*yaml_types.Container{{
+				Name:     "build",
+				Image:    "golang",
+				Commands: []string{"go build"},
+			}},
+		},
+	}
+
+	backConf, err := New(WithPrefix("wp_01")).Compile(workflow)
+	assert.NoError(t, err)
+	assert.Contains(t, backConf.Volumes, &backend_types.Volume{Name: "wp_01_tmp"})
+	step := backConf.Stages[0].Steps[0]
+	assert.Equal(t, "/woodpecker-tmp", step.Environment["CI_WORKSPACE_TMP"])
+	assert.Contains(t, step.Volumes, "wp_01_tmp:/woodpecker-tmp")
+
+	// a local run has no volumes
+	backConf, err = New(WithPrefix("wp_01"), WithLocal(true)).Compile(workflow)
+	assert.NoError(t, err)
+	assert.NotContains(t, backConf.Volumes, &backend_types.Volume{Name: "wp_01_tmp"})
+	assert.NotContains(t, backConf.Stages[0].Steps[0].Environment, "CI_WORKSPACE_TMP")
+}
+
+func TestCompilerCompileUser(t *testing.T) {
+	newWorkflow := func(user string) *yaml_types.Workflow {
+		return &yaml_types.Workflow{
+			SkipClone: true,
+			Steps: yaml_types.ContainerList{
+				ContainerList: []*yaml_types.Container{{
+					Name:     "build",
+					Image:    "golang",
+					Commands: []string{"go build"},
+					User:     user,
+				}, {
+					Name:     "test",
+					Image:    "golang",
+					Commands: []string{"go test"},
+				}},
+			},
+		}
+	}
+
+	backConf, err := New().Compile(newWorkflow("1000:1000"))
+	assert.NoError(t, err)
+	assert.Equal(t, "1000:1000", backConf.Stages[0].Steps[0].User)
+	assert.Equal(t, "", backConf.Stages[1].Steps[0].User)
+
+	// forced for untrusted repos
+	backConf, err = New(WithForcedUser("2000")).Compile(newWorkflow("0"))
+	assert.NoError(t, err)
+	assert.Equal(t, "2000", backConf.Stages[0].Steps[0].User)
+	assert.Equal(t, "2000", backConf.Stages[1].Steps[0].User)
+
+	// the clone step keeps its user
+	workflow := newWorkflow("0")
+	workflow.SkipClone = false
+	backConf, err = New(WithForcedUser("2000")).Compile(workflow)
+	assert.NoError(t, err)
+	assert.Equal(t, backend_types.StepTypeClone, backConf.Stages[0].Steps[0].Type)
+	assert.Equal(t, "", backConf.Stages[0].Steps[0].User)
+	assert.Equal(t, "2000", backConf.Stages[1].Steps[0].User)
+
+	_, err = New().Compile(newWorkflow("root"))
+	assert.ErrorIs(t, err, &ErrUserFormat{})
+	_, err = New().Compile(newWorkflow("1000:"))
+	assert.ErrorIs(t, err, &ErrUserFormat{})
+}
+
+func TestCompilerCompilePullDefault(t *testing.T) {
+	pull := false
+	backConf, err := New().Compile(&yaml_types.Workflow{
+		SkipClone: true,
+		Pull:      true,
+		Steps: yaml_types.ContainerList{
+			ContainerList: []*yaml_types.Container{{
+				Name:     "build",
+				Image:    "golang",
+				Commands: []string{"go build"},
+			}, {
+				Name:     "publish",
+				Image:    "plugins/docker",
+				Settings: map[string]any{"repo": "foo/bar"},
+				Pull:     &pull,
+			}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.True(t, backConf.Stages[0].Steps[0].Pull)
+	assert.False(t, backConf.Stages[1].Steps[0].Pull)
+}
+
+func TestCompilerCompileStopSignal(t *testing.T) {
+	newWorkflow := func(signal, gracePeriod string) *yaml_types.Workflow {
+		return &yaml_types.Workflow{
+			SkipClone: true,
+			Services: yaml_types.ContainerList{
+				ContainerList: []*yaml_types.Container{{
+					Name:       "database",
+					Image:      "postgres",
+					StopSignal: signal,
+					StopGrace:  gracePeriod,
+				}},
+			},
+			Steps: yaml_types.ContainerList{
+				ContainerList: []*yaml_types.Container{{
+					Name:     "test",
+					Image:    "golang",
+					Commands: []string{"go test"},
+				}},
+			},
+		}
+	}
+
+	backConf, err := New().Compile(newWorkflow("SIGINT", "30s"))
+	assert.NoError(t, err)
+	service := backConf.Stages[0].Steps[0]
+	assert.Equal(t, "SIGINT", service.StopSignal)
+	assert.Equal(t, 30*time.Second, service.StopGrace)
+	assert.Equal(t, "", backConf.Stages[1].Steps[0].StopSignal)
+	assert.Equal(t, time.Duration(0), backConf.Stages[1].Steps[0].StopGrace)
+
+	_, err = New().Compile(newWorkflow("SIGSTOP", ""))
+	assert.ErrorIs(t, err, &ErrStopSignal{})
+	_, err = New().Compile(newWorkflow("", "30"))
+	assert.ErrorIs(t, err, &ErrStopGracePeriod{})
+	_, err = New().Compile(newWorkflow("", "-1m"))
+	assert.ErrorIs(t, err, &ErrStopGracePeriod{})
+}
+
+func TestCompilerCompileSecretFallback(t *testing.T) {
+	t.Setenv("DEPLOY_TOKEN", "from-host")
+
+	workflow := &yaml_types.Workflow{
+		Steps: yaml_types.ContainerList{
+			ContainerList: []*yaml_types.Container{{
+				Name:        "deploy",
+				Image:       "alpine",
+				Commands:    []string{"./deploy.sh"},
+				Environment: map[string]any{"TOKEN": map[string]any{"from_secret": "deploy_token"}},
+			}},
+		},
+	}
+
+	_, err := New(WithLocal(true)).Compile(workflow)
+	assert.ErrorContains(t, err, `secret "deploy_token" not found`)
+
+	backConf, err := New(
+		WithLocal(true),
+		WithSecretFallback(func(name string) (string, bool) {
+			return os.LookupEnv(strings.ToUpper(name))
+		}),
+	).Compile(workflow)
+	assert.NoError(t, err)
+	assert.Equal(t, "from-host", backConf.Stages[0].Steps[0].Environment["TOKEN"])
+	// values from the fallback are masked as well
+	assert.Equal(t, []*backend_types.Secret{{Name: "deploy_token", Value: "from-host"}}, backConf.Secrets)
+}
+
+func TestCompilerCompileInitSteps(t *testing.T) {
+	newWorkflow := func(dependsOn []string) *yaml_types.Workflow {
+		return &yaml_types.Workflow{
+			SkipClone: true,
+			Steps: yaml_types.ContainerList{
+				ContainerList: []*yaml_types.Container{{
+					Name:     "lint",
+					Image:    "golang",
+					Commands: []string{"go vet"},
+				}, {
+					Name:      "test",
+					Image:     "golang",
+					Commands:  []string{"go test"},
+					DependsOn: dependsOn,
+					Init: []*yaml_types.Container{{
+						Image:    "golang",
+						Commands: []string{"go mod download"},
+					}, {
+						Name:     "warmup",
+						Image:    "golang",
+						Commands: []string{"go build ./..."},
+					}},
+				}},
+			},
+		}
+	}
+
+	stepNames := func(stages []*backend_types.Stage) (names [][]string) {
+		for _, stage := range stages {
+			var stageNames []string
+			for _, step := range stage.Steps {
+				stageNames = append(stageNames, step.Name)
+			}
+			names = append(names, stageNames)
+		}
+		return names
+	}
+
+	// in sequence
+	backConf, err := New().Compile(newWorkflow(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"lint"}, {"test-init-1"}, {"warmup"}, {"test"}}, stepNames(backConf.Stages))
+	assert.Equal(t, backend_types.StepTypeCommands, backConf.Stages[1].Steps[0].Type)
+
+	// by depends_on the init steps inherit the dependencies of the step
+	backConf, err = New().Compile(newWorkflow([]string{"lint"}))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"lint"}, {"test-init-1"}, {"warmup"}, {"test"}}, stepNames(backConf.Stages))
+
+	backConf, err = New().Compile(newWorkflow([]string{}))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"lint", "test-init-1"}, {"warmup"}, {"test"}}, stepNames(backConf.Stages))
+}
+
+func TestCompilerCompileShmSize(t *testing.T) {
+	workflow := &yaml_types.Workflow{
+		SkipClone: true,
+		Steps: yaml_types.ContainerList{
+			ContainerList: []*yaml_types.Container{{
+				Name:     "e2e",
+				Image:    "chromium",
+				Commands: []string{"npm run e2e"},
+				ShmSize:  2 << 30,
+			}, {
+				Name:     "unit",
+				Image:    "node",
+				Commands: []string{"npm test"},
+			}},
+		},
+	}
+	shmSizes := func(opts ...Option) []int64 {
+		backConf, err := New(append(opts, WithResourceLimit(0, 0, 64<<20, 0, 0, ""))...).Compile(workflow)
+		assert.NoError(t, err)
+		return []int64{backConf.Stages[0].Steps[0].ShmSize, backConf.Stages[1].Steps[0].ShmSize}
+	}
+
+	// within the max
+	assert.Equal(t, []int64{2 << 30, 64 << 20}, shmSizes(WithMaxShmSize(4<<30)))
+	// exceeding the max
+	assert.Equal(t, []int64{1 << 30, 64 << 20}, shmSizes(WithMaxShmSize(1<<30)))
+	// untrusted pipelines can't override the size without a max
+	assert.Equal(t, []int64{64 << 20, 64 << 20}, shmSizes())
+	// trusted pipelines aren't capped
+	assert.Equal(t, []int64{2 << 30, 64 << 20}, shmSizes(WithTrusted(true), WithMaxShmSize(1<<30)))
+}
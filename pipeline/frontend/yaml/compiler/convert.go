@@ -17,9 +17,13 @@ package compiler
 import (
 	"fmt"
 	"maps"
+	"net"
 	"path"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/oklog/ulid/v2"
 
@@ -30,6 +34,22 @@ import (
 	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/utils"
 )
 
+// stopSignals are the signals a step can be stopped with
+var stopSignals = []string{"SIGTERM", "SIGINT", "SIGQUIT", "SIGKILL", "SIGHUP", "SIGUSR1", "SIGUSR2"}
+
+// validUser matches a numeric uid with an optional gid, e.g. 1000:1000
+var validUser = regexp.MustCompile(`^\d+(:\d+)?$`)
+
+const (
+	tmpDir = "/tmp"
+	// workspaceTmpDir is the mount point of the temp volume of the workflow
+	workspaceTmpDir = "/woodpecker-tmp"
+	// hostGateway is resolved by docker to the ip of the host
+	hostGateway = "host-gateway"
+	// secretFilesDir is the folder file secrets are written to in the step container
+	secretFilesDir = "/run/woodpecker/secrets"
+)
+
 func (c *Compiler) createProcess(container *yaml_types.Container, stepType backend_types.StepType) (*backend_types.Step, error) {
 	var (
 		uuid = ulid.Make()
@@ -38,6 +58,7 @@ func (c *Compiler) createProcess(container *yaml_types.Container, stepType backe
 		workingDir string
 
 		workspace   = fmt.Sprintf("%s_default:%s", c.prefix, c.base)
+		workflowTmp = fmt.Sprintf("%s_tmp:%s", c.prefix, workspaceTmpDir)
 		privileged  = container.Privileged
 		networkMode = container.NetworkMode
 		// network    = container.Network
@@ -58,16 +79,47 @@ func (c *Compiler) createProcess(container *yaml_types.Container, stepType backe
 	extraHosts := make([]backend_types.HostAlias, len(container.ExtraHosts))
 	for i, extraHost := range container.ExtraHosts {
 		name, ip, ok := strings.Cut(extraHost, ":")
-		if !ok {
+		if !ok || name == "" || (ip != hostGateway && net.ParseIP(ip) == nil) {
 			return nil, &ErrExtraHostFormat{host: extraHost}
 		}
 		extraHosts[i].Name = name
 		extraHosts[i].IP = ip
 	}
 
+	pull := c.pull
+	if container.Pull != nil {
+		pull = *container.Pull
+	}
+
+	user := container.User
+	if user != "" && !validUser.MatchString(user) {
+		return nil, &ErrUserFormat{user: user}
+	}
+	// the clone step prepares the workspace for all other steps and keeps its user
+	if c.forcedUser != "" && stepType != backend_types.StepTypeClone {
+		user = c.forcedUser
+	}
+
+	if container.StopSignal != "" && !slices.Contains(stopSignals, container.StopSignal) {
+		return nil, &ErrStopSignal{signal: container.StopSignal}
+	}
+	var stopGrace time.Duration
+	if container.StopGrace != "" {
+		var err error
+		if stopGrace, err = time.ParseDuration(container.StopGrace); err != nil || stopGrace < 0 {
+			return nil, &ErrStopGracePeriod{period: container.StopGrace}
+		}
+	}
+
+	for _, dns := range container.DNS {
+		if net.ParseIP(dns) == nil {
+			return nil, &ErrDNSFormat{server: dns}
+		}
+	}
+
 	var volumes []string
 	if !c.local {
-		volumes = append(volumes, workspace)
+		volumes = append(volumes, workspace, workflowTmp)
 	}
 	volumes = append(volumes, c.volumes...)
 	for _, volume := range container.Volumes.Volumes {
@@ -79,6 +131,9 @@ func (c *Compiler) createProcess(container *yaml_types.Container, stepType backe
 	maps.Copy(environment, c.env)
 
 	environment["CI_WORKSPACE"] = path.Join(c.base, c.path)
+	if !c.local {
+		environment["CI_WORKSPACE_TMP"] = workspaceTmpDir
+	}
 
 	if stepType == backend_types.StepTypeService || container.Detached {
 		detached = true
@@ -88,9 +143,17 @@ func (c *Compiler) createProcess(container *yaml_types.Container, stepType backe
 		workingDir = c.stepWorkingDir(container)
 	}
 
+	var files map[string]string
 	getSecretValue := func(name string) (string, error) {
 		name = strings.ToLower(name)
 		secret, ok := c.secrets[name]
+		if !ok && c.secretFallback != nil {
+			var value string
+			if value, ok = c.secretFallback(name); ok {
+				secret = Secret{Name: name, Value: value}
+				c.secrets[name] = secret
+			}
+		}
 		if !ok {
 			return "", fmt.Errorf("secret %q not found", name)
 		}
@@ -101,6 +164,19 @@ func (c *Compiler) createProcess(container *yaml_types.Container, stepType backe
 			return "", err
 		}
 
+		// file secrets are written to the container, the step only gets their path
+		if secret.File {
+			if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+				return "", fmt.Errorf("secret %q can't be used as file, its name isn't a valid file name", name)
+			}
+			if files == nil {
+				files = map[string]string{}
+			}
+			filePath := path.Join(secretFilesDir, name)
+			files[filePath] = secret.Value
+			return filePath, nil
+		}
+
 		return secret.Value, nil
 	}
 
@@ -129,6 +205,9 @@ func (c *Compiler) createProcess(container *yaml_types.Container, stepType backe
 	if utils.MatchImage(container.Image, c.escalated...) && container.IsPlugin() {
 		privileged = true
 	}
+	if c.privilegedDisabled {
+		privileged = false
+	}
 
 	authConfig := backend_types.Auth{}
 	for _, registry := range c.registries {
@@ -147,10 +226,7 @@ func (c *Compiler) createProcess(container *yaml_types.Container, stepType backe
 	if c.reslimit.MemLimit != 0 {
 		memLimit = c.reslimit.MemLimit
 	}
-	shmSize := int64(container.ShmSize)
-	if c.reslimit.ShmSize != 0 {
-		shmSize = c.reslimit.ShmSize
-	}
+	shmSize := c.shmSize(container)
 	cpuQuota := int64(container.CPUQuota)
 	if c.reslimit.CPUQuota != 0 {
 		cpuQuota = c.reslimit.CPUQuota
@@ -163,6 +239,36 @@ func (c *Compiler) createProcess(container *yaml_types.Container, stepType backe
 	if c.reslimit.CPUSet != "" {
 		cpuSet = c.reslimit.CPUSet
 	}
+	// the resources of the step take precedence over the limits of the server,
+	// the linter makes sure they don't exceed them
+	if resources := container.Resources; resources != nil {
+		if resources.MemSwapLimit > 0 {
+			memSwapLimit = int64(resources.MemSwapLimit)
+		}
+		if resources.MemLimit > 0 {
+			memLimit = int64(resources.MemLimit)
+		}
+		if resources.CPUQuota > 0 {
+			cpuQuota = int64(resources.CPUQuota)
+		}
+		if resources.CPUShares > 0 {
+			cpuShares = int64(resources.CPUShares)
+		}
+		// the step can only narrow down the cpuset of the server
+		if resources.CPUSet != "" && (cpuSet == "" || utils.IsCPUSubset(resources.CPUSet, cpuSet)) {
+			cpuSet = resources.CPUSet
+		}
+	}
+
+	// a read-only root filesystem still needs writable scratch space,
+	// the workspace is a volume and therefore stays writable
+	readOnlyRootfs := container.ReadOnly || (c.readOnlyRootfs && stepType != backend_types.StepTypeClone)
+	tmpfs := container.Tmpfs
+	if readOnlyRootfs && !slices.ContainsFunc(tmpfs, func(mount string) bool {
+		return mount == tmpDir || strings.HasPrefix(mount, tmpDir+":")
+	}) {
+		tmpfs = append(tmpfs, tmpDir)
+	}
 
 	var ports []backend_types.Port
 	for _, portDef := range container.Ports {
@@ -178,26 +284,45 @@ func (c *Compiler) createProcess(container *yaml_types.Container, stepType backe
 	// at least one constraint must include the status failure.
 	onFailure := container.When.IncludesStatusFailure()
 
+	// conditions on outputs of other steps are evaluated by the agent before the step runs
+	condition, err := container.When.RuntimeCondition(c.metadata, false, c.env)
+	if err != nil {
+		return nil, err
+	}
+
 	failure := container.Failure
 	if container.Failure == "" {
 		failure = metadata.FailureFail
 	}
 
+	var cache *backend_types.StepCache
+	if container.Cache != nil {
+		cache = &backend_types.StepCache{
+			Key:   container.Cache.Key,
+			Paths: container.Cache.Paths,
+		}
+	}
+
 	return &backend_types.Step{
 		Name:           container.Name,
 		UUID:           uuid.String(),
 		Type:           stepType,
 		Image:          container.Image,
-		Pull:           container.Pull,
+		Pull:           pull,
 		Detached:       detached,
 		Privileged:     privileged,
+		CapAdd:         container.CapAdd,
+		CapDrop:        container.CapDrop,
+		ReadOnlyRootfs: readOnlyRootfs,
 		WorkingDir:     workingDir,
+		User:           user,
 		Environment:    environment,
 		Commands:       container.Commands,
 		Entrypoint:     container.Entrypoint,
 		ExtraHosts:     extraHosts,
 		Volumes:        volumes,
-		Tmpfs:          container.Tmpfs,
+		Tmpfs:          tmpfs,
+		Files:          files,
 		Devices:        container.Devices,
 		Networks:       networks,
 		DNS:            container.DNS,
@@ -212,9 +337,15 @@ func (c *Compiler) createProcess(container *yaml_types.Container, stepType backe
 		OnSuccess:      onSuccess,
 		OnFailure:      onFailure,
 		Failure:        failure,
+		FailureMessage: container.FailureMessage,
 		NetworkMode:    networkMode,
 		Ports:          ports,
 		BackendOptions: container.BackendOptions,
+		Cache:          cache,
+		StopSignal:     container.StopSignal,
+		StopGrace:      stopGrace,
+		Outputs:        container.Outputs,
+		Condition:      condition,
 	}, nil
 }
 
@@ -240,3 +371,19 @@ func convertPort(portDef string) (backend_types.Port, error) {
 
 	return port, nil
 }
+
+// shmSize returns the shm size of the step, steps can override the default of the resource
+// limits. For untrusted pipelines the override is capped by the max shm size.
+func (c *Compiler) shmSize(container *yaml_types.Container) int64 {
+	shmSize := int64(container.ShmSize)
+	if shmSize <= 0 {
+		return c.reslimit.ShmSize
+	}
+	if !c.trustedPipeline {
+		if c.maxShmSize == 0 {
+			return c.reslimit.ShmSize
+		}
+		return min(shmSize, c.maxShmSize)
+	}
+	return shmSize
+}
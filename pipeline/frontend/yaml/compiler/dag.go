@@ -15,9 +15,12 @@
 package compiler
 
 import (
+	"fmt"
+	"slices"
 	"sort"
 
 	backend_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/backend/types"
+	yaml_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/types"
 )
 
 type dagCompilerStep struct {
@@ -54,6 +57,50 @@ func (c dagCompiler) compile() ([]*backend_types.Stage, error) {
 	return c.compileByGroup()
 }
 
+// dependencies returns the step and all steps it depends on directly or indirectly. Without
+// any depends_on, steps depend on all steps of the stages before them.
+func (c dagCompiler) dependencies(name string) (map[string]bool, error) {
+	if !slices.ContainsFunc(c.steps, func(s *dagCompilerStep) bool { return s.name == name }) {
+		return nil, fmt.Errorf("step '%s' not found", name)
+	}
+
+	// compiling checks for missing dependencies and cycles
+	stages, err := c.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	needed := make(map[string]bool)
+	if !c.isDAG() {
+		for _, stage := range stages {
+			for _, step := range stage.Steps {
+				needed[step.Name] = true
+			}
+			if slices.ContainsFunc(stage.Steps, func(s *backend_types.Step) bool { return s.Name == name }) {
+				break
+			}
+		}
+		return needed, nil
+	}
+
+	stepMap := make(map[string]*dagCompilerStep, len(c.steps))
+	for _, s := range c.steps {
+		stepMap[s.name] = s
+	}
+	var visit func(name string)
+	visit = func(name string) {
+		if needed[name] {
+			return
+		}
+		needed[name] = true
+		for _, dep := range stepMap[name].dependsOn {
+			visit(dep)
+		}
+	}
+	visit(name)
+	return needed, nil
+}
+
 func (c dagCompiler) compileByGroup() ([]*backend_types.Stage, error) {
 	stages := make([]*backend_types.Stage, 0, len(c.steps))
 	var currentStage *backend_types.Stage
@@ -163,3 +210,18 @@ func allDependenciesSatisfied(step *dagCompilerStep, addedSteps map[string]struc
 	}
 	return true
 }
+
+// StepDependencies returns the names of the step and all steps it depends on directly or indirectly.
+func StepDependencies(containers []*yaml_types.Container, name string) (map[string]bool, error) {
+	steps := make([]*dagCompilerStep, 0, len(containers))
+	for pos, container := range containers {
+		steps = append(steps, &dagCompilerStep{
+			step:      &backend_types.Step{Name: container.Name},
+			position:  pos,
+			name:      container.Name,
+			group:     container.Group,
+			dependsOn: container.DependsOn,
+		})
+	}
+	return newDAGCompiler(steps).dependencies(name)
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	backend_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/backend/types"
+	yaml_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/types"
 )
 
 func TestConvertDAGToStages(t *testing.T) {
@@ -159,3 +160,36 @@ func TestIsDag(t *testing.T) {
 	c = newDAGCompiler(steps)
 	assert.True(t, c.isDAG())
 }
+
+func TestStepDependencies(t *testing.T) {
+	t.Parallel()
+
+	steps := []*yaml_types.Container{
+		{Name: "deps"},
+		{Name: "lint", Group: "check"},
+		{Name: "test", Group: "check"},
+		{Name: "build"},
+	}
+	needed, err := StepDependencies(steps, "lint")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"deps": true, "lint": true, "test": true}, needed)
+
+	steps = []*yaml_types.Container{
+		{Name: "deps", DependsOn: []string{}},
+		{Name: "lint", DependsOn: []string{}},
+		{Name: "build", DependsOn: []string{"deps"}},
+		{Name: "deploy", DependsOn: []string{"build"}},
+	}
+	needed, err = StepDependencies(steps, "deploy")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"deps": true, "build": true, "deploy": true}, needed)
+
+	_, err = StepDependencies(steps, "release")
+	assert.EqualError(t, err, "step 'release' not found")
+
+	steps = []*yaml_types.Container{
+		{Name: "build", DependsOn: []string{"deps"}},
+	}
+	_, err = StepDependencies(steps, "build")
+	assert.ErrorIs(t, err, &ErrStepMissingDependency{})
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"sort"
+
+	backend_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/backend/types"
+)
+
+type ConfigChangeType string
+
+const (
+	ConfigChangeStepAdded   ConfigChangeType = "step_added"
+	ConfigChangeStepRemoved ConfigChangeType = "step_removed"
+	ConfigChangeImage       ConfigChangeType = "image_changed"
+	ConfigChangeEnvironment ConfigChangeType = "environment_changed"
+)
+
+// ConfigChange describes a single difference between two compiled configs.
+type ConfigChange struct {
+	Type ConfigChangeType
+	Step string
+	// Key is the environment variable name for environment changes.
+	Key string
+	Old string
+	New string
+}
+
+// DiffConfigs returns the structural differences between two compiled configs.
+// Steps are matched by name, volatile fields like UUIDs and prefixed resource
+// names are not compared.
+func DiffConfigs(a, b *backend_types.Config) []ConfigChange {
+	oldSteps := configStepsByName(a)
+	newSteps := configStepsByName(b)
+
+	var changes []ConfigChange
+	for _, name := range configStepNames(a) {
+		oldStep := oldSteps[name]
+		newStep, ok := newSteps[name]
+		if !ok {
+			changes = append(changes, ConfigChange{Type: ConfigChangeStepRemoved, Step: name, Old: oldStep.Image})
+			continue
+		}
+
+		if oldStep.Image != newStep.Image {
+			changes = append(changes, ConfigChange{Type: ConfigChangeImage, Step: name, Old: oldStep.Image, New: newStep.Image})
+		}
+
+		keys := make([]string, 0, len(oldStep.Environment))
+		for k := range oldStep.Environment {
+			keys = append(keys, k)
+		}
+		for k := range newStep.Environment {
+			if _, exists := oldStep.Environment[k]; !exists {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			oldValue, newValue := oldStep.Environment[k], newStep.Environment[k]
+			if oldValue != newValue {
+				changes = append(changes, ConfigChange{Type: ConfigChangeEnvironment, Step: name, Key: k, Old: oldValue, New: newValue})
+			}
+		}
+	}
+
+	for _, name := range configStepNames(b) {
+		if _, ok := oldSteps[name]; !ok {
+			changes = append(changes, ConfigChange{Type: ConfigChangeStepAdded, Step: name, New: newSteps[name].Image})
+		}
+	}
+
+	return changes
+}
+
+func configStepNames(config *backend_types.Config) []string {
+	var names []string
+	if config == nil {
+		return names
+	}
+	for _, stage := range config.Stages {
+		for _, step := range stage.Steps {
+			names = append(names, step.Name)
+		}
+	}
+	return names
+}
+
+func configStepsByName(config *backend_types.Config) map[string]*backend_types.Step {
+	steps := map[string]*backend_types.Step{}
+	if config == nil {
+		return steps
+	}
+	for _, stage := range config.Stages {
+		for _, step := range stage.Steps {
+			steps[step.Name] = step
+		}
+	}
+	return steps
+}
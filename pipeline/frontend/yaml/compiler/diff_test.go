@@ -0,0 +1,62 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	backend_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/backend/types"
+)
+
+func TestDiffConfigs(t *testing.T) {
+	oldConf := &backend_types.Config{Stages: []*backend_types.Stage{{
+		Steps: []*backend_types.Step{{
+			Name:        "build",
+			UUID:        "01HZ0000000000000000000001",
+			Image:       "golang:1.21",
+			Environment: map[string]string{"GOOS": "linux"},
+		}},
+	}}}
+	newConf := &backend_types.Config{Stages: []*backend_types.Stage{{
+		Steps: []*backend_types.Step{{
+			Name:        "build",
+			UUID:        "01HZ0000000000000000000002",
+			Image:       "golang:1.22",
+			Environment: map[string]string{"GOOS": "linux"},
+		}},
+	}, {
+		Steps: []*backend_types.Step{{
+			Name:  "test",
+			Image: "golang:1.22",
+		}},
+	}}}
+
+	assert.Empty(t, DiffConfigs(oldConf, oldConf))
+	assert.Equal(t, []ConfigChange{
+		{Type: ConfigChangeImage, Step: "build", Old: "golang:1.21", New: "golang:1.22"},
+		{Type: ConfigChangeStepAdded, Step: "test", New: "golang:1.22"},
+	}, DiffConfigs(oldConf, newConf))
+	assert.Equal(t, []ConfigChange{
+		{Type: ConfigChangeImage, Step: "build", Old: "golang:1.22", New: "golang:1.21"},
+		{Type: ConfigChangeStepRemoved, Step: "test", Old: "golang:1.22"},
+	}, DiffConfigs(newConf, oldConf))
+
+	newConf.Stages[0].Steps[0].Environment["CGO_ENABLED"] = "0"
+	assert.Contains(t, DiffConfigs(oldConf, newConf), ConfigChange{
+		Type: ConfigChangeEnvironment, Step: "build", Key: "CGO_ENABLED", New: "0",
+	})
+}
@@ -29,6 +29,58 @@ func (*ErrExtraHostFormat) Is(target error) bool {
 	return ok
 }
 
+type ErrDNSFormat struct {
+	server string
+}
+
+func (err *ErrDNSFormat) Error() string {
+	return fmt.Sprintf("dns server %s is not a valid ip address", err.server)
+}
+
+func (*ErrDNSFormat) Is(target error) bool {
+	_, ok := target.(*ErrDNSFormat)
+	return ok
+}
+
+type ErrUserFormat struct {
+	user string
+}
+
+func (err *ErrUserFormat) Error() string {
+	return fmt.Sprintf("user %s has to be a numeric uid with an optional gid like 1000:1000", err.user)
+}
+
+func (*ErrUserFormat) Is(target error) bool {
+	_, ok := target.(*ErrUserFormat)
+	return ok
+}
+
+type ErrStopSignal struct {
+	signal string
+}
+
+func (err *ErrStopSignal) Error() string {
+	return fmt.Sprintf("stop signal %s is not supported", err.signal)
+}
+
+func (*ErrStopSignal) Is(target error) bool {
+	_, ok := target.(*ErrStopSignal)
+	return ok
+}
+
+type ErrStopGracePeriod struct {
+	period string
+}
+
+func (err *ErrStopGracePeriod) Error() string {
+	return fmt.Sprintf("stop grace period %s is not a valid duration", err.period)
+}
+
+func (*ErrStopGracePeriod) Is(target error) bool {
+	_, ok := target.(*ErrStopGracePeriod)
+	return ok
+}
+
 type ErrStepMissingDependency struct {
 	name,
 	dep string
@@ -55,3 +107,14 @@ func (*ErrStepDependencyCycle) Is(target error) bool {
 	_, ok := target.(*ErrStepDependencyCycle)
 	return ok
 }
+
+type ErrArtifactImageMissing struct{}
+
+func (*ErrArtifactImageMissing) Error() string {
+	return "workflow artifacts can't be saved or restored, no artifact image is configured by the server admin"
+}
+
+func (*ErrArtifactImageMissing) Is(target error) bool {
+	_, ok := target.(*ErrArtifactImageMissing)
+	return ok
+}
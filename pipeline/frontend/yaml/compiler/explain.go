@@ -0,0 +1,108 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"sort"
+
+	backend_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/backend/types"
+)
+
+type EnvSource string
+
+const (
+	EnvSourceMetadata EnvSource = "metadata"
+	EnvSourceMatrix   EnvSource = "matrix"
+	EnvSourceGlobal   EnvSource = "global"
+	EnvSourceVariable EnvSource = "variable"
+	EnvSourceStep     EnvSource = "step"
+)
+
+// EnvLayer is a set of environment variables passed to the compiler.
+type EnvLayer struct {
+	Source EnvSource
+	Env    map[string]string
+}
+
+// ExplainedEnv is a resolved environment variable of a step together
+// with the layer its value originates from.
+type ExplainedEnv struct {
+	Key    string
+	Value  string
+	Source EnvSource
+}
+
+// ExplainEnv reports for every environment variable of the compiled step which
+// layer provided its value. Layers have to be passed in the order they were
+// applied to the compiler, later layers override earlier ones. Values that do
+// not match the merged layers were set by the step itself.
+func ExplainEnv(step *backend_types.Step, layers ...EnvLayer) []ExplainedEnv {
+	merged := make(map[string]string)
+	sources := make(map[string]EnvSource)
+	for _, layer := range layers {
+		for k, v := range layer.Env {
+			merged[k] = v
+			sources[k] = layer.Source
+		}
+	}
+
+	keys := make([]string, 0, len(step.Environment))
+	for k := range step.Environment {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	explained := make([]ExplainedEnv, 0, len(keys))
+	for _, k := range keys {
+		value := step.Environment[k]
+		source := EnvSourceStep
+		if v, ok := merged[k]; ok && v == value {
+			source = sources[k]
+		}
+		explained = append(explained, ExplainedEnv{Key: k, Value: value, Source: source})
+	}
+	return explained
+}
+
+// EnvCollision is an environment variable of a step set by several layers with different values.
+type EnvCollision struct {
+	Key     string
+	Sources []EnvSource
+}
+
+// EnvCollisions reports the environment variables of the compiled step which are set
+// by several layers with different values. Layers have to be passed in the order they
+// were applied to the compiler. If the step overrides the value it is the last source.
+func EnvCollisions(step *backend_types.Step, layers ...EnvLayer) []EnvCollision {
+	var collisions []EnvCollision
+	for _, env := range ExplainEnv(step, layers...) {
+		var sources []EnvSource
+		values := make(map[string]bool)
+		for _, layer := range layers {
+			if v, ok := layer.Env[env.Key]; ok {
+				sources = append(sources, layer.Source)
+				values[v] = true
+			}
+		}
+		if env.Source == EnvSourceStep && len(sources) > 0 {
+			sources = append(sources, EnvSourceStep)
+			values[env.Value] = true
+		}
+		if len(values) > 1 {
+			collisions = append(collisions, EnvCollision{Key: env.Key, Sources: sources})
+		}
+	}
+	return collisions
+}
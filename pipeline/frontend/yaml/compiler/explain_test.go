@@ -0,0 +1,90 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	yaml_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/types"
+)
+
+func TestExplainEnv(t *testing.T) {
+	matrixEnv := map[string]string{"GO_VERSION": "1.22", "GOOS": "linux"}
+	globalEnv := map[string]string{"GOOS": "darwin", "REGION": "eu"}
+
+	backConf, err := New(
+		WithEnviron(matrixEnv),
+		WithEnviron(globalEnv),
+	).Compile(&yaml_types.Workflow{
+		SkipClone: true,
+		Steps: yaml_types.ContainerList{
+			ContainerList: []*yaml_types.Container{{
+				Name:        "build",
+				Image:       "golang",
+				Commands:    []string{"go build"},
+				Environment: map[string]any{"REGION": "us"},
+			}},
+		},
+	})
+	assert.NoError(t, err)
+
+	explained := ExplainEnv(backConf.Stages[0].Steps[0],
+		EnvLayer{Source: EnvSourceMatrix, Env: matrixEnv},
+		EnvLayer{Source: EnvSourceGlobal, Env: globalEnv},
+	)
+
+	sources := make(map[string]ExplainedEnv, len(explained))
+	for _, env := range explained {
+		sources[env.Key] = env
+	}
+	assert.Equal(t, ExplainedEnv{Key: "GO_VERSION", Value: "1.22", Source: EnvSourceMatrix}, sources["GO_VERSION"])
+	// overridden by a later layer
+	assert.Equal(t, ExplainedEnv{Key: "GOOS", Value: "darwin", Source: EnvSourceGlobal}, sources["GOOS"])
+	// overridden by the step
+	assert.Equal(t, ExplainedEnv{Key: "REGION", Value: "us", Source: EnvSourceStep}, sources["REGION"])
+	assert.Equal(t, EnvSourceStep, sources["CI_WORKSPACE"].Source)
+}
+
+func TestEnvCollisions(t *testing.T) {
+	matrixEnv := map[string]string{"GO_VERSION": "1.22", "GOOS": "linux"}
+	globalEnv := map[string]string{"GOOS": "darwin", "REGION": "eu", "GO_VERSION": "1.22"}
+
+	backConf, err := New(
+		WithEnviron(matrixEnv),
+		WithEnviron(globalEnv),
+	).Compile(&yaml_types.Workflow{
+		SkipClone: true,
+		Steps: yaml_types.ContainerList{
+			ContainerList: []*yaml_types.Container{{
+				Name:        "build",
+				Image:       "golang",
+				Commands:    []string{"go build"},
+				Environment: map[string]any{"REGION": "us", "CGO_ENABLED": "0"},
+			}},
+		},
+	})
+	assert.NoError(t, err)
+
+	collisions := EnvCollisions(backConf.Stages[0].Steps[0],
+		EnvLayer{Source: EnvSourceMatrix, Env: matrixEnv},
+		EnvLayer{Source: EnvSourceGlobal, Env: globalEnv},
+	)
+	assert.Equal(t, []EnvCollision{
+		{Key: "GOOS", Sources: []EnvSource{EnvSourceMatrix, EnvSourceGlobal}},
+		{Key: "REGION", Sources: []EnvSource{EnvSourceGlobal, EnvSourceStep}},
+	}, collisions)
+}
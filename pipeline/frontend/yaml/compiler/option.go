@@ -66,6 +66,15 @@ func WithSecret(secrets ...Secret) Option {
 	}
 }
 
+// WithSecretFallback configures the compiler with a lookup for secrets
+// which are not configured. Found values are masked like configured
+// secrets. This is meant for local execution only.
+func WithSecretFallback(lookup func(name string) (string, bool)) Option {
+	return func(compiler *Compiler) {
+		compiler.secretFallback = lookup
+	}
+}
+
 // WithMetadata configures the compiler with the repository, pipeline
 // and system metadata. The metadata is used to remove steps from
 // the compiled pipeline configuration that should be skipped. The
@@ -121,6 +130,14 @@ func WithEscalated(images ...string) Option {
 	}
 }
 
+// WithPrivilegedDisabled configures the compiler to run privileged steps and
+// escalated plugins without privileges.
+func WithPrivilegedDisabled(disabled bool) Option {
+	return func(compiler *Compiler) {
+		compiler.privilegedDisabled = disabled
+	}
+}
+
 // WithPrefix configures the compiler with the prefix. The prefix is
 // used to prefix container, volume and network names to avoid
 // collision at runtime.
@@ -172,12 +189,69 @@ func WithResourceLimit(swap, mem, shmSize, cpuQuota, cpuShares int64, cpuSet str
 	}
 }
 
+// WithMaxShmSize caps the shm_size steps of untrusted pipelines can request.
+// If it is not set untrusted pipelines can't override the shm size.
+func WithMaxShmSize(size int64) Option {
+	return func(compiler *Compiler) {
+		compiler.maxShmSize = size
+	}
+}
+
 func WithDefaultCloneImage(cloneImage string) Option {
 	return func(compiler *Compiler) {
 		compiler.defaultCloneImage = cloneImage
 	}
 }
 
+// WithArtifactImage configures the image of the steps saving and restoring workflow artifacts.
+// Workflows using artifacts fail to compile without it.
+func WithArtifactImage(artifactImage string) Option {
+	return func(compiler *Compiler) {
+		compiler.artifactImage = artifactImage
+	}
+}
+
+// WithStepSelector configures the compiler to only run steps having all of the
+// given labels, all other steps are skipped. Clone steps and services are not affected.
+func WithStepSelector(selector map[string]string) Option {
+	return func(compiler *Compiler) {
+		compiler.stepSelector = selector
+	}
+}
+
+// WithSkippedSteps configures the compiler to skip the steps with the given
+// names. Like steps not matching the step selector, they are kept, but never run.
+func WithSkippedSteps(names ...string) Option {
+	return func(compiler *Compiler) {
+		compiler.skippedSteps = names
+	}
+}
+
+// WithAutoSkipClone configures the compiler to skip the default clone step of
+// workflows which don't use the source, as all their steps and services are
+// plugins and they don't save artifacts.
+func WithAutoSkipClone() Option {
+	return func(compiler *Compiler) {
+		compiler.autoSkipClone = true
+	}
+}
+
+// WithReadOnlyRootfs configures the compiler to run all steps except the clone
+// with a read-only root filesystem, regardless of the step configuration.
+func WithReadOnlyRootfs(readOnly bool) Option {
+	return func(compiler *Compiler) {
+		compiler.readOnlyRootfs = readOnly
+	}
+}
+
+// WithForcedUser configures the compiler to run all steps as the given
+// user, regardless of the step configuration. Clone steps are not affected.
+func WithForcedUser(user string) Option {
+	return func(compiler *Compiler) {
+		compiler.forcedUser = user
+	}
+}
+
 // WithTrusted configures the compiler with the trusted repo option.
 func WithTrusted(trusted bool) Option {
 	return func(compiler *Compiler) {
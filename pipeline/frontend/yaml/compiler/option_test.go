@@ -172,3 +172,10 @@ func TestWithDefaultCloneImage(t *testing.T) {
 	)
 	assert.Equal(t, "not-an-image", compiler.defaultCloneImage)
 }
+
+func TestWithReadOnlyRootfs(t *testing.T) {
+	compiler := New(
+		WithReadOnlyRootfs(true),
+	)
+	assert.True(t, compiler.readOnlyRootfs)
+}
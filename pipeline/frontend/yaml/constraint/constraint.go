@@ -17,7 +17,6 @@ package constraint
 import (
 	"fmt"
 	"maps"
-	"path"
 	"strings"
 
 	"github.com/bmatcuk/doublestar/v4"
@@ -37,20 +36,20 @@ type (
 	}
 
 	Constraint struct {
-		Ref         List
-		Repo        List
-		Instance    List
-		Platform    List
-		Environment List
-		Branch      List
-		Cron        List
-		Status      List
-		Matrix      Map
-		Local       yamlBaseTypes.BoolTrue
-		Path        Path
-		Evaluate    string `yaml:"evaluate,omitempty"`
+		Ref         List                   `yaml:"ref,omitempty"`
+		Repo        List                   `yaml:"repo,omitempty"`
+		Instance    List                   `yaml:"instance,omitempty"`
+		Platform    List                   `yaml:"platform,omitempty"`
+		Environment List                   `yaml:"environment,omitempty"`
+		Branch      List                   `yaml:"branch,omitempty"`
+		Cron        List                   `yaml:"cron,omitempty"`
+		Status      List                   `yaml:"status,omitempty"`
+		Matrix      Map                    `yaml:"matrix,omitempty"`
+		Local       yamlBaseTypes.BoolTrue `yaml:"local,omitempty"`
+		Path        Path                   `yaml:"path,omitempty"`
+		Evaluate    string                 `yaml:"evaluate,omitempty"`
 		// TODO: change to StringOrSlice in 3.x
-		Event List
+		Event List `yaml:"event,omitempty"`
 	}
 
 	// List defines a runtime constraint for exclude & include string slices.
@@ -80,22 +79,11 @@ func (when *When) IsEmpty() bool {
 
 // Returns true if at least one of the internal constraints is true.
 func (when *When) Match(metadata metadata.Metadata, global bool, env map[string]string) (bool, error) {
-	for _, c := range when.Constraints {
-		match, err := c.Match(metadata, global, env)
-		if err != nil {
-			return false, err
-		}
-		if match {
-			return true, nil
-		}
-	}
-
-	if when.IsEmpty() {
-		// test against default Constraints
-		empty := &Constraint{}
-		return empty.Match(metadata, global, env)
+	reasons, err := when.Mismatches(metadata, global, env)
+	if err != nil {
+		return false, err
 	}
-	return false, nil
+	return len(reasons) == 0, nil
 }
 
 func (when *When) IncludesStatusFailure() bool {
@@ -133,6 +121,11 @@ func (when *When) IsLocal() bool {
 	return true
 }
 
+// MarshalYAML implements the Marshaller interface.
+func (when When) MarshalYAML() (any, error) {
+	return when.Constraints, nil
+}
+
 func (when *When) UnmarshalYAML(value *yaml.Node) error {
 	switch value.Kind {
 	case yaml.SequenceNode:
@@ -157,54 +150,38 @@ func (when *When) UnmarshalYAML(value *yaml.Node) error {
 // Match returns true if all constraints match the given input. If a single
 // constraint fails a false value is returned.
 func (c *Constraint) Match(m metadata.Metadata, global bool, env map[string]string) (bool, error) {
-	match := true
-	if !global {
-		// apply step only filters
-		match = c.Matrix.Match(m.Workflow.Matrix)
+	reasons, err := c.Mismatches(m, global, env)
+	if err != nil {
+		return false, err
 	}
+	return len(reasons) == 0, nil
+}
 
-	match = match && c.Platform.Match(m.Sys.Platform) &&
-		c.Environment.Match(m.Curr.Target) &&
-		c.Event.Match(m.Curr.Event) &&
-		c.Repo.Match(path.Join(m.Repo.Owner, m.Repo.Name)) &&
-		c.Ref.Match(m.Curr.Commit.Ref) &&
-		c.Instance.Match(m.Sys.Host)
-
-	// changed files filter apply only for pull-request and push events
-	if m.Curr.Event == metadata.EventPull || m.Curr.Event == metadata.EventPush {
-		match = match && c.Path.Match(m.Curr.Commit.ChangedFiles, m.Curr.Commit.Message)
+// evaluate runs the evaluate expression of the constraint.
+func (c *Constraint) evaluate(m metadata.Metadata, env map[string]string) (bool, error) {
+	// outputs of steps are only known while the workflow runs, the agent evaluates it then
+	if len(c.StepOutputs()) != 0 {
+		return true, nil
 	}
 
-	if m.Curr.Event != metadata.EventTag {
-		match = match && c.Branch.Match(m.Curr.Commit.Branch)
+	if env == nil {
+		env = m.Environ()
+	} else {
+		maps.Copy(env, m.Environ())
 	}
-
-	if m.Curr.Event == metadata.EventCron {
-		match = match && c.Cron.Match(m.Curr.Cron)
+	out, err := expr.Compile(c.Evaluate, expr.Env(env), expr.AllowUndefinedVariables(), expr.AsBool())
+	if err != nil {
+		return false, err
 	}
-
-	if c.Evaluate != "" {
-		if env == nil {
-			env = m.Environ()
-		} else {
-			maps.Copy(env, m.Environ())
-		}
-		out, err := expr.Compile(c.Evaluate, expr.Env(env), expr.AllowUndefinedVariables(), expr.AsBool())
-		if err != nil {
-			return false, err
-		}
-		result, err := expr.Run(out, env)
-		if err != nil {
-			return false, err
-		}
-		bResult, ok := result.(bool)
-		if !ok {
-			return false, fmt.Errorf("could not parse result: %v", result)
-		}
-		match = match && bResult
+	result, err := expr.Run(out, env)
+	if err != nil {
+		return false, err
 	}
-
-	return match, nil
+	bResult, ok := result.(bool)
+	if !ok {
+		return false, fmt.Errorf("could not parse result: %v", result)
+	}
+	return bResult, nil
 }
 
 // IsEmpty return true if a constraint has no conditions.
@@ -247,6 +224,17 @@ func (c *List) Excludes(v string) bool {
 	return false
 }
 
+// MarshalYAML marshals the constraint, using the short form if there are no excludes.
+func (c List) MarshalYAML() (any, error) {
+	if len(c.Exclude) == 0 {
+		return c.Include, nil
+	}
+	return struct {
+		Include []string `yaml:"include,omitempty"`
+		Exclude []string `yaml:"exclude,omitempty"`
+	}{c.Include, c.Exclude}, nil
+}
+
 // UnmarshalYAML unmarshal the constraint.
 func (c *List) UnmarshalYAML(value *yaml.Node) error {
 	out1 := struct {
@@ -303,6 +291,17 @@ func (c *Map) Match(params map[string]string) bool {
 	return true
 }
 
+// MarshalYAML marshals the constraint map, using the short form if there are no excludes.
+func (c Map) MarshalYAML() (any, error) {
+	if len(c.Exclude) == 0 {
+		return c.Include, nil
+	}
+	return struct {
+		Include map[string]string `yaml:"include,omitempty"`
+		Exclude map[string]string `yaml:"exclude,omitempty"`
+	}{c.Include, c.Exclude}, nil
+}
+
 // UnmarshalYAML unmarshal the constraint map.
 func (c *Map) UnmarshalYAML(unmarshal func(any) error) error {
 	out1 := struct {
@@ -326,6 +325,19 @@ func (c *Map) UnmarshalYAML(unmarshal func(any) error) error {
 	return nil
 }
 
+// MarshalYAML marshals the constraint, using the short form if only includes are set.
+func (c Path) MarshalYAML() (any, error) {
+	if len(c.Exclude) == 0 && c.IgnoreMessage == "" && c.OnEmpty.Bool() {
+		return c.Include, nil
+	}
+	return struct {
+		Include       []string               `yaml:"include,omitempty"`
+		Exclude       []string               `yaml:"exclude,omitempty"`
+		IgnoreMessage string                 `yaml:"ignore_message,omitempty"`
+		OnEmpty       yamlBaseTypes.BoolTrue `yaml:"on_empty,omitempty"`
+	}{c.Include, c.Exclude, c.IgnoreMessage, c.OnEmpty}, nil
+}
+
 // UnmarshalYAML unmarshal the constraint.
 func (c *Path) UnmarshalYAML(value *yaml.Node) error {
 	out1 := struct {
@@ -270,6 +270,17 @@ func TestConstraintList(t *testing.T) {
 			with: []string{},
 			want: true,
 		},
+		// no changed files, e.g. tags on github, behave like an empty list
+		{
+			conf: "{ include: [ README.md ] }",
+			with: nil,
+			want: true,
+		},
+		{
+			conf: "{ include: [ README.md ], on_empty: false }",
+			with: nil,
+			want: false,
+		},
 	}
 	for _, test := range testdata {
 		c := parseConstraintPath(t, test.conf)
@@ -447,6 +458,18 @@ func TestConstraints(t *testing.T) {
 			with: metadata.Metadata{Curr: metadata.Pipeline{Event: metadata.EventPush, Commit: metadata.Commit{Branch: "main"}}},
 			want: true,
 		},
+		{
+			desc: "path filter without changed files",
+			conf: "{ path: { include: [ src/** ], on_empty: false } }",
+			with: metadata.Metadata{Curr: metadata.Pipeline{Event: metadata.EventPush}},
+			want: false,
+		},
+		{
+			desc: "path filter if the forge can't provide the changed files",
+			conf: "{ path: { include: [ src/** ], on_empty: false } }",
+			with: metadata.Metadata{Curr: metadata.Pipeline{Event: metadata.EventPush, Commit: metadata.Commit{ChangedFilesUnknown: true}}},
+			want: true,
+		},
 		{
 			desc: "repo constraint",
 			conf: "{ repo: owner/* }",
@@ -552,10 +575,69 @@ func TestConstraints(t *testing.T) {
 			got, err := c.Match(test.with, false, test.env)
 			assert.NoError(t, err)
 			assert.Equal(t, test.want, got)
+
+			// the reasons have to agree with the result
+			reasons, err := c.Mismatches(test.with, false, test.env)
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, len(reasons) == 0, reasons)
 		})
 	}
 }
 
+func TestConstraintsMismatches(t *testing.T) {
+	push := metadata.Metadata{Curr: metadata.Pipeline{
+		Event:  metadata.EventPush,
+		Commit: metadata.Commit{Branch: "feature/x", ChangedFiles: []string{"README.md"}},
+	}}
+
+	reasons, err := parseConstraints(t, "{ branch: [ main, release/* ] }").Mismatches(push, true, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"branch 'feature/x' does not match [main, release/*]"}, reasons)
+
+	reasons, err = parseConstraints(t, "{ event: [ push, tag ], branch: { exclude: feature/* }, path: 'src/**' }").Mismatches(push, true, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"no changed file matches the path filter",
+		"branch 'feature/x' is excluded by [feature/*]",
+	}, reasons)
+
+	reasons, err = parseConstraints(t, "[ { event: tag }, { event: push, evaluate: 'CI_COMMIT_BRANCH == \"main\"' } ]").Mismatches(push, true, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"constraint 1: event 'push' does not match [tag]",
+		`constraint 2: evaluate 'CI_COMMIT_BRANCH == "main"' is false`,
+	}, reasons)
+
+	reasons, err = parseConstraints(t, "[ { event: tag }, { event: push } ]").Mismatches(push, true, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, reasons)
+}
+
+func TestConstraintsRuntimeCondition(t *testing.T) {
+	push := metadata.Metadata{Curr: metadata.Pipeline{Event: metadata.EventPush}}
+
+	c := parseConstraints(t, `{ event: push, evaluate: "steps.detect.outputs.changed == 'true'" }`)
+	match, err := c.Match(push, false, nil)
+	assert.NoError(t, err)
+	assert.True(t, match)
+	condition, err := c.RuntimeCondition(push, false, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "(steps.detect.outputs.changed == 'true')", condition)
+	assert.Equal(t, []StepOutput{{Step: "detect", Key: "changed"}}, c.StepOutputs())
+
+	// constraints which don't match are not part of the condition
+	c = parseConstraints(t, `[ { event: tag, evaluate: "steps.a.outputs.x == '1'" }, { evaluate: "steps.b.outputs.y == '2'" } ]`)
+	condition, err = c.RuntimeCondition(push, false, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "(steps.b.outputs.y == '2')", condition)
+
+	// a matching constraint without outputs lets the step run anyway
+	c = parseConstraints(t, `[ { evaluate: "steps.a.outputs.x == '1'" }, { event: push } ]`)
+	condition, err = c.RuntimeCondition(push, false, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, condition)
+}
+
 func parseConstraints(t *testing.T, s string) *When {
 	c := &When{}
 	assert.NoError(t, yaml.Unmarshal([]byte(s), c))
@@ -0,0 +1,118 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package constraint
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/metadata"
+)
+
+// Mismatches returns why the when block doesn't match, e.g. "branch 'feature/x' does not match [main, release/*]".
+// It returns nil if the when block matches. If there are several constraints the reasons are prefixed with
+// the number of the constraint, as every one of them has to fail.
+func (when *When) Mismatches(m metadata.Metadata, global bool, env map[string]string) ([]string, error) {
+	if when.IsEmpty() {
+		empty := &Constraint{}
+		return empty.Mismatches(m, global, env)
+	}
+
+	var reasons []string
+	for i, c := range when.Constraints {
+		constraintReasons, err := c.Mismatches(m, global, env)
+		if err != nil {
+			return nil, err
+		}
+		if len(constraintReasons) == 0 {
+			return nil, nil
+		}
+		if len(when.Constraints) == 1 {
+			return constraintReasons, nil
+		}
+		for _, reason := range constraintReasons {
+			reasons = append(reasons, fmt.Sprintf("constraint %d: %s", i+1, reason))
+		}
+	}
+	return reasons, nil
+}
+
+// Mismatches returns why the constraint doesn't match, Match is true if there is no reason.
+func (c *Constraint) Mismatches(m metadata.Metadata, global bool, env map[string]string) ([]string, error) {
+	var reasons []string
+	list := func(name string, l *List, v string) {
+		if !l.Match(v) {
+			reasons = append(reasons, l.mismatch(name, v))
+		}
+	}
+
+	if !global && !c.Matrix.Match(m.Workflow.Matrix) {
+		reasons = append(reasons, fmt.Sprintf("matrix axis %s does not match the matrix filter", formatMap(m.Workflow.Matrix)))
+	}
+	list("platform", &c.Platform, m.Sys.Platform)
+	list("environment", &c.Environment, m.Curr.Target)
+	list("event", &c.Event, m.Curr.Event)
+	list("repo", &c.Repo, path.Join(m.Repo.Owner, m.Repo.Name))
+	list("ref", &c.Ref, m.Curr.Commit.Ref)
+	list("instance", &c.Instance, m.Sys.Host)
+
+	if (m.Curr.Event == metadata.EventPull || m.Curr.Event == metadata.EventPush) && !m.Curr.Commit.ChangedFilesUnknown {
+		if !c.Path.Match(m.Curr.Commit.ChangedFiles, m.Curr.Commit.Message) {
+			if len(m.Curr.Commit.ChangedFiles) == 0 {
+				reasons = append(reasons, "commit has no changed files and on_empty is disabled")
+			} else {
+				reasons = append(reasons, "no changed file matches the path filter")
+			}
+		}
+	}
+
+	if m.Curr.Event != metadata.EventTag {
+		list("branch", &c.Branch, m.Curr.Commit.Branch)
+	}
+
+	if m.Curr.Event == metadata.EventCron {
+		list("cron", &c.Cron, m.Curr.Cron)
+	}
+
+	if c.Evaluate != "" {
+		match, err := c.evaluate(m, env)
+		if err != nil {
+			return nil, err
+		}
+		if !match {
+			reasons = append(reasons, fmt.Sprintf("evaluate '%s' is false", c.Evaluate))
+		}
+	}
+
+	return reasons, nil
+}
+
+func (c *List) mismatch(name, v string) string {
+	if c.Excludes(v) {
+		return fmt.Sprintf("%s '%s' is excluded by [%s]", name, v, strings.Join(c.Exclude, ", "))
+	}
+	return fmt.Sprintf("%s '%s' does not match [%s]", name, v, strings.Join(c.Include, ", "))
+}
+
+func formatMap(m map[string]string) string {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return "[" + strings.Join(pairs, ", ") + "]"
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package constraint
+
+import (
+	"regexp"
+	"strings"
+
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/metadata"
+)
+
+// stepOutputRegex matches references to outputs of other steps like "steps.detect.outputs.changed".
+var stepOutputRegex = regexp.MustCompile(`\bsteps\.(\w+)\.outputs\.(\w+)`)
+
+// StepOutput is a reference to an output of another step of the workflow.
+type StepOutput struct {
+	Step string
+	Key  string
+}
+
+// StepOutputs returns the outputs of other steps the evaluate expression references.
+func (c *Constraint) StepOutputs() []StepOutput {
+	var outputs []StepOutput
+	for _, match := range stepOutputRegex.FindAllStringSubmatch(c.Evaluate, -1) {
+		outputs = append(outputs, StepOutput{Step: match[1], Key: match[2]})
+	}
+	return outputs
+}
+
+// StepOutputs returns the outputs of other steps the constraints reference.
+func (when *When) StepOutputs() []StepOutput {
+	var outputs []StepOutput
+	for _, c := range when.Constraints {
+		outputs = append(outputs, c.StepOutputs()...)
+	}
+	return outputs
+}
+
+// RuntimeCondition returns the expression which has to be true when the step is about to run, as
+// it references outputs of other steps. The expression is empty if the step doesn't depend on outputs.
+func (when *When) RuntimeCondition(m metadata.Metadata, global bool, env map[string]string) (string, error) {
+	var conditions []string
+	for _, c := range when.Constraints {
+		match, err := c.Match(m, global, env)
+		if err != nil {
+			return "", err
+		}
+		if !match {
+			continue
+		}
+		if len(c.StepOutputs()) == 0 {
+			// a constraint matching without outputs lets the step run anyway
+			return "", nil
+		}
+		conditions = append(conditions, "("+c.Evaluate+")")
+	}
+	return strings.Join(conditions, " || "), nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yaml
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/types"
+)
+
+// EmitString serializes a parsed workflow back to normalized yaml. Steps are
+// emitted as list and deprecated keywords in their replaced form. Secrets are
+// kept as references, as they are only resolved by the compiler.
+func EmitString(workflow *types.Workflow) (string, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(workflow); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// SemanticHash returns a checksum of the normalized workflow, so formatting,
+// comments and key order of the config don't change it.
+func SemanticHash(workflow *types.Workflow) (string, error) {
+	normalized, err := EmitString(workflow)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(normalized))), nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yaml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var sampleEmitYaml = `
+when:
+  event: [push, tag]
+  branch:
+    exclude: [dev]
+  path:
+    include: ["src/**"]
+    on_empty: false
+skip_clone: true
+steps:
+  build:
+    image: golang
+    commands: go build
+    environment:
+      TOKEN:
+        from_secret: deploy_token
+    volumes:
+      - /tmp:/tmp
+  publish:
+    image: plugins/docker
+    settings:
+      repo: foo/bar
+      password:
+        from_secret: docker_password
+    secrets: [docker_username]
+    depends_on: build
+    when:
+      - event: tag
+        local: false
+      - matrix:
+          GO: "1.22"
+services:
+  database:
+    image: mysql
+labels:
+  platform: linux/amd64
+`
+
+func TestEmitString(t *testing.T) {
+	for _, data := range []string{sampleEmitYaml, sampleYaml} {
+		parsed, err := ParseString(data)
+		assert.NoError(t, err)
+
+		emitted, err := EmitString(parsed)
+		assert.NoError(t, err)
+
+		reparsed, err := ParseString(emitted)
+		assert.NoError(t, err)
+		assert.Equal(t, parsed, reparsed)
+
+		reemitted, err := EmitString(reparsed)
+		assert.NoError(t, err)
+		assert.Equal(t, emitted, reemitted)
+	}
+
+	parsed, err := ParseString(sampleEmitYaml)
+	assert.NoError(t, err)
+	emitted, err := EmitString(parsed)
+	assert.NoError(t, err)
+	assert.Contains(t, emitted, "from_secret: deploy_token")
+	assert.Contains(t, emitted, "  name: publish\n")
+}
+
+func TestSemanticHash(t *testing.T) {
+	hash := func(data string) string {
+		parsed, err := ParseString(data)
+		assert.NoError(t, err)
+		h, err := SemanticHash(parsed)
+		assert.NoError(t, err)
+		return h
+	}
+
+	original := hash(`
+steps:
+  build:
+    image: golang
+    commands: [go build, go test]
+when:
+  event: push
+`)
+
+	// reformatted, reordered and commented
+	assert.Equal(t, original, hash(`
+# run on every push
+when: { event: [ push ] }
+
+steps:
+  - name: build
+    commands:
+      - go build
+      - go test   # also test
+    image: golang
+`))
+
+	// changed command
+	assert.NotEqual(t, original, hash(`
+steps:
+  build:
+    image: golang
+    commands: [go build, go vet]
+when:
+  event: push
+`))
+}
@@ -16,24 +16,57 @@ package linter
 
 import (
 	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"codeberg.org/6543/xyaml"
+	"github.com/docker/go-units"
 	"go.uber.org/multierr"
 
 	"go.woodpecker-ci.org/woodpecker/v2/pipeline/errors"
 	errorTypes "go.woodpecker-ci.org/woodpecker/v2/pipeline/errors/types"
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/metadata"
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/constraint"
 	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/linter/schema"
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/matrix"
 	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/types"
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/utils"
+	"go.woodpecker-ci.org/woodpecker/v2/shared/constant"
 )
 
 // A Linter lints a pipeline configuration.
 type Linter struct {
-	trusted bool
+	trusted        bool
+	disabledRules  map[Rule]bool
+	imagePlatforms map[string][]string
+	variables      map[string]string
+	capabilities   []string
+	maxTmpfsSize   int64
+	resourceLimit  resourceLimit
+
+	unreachableStepsAsErrors bool
+	requirePinnedImages      bool
+}
+
+// resourceLimit are the limits of the server the resources of steps can't exceed.
+type resourceLimit struct {
+	memSwapLimit int64
+	memLimit     int64
+	cpuQuota     int64
+	cpuShares    int64
+	cpuSet       string
 }
 
 // New creates a new Linter with options.
 func New(opts ...Option) *Linter {
-	linter := new(Linter)
+	linter := &Linter{
+		disabledRules: make(map[Rule]bool),
+	}
 	for _, opt := range opts {
 		opt(linter)
 	}
@@ -67,7 +100,36 @@ func (l *Linter) Lint(configs []*WorkflowConfig) error {
 func (l *Linter) lintFile(config *WorkflowConfig) error {
 	var linterErr error
 
-	if len(config.Workflow.Steps.ContainerList) == 0 {
+	if !schema.IsSupportedVersion(config.Workflow.Version) {
+		return newLinterError(fmt.Sprintf("Unknown schema version %d", config.Workflow.Version), config.File, "version", false)
+	}
+
+	if err := l.lintTimeout(config); err != nil {
+		linterErr = multierr.Append(linterErr, err)
+	}
+	if config.Workflow.Clone.Depth < 0 {
+		linterErr = multierr.Append(linterErr, newLinterError("Clone depth has to be positive", config.File, "clone.depth", false))
+	}
+	// the default clone step gets the credentials of the repo, so untrusted repos can't replace its image
+	if image := config.Workflow.Clone.Image; image != "" && !l.trusted && !utils.MatchImage(image, constant.TrustedCloneImages...) {
+		linterErr = multierr.Append(linterErr, newLinterError(
+			fmt.Sprintf("Insufficient privileges to use the clone image '%s'", image), config.File, "clone.image", false))
+	}
+	if image := config.Workflow.Clone.Image; image != "" && l.pinnedImagesRequired() && !utils.IsImagePinned(image) {
+		linterErr = multierr.Append(linterErr, newLinterError(
+			fmt.Sprintf("Clone image '%s' has to be pinned to a version tag or digest", image), config.File, "clone.image", false))
+	}
+	if err := l.lintNotify(config); err != nil {
+		linterErr = multierr.Append(linterErr, err)
+	}
+	if err := l.lintBackend(config); err != nil {
+		linterErr = multierr.Append(linterErr, err)
+	}
+	if err := l.lintDependencies(config); err != nil {
+		linterErr = multierr.Append(linterErr, err)
+	}
+
+	if l.ruleEnabled(RuleStepsRequired) && len(config.Workflow.Steps.ContainerList) == 0 {
 		linterErr = multierr.Append(linterErr, newLinterError("Invalid or missing steps section", config.File, "steps", false))
 	}
 
@@ -81,16 +143,161 @@ func (l *Linter) lintFile(config *WorkflowConfig) error {
 		linterErr = multierr.Append(linterErr, err)
 	}
 
-	if err := l.lintSchema(config); err != nil {
-		linterErr = multierr.Append(linterErr, err)
+	if l.ruleEnabled(RuleImagePlatform) {
+		if err := l.lintImagePlatforms(config); err != nil {
+			linterErr = multierr.Append(linterErr, err)
+		}
 	}
-	if err := l.lintDeprecations(config); err != nil {
-		linterErr = multierr.Append(linterErr, err)
+
+	if l.variables != nil && l.ruleEnabled(RuleUnknownVariable) {
+		if err := l.lintVariables(config); err != nil {
+			linterErr = multierr.Append(linterErr, err)
+		}
 	}
-	if err := l.lintBadHabits(config); err != nil {
-		linterErr = multierr.Append(linterErr, err)
+
+	if l.ruleEnabled(RuleMatrixValues) {
+		if err := l.lintMatrixValues(config); err != nil {
+			linterErr = multierr.Append(linterErr, err)
+		}
+	}
+
+	if l.ruleEnabled(RuleUnreachableStep) {
+		if err := l.lintUnreachableSteps(config); err != nil {
+			linterErr = multierr.Append(linterErr, err)
+		}
+	}
+
+	if l.ruleEnabled(RuleArtifactPaths) {
+		if err := l.lintArtifacts(config); err != nil {
+			linterErr = multierr.Append(linterErr, err)
+		}
+	}
+
+	if l.ruleEnabled(RuleSchema) {
+		if err := l.lintSchema(config); err != nil {
+			linterErr = multierr.Append(linterErr, err)
+		}
+	}
+	if l.ruleEnabled(RuleDeprecations) {
+		if err := l.lintDeprecations(config); err != nil {
+			linterErr = multierr.Append(linterErr, err)
+		}
+	}
+	if l.ruleEnabled(RuleEventFilter) {
+		if err := l.lintBadHabits(config); err != nil {
+			linterErr = multierr.Append(linterErr, err)
+		}
+	}
+
+	return linterErr
+}
+
+// lintTimeout checks the timeouts of the workflow and the pipeline are positive durations like 45m.
+func (l *Linter) lintTimeout(config *WorkflowConfig) error {
+	var linterErr error
+	if timeout := config.Workflow.Timeout; timeout != "" {
+		if duration, err := time.ParseDuration(timeout); err != nil || duration <= 0 {
+			linterErr = multierr.Append(linterErr, newLinterError(fmt.Sprintf("Invalid timeout '%s', expected a positive duration like 45m or 1h30m", timeout), config.File, "timeout", false))
+		}
+	}
+	if timeout := config.Workflow.PipelineTimeout; timeout != "" {
+		if duration, err := time.ParseDuration(timeout); err != nil || duration <= 0 {
+			linterErr = multierr.Append(linterErr, newLinterError(fmt.Sprintf("Invalid pipeline timeout '%s', expected a positive duration like 45m or 1h30m", timeout), config.File, "pipeline_timeout", false))
+		}
+	}
+	return linterErr
+}
+
+// notifyScheme matches the scheme of a notify target like "https" or "slack".
+var notifyScheme = regexp.MustCompile(`^[a-z][a-z0-9+.-]*$`)
+
+// lintNotify checks the notify target of the workflow is a URL like https://example.com/hook or slack:#builds.
+func (l *Linter) lintNotify(config *WorkflowConfig) error {
+	target := config.Workflow.Notify
+	if target == "" {
+		return nil
+	}
+
+	valid := false
+	if scheme, rest, ok := strings.Cut(target, ":"); ok && notifyScheme.MatchString(scheme) && rest != "" && !strings.ContainsAny(target, " \t\n") {
+		valid = true
+		if scheme == "http" || scheme == "https" {
+			u, err := url.Parse(target)
+			valid = err == nil && u.Host != ""
+		}
+	}
+	if !valid {
+		return newLinterError(fmt.Sprintf("Invalid notify target '%s', expected a URL like https://example.com/hook or slack:#builds", target), config.File, "notify", false)
+	}
+	return nil
+}
+
+// backendFeatures maps the step options only some backends support to these backends.
+var backendFeatures = []struct {
+	option   string
+	backends []string
+	used     func(c *types.Container) bool
+}{
+	{"volumes", []string{"docker"}, func(c *types.Container) bool { return len(c.Volumes.Volumes) != 0 }},
+	{"devices", []string{"docker"}, func(c *types.Container) bool { return len(c.Devices) != 0 }},
+	{"network_mode", []string{"docker"}, func(c *types.Container) bool { return c.NetworkMode != "" }},
+	{"cap_add", []string{"docker", "kubernetes"}, func(c *types.Container) bool { return len(c.CapAdd) != 0 }},
+	{"cap_drop", []string{"docker", "kubernetes"}, func(c *types.Container) bool { return len(c.CapDrop) != 0 }},
+	{"tmpfs", []string{"docker"}, func(c *types.Container) bool { return len(c.Tmpfs) != 0 }},
+	{"resources", []string{"docker"}, func(c *types.Container) bool { return c.Resources != nil }},
+	{"backend_options.kubernetes", []string{"kubernetes"}, func(c *types.Container) bool { return c.BackendOptions["kubernetes"] != nil }},
+}
+
+// lintBackend checks the backend the workflow requires is known and supports the options of its steps.
+func (l *Linter) lintBackend(config *WorkflowConfig) error {
+	backend := config.Workflow.Backend
+	if backend == "" {
+		return nil
+	}
+	if !slices.Contains([]string{"docker", "kubernetes", "local"}, backend) {
+		return newLinterError(fmt.Sprintf("Unknown backend '%s', expected docker, kubernetes or local", backend), config.File, "backend", false)
+	}
+
+	var linterErr error
+	if label, ok := config.Workflow.Labels["backend"]; ok && label != backend {
+		linterErr = multierr.Append(linterErr, newLinterError(
+			fmt.Sprintf("Label backend '%s' contradicts the required backend '%s'", label, backend), config.File, "labels.backend", false))
+	}
+	for _, area := range []string{"clone", "steps", "services"} {
+		var containers []*types.Container
+		switch area {
+		case "clone":
+			containers = config.Workflow.Clone.ContainerList
+		case "steps":
+			containers = config.Workflow.Steps.ContainerList
+		case "services":
+			containers = config.Workflow.Services.ContainerList
+		}
+		for _, container := range containers {
+			for _, feature := range backendFeatures {
+				if feature.used(container) && !slices.Contains(feature.backends, backend) {
+					linterErr = multierr.Append(linterErr, newLinterError(
+						fmt.Sprintf("The %s backend doesn't support %s", backend, feature.option),
+						config.File, fmt.Sprintf("%s.%s.%s", area, container.Name, feature.option), false))
+				}
+			}
+		}
 	}
+	return linterErr
+}
 
+// lintDependencies checks the status conditions of the workflow dependencies.
+func (l *Linter) lintDependencies(config *WorkflowConfig) error {
+	var linterErr error
+	for i, dep := range config.Workflow.DependsOn {
+		switch dep.StatusOrDefault() {
+		case types.DependencyStatusSuccess, types.DependencyStatusFailure, types.DependencyStatusAlways:
+		default:
+			linterErr = multierr.Append(linterErr, newLinterError(
+				fmt.Sprintf("Invalid status '%s' of dependency '%s', use success, failure or always", dep.Status, dep.Name),
+				config.File, fmt.Sprintf("depends_on[%d]", i), false))
+		}
+	}
 	return linterErr
 }
 
@@ -109,22 +316,96 @@ func (l *Linter) lintContainers(config *WorkflowConfig, area string) error {
 	}
 
 	for _, container := range containers {
-		if err := l.lintImage(config, container, area); err != nil {
+		if l.ruleEnabled(RuleImageRequired) {
+			if err := l.lintImage(config, container, area); err != nil {
+				linterErr = multierr.Append(linterErr, err)
+			}
+		}
+		if !l.trusted && l.ruleEnabled(RuleTrusted) {
+			if err := l.lintTrusted(config, container, fmt.Sprintf("%s.%s", area, container.Name)); err != nil {
+				linterErr = multierr.Append(linterErr, err)
+			}
+		}
+		if l.trusted {
+			if err := l.lintCapabilities(config, container, fmt.Sprintf("%s.%s", area, container.Name)); err != nil {
+				linterErr = multierr.Append(linterErr, err)
+			}
+		}
+		if err := l.lintTmpfs(config, container, fmt.Sprintf("%s.%s", area, container.Name)); err != nil {
 			linterErr = multierr.Append(linterErr, err)
 		}
+		if err := l.lintResources(config, container, fmt.Sprintf("%s.%s", area, container.Name)); err != nil {
+			linterErr = multierr.Append(linterErr, err)
+		}
+		if err := l.lintInit(config, container, area); err != nil {
+			linterErr = multierr.Append(linterErr, err)
+		}
+		if l.ruleEnabled(RuleCommandsWithSettings) {
+			if err := l.lintCommands(config, container, area); err != nil {
+				linterErr = multierr.Append(linterErr, err)
+			}
+		}
+		if l.ruleEnabled(RuleCachePaths) {
+			if err := l.lintCache(config, container, area); err != nil {
+				linterErr = multierr.Append(linterErr, err)
+			}
+		}
+		if l.pinnedImagesRequired() && len(container.Image) != 0 && !utils.IsImagePinned(container.Image) {
+			linterErr = multierr.Append(linterErr, newLinterError(
+				fmt.Sprintf("Image '%s' of step '%s' has to be pinned to a version tag or digest", container.Image, container.Name),
+				config.File, fmt.Sprintf("%s.%s.image", area, container.Name), false))
+		}
+		if container.ShmSize < 0 {
+			linterErr = multierr.Append(linterErr, newLinterError("Invalid shm_size, it has to be a positive size", config.File, fmt.Sprintf("%s.%s.shm_size", area, container.Name), false))
+		}
+	}
+
+	return linterErr
+}
+
+// lintInit checks the init steps of a step, they run commands before the step.
+func (l *Linter) lintInit(config *WorkflowConfig, c *types.Container, area string) error {
+	if len(c.Init) == 0 {
+		return nil
+	}
+	if area != "steps" {
+		return newLinterError("Init steps are only supported by steps", config.File, fmt.Sprintf("%s.%s.init", area, c.Name), false)
+	}
+
+	var linterErr error
+	for i, init := range c.Init {
+		yamlPath := fmt.Sprintf("%s.%s.init[%d]", area, c.Name, i)
+		if len(init.Image) == 0 {
+			linterErr = multierr.Append(linterErr, newLinterError("Invalid or missing image", config.File, yamlPath, false))
+		}
+		if len(init.Commands) == 0 {
+			linterErr = multierr.Append(linterErr, newLinterError("Init steps require commands", config.File, yamlPath, false))
+		}
+		if len(init.Init) != 0 {
+			linterErr = multierr.Append(linterErr, newLinterError("Init steps can't have init steps", config.File, yamlPath, false))
+		}
 		if !l.trusted {
-			if err := l.lintTrusted(config, container, area); err != nil {
+			if err := l.lintTrusted(config, init, yamlPath); err != nil {
 				linterErr = multierr.Append(linterErr, err)
 			}
+		} else if err := l.lintCapabilities(config, init, yamlPath); err != nil {
+			linterErr = multierr.Append(linterErr, err)
 		}
-		if err := l.lintCommands(config, container, area); err != nil {
+		if err := l.lintTmpfs(config, init, yamlPath); err != nil {
+			linterErr = multierr.Append(linterErr, err)
+		}
+		if err := l.lintResources(config, init, yamlPath); err != nil {
 			linterErr = multierr.Append(linterErr, err)
 		}
 	}
-
 	return linterErr
 }
 
+// pinnedImagesRequired returns true if images have to be pinned, only trusted repos can skip the check.
+func (l *Linter) pinnedImagesRequired() bool {
+	return l.requirePinnedImages && (!l.trusted || l.ruleEnabled(RulePinnedImages))
+}
+
 func (l *Linter) lintImage(config *WorkflowConfig, c *types.Container, area string) error {
 	if len(c.Image) == 0 {
 		return newLinterError("Invalid or missing image", config.File, fmt.Sprintf("%s.%s", area, c.Name), false)
@@ -132,6 +413,56 @@ func (l *Linter) lintImage(config *WorkflowConfig, c *types.Container, area stri
 	return nil
 }
 
+// lintImagePlatforms warns about steps whose image doesn't support the platform of the workflow.
+func (l *Linter) lintImagePlatforms(config *WorkflowConfig) error {
+	platform := config.Workflow.Labels["platform"]
+	if l.imagePlatforms == nil || platform == "" {
+		return nil
+	}
+
+	var linterErr error
+	for _, c := range config.Workflow.Steps.ContainerList {
+		platforms, ok := l.platformsOfImage(c.Image)
+		if !ok {
+			continue
+		}
+		supported := slices.ContainsFunc(platforms, func(p string) bool {
+			// a variant like linux/arm64/v8 supports linux/arm64
+			return p == platform || strings.HasPrefix(p, platform+"/")
+		})
+		if !supported {
+			linterErr = multierr.Append(linterErr, newLinterError(
+				fmt.Sprintf("Image '%s' does not support the workflow platform '%s'", c.Image, platform),
+				config.File, fmt.Sprintf("steps.%s.image", c.Name), true))
+		}
+	}
+	return linterErr
+}
+
+func (l *Linter) platformsOfImage(image string) ([]string, bool) {
+	if platforms, ok := l.imagePlatforms[image]; ok {
+		return platforms, true
+	}
+	for name, platforms := range l.imagePlatforms {
+		if utils.MatchImage(image, name) {
+			return platforms, true
+		}
+	}
+	return nil, false
+}
+
+// lintVariables warns about references in the raw config to variables which are neither declared
+// by the matrix nor known otherwise, the substitution silently replaces them by an empty string.
+func (l *Linter) lintVariables(config *WorkflowConfig) error {
+	var linterErr error
+	for _, name := range metadata.UnknownVariables(config.RawConfig, l.variables) {
+		linterErr = multierr.Append(linterErr, newLinterError(
+			fmt.Sprintf("Variable '%s' is neither declared by the matrix nor a known variable and resolves to an empty value", name),
+			config.File, "", true))
+	}
+	return linterErr
+}
+
 func (l *Linter) lintCommands(config *WorkflowConfig, c *types.Container, field string) error {
 	if len(c.Commands) == 0 {
 		return nil
@@ -146,15 +477,140 @@ func (l *Linter) lintCommands(config *WorkflowConfig, c *types.Container, field
 	return nil
 }
 
-func (l *Linter) lintTrusted(config *WorkflowConfig, c *types.Container, area string) error {
-	yamlPath := fmt.Sprintf("%s.%s", area, c.Name)
+func (l *Linter) lintCache(config *WorkflowConfig, c *types.Container, area string) error {
+	if c.Cache == nil {
+		return nil
+	}
+	yamlPath := fmt.Sprintf("%s.%s.cache", area, c.Name)
+	if c.Cache.Key == "" {
+		return newLinterError("Cache key must not be empty", config.File, yamlPath, false)
+	}
+
+	var linterErr error
+	for _, p := range c.Cache.Paths {
+		if !filepath.IsLocal(p) {
+			linterErr = multierr.Append(linterErr, newLinterError(fmt.Sprintf("Cache path '%s' has to be relative to the workspace", p), config.File, yamlPath, false))
+		}
+	}
+	return linterErr
+}
+
+// lintMatrixValues warns about unquoted matrix values YAML reads as a different number, e.g. 1.20.
+func (l *Linter) lintMatrixValues(config *WorkflowConfig) error {
+	// an invalid matrix is reported when the workflow gets parsed
+	coerced, _ := matrix.CoercedValues([]byte(config.RawConfig))
+
+	var linterErr error
+	for _, value := range coerced {
+		linterErr = multierr.Append(linterErr, newLinterError(
+			fmt.Sprintf("Matrix value %s of '%s' is the number %s in YAML, quote it to keep it as written", value.Value, value.Key, value.Number),
+			config.File, "matrix."+value.Key, true))
+	}
+	return linterErr
+}
+
+// events are all events a pipeline can run for.
+var events = []string{
+	metadata.EventPush,
+	metadata.EventPull,
+	metadata.EventPullClosed,
+	metadata.EventTag,
+	metadata.EventRelease,
+	metadata.EventDeploy,
+	metadata.EventCron,
+	metadata.EventManual,
+}
+
+// lintUnreachableSteps reports steps whose when conditions can't match together with the when
+// conditions of the workflow. Only the event and branch filters are compared.
+func (l *Linter) lintUnreachableSteps(config *WorkflowConfig) error {
+	workflowConstraints := config.Workflow.When.Constraints
+	if len(workflowConstraints) == 0 {
+		workflowConstraints = []constraint.Constraint{{}}
+	}
+
+	var linterErr error
+	for _, step := range config.Workflow.Steps.ContainerList {
+		if len(step.When.Constraints) == 0 || reachable(workflowConstraints, step.When.Constraints) {
+			continue
+		}
+		linterErr = multierr.Append(linterErr, newLinterError(
+			fmt.Sprintf("Step '%s' can never run, its when conditions don't match the ones of the workflow", step.Name),
+			config.File, fmt.Sprintf("steps.%s.when", step.Name), !l.unreachableStepsAsErrors))
+	}
+	return linterErr
+}
+
+// reachable returns true unless every pair of workflow and step constraints is provably disjoint.
+func reachable(workflowConstraints, stepConstraints []constraint.Constraint) bool {
+	for _, w := range workflowConstraints {
+		for _, s := range stepConstraints {
+			if constraintsOverlap(w, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// constraintsOverlap returns true if an event exists both constraints match, the branch is
+// only compared for events it is checked for.
+func constraintsOverlap(a, b constraint.Constraint) bool {
+	for _, event := range events {
+		if !a.Event.Match(event) || !b.Event.Match(event) {
+			continue
+		}
+		if event == metadata.EventTag || branchesOverlap(a.Branch, b.Branch) {
+			return true
+		}
+	}
+	return false
+}
+
+// branchesOverlap returns true unless one of the lists only includes plain branch names and
+// none of them is matched by both lists.
+func branchesOverlap(a, b constraint.List) bool {
+	for _, list := range []constraint.List{a, b} {
+		if len(list.Include) == 0 || slices.ContainsFunc(list.Include, isPattern) {
+			continue
+		}
+		return slices.ContainsFunc(list.Include, func(branch string) bool {
+			return a.Match(branch) && b.Match(branch)
+		})
+	}
+	return true
+}
+
+func isPattern(s string) bool {
+	return strings.ContainsAny(s, "*?[{\\")
+}
+
+func (l *Linter) lintArtifacts(config *WorkflowConfig) error {
+	var linterErr error
+	for _, p := range config.Workflow.Artifacts {
+		if !filepath.IsLocal(p) {
+			linterErr = multierr.Append(linterErr, newLinterError(fmt.Sprintf("Artifact path '%s' has to be relative to the workspace", p), config.File, "artifacts", false))
+		}
+	}
+	for i, dep := range config.Workflow.Consumes {
+		yamlPath := fmt.Sprintf("consumes[%d]", i)
+		if dep.Workflow == "" {
+			linterErr = multierr.Append(linterErr, newLinterError("Consumed workflow must not be empty", config.File, yamlPath, false))
+		}
+		for _, p := range dep.Paths {
+			if !filepath.IsLocal(p) {
+				linterErr = multierr.Append(linterErr, newLinterError(fmt.Sprintf("Artifact path '%s' has to be relative to the workspace", p), config.File, yamlPath, false))
+			}
+		}
+	}
+	return linterErr
+}
+
+func (l *Linter) lintTrusted(config *WorkflowConfig, c *types.Container, yamlPath string) error {
 	errors := []string{}
 	if c.Privileged {
 		errors = append(errors, "Insufficient privileges to use privileged mode")
 	}
-	if c.ShmSize != 0 {
-		errors = append(errors, "Insufficient privileges to override shm_size")
-	}
 	if len(c.DNS) != 0 {
 		errors = append(errors, "Insufficient privileges to use custom dns")
 	}
@@ -176,9 +632,15 @@ func (l *Linter) lintTrusted(config *WorkflowConfig, c *types.Container, area st
 	if c.Volumes.Volumes != nil && len(c.Volumes.Volumes) != 0 {
 		errors = append(errors, "Insufficient privileges to use volumes")
 	}
-	if len(c.Tmpfs) != 0 {
+	if len(c.Tmpfs) != 0 && l.maxTmpfsSize <= 0 {
 		errors = append(errors, "Insufficient privileges to use tmpfs")
 	}
+	if len(c.CapAdd) != 0 {
+		errors = append(errors, "Insufficient privileges to use cap_add")
+	}
+	if len(c.CapDrop) != 0 {
+		errors = append(errors, "Insufficient privileges to use cap_drop")
+	}
 
 	if len(errors) > 0 {
 		var err error
@@ -193,9 +655,125 @@ func (l *Linter) lintTrusted(config *WorkflowConfig, c *types.Container, area st
 	return nil
 }
 
+// lintCapabilities checks that the capabilities added by a step of a trusted repo are allowed by the server.
+func (l *Linter) lintCapabilities(config *WorkflowConfig, c *types.Container, yamlPath string) error {
+	var linterErr error
+	for _, capability := range c.CapAdd {
+		if !slices.Contains(l.capabilities, NormalizeCapability(capability)) {
+			linterErr = multierr.Append(linterErr, newLinterError(
+				fmt.Sprintf("Capability '%s' is not allowed by the server", capability),
+				config.File, yamlPath+".cap_add", false))
+		}
+	}
+	return linterErr
+}
+
+// lintTmpfs checks the tmpfs mounts of a step, untrusted repos have to stay within the size allowed
+// by the server with all mounts of the step together.
+func (l *Linter) lintTmpfs(config *WorkflowConfig, c *types.Container, yamlPath string) error {
+	var linterErr error
+	var totalSize int64
+	for _, mount := range c.Tmpfs {
+		mountPath, size, err := ParseTmpfs(mount)
+		if err != nil {
+			linterErr = multierr.Append(linterErr, newLinterError(
+				fmt.Sprintf("Invalid size of tmpfs '%s', %s", mountPath, err), config.File, yamlPath+".tmpfs", false))
+			continue
+		}
+		if !path.IsAbs(mountPath) {
+			linterErr = multierr.Append(linterErr, newLinterError(
+				fmt.Sprintf("Tmpfs path '%s' has to be absolute", mountPath), config.File, yamlPath+".tmpfs", false))
+		}
+		if l.trusted || l.maxTmpfsSize <= 0 {
+			continue
+		}
+		if size == 0 || size > l.maxTmpfsSize {
+			linterErr = multierr.Append(linterErr, newLinterError(
+				fmt.Sprintf("Tmpfs '%s' needs a size of at most %s", mountPath, units.BytesSize(float64(l.maxTmpfsSize))),
+				config.File, yamlPath+".tmpfs", false))
+			continue
+		}
+		totalSize += size
+	}
+	if !l.trusted && l.maxTmpfsSize > 0 && totalSize > l.maxTmpfsSize {
+		linterErr = multierr.Append(linterErr, newLinterError(
+			fmt.Sprintf("Tmpfs mounts of '%s' need a total size of at most %s", c.Name, units.BytesSize(float64(l.maxTmpfsSize))),
+			config.File, yamlPath+".tmpfs", false))
+	}
+	return linterErr
+}
+
+// lintResources checks the resources of a step are positive and within the limits of the server.
+func (l *Linter) lintResources(config *WorkflowConfig, c *types.Container, yamlPath string) error {
+	if c.Resources == nil {
+		return nil
+	}
+
+	var linterErr error
+	check := func(name string, value, limit int64) {
+		switch {
+		case value < 0:
+			linterErr = multierr.Append(linterErr, newLinterError(
+				fmt.Sprintf("Invalid %s, it has to be positive", name), config.File, yamlPath+".resources."+name, false))
+		case limit > 0 && value > limit:
+			linterErr = multierr.Append(linterErr, newLinterError(
+				fmt.Sprintf("The %s of %d exceeds the limit of %d set by the server", name, value, limit), config.File, yamlPath+".resources."+name, false))
+		}
+	}
+	check("mem_limit", int64(c.Resources.MemLimit), l.resourceLimit.memLimit)
+	check("memswap_limit", int64(c.Resources.MemSwapLimit), l.resourceLimit.memSwapLimit)
+	check("cpu_quota", int64(c.Resources.CPUQuota), l.resourceLimit.cpuQuota)
+	check("cpu_shares", int64(c.Resources.CPUShares), l.resourceLimit.cpuShares)
+
+	if cpuSet := c.Resources.CPUSet; cpuSet != "" {
+		if _, err := utils.ParseCPUSet(cpuSet); err != nil {
+			linterErr = multierr.Append(linterErr, newLinterError(
+				fmt.Sprintf("Invalid cpuset '%s'", cpuSet), config.File, yamlPath+".resources.cpuset", false))
+		} else if l.resourceLimit.cpuSet != "" && !utils.IsCPUSubset(cpuSet, l.resourceLimit.cpuSet) {
+			linterErr = multierr.Append(linterErr, newLinterError(
+				fmt.Sprintf("The cpuset '%s' is not a subset of the cpuset '%s' set by the server", cpuSet, l.resourceLimit.cpuSet), config.File, yamlPath+".resources.cpuset", false))
+		}
+	}
+	return linterErr
+}
+
+// ParseTmpfs returns the path and the size in bytes of a tmpfs mount, e.g. "/tmp/fast:size=64m,mode=1777".
+// The size is zero if the mount doesn't set one or sets it by nr_blocks. As docker uses the last of
+// several size options, setting the size more than once is an error.
+func ParseTmpfs(mount string) (string, int64, error) {
+	mountPath, options, _ := strings.Cut(mount, ":")
+	var size int64
+	var sizeOptions int
+	for _, option := range strings.Split(options, ",") {
+		if strings.HasPrefix(option, "nr_blocks=") {
+			sizeOptions++
+			continue
+		}
+		value, ok := strings.CutPrefix(option, "size=")
+		if !ok {
+			continue
+		}
+		sizeOptions++
+		var err error
+		size, err = units.RAMInBytes(value)
+		if err != nil || size <= 0 {
+			return mountPath, 0, fmt.Errorf("'%s' is no valid size", value)
+		}
+	}
+	if sizeOptions > 1 {
+		return mountPath, 0, fmt.Errorf("it is set more than once")
+	}
+	return mountPath, size, nil
+}
+
+// NormalizeCapability returns the name of a linux capability in the form docker uses, e.g. "cap_net_admin" -> "NET_ADMIN".
+func NormalizeCapability(capability string) string {
+	return strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(capability)), "CAP_")
+}
+
 func (l *Linter) lintSchema(config *WorkflowConfig) error {
 	var linterErr error
-	schemaErrors, err := schema.LintString(config.RawConfig)
+	schemaErrors, err := schema.LintStringVersion(config.RawConfig, config.Workflow.Version)
 	if err != nil {
 		for _, schemaError := range schemaErrors {
 			linterErr = multierr.Append(linterErr, newLinterError(
@@ -20,6 +20,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"go.woodpecker-ci.org/woodpecker/v2/pipeline/errors"
+	errorTypes "go.woodpecker-ci.org/woodpecker/v2/pipeline/errors/types"
 	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml"
 	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/linter"
 )
@@ -86,6 +87,19 @@ steps:
   test base step with latest image:
     <<: *base-step
     image: golang:latest
+`,
+	}, {
+		Title: "version", Data: `
+version: 1
+
+when:
+  event: push
+
+steps:
+  build:
+    image: golang
+    commands:
+      - go build
 `,
 	}}
 
@@ -116,13 +130,17 @@ func TestLintErrors(t *testing.T) {
 			from: "steps: { build: { image: '' }  }",
 			want: "Invalid or missing image",
 		},
+		{
+			from: "version: 99\nsteps: { build: { image: golang }  }",
+			want: "Unknown schema version 99",
+		},
 		{
 			from: "steps: { build: { image: golang, privileged: true }  }",
 			want: "Insufficient privileges to use privileged mode",
 		},
 		{
-			from: "steps: { build: { image: golang, shm_size: 10gb }  }",
-			want: "Insufficient privileges to override shm_size",
+			from: "steps: { build: { image: golang, shm_size: -1 }  }",
+			want: "Invalid shm_size, it has to be a positive size",
 		},
 		{
 			from: "steps: { build: { image: golang, dns: [ 8.8.8.8 ] }  }",
@@ -141,6 +159,14 @@ func TestLintErrors(t *testing.T) {
 			from: "steps: { build: { image: golang, extra_hosts: [ 'somehost:162.242.195.82' ] }  }",
 			want: "Insufficient privileges to use extra_hosts",
 		},
+		{
+			from: "steps: { build: { image: golang, cap_add: [ NET_ADMIN ] }  }",
+			want: "Insufficient privileges to use cap_add",
+		},
+		{
+			from: "steps: { build: { image: golang, cap_drop: [ ALL ] }  }",
+			want: "Insufficient privileges to use cap_drop",
+		},
 		{
 			from: "steps: { build: { image: golang, network_mode: host }  }",
 			want: "Insufficient privileges to use network_mode",
@@ -157,6 +183,66 @@ func TestLintErrors(t *testing.T) {
 			from: "steps: { build: { image: golang, network_mode: 'container:name' }  }",
 			want: "Insufficient privileges to use network_mode",
 		},
+		{
+			from: "steps: { build: { image: golang, cache: { key: go, paths: [ /root/.cache ] } }  }",
+			want: "Cache path '/root/.cache' has to be relative to the workspace",
+		},
+		{
+			from: "steps: { build: { image: golang, cache: { key: go, paths: [ ../cache ] } }  }",
+			want: "Cache path '../cache' has to be relative to the workspace",
+		},
+		{
+			from: "steps: { build: { image: golang, cache: { key: '', paths: [ cache ] } }  }",
+			want: "Cache key must not be empty",
+		},
+		{
+			from: "steps: { build: { image: golang } }\nartifacts: [ /dist ]",
+			want: "Artifact path '/dist' has to be relative to the workspace",
+		},
+		{
+			from: "steps: { test: { image: golang } }\nconsumes: [ { workflow: build, paths: [ ../dist ] } ]",
+			want: "Artifact path '../dist' has to be relative to the workspace",
+		},
+		{
+			from: "steps: { test: { image: golang } }\nconsumes: [ { workflow: '', paths: [ dist ] } ]",
+			want: "Consumed workflow must not be empty",
+		},
+		{
+			from: "depends_on: [ { name: build, status: skipped } ]\nsteps: { test: { image: golang } }",
+			want: "Invalid status 'skipped' of dependency 'build', use success, failure or always",
+		},
+		{
+			from: "timeout: 45 minutes\nsteps: { build: { image: golang } }",
+			want: "Invalid timeout '45 minutes', expected a positive duration like 45m or 1h30m",
+		},
+		{
+			from: "timeout: -5m\nsteps: { build: { image: golang } }",
+			want: "Invalid timeout '-5m', expected a positive duration like 45m or 1h30m",
+		},
+		{
+			from: "pipeline_timeout: 2 hours\nsteps: { build: { image: golang } }",
+			want: "Invalid pipeline timeout '2 hours', expected a positive duration like 45m or 1h30m",
+		},
+		{
+			from: "notify: not a target\nsteps: { build: { image: golang } }",
+			want: "Invalid notify target 'not a target', expected a URL like https://example.com/hook or slack:#builds",
+		},
+		{
+			from: "notify: https://\nsteps: { build: { image: golang } }",
+			want: "Invalid notify target 'https://', expected a URL like https://example.com/hook or slack:#builds",
+		},
+		{
+			from: "steps: { build: { image: golang, init: [ { commands: [ go mod download ] } ] } }",
+			want: "Invalid or missing image",
+		},
+		{
+			from: "steps: { build: { image: golang, init: [ { image: golang } ] } }",
+			want: "Init steps require commands",
+		},
+		{
+			from: "steps: { build: { image: golang, init: [ { image: golang, commands: [ ls ], privileged: true } ] } }",
+			want: "Insufficient privileges to use privileged mode",
+		},
 	}
 
 	for _, test := range testdata {
@@ -219,3 +305,451 @@ func TestBadHabits(t *testing.T) {
 		assert.True(t, found, "Expected error %q, got %q", test.want, lerrors)
 	}
 }
+
+func TestVersionDeprecations(t *testing.T) {
+	from := "version: 1\nwhen: { event: push }\nsteps: { build: { image: golang, group: test } }"
+	conf, err := yaml.ParseString(from)
+	assert.NoError(t, err)
+
+	lerr := linter.New().Lint([]*linter.WorkflowConfig{{
+		File:      from,
+		RawConfig: from,
+		Workflow:  conf,
+	}})
+	assert.Error(t, lerr)
+	assert.False(t, errors.HasBlockingErrors(lerr))
+
+	lerrors := errors.GetPipelineErrors(lerr)
+	if assert.Len(t, lerrors, 1) {
+		assert.Equal(t, "Please use depends_on instead of deprecated 'group' setting", lerrors[0].Message)
+		assert.True(t, lerrors[0].IsWarning)
+	}
+}
+
+func TestDisabledLintRules(t *testing.T) {
+	from := "steps: { build: { image: golang, privileged: true } }"
+	conf, err := yaml.ParseString(from)
+	assert.NoError(t, err)
+
+	lint := func(opts ...linter.Option) []string {
+		lerr := linter.New(opts...).Lint([]*linter.WorkflowConfig{{
+			File:      from,
+			RawConfig: from,
+			Workflow:  conf,
+		}})
+		var messages []string
+		for _, lerr := range errors.GetPipelineErrors(lerr) {
+			messages = append(messages, lerr.Message)
+		}
+		return messages
+	}
+
+	eventFilterMsg := "Please set an event filter for all steps or the whole workflow on all items of the when block"
+	privilegedMsg := "Insufficient privileges to use privileged mode"
+
+	assert.Contains(t, lint(), eventFilterMsg)
+	assert.Contains(t, lint(), privilegedMsg)
+
+	messages := lint(linter.WithDisabledLintRules(linter.RuleEventFilter))
+	assert.NotContains(t, messages, eventFilterMsg)
+	assert.Contains(t, messages, privilegedMsg)
+
+	// security rules can't be disabled
+	assert.Contains(t, lint(linter.WithDisabledLintRules(linter.RuleTrusted)), privilegedMsg)
+
+	from = `
+when: { event: push }
+steps:
+  build:
+    image: golang
+    cache: { key: go, paths: [ ../cache ] }
+artifacts: [ ../dist ]
+`
+	conf, err = yaml.ParseString(from)
+	assert.NoError(t, err)
+	messages = lint(linter.WithDisabledLintRules(linter.RuleCachePaths, linter.RuleArtifactPaths))
+	assert.Contains(t, messages, "Cache path '../cache' has to be relative to the workspace")
+	assert.Contains(t, messages, "Artifact path '../dist' has to be relative to the workspace")
+}
+
+func TestCapabilities(t *testing.T) {
+	from := `
+when: { event: push }
+steps:
+  build:
+    image: golang
+    commands: [ ip link ]
+    cap_add: [ NET_ADMIN, cap_sys_time ]
+    cap_drop: [ ALL ]
+`
+	conf, err := yaml.ParseString(from)
+	assert.NoError(t, err)
+
+	lint := func(opts ...linter.Option) []*errorTypes.PipelineError {
+		return errors.GetPipelineErrors(linter.New(opts...).Lint([]*linter.WorkflowConfig{{
+			File:      ".woodpecker.yaml",
+			RawConfig: from,
+			Workflow:  conf,
+		}}))
+	}
+
+	assert.Empty(t, lint(
+		linter.WithTrusted(true),
+		linter.WithAllowedCapabilities([]string{"NET_ADMIN", "CAP_SYS_TIME"}),
+	))
+
+	lerrors := lint(linter.WithTrusted(true), linter.WithAllowedCapabilities([]string{"NET_ADMIN"}))
+	if assert.Len(t, lerrors, 1) {
+		assert.Equal(t, "Capability 'cap_sys_time' is not allowed by the server", lerrors[0].Message)
+		assert.False(t, lerrors[0].IsWarning)
+	}
+
+	var messages []string
+	for _, lerr := range lint(linter.WithAllowedCapabilities([]string{"NET_ADMIN", "SYS_TIME"})) {
+		messages = append(messages, lerr.Message)
+	}
+	assert.Contains(t, messages, "Insufficient privileges to use cap_add")
+	assert.Contains(t, messages, "Insufficient privileges to use cap_drop")
+}
+
+func TestTmpfs(t *testing.T) {
+	lint := func(from string, opts ...linter.Option) []string {
+		conf, err := yaml.ParseString(from)
+		assert.NoError(t, err)
+		var messages []string
+		for _, lerr := range errors.GetPipelineErrors(linter.New(opts...).Lint([]*linter.WorkflowConfig{{
+			File:      ".woodpecker.yaml",
+			RawConfig: from,
+			Workflow:  conf,
+		}})) {
+			messages = append(messages, lerr.Message)
+		}
+		return messages
+	}
+	config := func(tmpfs string) string {
+		return `
+when: { event: push }
+steps:
+  build:
+    image: golang
+    commands: [ go build ]
+    tmpfs: [ ` + tmpfs + ` ]
+`
+	}
+
+	assert.Empty(t, lint(config("/tmp/fast"), linter.WithTrusted(true)))
+	assert.Empty(t, lint(config("'/tmp/fast:size=64m,mode=1777'"), linter.WithMaxTmpfsSize(128*1024*1024)))
+
+	assert.Equal(t, []string{"Tmpfs path 'tmp/fast' has to be absolute"}, lint(config("tmp/fast"), linter.WithTrusted(true)))
+	assert.Equal(t, []string{"Invalid size of tmpfs '/tmp/fast', 'much' is no valid size"}, lint(config("'/tmp/fast:size=much'"), linter.WithTrusted(true)))
+	assert.Equal(t, []string{"Insufficient privileges to use tmpfs"}, lint(config("/tmp/fast")))
+	assert.Equal(t, []string{"Tmpfs '/tmp/fast' needs a size of at most 64MiB"}, lint(config("'/tmp/fast:size=1g'"), linter.WithMaxTmpfsSize(64*1024*1024)))
+	assert.Equal(t, []string{"Tmpfs '/tmp/fast' needs a size of at most 64MiB"}, lint(config("/tmp/fast"), linter.WithMaxTmpfsSize(64*1024*1024)))
+
+	// docker uses the last size option, so only a single one is allowed
+	assert.Equal(t, []string{"Invalid size of tmpfs '/tmp/fast', it is set more than once"}, lint(config("'/tmp/fast:size=1k,size=100g'"), linter.WithMaxTmpfsSize(64*1024*1024)))
+	assert.Equal(t, []string{"Invalid size of tmpfs '/tmp/fast', it is set more than once"}, lint(config("'/tmp/fast:size=1k,nr_blocks=1000000'"), linter.WithMaxTmpfsSize(64*1024*1024)))
+	assert.Equal(t, []string{"Tmpfs '/tmp/fast' needs a size of at most 64MiB"}, lint(config("'/tmp/fast:nr_blocks=1000000'"), linter.WithMaxTmpfsSize(64*1024*1024)))
+	assert.Empty(t, lint(config("'/tmp/fast:nr_blocks=1000000'"), linter.WithTrusted(true)))
+
+	// the limit applies to all mounts of a step together
+	assert.Empty(t, lint(config("'/tmp/a:size=32m', '/tmp/b:size=32m'"), linter.WithMaxTmpfsSize(64*1024*1024)))
+	assert.Equal(t, []string{"Tmpfs mounts of 'build' need a total size of at most 64MiB"}, lint(config("'/tmp/a:size=48m', '/tmp/b:size=48m'"), linter.WithMaxTmpfsSize(64*1024*1024)))
+	assert.Empty(t, lint(config("'/tmp/a:size=48m', '/tmp/b:size=48m'"), linter.WithTrusted(true), linter.WithMaxTmpfsSize(64*1024*1024)))
+}
+
+func TestResources(t *testing.T) {
+	from := `
+when: { event: push }
+steps:
+  build:
+    image: golang
+    commands: [ go build ]
+    resources:
+      mem_limit: 2g
+      cpu_quota: -1
+`
+	conf, err := yaml.ParseString(from)
+	assert.NoError(t, err)
+
+	lint := func(opts ...linter.Option) []string {
+		var messages []string
+		for _, lerr := range errors.GetPipelineErrors(linter.New(opts...).Lint([]*linter.WorkflowConfig{{
+			File:      ".woodpecker.yaml",
+			RawConfig: from,
+			Workflow:  conf,
+		}})) {
+			messages = append(messages, lerr.Message)
+		}
+		return messages
+	}
+
+	assert.Equal(t, []string{"Invalid cpu_quota, it has to be positive"}, lint())
+	assert.Equal(t, []string{"Invalid cpu_quota, it has to be positive"}, lint(linter.WithResourceLimit(0, 4<<30, 0, 0, "")))
+	assert.Equal(t, []string{
+		"The mem_limit of 2147483648 exceeds the limit of 1073741824 set by the server",
+		"Invalid cpu_quota, it has to be positive",
+	}, lint(linter.WithResourceLimit(0, 1<<30, 0, 0, "")))
+}
+
+func TestResourcesCPUSet(t *testing.T) {
+	lint := func(cpuSet string, opts ...linter.Option) []string {
+		from := `
+when: { event: push }
+steps:
+  build:
+    image: golang
+    commands: [ go build ]
+    resources:
+      cpuset: "` + cpuSet + `"
+`
+		conf, err := yaml.ParseString(from)
+		assert.NoError(t, err)
+
+		var messages []string
+		for _, lerr := range errors.GetPipelineErrors(linter.New(opts...).Lint([]*linter.WorkflowConfig{{
+			File:      ".woodpecker.yaml",
+			RawConfig: from,
+			Workflow:  conf,
+		}})) {
+			messages = append(messages, lerr.Message)
+		}
+		return messages
+	}
+
+	assert.Empty(t, lint("0-1"))
+	assert.Empty(t, lint("1", linter.WithResourceLimit(0, 0, 0, 0, "0-3")))
+	assert.Equal(t, []string{"Invalid cpuset '0-x'"}, lint("0-x"))
+	assert.Equal(t, []string{"The cpuset '2-5' is not a subset of the cpuset '0-3' set by the server"},
+		lint("2-5", linter.WithResourceLimit(0, 0, 0, 0, "0-3")))
+}
+
+func TestBackend(t *testing.T) {
+	lint := func(from string) []string {
+		conf, err := yaml.ParseString(from)
+		assert.NoError(t, err)
+
+		var messages []string
+		for _, lerr := range errors.GetPipelineErrors(linter.New(linter.WithTrusted(true)).Lint([]*linter.WorkflowConfig{{
+			File:      ".woodpecker.yaml",
+			RawConfig: from,
+			Workflow:  conf,
+		}})) {
+			messages = append(messages, lerr.Message)
+		}
+		return messages
+	}
+
+	assert.Empty(t, lint(`
+when: { event: push }
+backend: kubernetes
+steps:
+  build:
+    image: golang
+    commands: [ go build ]
+    backend_options:
+      kubernetes:
+        resources:
+          limits:
+            memory: 1Gi
+`))
+	assert.Equal(t, []string{
+		"The kubernetes backend doesn't support tmpfs",
+		"The kubernetes backend doesn't support resources",
+	}, lint(`
+when: { event: push }
+backend: kubernetes
+steps:
+  build:
+    image: golang
+    commands: [ go build ]
+    tmpfs: [ "/tmp:size=64m" ]
+    resources:
+      mem_limit: 1g
+`))
+	assert.Equal(t, []string{"Label backend 'docker' contradicts the required backend 'local'"}, lint(`
+when: { event: push }
+backend: local
+labels:
+  backend: docker
+steps:
+  build:
+    image: golang
+    commands: [ go build ]
+`))
+	assert.Contains(t, lint(`
+when: { event: push }
+backend: podman
+steps:
+  build:
+    image: golang
+    commands: [ go build ]
+`), "Unknown backend 'podman', expected docker, kubernetes or local")
+}
+
+func TestCloneImage(t *testing.T) {
+	lint := func(image string, opts ...linter.Option) []string {
+		from := `
+when: { event: push }
+clone:
+  image: ` + image + `
+steps:
+  build:
+    image: golang
+    commands: [ go build ]
+`
+		conf, err := yaml.ParseString(from)
+		assert.NoError(t, err)
+
+		var messages []string
+		for _, lerr := range errors.GetPipelineErrors(linter.New(opts...).Lint([]*linter.WorkflowConfig{{
+			File:      ".woodpecker.yaml",
+			RawConfig: from,
+			Workflow:  conf,
+		}})) {
+			messages = append(messages, lerr.Message)
+		}
+		return messages
+	}
+
+	assert.Equal(t, []string{"Insufficient privileges to use the clone image 'registry.example.com/plugin-git'"}, lint("registry.example.com/plugin-git"))
+	assert.Empty(t, lint("registry.example.com/plugin-git", linter.WithTrusted(true)))
+	assert.Empty(t, lint("quay.io/woodpeckerci/plugin-git:2.5.0"))
+}
+
+func TestUnreachableSteps(t *testing.T) {
+	lint := func(workflowWhen, stepWhen string, opts ...linter.Option) []*errorTypes.PipelineError {
+		from := `
+when: ` + workflowWhen + `
+steps:
+  deploy:
+    image: alpine
+    commands: [ ./deploy.sh ]
+    when: ` + stepWhen + `
+`
+		conf, err := yaml.ParseString(from)
+		assert.NoError(t, err)
+
+		return errors.GetPipelineErrors(linter.New(append(opts, linter.WithTrusted(true))...).Lint([]*linter.WorkflowConfig{{
+			File:      ".woodpecker.yaml",
+			RawConfig: from,
+			Workflow:  conf,
+		}}))
+	}
+
+	unreachable := []struct{ workflow, step string }{
+		{"{ event: push }", "{ event: tag }"},
+		{"{ event: [push, pull_request] }", "[{ event: tag }, { event: cron }]"},
+		{"{ event: push, branch: main }", "{ event: push, branch: develop }"},
+		{"{ event: push, branch: [main, develop] }", "{ event: push, branch: { exclude: [main, develop] } }"},
+		{"{ event: push, branch: main }", "{ branch: release/* }"},
+	}
+	for _, tc := range unreachable {
+		lerrors := lint(tc.workflow, tc.step)
+		if assert.Len(t, lerrors, 1, tc.step) {
+			assert.Equal(t, "Step 'deploy' can never run, its when conditions don't match the ones of the workflow", lerrors[0].Message)
+			assert.True(t, lerrors[0].IsWarning)
+		}
+	}
+
+	reachable := []struct{ workflow, step string }{
+		{"{ event: push }", "{ event: [push, tag] }"},
+		{"{ event: push, branch: main }", "{ branch: ma* }"},
+		{"[{ event: push, branch: main }, { event: tag }]", "{ event: tag }"},
+		// the branch isn't checked for tags
+		{"{ event: tag }", "{ branch: main }"},
+		{"{ event: push, branch: main }", "{ event: push, branch: { exclude: develop } }"},
+	}
+	for _, tc := range reachable {
+		assert.Empty(t, lint(tc.workflow, tc.step), tc.step)
+	}
+
+	lerrors := lint("{ event: push }", "{ event: tag }", linter.WithUnreachableStepsAsErrors(true))
+	if assert.Len(t, lerrors, 1) {
+		assert.False(t, lerrors[0].IsWarning)
+	}
+	assert.Empty(t, lint("{ event: push }", "{ event: tag }", linter.WithDisabledLintRules(linter.RuleUnreachableStep)))
+}
+
+func TestPinnedImages(t *testing.T) {
+	from := `
+when: { event: push }
+clone:
+  image: quay.io/woodpeckerci/plugin-git
+steps:
+  build:
+    image: golang:1.22
+    commands: [ go build ]
+  lint:
+    image: golangci/golangci-lint:latest
+    commands: [ golangci-lint run ]
+  test:
+    image: golang@sha256:b4c11d7cb8fa1d4f4ae7c8ee7b1df5d5ca9b3e04a3d6c2cf66d0da4d1e7f5b2a
+    commands: [ go test ./... ]
+`
+	conf, err := yaml.ParseString(from)
+	assert.NoError(t, err)
+
+	lint := func(opts ...linter.Option) []string {
+		var messages []string
+		for _, lerr := range errors.GetPipelineErrors(linter.New(opts...).Lint([]*linter.WorkflowConfig{{
+			File:      ".woodpecker.yaml",
+			RawConfig: from,
+			Workflow:  conf,
+		}})) {
+			assert.False(t, lerr.IsWarning)
+			messages = append(messages, lerr.Message)
+		}
+		return messages
+	}
+
+	assert.Empty(t, lint())
+	assert.Equal(t, []string{
+		"Clone image 'quay.io/woodpeckerci/plugin-git' has to be pinned to a version tag or digest",
+		"Image 'golangci/golangci-lint:latest' of step 'lint' has to be pinned to a version tag or digest",
+	}, lint(linter.WithRequirePinnedImages()))
+
+	// only trusted repos can skip the check
+	assert.Len(t, lint(linter.WithRequirePinnedImages(), linter.WithDisabledLintRules(linter.RulePinnedImages)), 2)
+	assert.Empty(t, lint(linter.WithRequirePinnedImages(), linter.WithTrusted(true), linter.WithDisabledLintRules(linter.RulePinnedImages)))
+}
+
+func TestUnknownVariables(t *testing.T) {
+	raw := `
+when: { event: push }
+matrix:
+  GO_VERSION: [ "1.22" ]
+steps:
+  build:
+    image: golang:${GO_VERSION}
+    commands:
+      - go build -tags ${GO_TAGS}
+      - go test -tags ${GO_TAGS} ${TEST_FLAGS:--short}
+      - echo $${HOME}
+`
+	// the parsed config is substituted, the raw config keeps the references
+	conf, err := yaml.ParseString("when: { event: push }\nsteps: { build: { image: golang, commands: [ go build ] } }")
+	assert.NoError(t, err)
+
+	lint := func(opts ...linter.Option) []*errorTypes.PipelineError {
+		return errors.GetPipelineErrors(linter.New(opts...).Lint([]*linter.WorkflowConfig{{
+			File:      ".woodpecker.yaml",
+			RawConfig: raw,
+			Workflow:  conf,
+		}}))
+	}
+
+	// without known variables nothing is checked
+	assert.Empty(t, lint())
+
+	lerrors := lint(linter.WithVariables(map[string]string{"GO_VERSION": "1.22", "CI_COMMIT_BRANCH": "main"}))
+	if assert.Len(t, lerrors, 1) {
+		assert.Equal(t, "Variable 'GO_TAGS' is neither declared by the matrix nor a known variable and resolves to an empty value", lerrors[0].Message)
+		assert.True(t, lerrors[0].IsWarning)
+	}
+
+	assert.Empty(t, lint(
+		linter.WithVariables(map[string]string{"GO_VERSION": "1.22"}),
+		linter.WithDisabledLintRules(linter.RuleUnknownVariable),
+	))
+}
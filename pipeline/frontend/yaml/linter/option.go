@@ -23,3 +23,76 @@ func WithTrusted(trusted bool) Option {
 		linter.trusted = trusted
 	}
 }
+
+// WithImagePlatformData sets the platforms supported by images, e.g. "golang:1.22" -> ["linux/amd64"].
+// Steps using images without data are not checked.
+func WithImagePlatformData(platforms map[string][]string) Option {
+	return func(linter *Linter) {
+		linter.imagePlatforms = platforms
+	}
+}
+
+// WithVariables sets the variables available for substituting the config, like the metadata and
+// the matrix axis of the workflow. References to other variables are reported if set.
+func WithVariables(variables map[string]string) Option {
+	return func(linter *Linter) {
+		linter.variables = variables
+	}
+}
+
+// WithAllowedCapabilities sets the linux capabilities steps of trusted repos are allowed to add.
+func WithAllowedCapabilities(capabilities []string) Option {
+	return func(linter *Linter) {
+		linter.capabilities = make([]string, 0, len(capabilities))
+		for _, capability := range capabilities {
+			linter.capabilities = append(linter.capabilities, NormalizeCapability(capability))
+		}
+	}
+}
+
+// WithMaxTmpfsSize sets the size in bytes the tmpfs mounts of a step of untrusted repos are limited
+// to together. Untrusted repos can't use tmpfs mounts if it isn't set.
+func WithMaxTmpfsSize(size int64) Option {
+	return func(linter *Linter) {
+		linter.maxTmpfsSize = size
+	}
+}
+
+// WithResourceLimit sets the resource limits of the server, the resources of steps can't exceed them.
+// Limits which aren't set don't restrict the steps.
+func WithResourceLimit(swap, mem, cpuQuota, cpuShares int64, cpuSet string) Option {
+	return func(linter *Linter) {
+		linter.resourceLimit = resourceLimit{
+			memSwapLimit: swap,
+			memLimit:     mem,
+			cpuQuota:     cpuQuota,
+			cpuShares:    cpuShares,
+			cpuSet:       cpuSet,
+		}
+	}
+}
+
+// WithUnreachableStepsAsErrors reports steps which can never run as errors instead of warnings.
+func WithUnreachableStepsAsErrors(asErrors bool) Option {
+	return func(linter *Linter) {
+		linter.unreachableStepsAsErrors = asErrors
+	}
+}
+
+// WithRequirePinnedImages rejects step and clone images without a digest or a tag other than latest.
+// Trusted repos can skip the check by disabling RulePinnedImages.
+func WithRequirePinnedImages() Option {
+	return func(linter *Linter) {
+		linter.requirePinnedImages = true
+	}
+}
+
+// WithDisabledLintRules skips the given rules while linting.
+// Security related rules are always checked.
+func WithDisabledLintRules(rules ...Rule) Option {
+	return func(linter *Linter) {
+		for _, rule := range rules {
+			linter.disabledRules[rule] = true
+		}
+	}
+}
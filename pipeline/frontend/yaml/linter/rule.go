@@ -0,0 +1,47 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linter
+
+// Rule is the stable identifier of a lint check.
+type Rule string
+
+const (
+	RuleStepsRequired        Rule = "steps-required"
+	RuleImageRequired        Rule = "image-required"
+	RuleCommandsWithSettings Rule = "commands-with-settings"
+	RuleTrusted              Rule = "trusted"
+	RuleCachePaths           Rule = "cache-paths"
+	RuleArtifactPaths        Rule = "artifact-paths"
+	RuleSchema               Rule = "schema"
+	RuleDeprecations         Rule = "deprecations"
+	RuleEventFilter          Rule = "event-filter"
+	RuleImagePlatform        Rule = "image-platform"
+	RuleUnknownVariable      Rule = "unknown-variable"
+	RuleMatrixValues         Rule = "matrix-values"
+	RuleUnreachableStep      Rule = "unreachable-step"
+	RulePinnedImages         Rule = "pinned-images"
+)
+
+// securityRules can't be disabled as they protect the agents from untrusted pipelines.
+var securityRules = map[Rule]bool{
+	RuleTrusted: true,
+	// paths leaving the workspace could read or overwrite files of the agent
+	RuleCachePaths:    true,
+	RuleArtifactPaths: true,
+}
+
+func (l *Linter) ruleEnabled(rule Rule) bool {
+	return securityRules[rule] || !l.disabledRules[rule]
+}
@@ -29,9 +29,38 @@ import (
 //go:embed schema.json
 var schemaDefinition []byte
 
+// LatestVersion is the schema version used for workflows that don't declare one.
+const LatestVersion = 1
+
+var schemaDefinitions = map[int][]byte{
+	1: schemaDefinition,
+}
+
+// IsSupportedVersion reports whether a workflow can declare the schema version.
+// Zero means no version was declared.
+func IsSupportedVersion(version int) bool {
+	if version == 0 {
+		return true
+	}
+	_, ok := schemaDefinitions[version]
+	return ok
+}
+
 // Lint lints an io.Reader against the Woodpecker `schema.json`.
 func Lint(r io.Reader) ([]json_schema.ResultError, error) {
-	schemaLoader := json_schema.NewBytesLoader(schemaDefinition)
+	return LintVersion(r, LatestVersion)
+}
+
+// LintVersion lints an io.Reader against the Woodpecker schema of the given version.
+func LintVersion(r io.Reader, version int) ([]json_schema.ResultError, error) {
+	if version == 0 {
+		version = LatestVersion
+	}
+	definition, ok := schemaDefinitions[version]
+	if !ok {
+		return nil, fmt.Errorf("unknown schema version %d", version)
+	}
+	schemaLoader := json_schema.NewBytesLoader(definition)
 
 	// read yaml config
 	rBytes, err := io.ReadAll(r)
@@ -67,3 +96,7 @@ func Lint(r io.Reader) ([]json_schema.ResultError, error) {
 func LintString(s string) ([]json_schema.ResultError, error) {
 	return Lint(bytes.NewBufferString(s))
 }
+
+func LintStringVersion(s string, version int) ([]json_schema.ResultError, error) {
+	return LintVersion(bytes.NewBufferString(s), version)
+}
@@ -96,6 +96,10 @@ func TestSchema(t *testing.T) {
 			name:     "Map and Sequence Merge", // https://woodpecker-ci.org/docs/next/usage/advanced-yaml-syntax
 			testFile: ".woodpecker/test-merge-map-and-sequence.yaml",
 		},
+		{
+			name:     "Step template",
+			testFile: ".woodpecker/test-step-template.yaml",
+		},
 		{
 			name:     "Broken Config",
 			testFile: ".woodpecker/test-broken.yaml",
@@ -15,9 +15,14 @@
 package matrix
 
 import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
 
 	"codeberg.org/6543/xyaml"
+	"gopkg.in/yaml.v3"
 
 	errorTypes "go.woodpecker-ci.org/woodpecker/v2/pipeline/errors/types"
 )
@@ -25,8 +30,26 @@ import (
 const (
 	limitTags = 10
 	limitAxis = 25
+	// maxCalculatedAxes is the size up to which a matrix with excluded combinations
+	// is calculated to check it against a limit, larger matrices fail right away.
+	maxCalculatedAxes = 1 << 16
 )
 
+// ErrTooManyAxes is returned if the matrix expands to more axes than the limit.
+type ErrTooManyAxes struct {
+	Size  int
+	Limit int
+}
+
+func (err *ErrTooManyAxes) Error() string {
+	return fmt.Sprintf("matrix expands to %d jobs, limit is %d", err.Size, err.Limit)
+}
+
+func (*ErrTooManyAxes) Is(target error) bool {
+	_, ok := target.(*ErrTooManyAxes)
+	return ok
+}
+
 // Matrix represents the pipeline matrix.
 type Matrix map[string][]string
 
@@ -43,23 +66,161 @@ func (a Axis) String() string {
 	return strings.Join(envs, " ")
 }
 
-// Parse parses the Yaml matrix definition.
+// Label returns a human-readable representation of an Axis with sorted keys,
+// e.g. "GO=1.21, OS=linux".
+func (a Axis) Label() string {
+	keys := make([]string, 0, len(a))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	envs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		envs = append(envs, k+"="+a[k])
+	}
+	return strings.Join(envs, ", ")
+}
+
+// Parse parses the Yaml matrix definition. The combinations of the axes matching
+// an entry of exclude are removed, the entries of include are appended as they are.
 func Parse(data []byte) ([]Axis, error) {
-	axis, err := parseList(data)
-	if err == nil && len(axis) != 0 {
-		return axis, nil
+	return parseAxes(data, 0)
+}
+
+// ParseWithLimit is like Parse, but instead of truncating large matrices it fails
+// with ErrTooManyAxes if the matrix expands to more axes than the limit.
+func ParseWithLimit(data []byte, limit int) ([]Axis, error) {
+	return parseAxes(data, limit)
+}
+
+// ParseFailFast returns if the matrix is marked as fail_fast, so the remaining axes
+// get canceled once one of them fails.
+func ParseFailFast(data []byte) (bool, error) {
+	def, err := parse(data)
+	if err != nil {
+		return false, err
 	}
+	return def.failFast, nil
+}
 
-	matrix, err := parse(data)
+func parseAxes(data []byte, limit int) ([]Axis, error) {
+	def, err := parse(data)
 	if err != nil {
 		return nil, err
 	}
+	matrix, include, exclude := def.axes, def.include, def.exclude
 
 	if len(matrix) == 0 {
+		if limit > 0 && len(include) > limit {
+			return nil, &ErrTooManyAxes{Size: len(include), Limit: limit}
+		}
+		if len(include) != 0 {
+			return include, nil
+		}
 		return []Axis{}, nil
 	}
 
-	return calc(matrix), nil
+	// fail before calculating the combinations if the matrix is too large anyway
+	if limit > 0 {
+		size := combinations(matrix) + len(include)
+		if size > limit && (len(exclude) == 0 || size > maxCalculatedAxes) {
+			return nil, &ErrTooManyAxes{Size: size, Limit: limit}
+		}
+	}
+
+	axes := append(calc(matrix, exclude, limit > 0), include...)
+	if limit > 0 && len(axes) > limit {
+		return nil, &ErrTooManyAxes{Size: len(axes), Limit: limit}
+	}
+	return axes, nil
+}
+
+// combinations returns the number of combinations of the matrix axes.
+func combinations(matrix Matrix) int {
+	size := 1
+	for _, values := range matrix {
+		// don't overflow for absurd matrices
+		if len(values) > 0 && size > math.MaxInt32/len(values) {
+			return math.MaxInt32
+		}
+		size *= len(values)
+	}
+	return size
+}
+
+// CoercedValue is an unquoted matrix value which YAML reads as a number with a different representation.
+type CoercedValue struct {
+	Key    string
+	Value  string
+	Number string
+}
+
+// CoercedValues returns the unquoted values of the matrix which YAML resolves to numbers with a
+// different representation, e.g. 1.20 is the float 1.2. The axes keep the values as they are
+// written, but other YAML tools don't, so such values should be quoted.
+func CoercedValues(data []byte) ([]CoercedValue, error) {
+	raw := struct {
+		Matrix map[string]yaml.Node
+	}{}
+	if err := xyaml.Unmarshal(data, &raw); err != nil {
+		return nil, &errorTypes.PipelineError{Message: err.Error(), Type: errorTypes.PipelineErrorTypeCompiler}
+	}
+
+	var coerced []CoercedValue
+	add := func(key string, node *yaml.Node) {
+		if number, ok := coercedNumber(node); ok {
+			coerced = append(coerced, CoercedValue{Key: key, Value: node.Value, Number: number})
+		}
+	}
+	for key, node := range raw.Matrix {
+		switch key {
+		case "fail_fast":
+		case "include", "exclude":
+			for _, entry := range node.Content {
+				for i := 0; i+1 < len(entry.Content); i += 2 {
+					add(entry.Content[i].Value, entry.Content[i+1])
+				}
+			}
+		default:
+			for _, value := range node.Content {
+				add(key, value)
+			}
+		}
+	}
+
+	sort.Slice(coerced, func(i, j int) bool {
+		if coerced[i].Key != coerced[j].Key {
+			return coerced[i].Key < coerced[j].Key
+		}
+		return coerced[i].Value < coerced[j].Value
+	})
+	return coerced, nil
+}
+
+// coercedNumber returns the number an unquoted scalar resolves to if it differs from how it's written.
+func coercedNumber(node *yaml.Node) (string, bool) {
+	if node.Kind != yaml.ScalarNode || node.Style != 0 {
+		return "", false
+	}
+	var number string
+	switch node.ShortTag() {
+	case "!!float":
+		f, err := strconv.ParseFloat(node.Value, 64)
+		if err != nil {
+			return "", false
+		}
+		number = strconv.FormatFloat(f, 'f', -1, 64)
+	case "!!int":
+		i, err := strconv.ParseInt(node.Value, 0, 64)
+		if err != nil {
+			return "", false
+		}
+		number = strconv.FormatInt(i, 10)
+	default:
+		return "", false
+	}
+	return number, number != node.Value
 }
 
 // ParseString parses the Yaml string matrix definition.
@@ -67,7 +228,9 @@ func ParseString(data string) ([]Axis, error) {
 	return Parse([]byte(data))
 }
 
-func calc(matrix Matrix) []Axis {
+// calc calculates the combinations of the matrix axes, unless all is set the
+// number of combinations and tags are limited.
+func calc(matrix Matrix, exclude []Axis, all bool) []Axis {
 	// calculate number of permutations and extract the list of tags
 	// (ie go_version, redis_version, etc)
 	var perm int
@@ -94,16 +257,20 @@ func calc(matrix Matrix) []Axis {
 			axis[tag] = elems[elem]
 
 			// enforce a maximum number of tags in the pipeline matrix.
-			if i > limitTags {
+			if !all && i > limitTags {
 				break
 			}
 		}
 
+		if isExcluded(axis, exclude) {
+			continue
+		}
+
 		// append to the list of axis.
 		axisList = append(axisList, axis)
 
 		// enforce a maximum number of axis that should be calculated.
-		if p > limitAxis {
+		if !all && len(axisList) > limitAxis+1 {
 			break
 		}
 	}
@@ -111,25 +278,60 @@ func calc(matrix Matrix) []Axis {
 	return axisList
 }
 
-func parse(raw []byte) (Matrix, error) {
-	data := struct {
-		Matrix map[string][]string
-	}{}
-	if err := xyaml.Unmarshal(raw, &data); err != nil {
-		return nil, &errorTypes.PipelineError{Message: err.Error(), Type: errorTypes.PipelineErrorTypeCompiler}
+// isExcluded returns true if all values of an entry of exclude match the axis.
+func isExcluded(axis Axis, exclude []Axis) bool {
+	for _, entry := range exclude {
+		if len(entry) == 0 {
+			continue
+		}
+		matches := true
+		for k, v := range entry {
+			if axis[k] != v {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
 	}
-	return data.Matrix, nil
+	return false
 }
 
-func parseList(raw []byte) ([]Axis, error) {
+// definition is the matrix section of a workflow.
+type definition struct {
+	axes     Matrix
+	include  []Axis
+	exclude  []Axis
+	failFast bool
+}
+
+func parse(raw []byte) (*definition, error) {
 	data := struct {
-		Matrix struct {
-			Include []Axis
-		}
+		Matrix map[string]yaml.Node
 	}{}
-
 	if err := xyaml.Unmarshal(raw, &data); err != nil {
 		return nil, &errorTypes.PipelineError{Message: err.Error(), Type: errorTypes.PipelineErrorTypeCompiler}
 	}
-	return data.Matrix.Include, nil
+
+	def := &definition{axes: Matrix{}}
+	for key, node := range data.Matrix {
+		var err error
+		switch key {
+		case "include":
+			err = node.Decode(&def.include)
+		case "exclude":
+			err = node.Decode(&def.exclude)
+		case "fail_fast":
+			err = node.Decode(&def.failFast)
+		default:
+			var values []string
+			err = node.Decode(&values)
+			def.axes[key] = values
+		}
+		if err != nil {
+			return nil, &errorTypes.PipelineError{Message: err.Error(), Type: errorTypes.PipelineErrorTypeCompiler}
+		}
+	}
+	return def, nil
 }
@@ -15,6 +15,7 @@
 package matrix
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/franela/goblin"
@@ -52,6 +53,100 @@ func TestMatrix(t *testing.T) {
 			g.Assert(axis[0]["python_version"]).Equal("3.4")
 			g.Assert(axis[1]["python_version"]).Equal("3.4")
 		})
+
+		g.It("Should label axis with sorted keys", func() {
+			g.Assert(Axis{"OS": "linux", "GO": "1.21"}.Label()).Equal("GO=1.21, OS=linux")
+			g.Assert(Axis{}.Label()).Equal("")
+		})
+
+		g.It("Should remove excluded axis", func() {
+			axis, err := ParseString(fakeMatrixExclude)
+			g.Assert(err).IsNil()
+			g.Assert(len(axis)).Equal(4)
+			for _, a := range axis {
+				g.Assert(a["GO"] == "1.19" && a["OS"] == "windows").IsFalse()
+			}
+		})
+
+		g.It("Should append included axis to the combinations", func() {
+			axis, err := ParseString(fakeMatrixIncludeExclude)
+			g.Assert(err).IsNil()
+			g.Assert(len(axis)).Equal(4)
+			g.Assert(axis[3]).Equal(Axis{"GO": "1.22", "OS": "darwin", "CGO": "1"})
+		})
+
+		g.It("Should fail if the matrix exceeds the limit", func() {
+			_, err := ParseWithLimit([]byte(fakeMatrix), 20)
+			g.Assert(err.Error()).Equal("matrix expands to 24 jobs, limit is 20")
+			g.Assert(errors.Is(err, &ErrTooManyAxes{})).IsTrue()
+
+			axis, err := ParseWithLimit([]byte(fakeMatrix), 24)
+			g.Assert(err).IsNil()
+			g.Assert(len(axis)).Equal(24)
+
+			// excluded combinations don't count
+			axis, err = ParseWithLimit([]byte(fakeMatrixExclude), 4)
+			g.Assert(err).IsNil()
+			g.Assert(len(axis)).Equal(4)
+			_, err = ParseWithLimit([]byte(fakeMatrixIncludeExclude), 3)
+			g.Assert(err.Error()).Equal("matrix expands to 4 jobs, limit is 3")
+		})
+
+		g.It("Should not truncate matrices below the limit", func() {
+			axis, err := ParseWithLimit([]byte(fakeMatrixLarge), 50)
+			g.Assert(err).IsNil()
+			g.Assert(len(axis)).Equal(36)
+
+			axis, err = ParseString(fakeMatrixLarge)
+			g.Assert(err).IsNil()
+			g.Assert(len(axis)).Equal(27)
+		})
+
+		g.It("Should parse fail_fast", func() {
+			axis, err := ParseString(fakeMatrixFailFast)
+			g.Assert(err).IsNil()
+			g.Assert(len(axis)).Equal(2)
+			failFast, err := ParseFailFast([]byte(fakeMatrixFailFast))
+			g.Assert(err).IsNil()
+			g.Assert(failFast).IsTrue()
+
+			failFast, err = ParseFailFast([]byte(fakeMatrix))
+			g.Assert(err).IsNil()
+			g.Assert(failFast).IsFalse()
+		})
+
+		g.It("Should keep numeric values as they are written", func() {
+			axis, err := ParseString(fakeMatrixNumbers)
+			g.Assert(err).IsNil()
+			g.Assert(axis).Equal([]Axis{
+				{"GO": "1.20"},
+				{"GO": "1.21.0"},
+				{"GO": "1.0"},
+				{"GO": "1.22"},
+			})
+		})
+
+		g.It("Should report values coerced by YAML", func() {
+			coerced, err := CoercedValues([]byte(fakeMatrixNumbers))
+			g.Assert(err).IsNil()
+			g.Assert(coerced).Equal([]CoercedValue{
+				{Key: "GO", Value: "1.0", Number: "1"},
+				{Key: "GO", Value: "1.20", Number: "1.2"},
+			})
+
+			coerced, err = CoercedValues([]byte(fakeMatrixIncludeFlow))
+			g.Assert(err).IsNil()
+			g.Assert(coerced).Equal([]CoercedValue{{Key: "GO", Value: "1.20", Number: "1.2"}})
+		})
+
+		g.It("Should not cross multiply included axis", func() {
+			axis, err := ParseString(fakeMatrixIncludeFlow)
+			g.Assert(err).IsNil()
+			g.Assert(axis).Equal([]Axis{
+				{"GO": "1.21", "OS": "linux"},
+				{"GO": "1.20", "OS": "windows"},
+			})
+		})
 	})
 }
 
@@ -80,3 +175,57 @@ matrix:
     - go_version: 1.6
       python_version: 3.4
 `
+
+var fakeMatrixIncludeFlow = `
+matrix: { include: [{GO: 1.21, OS: linux}, {GO: 1.20, OS: windows}] }
+`
+
+var fakeMatrixExclude = `
+matrix:
+  GO:
+    - 1.19
+    - 1.22
+  OS:
+    - linux
+    - windows
+    - darwin
+  exclude:
+    - GO: 1.19
+      OS: windows
+    - GO: 1.19
+      OS: darwin
+`
+
+var fakeMatrixIncludeExclude = `
+matrix:
+  GO: [ 1.19, 1.22 ]
+  OS: [ linux, windows ]
+  exclude:
+    - GO: 1.19
+      OS: windows
+  include:
+    - GO: 1.22
+      OS: darwin
+      CGO: 1
+`
+
+var fakeMatrixLarge = `
+matrix:
+  A: [ 1, 2, 3, 4, 5, 6 ]
+  B: [ 1, 2, 3, 4, 5, 6 ]
+`
+
+var fakeMatrixFailFast = `
+matrix:
+  fail_fast: true
+  GO: [ 1.21, 1.22 ]
+`
+
+var fakeMatrixNumbers = `
+matrix:
+  GO:
+    - 1.20
+    - 1.21.0
+    - 1.0
+    - "1.22"
+`
@@ -70,3 +70,26 @@ func ParseString(s string) (*types.Workflow, error) {
 		[]byte(s),
 	)
 }
+
+// ParseTemplateString parses a step template from string s.
+func ParseTemplateString(s string) (*types.StepTemplate, error) {
+	out := new(types.StepTemplate)
+	err := xyaml.Unmarshal([]byte(s), out)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ParseEnvironment parses the environment block of the workflow configuration
+// from string s without substituting variables first.
+func ParseEnvironment(s string) (map[string]string, error) {
+	out := new(struct {
+		Environment map[string]string `yaml:"environment"`
+	})
+	err := xyaml.Unmarshal([]byte(s), out)
+	if err != nil {
+		return nil, err
+	}
+	return out.Environment, nil
+}
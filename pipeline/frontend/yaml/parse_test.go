@@ -56,8 +56,8 @@ func TestParse(t *testing.T) {
 				// g.Assert(out.Steps.ContainerList[2].NetworkMode).Equal("container:name")
 				g.Assert(out.Labels["com.example.team"]).Equal("frontend")
 				g.Assert(out.Labels["com.example.type"]).Equal("build")
-				g.Assert(out.DependsOn[0]).Equal("lint")
-				g.Assert(out.DependsOn[1]).Equal("test")
+				g.Assert(out.DependsOn[0].Name).Equal("lint")
+				g.Assert(out.DependsOn[1].Name).Equal("test")
 				g.Assert(out.RunsOn[0]).Equal("success")
 				g.Assert(out.RunsOn[1]).Equal("failure")
 				g.Assert(out.SkipClone).Equal(false)
@@ -0,0 +1,23 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import "go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/types/base"
+
+// ArtifactDependency defines artifact paths produced by another workflow which are restored into the workspace.
+type ArtifactDependency struct {
+	Workflow string             `yaml:"workflow"`
+	Paths    base.StringOrSlice `yaml:"paths"`
+}
@@ -25,6 +25,16 @@ type BoolTrue struct {
 	value bool
 }
 
+// IsZero returns true if the value is the default, so it can be omitted when marshaling.
+func (b BoolTrue) IsZero() bool {
+	return !b.value
+}
+
+// MarshalYAML implements custom Yaml marshaling.
+func (b BoolTrue) MarshalYAML() (any, error) {
+	return b.Bool(), nil
+}
+
 // UnmarshalYAML implements custom Yaml unmarshal.
 func (b *BoolTrue) UnmarshalYAML(value *yaml.Node) error {
 	var s string
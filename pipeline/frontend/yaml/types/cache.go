@@ -0,0 +1,23 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import "go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/types/base"
+
+// Cache defines paths in the workspace which are restored before and saved after a step.
+type Cache struct {
+	Key   string             `yaml:"key"`
+	Paths base.StringOrSlice `yaml:"paths"`
+}
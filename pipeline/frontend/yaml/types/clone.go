@@ -0,0 +1,76 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import "gopkg.in/yaml.v3"
+
+// WorkflowClone defines the clone steps of a workflow. Instead of steps it can
+// configure the Image and Depth of the default clone step or Disable it.
+type WorkflowClone struct {
+	ContainerList []*Container
+	Image         string
+	Depth         int
+	Disable       bool
+}
+
+type cloneOptions struct {
+	Image   string `yaml:"image,omitempty"`
+	Depth   int    `yaml:"depth,omitempty"`
+	Disable bool   `yaml:"disable,omitempty"`
+}
+
+// UnmarshalYAML implements the Unmarshaler interface.
+func (c *WorkflowClone) UnmarshalYAML(value *yaml.Node) error {
+	if isCloneOptions(value) {
+		var options cloneOptions
+		if err := value.Decode(&options); err != nil {
+			return err
+		}
+		c.Image = options.Image
+		c.Depth = options.Depth
+		c.Disable = options.Disable
+		return nil
+	}
+
+	var list ContainerList
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	c.ContainerList = list.ContainerList
+	return nil
+}
+
+// MarshalYAML implements the Marshaller interface.
+func (c WorkflowClone) MarshalYAML() (any, error) {
+	if c.Image != "" || c.Depth != 0 || c.Disable {
+		return cloneOptions{Image: c.Image, Depth: c.Depth, Disable: c.Disable}, nil
+	}
+	return c.ContainerList, nil
+}
+
+// isCloneOptions returns true if the node is a map of clone options. Clone steps
+// are maps too, but their values aren't scalars.
+func isCloneOptions(value *yaml.Node) bool {
+	if value.Kind != yaml.MappingNode || len(value.Content) == 0 {
+		return false
+	}
+	for i := 0; i < len(value.Content); i += 2 {
+		key, val := value.Content[i], value.Content[i+1]
+		if (key.Value != "image" && key.Value != "depth" && key.Value != "disable") || val.Kind != yaml.ScalarNode {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestUnmarshalWorkflowClone(t *testing.T) {
+	var clone WorkflowClone
+	assert.NoError(t, yaml.Unmarshal([]byte("{ depth: 1 }"), &clone))
+	assert.Equal(t, WorkflowClone{Depth: 1}, clone)
+
+	clone = WorkflowClone{}
+	assert.NoError(t, yaml.Unmarshal([]byte("{ disable: true }"), &clone))
+	assert.Equal(t, WorkflowClone{Disable: true}, clone)
+
+	clone = WorkflowClone{}
+	assert.NoError(t, yaml.Unmarshal([]byte("{ image: registry.example.com/plugin-git, depth: 1 }"), &clone))
+	assert.Equal(t, WorkflowClone{Image: "registry.example.com/plugin-git", Depth: 1}, clone)
+
+	// clone steps are still supported as map and list
+	clone = WorkflowClone{}
+	assert.NoError(t, yaml.Unmarshal([]byte("{ depth: { image: woodpeckerci/plugin-git } }"), &clone))
+	if assert.Len(t, clone.ContainerList, 1) {
+		assert.Equal(t, "depth", clone.ContainerList[0].Name)
+		assert.Equal(t, "woodpeckerci/plugin-git", clone.ContainerList[0].Image)
+	}
+	assert.Zero(t, clone.Depth)
+
+	clone = WorkflowClone{}
+	assert.NoError(t, yaml.Unmarshal([]byte("[ { name: hg, image: plugins/hg } ]"), &clone))
+	if assert.Len(t, clone.ContainerList, 1) {
+		assert.Equal(t, "hg", clone.ContainerList[0].Name)
+	}
+
+	clone = WorkflowClone{}
+	assert.Error(t, yaml.Unmarshal([]byte("{ depth: many }"), &clone))
+}
+
+func TestMarshalWorkflowClone(t *testing.T) {
+	out, err := yaml.Marshal(WorkflowClone{Depth: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "depth: 1\n", string(out))
+
+	out, err = yaml.Marshal(WorkflowClone{ContainerList: []*Container{{Name: "git", Image: "woodpeckerci/plugin-git"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, "- image: woodpeckerci/plugin-git\n  name: git\n", string(out))
+}
@@ -34,20 +34,31 @@ type (
 	// Container defines a container.
 	Container struct {
 		BackendOptions map[string]any     `yaml:"backend_options,omitempty"`
+		Cache          *Cache             `yaml:"cache,omitempty"`
 		Commands       base.StringOrSlice `yaml:"commands,omitempty"`
 		Entrypoint     base.StringOrSlice `yaml:"entrypoint,omitempty"`
 		Detached       bool               `yaml:"detach,omitempty"`
 		Directory      string             `yaml:"directory,omitempty"`
 		Failure        string             `yaml:"failure,omitempty"`
+		FailureMessage string             `yaml:"failure_message,omitempty"`
 		Group          string             `yaml:"group,omitempty"`
 		Image          string             `yaml:"image,omitempty"`
+		Init           []*Container       `yaml:"init,omitempty"`
+		Labels         map[string]string  `yaml:"labels,omitempty"`
+		MatrixPosition string             `yaml:"matrix_position,omitempty"`
 		Name           string             `yaml:"name,omitempty"`
-		Pull           bool               `yaml:"pull,omitempty"`
-		Settings       map[string]any     `yaml:"settings"`
+		Outputs        base.StringOrSlice `yaml:"outputs,omitempty"`
+		Pull           *bool              `yaml:"pull,omitempty"`
+		Settings       map[string]any     `yaml:"settings,omitempty"`
+		StopSignal     string             `yaml:"stop_signal,omitempty"`
+		StopGrace      string             `yaml:"stop_grace_period,omitempty"`
+		User           string             `yaml:"user,omitempty"`
 		Volumes        Volumes            `yaml:"volumes,omitempty"`
 		When           constraint.When    `yaml:"when,omitempty"`
 		Ports          []string           `yaml:"ports,omitempty"`
 		DependsOn      base.StringOrSlice `yaml:"depends_on,omitempty"`
+		Use            string             `yaml:"use,omitempty"`
+		With           map[string]string  `yaml:"with,omitempty"`
 
 		// TODO: make []string in 3.x
 		Secrets Secrets `yaml:"secrets,omitempty"`
@@ -56,6 +67,12 @@ type (
 
 		// Docker and Kubernetes Specific
 		Privileged bool `yaml:"privileged,omitempty"`
+		ReadOnly   bool `yaml:"read_only,omitempty"`
+
+		// Docker Specific
+		CapAdd    base.StringOrSlice `yaml:"cap_add,omitempty"`
+		CapDrop   base.StringOrSlice `yaml:"cap_drop,omitempty"`
+		Resources *Resources         `yaml:"resources,omitempty"`
 
 		// Undocumented
 		CPUQuota     base.StringOrInt    `yaml:"cpu_quota,omitempty"`
@@ -74,6 +91,11 @@ type (
 	}
 )
 
+// MarshalYAML implements the Marshaller interface.
+func (c ContainerList) MarshalYAML() (any, error) {
+	return c.ContainerList, nil
+}
+
 // UnmarshalYAML implements the Unmarshaler interface.
 func (c *ContainerList) UnmarshalYAML(value *yaml.Node) error {
 	switch value.Kind {
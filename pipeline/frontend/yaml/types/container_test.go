@@ -77,6 +77,7 @@ ports:
 `)
 
 func TestUnmarshalContainer(t *testing.T) {
+	pull := true
 	want := Container{
 		Commands:     base.StringOrSlice{"go build", "go test"},
 		CPUQuota:     base.StringOrInt(11),
@@ -101,7 +102,7 @@ func TestUnmarshalContainer(t *testing.T) {
 			},
 		},
 		NetworkMode: "bridge",
-		Pull:        true,
+		Pull:        &pull,
 		Privileged:  true,
 		ShmSize:     base.MemStringOrInt(1024),
 		Tmpfs:       base.StringOrSlice{"/var/lib/test"},
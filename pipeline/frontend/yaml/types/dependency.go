@@ -0,0 +1,109 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DependencyStatusSuccess = "success"
+	DependencyStatusFailure = "failure"
+	DependencyStatusAlways  = "always"
+
+	// optionalDependencyMarker prefixes the name of an optional dependency in the short form, like "?build".
+	optionalDependencyMarker = "?"
+)
+
+type (
+	// WorkflowDependencies defines the workflows a workflow depends on.
+	WorkflowDependencies []*WorkflowDependency
+
+	// WorkflowDependency defines a workflow another workflow depends on. Status is
+	// the result of the dependency the workflow runs on, it defaults to success.
+	// If an optional dependency doesn't run, the workflow runs without it.
+	WorkflowDependency struct {
+		Name     string `yaml:"name"`
+		Status   string `yaml:"status,omitempty"`
+		Optional bool   `yaml:"optional,omitempty"`
+	}
+)
+
+// Names returns the names of the workflows.
+func (d WorkflowDependencies) Names() []string {
+	if d == nil {
+		return nil
+	}
+	names := make([]string, 0, len(d))
+	for _, dep := range d {
+		names = append(names, dep.Name)
+	}
+	return names
+}
+
+// OptionalNames returns the names of the optional dependencies.
+func (d WorkflowDependencies) OptionalNames() []string {
+	var names []string
+	for _, dep := range d {
+		if dep.Optional {
+			names = append(names, dep.Name)
+		}
+	}
+	return names
+}
+
+// Statuses returns the status condition of every dependency, using success if none is set.
+func (d WorkflowDependencies) Statuses() map[string]string {
+	if len(d) == 0 {
+		return nil
+	}
+	statuses := make(map[string]string, len(d))
+	for _, dep := range d {
+		statuses[dep.Name] = dep.StatusOrDefault()
+	}
+	return statuses
+}
+
+// StatusOrDefault returns the status condition of the dependency, success if none is set.
+func (d *WorkflowDependency) StatusOrDefault() string {
+	if d.Status == "" {
+		return DependencyStatusSuccess
+	}
+	return d.Status
+}
+
+// MarshalYAML implements the Marshaller interface.
+func (d WorkflowDependency) MarshalYAML() (any, error) {
+	if d.Status == "" {
+		if d.Optional {
+			return optionalDependencyMarker + d.Name, nil
+		}
+		return d.Name, nil
+	}
+	type dependency WorkflowDependency
+	return dependency(d), nil
+}
+
+// UnmarshalYAML implements the Unmarshaler interface.
+func (d *WorkflowDependency) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		d.Name, d.Optional = strings.CutPrefix(value.Value, optionalDependencyMarker)
+		return nil
+	}
+	type dependency WorkflowDependency
+	return value.Decode((*dependency)(d))
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestUnmarshalWorkflowDependencies(t *testing.T) {
+	var deps WorkflowDependencies
+	err := yaml.Unmarshal([]byte("[ build, { name: test, status: failure }, { name: lint } ]"), &deps)
+	assert.NoError(t, err)
+	assert.Equal(t, WorkflowDependencies{
+		{Name: "build"},
+		{Name: "test", Status: DependencyStatusFailure},
+		{Name: "lint"},
+	}, deps)
+	assert.Equal(t, []string{"build", "test", "lint"}, deps.Names())
+	assert.Equal(t, map[string]string{
+		"build": DependencyStatusSuccess,
+		"test":  DependencyStatusFailure,
+		"lint":  DependencyStatusSuccess,
+	}, deps.Statuses())
+}
+
+func TestUnmarshalOptionalWorkflowDependencies(t *testing.T) {
+	var deps WorkflowDependencies
+	err := yaml.Unmarshal([]byte("[ build, '?docs', { name: lint, optional: true, status: always } ]"), &deps)
+	assert.NoError(t, err)
+	assert.Equal(t, WorkflowDependencies{
+		{Name: "build"},
+		{Name: "docs", Optional: true},
+		{Name: "lint", Status: DependencyStatusAlways, Optional: true},
+	}, deps)
+	assert.Equal(t, []string{"build", "docs", "lint"}, deps.Names())
+	assert.Equal(t, []string{"docs", "lint"}, deps.OptionalNames())
+
+	out, err := yaml.Marshal(deps)
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), "?docs")
+
+	var reparsed WorkflowDependencies
+	assert.NoError(t, yaml.Unmarshal(out, &reparsed))
+	assert.Equal(t, deps, reparsed)
+}
+
+func TestMarshalWorkflowDependencies(t *testing.T) {
+	deps := WorkflowDependencies{
+		{Name: "build"},
+		{Name: "test", Status: DependencyStatusAlways},
+	}
+	out, err := yaml.Marshal(deps)
+	assert.NoError(t, err)
+	// dependencies without a status stay short
+	assert.Contains(t, string(out), "- build\n")
+
+	var reparsed WorkflowDependencies
+	assert.NoError(t, yaml.Unmarshal(out, &reparsed))
+	assert.Equal(t, deps, reparsed)
+}
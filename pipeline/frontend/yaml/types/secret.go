@@ -29,6 +29,18 @@ type (
 	}
 )
 
+// MarshalYAML implements the Marshaller interface.
+func (s Secrets) MarshalYAML() (any, error) {
+	names := make([]string, 0, len(s.Secrets))
+	for _, secret := range s.Secrets {
+		if secret.Source != secret.Target {
+			return s.Secrets, nil
+		}
+		names = append(names, secret.Source)
+	}
+	return names, nil
+}
+
 // UnmarshalYAML implements the Unmarshaler interface.
 func (s *Secrets) UnmarshalYAML(value *yaml.Node) error {
 	y, _ := yaml.Marshal(value)
@@ -0,0 +1,29 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+type (
+	// StepTemplate defines reusable steps a workflow can inline with `use`.
+	StepTemplate struct {
+		Parameters map[string]*TemplateParameter `yaml:"parameters,omitempty"`
+		Steps      ContainerList                 `yaml:"steps"`
+	}
+
+	// TemplateParameter defines a parameter passed to a step template with `with`.
+	TemplateParameter struct {
+		Required bool   `yaml:"required,omitempty"`
+		Default  string `yaml:"default,omitempty"`
+	}
+)
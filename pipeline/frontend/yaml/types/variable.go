@@ -0,0 +1,70 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import "gopkg.in/yaml.v3"
+
+// VariableDefault is the key of the value used for events without a value of their own.
+const VariableDefault = "default"
+
+type (
+	// WorkflowVariables defines the default environment variables of the steps of a workflow.
+	WorkflowVariables map[string]Variable
+
+	// Variable maps pipeline events to the value of a variable, events without a value
+	// use the default. A plain string sets the default.
+	Variable map[string]string
+)
+
+// Resolve returns the values of the variables for the pipeline event.
+// Variables without a value for the event and without a default are left out.
+func (v WorkflowVariables) Resolve(event string) map[string]string {
+	if len(v) == 0 {
+		return nil
+	}
+	resolved := make(map[string]string, len(v))
+	for name, variable := range v {
+		if value, ok := variable.Value(event); ok {
+			resolved[name] = value
+		}
+	}
+	return resolved
+}
+
+// Value returns the value of the variable for the pipeline event.
+func (v Variable) Value(event string) (string, bool) {
+	if value, ok := v[event]; ok {
+		return value, true
+	}
+	value, ok := v[VariableDefault]
+	return value, ok
+}
+
+// MarshalYAML implements the Marshaller interface.
+func (v Variable) MarshalYAML() (any, error) {
+	if value, ok := v[VariableDefault]; ok && len(v) == 1 {
+		return value, nil
+	}
+	return map[string]string(v), nil
+}
+
+// UnmarshalYAML implements the Unmarshaler interface.
+func (v *Variable) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*v = Variable{VariableDefault: value.Value}
+		return nil
+	}
+	return value.Decode((*map[string]string)(v))
+}
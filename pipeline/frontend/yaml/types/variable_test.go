@@ -0,0 +1,58 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestUnmarshalWorkflowVariables(t *testing.T) {
+	var variables WorkflowVariables
+	err := yaml.Unmarshal([]byte("{ GOFLAGS: -mod=vendor, DEPLOY_ENV: { default: staging, tag: production }, RELEASE: { tag: 1 } }"), &variables)
+	assert.NoError(t, err)
+	assert.Equal(t, WorkflowVariables{
+		"GOFLAGS":    {VariableDefault: "-mod=vendor"},
+		"DEPLOY_ENV": {VariableDefault: "staging", "tag": "production"},
+		"RELEASE":    {"tag": "1"},
+	}, variables)
+
+	assert.Equal(t, map[string]string{
+		"GOFLAGS":    "-mod=vendor",
+		"DEPLOY_ENV": "staging",
+	}, variables.Resolve("push"))
+	assert.Equal(t, map[string]string{
+		"GOFLAGS":    "-mod=vendor",
+		"DEPLOY_ENV": "production",
+		"RELEASE":    "1",
+	}, variables.Resolve("tag"))
+}
+
+func TestMarshalWorkflowVariables(t *testing.T) {
+	variables := WorkflowVariables{
+		"GOFLAGS":    {VariableDefault: "-mod=vendor"},
+		"DEPLOY_ENV": {VariableDefault: "staging", "tag": "production"},
+	}
+	out, err := yaml.Marshal(variables)
+	assert.NoError(t, err)
+	// variables with only a default stay short
+	assert.Contains(t, string(out), "GOFLAGS: -mod=vendor\n")
+
+	var reparsed WorkflowVariables
+	assert.NoError(t, yaml.Unmarshal(out, &reparsed))
+	assert.Equal(t, variables, reparsed)
+}
@@ -16,20 +16,36 @@ package types
 
 import (
 	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/constraint"
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/types/base"
 )
 
 type (
 	// Workflow defines a workflow configuration.
 	Workflow struct {
-		When      constraint.When   `yaml:"when,omitempty"`
-		Workspace Workspace         `yaml:"workspace,omitempty"`
-		Clone     ContainerList     `yaml:"clone,omitempty"`
-		Steps     ContainerList     `yaml:"steps,omitempty"`
-		Services  ContainerList     `yaml:"services,omitempty"`
-		Labels    map[string]string `yaml:"labels,omitempty"`
-		DependsOn []string          `yaml:"depends_on,omitempty"`
-		RunsOn    []string          `yaml:"runs_on,omitempty"`
-		SkipClone bool              `yaml:"skip_clone"`
+		Version          int                   `yaml:"version,omitempty"`
+		When             constraint.When       `yaml:"when,omitempty"`
+		Workspace        Workspace             `yaml:"workspace,omitempty"`
+		Clone            WorkflowClone         `yaml:"clone,omitempty"`
+		Steps            ContainerList         `yaml:"steps,omitempty"`
+		Services         ContainerList         `yaml:"services,omitempty"`
+		Labels           map[string]string     `yaml:"labels,omitempty"`
+		DependsOn        WorkflowDependencies  `yaml:"depends_on,omitempty"`
+		RunsOn           []string              `yaml:"runs_on,omitempty"`
+		SkipClone        bool                  `yaml:"skip_clone"`
+		FailFast         bool                  `yaml:"fail_fast,omitempty"`
+		Artifacts        base.StringOrSlice    `yaml:"artifacts,omitempty"`
+		Consumes         []*ArtifactDependency `yaml:"consumes,omitempty"`
+		Priority         int                   `yaml:"priority,omitempty"`
+		Pull             bool                  `yaml:"pull,omitempty"`
+		Concurrency      *Concurrency          `yaml:"concurrency,omitempty"`
+		Timeout          string                `yaml:"timeout,omitempty"`
+		PipelineTimeout  string                `yaml:"pipeline_timeout,omitempty"`
+		MaxParallelSteps int                   `yaml:"max_parallel_steps,omitempty"`
+		Experimental     bool                  `yaml:"experimental,omitempty"`
+		Notify           string                `yaml:"notify,omitempty"`
+		EnvDefaults      WorkflowVariables     `yaml:"env_defaults,omitempty"`
+		Environment      map[string]string     `yaml:"environment,omitempty"`
+		Backend          string                `yaml:"backend,omitempty"`
 
 		// Undocumented
 		Networks WorkflowNetworks `yaml:"networks,omitempty"`
@@ -43,6 +59,12 @@ type (
 		PipelineDoNotUseIt ContainerList `yaml:"pipeline,omitempty"` // TODO: remove in next major version
 	}
 
+	// Concurrency groups workflows of a repo, a new workflow can cancel the running workflows of its group.
+	Concurrency struct {
+		Group            string `yaml:"group"`
+		CancelInProgress bool   `yaml:"cancel_in_progress,omitempty"`
+	}
+
 	// Workspace defines a pipeline workspace.
 	Workspace struct {
 		Base string
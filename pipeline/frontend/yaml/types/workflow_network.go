@@ -34,6 +34,15 @@ type (
 	}
 )
 
+// MarshalYAML implements the Marshaller interface.
+func (n WorkflowNetworks) MarshalYAML() (any, error) {
+	m := map[string]*WorkflowNetwork{}
+	for _, network := range n.WorkflowNetworks {
+		m[network.Name] = network
+	}
+	return m, nil
+}
+
 // UnmarshalYAML implements the Unmarshaler interface.
 func (n *WorkflowNetworks) UnmarshalYAML(value *yaml.Node) error {
 	networks := map[string]WorkflowNetwork{}
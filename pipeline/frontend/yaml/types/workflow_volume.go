@@ -34,6 +34,15 @@ type (
 	}
 )
 
+// MarshalYAML implements the Marshaller interface.
+func (v WorkflowVolumes) MarshalYAML() (any, error) {
+	m := map[string]*WorkflowVolume{}
+	for _, volume := range v.WorkflowVolumes {
+		m[volume.Name] = volume
+	}
+	return m, nil
+}
+
 // UnmarshalYAML implements the Unmarshaler interface.
 func (v *WorkflowVolumes) UnmarshalYAML(value *yaml.Node) error {
 	y, _ := yaml.Marshal(value)
@@ -0,0 +1,63 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseCPUSet returns the CPUs of a cpuset like "0-3,5".
+func ParseCPUSet(cpuSet string) (map[int]bool, error) {
+	cpus := make(map[int]bool)
+	for _, part := range strings.Split(cpuSet, ",") {
+		first, last, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		start, err := strconv.Atoi(first)
+		if err != nil || start < 0 {
+			return nil, fmt.Errorf("invalid cpuset '%s'", cpuSet)
+		}
+		end := start
+		if isRange {
+			end, err = strconv.Atoi(last)
+			if err != nil || end < start {
+				return nil, fmt.Errorf("invalid cpuset '%s'", cpuSet)
+			}
+		}
+		for cpu := start; cpu <= end; cpu++ {
+			cpus[cpu] = true
+		}
+	}
+	return cpus, nil
+}
+
+// IsCPUSubset reports whether all CPUs of the cpuset are part of the other cpuset.
+// Invalid cpusets are no subset.
+func IsCPUSubset(cpuSet, of string) bool {
+	cpus, err := ParseCPUSet(cpuSet)
+	if err != nil {
+		return false
+	}
+	available, err := ParseCPUSet(of)
+	if err != nil {
+		return false
+	}
+	for cpu := range cpus {
+		if !available[cpu] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCPUSet(t *testing.T) {
+	cpus, err := ParseCPUSet("0-2,5")
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]bool{0: true, 1: true, 2: true, 5: true}, cpus)
+
+	for _, invalid := range []string{"", "a", "3-1", "1,", "-1"} {
+		_, err := ParseCPUSet(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestIsCPUSubset(t *testing.T) {
+	assert.True(t, IsCPUSubset("1", "0-3"))
+	assert.True(t, IsCPUSubset("0,2-3", "0-3"))
+	assert.False(t, IsCPUSubset("3-4", "0-3"))
+	assert.False(t, IsCPUSubset("x", "0-3"))
+}
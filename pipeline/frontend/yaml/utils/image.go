@@ -14,7 +14,12 @@
 
 package utils
 
-import "github.com/distribution/reference"
+import (
+	"path"
+	"strings"
+
+	"github.com/distribution/reference"
+)
 
 // trimImage returns the short image name without tag.
 func trimImage(name string) string {
@@ -57,6 +62,30 @@ func MatchImage(from string, to ...string) bool {
 	return false
 }
 
+// MatchImageGlob is like MatchImage but the images in the list
+// may contain `*` wildcards matching any characters except `/`,
+// like "registry.example.com/ci/*". Images without a wildcard
+// have to match exactly.
+func MatchImageGlob(from string, to ...string) bool {
+	from = trimImage(from)
+	for _, match := range to {
+		if !strings.Contains(match, "*") {
+			if from == trimImage(match) {
+				return true
+			}
+			continue
+		}
+		// drop the tag, the pattern can't be parsed as image reference
+		if i := strings.LastIndex(match, ":"); i > strings.LastIndex(match, "/") {
+			match = match[:i]
+		}
+		if ok, _ := path.Match(match, from); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // MatchHostname returns true if the image hostname
 // matches the specified hostname.
 func MatchHostname(image, hostname string) bool {
@@ -73,3 +102,17 @@ func MatchHostname(image, hostname string) bool {
 	}
 	return reference.Domain(named) == hostname
 }
+
+// IsImagePinned returns true if the image references a digest or a tag
+// other than latest. Invalid image names are not reported as unpinned.
+func IsImagePinned(image string) bool {
+	ref, err := reference.ParseAnyReference(image)
+	if err != nil {
+		return true
+	}
+	if _, ok := ref.(reference.Digested); ok {
+		return true
+	}
+	tagged, ok := ref.(reference.Tagged)
+	return ok && tagged.Tag() != "latest"
+}
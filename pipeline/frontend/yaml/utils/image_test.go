@@ -205,6 +205,62 @@ func Test_matchImage(t *testing.T) {
 	}
 }
 
+func Test_matchImageGlob(t *testing.T) {
+	testdata := []struct {
+		from, to string
+		want     bool
+	}{
+		{
+			from: "golang:latest",
+			to:   "golang",
+			want: true,
+		},
+		{
+			from: "registry.example.com/ci/golang:1.22",
+			to:   "registry.example.com/ci/*",
+			want: true,
+		},
+		{
+			from: "registry.example.com/ci/golang",
+			to:   "registry.example.com/ci/*:latest",
+			want: true,
+		},
+		{
+			from: "localhost:5000/ci/golang",
+			to:   "localhost:5000/ci/*",
+			want: true,
+		},
+		{
+			from: "plugins/s3",
+			to:   "plugins/*",
+			want: true,
+		},
+		{
+			from: "registry.example.com/ci/go-lint",
+			to:   "registry.example.com/ci/go-*",
+			want: true,
+		},
+		{
+			from: "registry.example.com/ci/tools/golang",
+			to:   "registry.example.com/ci/*",
+			want: false,
+		},
+		{
+			from: "registry.example.com/other/golang",
+			to:   "registry.example.com/ci/*",
+			want: false,
+		},
+		{
+			from: "golang",
+			to:   "gcr.io/*",
+			want: false,
+		},
+	}
+	for _, test := range testdata {
+		assert.Equal(t, test.want, MatchImageGlob(test.from, test.to), test.to)
+	}
+}
+
 func Test_matchHostname(t *testing.T) {
 	testdata := []struct {
 		image, hostname string
@@ -260,3 +316,21 @@ func Test_matchHostname(t *testing.T) {
 		assert.Equal(t, test.want, MatchHostname(test.image, test.hostname))
 	}
 }
+
+func Test_isImagePinned(t *testing.T) {
+	testdata := []struct {
+		image string
+		want  bool
+	}{
+		{image: "golang:1.22", want: true},
+		{image: "golang", want: false},
+		{image: "golang:latest", want: false},
+		{image: "localhost:5000/golang", want: false},
+		{image: "localhost:5000/golang:1.22", want: true},
+		{image: "golang@sha256:b4c11d7cb8fa1d4f4ae7c8ee7b1df5d5ca9b3e04a3d6c2cf66d0da4d1e7f5b2a", want: true},
+		{image: "golang:latest@sha256:b4c11d7cb8fa1d4f4ae7c8ee7b1df5d5ca9b3e04a3d6c2cf66d0da4d1e7f5b2a", want: true},
+	}
+	for _, test := range testdata {
+		assert.Equal(t, test.want, IsImagePinned(test.image), test.image)
+	}
+}
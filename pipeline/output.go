@@ -0,0 +1,133 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/expr-lang/expr"
+
+	backend "go.woodpecker-ci.org/woodpecker/v2/pipeline/backend/types"
+)
+
+// outputPrefix starts log lines setting an output of the step, like "::set-output name=changed::true".
+const outputPrefix = "::set-output name="
+
+// outputs are the outputs set by the steps of the workflow.
+type outputs struct {
+	sync.Mutex
+	values map[string]map[string]string
+}
+
+// declare makes the outputs of the step known, so conditions on them are false until they are set.
+func (o *outputs) declare(step *backend.Step) {
+	o.Lock()
+	defer o.Unlock()
+	if o.values == nil {
+		o.values = map[string]map[string]string{}
+	}
+	if o.values[step.Name] == nil {
+		o.values[step.Name] = map[string]string{}
+	}
+}
+
+func (o *outputs) set(step *backend.Step, key, value string) {
+	o.declare(step)
+	o.Lock()
+	defer o.Unlock()
+	o.values[step.Name][key] = value
+}
+
+// env returns the outputs for expressions like "steps.detect.outputs.changed == 'true'".
+func (o *outputs) env() map[string]any {
+	o.Lock()
+	defer o.Unlock()
+	steps := map[string]any{}
+	for step, values := range o.values {
+		stepOutputs := map[string]any{}
+		for key, value := range values {
+			stepOutputs[key] = value
+		}
+		steps[step] = map[string]any{"outputs": stepOutputs}
+	}
+	return map[string]any{"steps": steps}
+}
+
+// evaluate returns whether the condition of the step on the outputs of other steps is true.
+func (o *outputs) evaluate(condition string) (bool, error) {
+	env := o.env()
+	program, err := expr.Compile(condition, expr.Env(env), expr.AllowUndefinedVariables(), expr.AsBool())
+	if err != nil {
+		return false, fmt.Errorf("invalid condition '%s': %w", condition, err)
+	}
+	result, err := expr.Run(program, env)
+	if err != nil {
+		return false, fmt.Errorf("could not evaluate condition '%s': %w", condition, err)
+	}
+	return result.(bool), nil
+}
+
+// capture returns a reader passing the log of the step through, while storing the outputs it declares.
+func (o *outputs) capture(step *backend.Step, rc io.ReadCloser) io.ReadCloser {
+	return &outputReader{
+		ReadCloser: rc,
+		reader:     io.TeeReader(rc, &outputWriter{step: step, outputs: o}),
+	}
+}
+
+type outputReader struct {
+	io.ReadCloser
+	reader io.Reader
+}
+
+func (r *outputReader) Read(p []byte) (int, error) {
+	return r.reader.Read(p)
+}
+
+// outputWriter scans the lines written to it for outputs of the step.
+type outputWriter struct {
+	step    *backend.Step
+	outputs *outputs
+	line    []byte
+}
+
+func (w *outputWriter) Write(p []byte) (int, error) {
+	w.line = append(w.line, p...)
+	for {
+		i := bytes.IndexByte(w.line, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		w.parse(strings.TrimRight(string(w.line[:i]), "\r"))
+		w.line = w.line[i+1:]
+	}
+}
+
+func (w *outputWriter) parse(line string) {
+	rest, ok := strings.CutPrefix(line, outputPrefix)
+	if !ok {
+		return
+	}
+	key, value, ok := strings.Cut(rest, "::")
+	if !ok || !slices.Contains(w.step.Outputs, key) {
+		return
+	}
+	w.outputs.set(w.step, key, value)
+}
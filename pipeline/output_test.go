@@ -0,0 +1,54 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	backend "go.woodpecker-ci.org/woodpecker/v2/pipeline/backend/types"
+)
+
+func TestOutputs(t *testing.T) {
+	o := &outputs{}
+	step := &backend.Step{Name: "detect", Outputs: []string{"changed", "version"}}
+	lint := &backend.Step{Name: "lint", Outputs: []string{"changed"}}
+	o.declare(step)
+	o.declare(lint)
+
+	log := "checking files\n::set-output name=changed::true\r\n::set-output name=other::x\n::set-output name=version::1.2"
+	rc := o.capture(step, io.NopCloser(strings.NewReader(log)))
+	out, err := io.ReadAll(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, log, string(out))
+	assert.NoError(t, rc.Close())
+
+	// only complete lines of declared outputs are taken
+	assert.Equal(t, map[string]map[string]string{"detect": {"changed": "true"}, "lint": {}}, o.values)
+
+	run, err := o.evaluate("steps.detect.outputs.changed == 'true'")
+	assert.NoError(t, err)
+	assert.True(t, run)
+
+	run, err = o.evaluate("steps.detect.outputs.version != nil || steps.lint.outputs.changed == 'true'")
+	assert.NoError(t, err)
+	assert.False(t, run)
+
+	_, err = o.evaluate("steps.detect.outputs.changed ==")
+	assert.Error(t, err)
+}
@@ -17,6 +17,7 @@ package pipeline
 import (
 	"context"
 	"errors"
+	"io"
 	"strings"
 	"sync"
 	"time"
@@ -63,6 +64,8 @@ type Runtime struct {
 
 	taskUUID string
 
+	outputs outputs
+
 	Description map[string]string // The runtime descriptors.
 }
 
@@ -96,6 +99,9 @@ func (r *Runtime) Run(runnerCtx context.Context) error {
 		stepNames := []string{}
 		for _, step := range stage.Steps {
 			stepNames = append(stepNames, step.Name)
+			if len(step.Outputs) != 0 {
+				r.outputs.declare(step)
+			}
 		}
 
 		logger.Debug().
@@ -189,6 +195,19 @@ func (r *Runtime) execAll(steps []*backend.Step) <-chan error {
 				return nil
 			}
 
+			if step.Condition != "" {
+				run, err := r.outputs.evaluate(step.Condition)
+				if err != nil {
+					return r.traceStep(nil, err, step)
+				}
+				if !run {
+					logger.Debug().
+						Str("step", step.Name).
+						Msgf("skipped due to condition %s", step.Condition)
+					return nil
+				}
+			}
+
 			// Trace started.
 			err := r.traceStep(nil, nil, step)
 			if err != nil {
@@ -231,18 +250,24 @@ func (r *Runtime) exec(step *backend.Step) (*backend.State, error) {
 	}
 
 	var wg sync.WaitGroup
-	if r.logger != nil {
+	if r.logger != nil || len(step.Outputs) != 0 {
 		rc, err := r.engine.TailStep(r.ctx, step, r.taskUUID)
 		if err != nil {
 			return nil, err
 		}
+		if len(step.Outputs) != 0 {
+			rc = r.outputs.capture(step, rc)
+		}
 
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			logger := r.MakeLogger()
 
-			if err := r.logger(step, rc); err != nil {
+			if r.logger == nil {
+				// only the outputs are needed
+				_, _ = io.Copy(io.Discard, rc)
+			} else if err := r.logger(step, rc); err != nil {
 				logger.Error().Err(err).Msg("process logging failed")
 			}
 			_ = rc.Close()
@@ -85,10 +85,12 @@ func PostGlobalSecret(c *gin.Context) {
 		return
 	}
 	secret := &model.Secret{
-		Name:   in.Name,
-		Value:  in.Value,
-		Events: in.Events,
-		Images: in.Images,
+		Name:     in.Name,
+		Value:    in.Value,
+		Events:   in.Events,
+		Images:   in.Images,
+		Required: in.Required,
+		File:     in.File,
 	}
 	if err := secret.Validate(); err != nil {
 		c.String(http.StatusBadRequest, "Error inserting global secret. %s", err)
@@ -110,13 +112,13 @@ func PostGlobalSecret(c *gin.Context) {
 //	@Produce	json
 //	@Success	200	{object}	Secret
 //	@Tags		Secrets
-//	@Param		Authorization	header	string	true	"Insert your personal access token"	default(Bearer <personal access token>)
-//	@Param		secret			path	string	true	"the secret's name"
-//	@Param		secretData		body	Secret	true	"the secret's data"
+//	@Param		Authorization	header	string		true	"Insert your personal access token"	default(Bearer <personal access token>)
+//	@Param		secret			path	string		true	"the secret's name"
+//	@Param		secretData		body	SecretPatch	true	"the secret's data"
 func PatchGlobalSecret(c *gin.Context) {
 	name := c.Param("secret")
 
-	in := new(model.Secret)
+	in := new(model.SecretPatch)
 	err := c.Bind(in)
 	if err != nil {
 		c.String(http.StatusBadRequest, "Error parsing secret. %s", err)
@@ -138,6 +140,12 @@ func PatchGlobalSecret(c *gin.Context) {
 	if in.Images != nil {
 		secret.Images = in.Images
 	}
+	if in.Required != nil {
+		secret.Required = *in.Required
+	}
+	if in.File != nil {
+		secret.File = *in.File
+	}
 
 	if err := secret.Validate(); err != nil {
 		c.String(http.StatusBadRequest, "Error updating global secret. %s", err)
@@ -145,7 +153,7 @@ func PatchGlobalSecret(c *gin.Context) {
 	}
 
 	if err := secretService.GlobalSecretUpdate(secret); err != nil {
-		c.String(http.StatusInternalServerError, "Error updating global secret %q. %s", in.Name, err)
+		c.String(http.StatusInternalServerError, "Error updating global secret %q. %s", name, err)
 		return
 	}
 	c.JSON(http.StatusOK, secret.Copy())
@@ -17,8 +17,10 @@
 package api
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -30,6 +32,7 @@ import (
 	"go.woodpecker-ci.org/woodpecker/v2/server/forge/types"
 	"go.woodpecker-ci.org/woodpecker/v2/server/model"
 	"go.woodpecker-ci.org/woodpecker/v2/server/pipeline"
+	"go.woodpecker-ci.org/woodpecker/v2/server/pipeline/stepbuilder"
 	"go.woodpecker-ci.org/woodpecker/v2/server/store"
 	"go.woodpecker-ci.org/woodpecker/v2/shared/token"
 )
@@ -115,6 +118,16 @@ func PostHook(c *gin.Context) {
 	// 1. Parse webhook
 	//
 
+	// keep the payload for its hash, the forge reads the body again
+	payload, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		msg := "failure to read hook"
+		log.Debug().Err(err).Msg(msg)
+		c.String(http.StatusBadRequest, msg)
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(payload))
+
 	tmpRepo, tmpPipeline, err := _forge.Hook(c, c.Request)
 	if err != nil {
 		if errors.Is(err, &types.ErrIgnoreEvent{}) {
@@ -142,6 +155,7 @@ func PostHook(c *gin.Context) {
 		c.String(http.StatusBadRequest, msg)
 		return
 	}
+	tmpPipeline.EventHash = stepbuilder.EventHash(payload)
 
 	//
 	// 2. Get related repo from store and take repo renaming into account
@@ -108,11 +108,13 @@ func PostOrgSecret(c *gin.Context) {
 		return
 	}
 	secret := &model.Secret{
-		OrgID:  orgID,
-		Name:   in.Name,
-		Value:  in.Value,
-		Events: in.Events,
-		Images: in.Images,
+		OrgID:    orgID,
+		Name:     in.Name,
+		Value:    in.Value,
+		Events:   in.Events,
+		Images:   in.Images,
+		Required: in.Required,
+		File:     in.File,
 	}
 	if err := secret.Validate(); err != nil {
 		c.String(http.StatusUnprocessableEntity, "Error inserting org %q secret. %s", orgID, err)
@@ -134,10 +136,10 @@ func PostOrgSecret(c *gin.Context) {
 //	@Produce	json
 //	@Success	200	{object}	Secret
 //	@Tags		Organization secrets
-//	@Param		Authorization	header	string	true	"Insert your personal access token"	default(Bearer <personal access token>)
-//	@Param		org_id			path	string	true	"the org's id"
-//	@Param		secret			path	string	true	"the secret's name"
-//	@Param		secretData		body	Secret	true	"the update secret data"
+//	@Param		Authorization	header	string		true	"Insert your personal access token"	default(Bearer <personal access token>)
+//	@Param		org_id			path	string		true	"the org's id"
+//	@Param		secret			path	string		true	"the secret's name"
+//	@Param		secretData		body	SecretPatch	true	"the update secret data"
 func PatchOrgSecret(c *gin.Context) {
 	name := c.Param("secret")
 	orgID, err := strconv.ParseInt(c.Param("org_id"), 10, 64)
@@ -146,7 +148,7 @@ func PatchOrgSecret(c *gin.Context) {
 		return
 	}
 
-	in := new(model.Secret)
+	in := new(model.SecretPatch)
 	err = c.Bind(in)
 	if err != nil {
 		c.String(http.StatusBadRequest, "Error parsing secret. %s", err)
@@ -168,6 +170,12 @@ func PatchOrgSecret(c *gin.Context) {
 	if in.Images != nil {
 		secret.Images = in.Images
 	}
+	if in.Required != nil {
+		secret.Required = *in.Required
+	}
+	if in.File != nil {
+		secret.File = *in.File
+	}
 
 	if err := secret.Validate(); err != nil {
 		c.String(http.StatusUnprocessableEntity, "Error updating org %q secret. %s", orgID, err)
@@ -175,7 +183,7 @@ func PatchOrgSecret(c *gin.Context) {
 	}
 
 	if err := secretService.OrgSecretUpdate(orgID, secret); err != nil {
-		c.String(http.StatusInternalServerError, "Error updating org %q secret %q. %s", orgID, in.Name, err)
+		c.String(http.StatusInternalServerError, "Error updating org %q secret %q. %s", orgID, name, err)
 		return
 	}
 	c.JSON(http.StatusOK, secret.Copy())
@@ -88,6 +88,7 @@ func createTmpPipeline(event model.WebhookEvent, commit *model.Commit, user *mod
 
 		Ref:                 opts.Branch,
 		AdditionalVariables: opts.Variables,
+		TraceID:             opts.TraceID,
 
 		Author: user.Login,
 		Email:  user.Email,
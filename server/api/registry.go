@@ -34,12 +34,13 @@ import (
 //	@Param		Authorization	header	string	true	"Insert your personal access token"	default(Bearer <personal access token>)
 //	@Param		repo_id			path	int		true	"the repository id"
 //	@Param		registry		path	string	true	"the registry name"
+//	@Param		environments	query	[]string	false	"the environments the registry is limited to"	collectionFormat(multi)
 func GetRegistry(c *gin.Context) {
 	repo := session.Repo(c)
 	name := c.Param("registry")
 
 	registryService := server.Config.Services.Manager.RegistryServiceFromRepo(repo)
-	registry, err := registryService.RegistryFind(repo, name)
+	registry, err := registryService.RegistryFind(repo, name, c.QueryArray("environments"))
 	if err != nil {
 		handleDBError(c, err)
 		return
@@ -66,10 +67,12 @@ func PostRegistry(c *gin.Context) {
 		return
 	}
 	registry := &model.Registry{
-		RepoID:   repo.ID,
-		Address:  in.Address,
-		Username: in.Username,
-		Password: in.Password,
+		RepoID:       repo.ID,
+		Address:      in.Address,
+		Username:     in.Username,
+		Password:     in.Password,
+		Environments: in.Environments,
+		TokenAuth:    in.TokenAuth,
 	}
 	if err := registry.Validate(); err != nil {
 		c.String(http.StatusBadRequest, "Error inserting registry. %s", err)
@@ -94,14 +97,15 @@ func PostRegistry(c *gin.Context) {
 //	@Param		Authorization	header	string		true	"Insert your personal access token"	default(Bearer <personal access token>)
 //	@Param		repo_id			path	int			true	"the repository id"
 //	@Param		registry		path	string		true	"the registry name"
-//	@Param		registryData	body	Registry	true	"the attributes for the registry"
+//	@Param		environments	query	[]string	false	"the environments the registry is limited to"	collectionFormat(multi)
+//	@Param		registryData	body	RegistryPatch	true	"the attributes for the registry"
 func PatchRegistry(c *gin.Context) {
 	var (
 		repo = session.Repo(c)
 		name = c.Param("registry")
 	)
 
-	in := new(model.Registry)
+	in := new(model.RegistryPatch)
 	err := c.Bind(in)
 	if err != nil {
 		c.String(http.StatusBadRequest, "Error parsing request. %s", err)
@@ -109,7 +113,7 @@ func PatchRegistry(c *gin.Context) {
 	}
 
 	registryService := server.Config.Services.Manager.RegistryServiceFromRepo(repo)
-	registry, err := registryService.RegistryFind(repo, name)
+	registry, err := registryService.RegistryFind(repo, name, c.QueryArray("environments"))
 	if err != nil {
 		handleDBError(c, err)
 		return
@@ -120,16 +124,22 @@ func PatchRegistry(c *gin.Context) {
 	if in.Password != "" {
 		registry.Password = in.Password
 	}
+	if in.Environments != nil {
+		registry.Environments = in.Environments
+	}
+	if in.TokenAuth != nil {
+		registry.TokenAuth = *in.TokenAuth
+	}
 
 	if err := registry.Validate(); err != nil {
 		c.String(http.StatusUnprocessableEntity, "Error updating registry. %s", err)
 		return
 	}
 	if err := registryService.RegistryUpdate(repo, registry); err != nil {
-		c.String(http.StatusInternalServerError, "Error updating registry %q. %s", in.Address, err)
+		c.String(http.StatusInternalServerError, "Error updating registry %q. %s", registry.Address, err)
 		return
 	}
-	c.JSON(http.StatusOK, in.Copy())
+	c.JSON(http.StatusOK, registry.Copy())
 }
 
 // GetRegistryList
@@ -169,12 +179,13 @@ func GetRegistryList(c *gin.Context) {
 //	@Param		Authorization	header	string	true	"Insert your personal access token"	default(Bearer <personal access token>)
 //	@Param		repo_id			path	int		true	"the repository id"
 //	@Param		registry		path	string	true	"the registry name"
+//	@Param		environments	query	[]string	false	"the environments the registry is limited to"	collectionFormat(multi)
 func DeleteRegistry(c *gin.Context) {
 	repo := session.Repo(c)
 	name := c.Param("registry")
 
 	registryService := server.Config.Services.Manager.RegistryServiceFromRepo(repo)
-	err := registryService.RegistryDelete(repo, name)
+	err := registryService.RegistryDelete(repo, name, c.QueryArray("environments"))
 	if err != nil {
 		handleDBError(c, err)
 		return
@@ -0,0 +1,77 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"go.woodpecker-ci.org/woodpecker/v2/server"
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+	mocks_services "go.woodpecker-ci.org/woodpecker/v2/server/services/mocks"
+	"go.woodpecker-ci.org/woodpecker/v2/server/services/registry"
+	"go.woodpecker-ci.org/woodpecker/v2/server/store/mocks"
+)
+
+func TestPatchRegistryTokenAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	_manager := server.Config.Services.Manager
+	t.Cleanup(func() { server.Config.Services.Manager = _manager })
+
+	patch := func(body string) (*httptest.ResponseRecorder, *model.Registry) {
+		repo := &model.Repo{ID: 1}
+		stored := &model.Registry{Address: "ecr.example.com", Username: "AWS", Password: "token", TokenAuth: true}
+
+		mockStore := mocks.NewStore(t)
+		mockStore.On("RegistryFind", repo, "ecr.example.com", mock.Anything).Return(stored, nil)
+		mockStore.On("RegistryUpdate", stored).Return(nil)
+		manager := mocks_services.NewManager(t)
+		manager.On("RegistryServiceFromRepo", repo).Return(registry.NewDB(mockStore))
+		server.Config.Services.Manager = manager
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("repo", repo)
+		c.Params = gin.Params{{Key: "registry", Value: "ecr.example.com"}}
+		c.Request, _ = http.NewRequest(http.MethodPatch, "/", bytes.NewBufferString(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		PatchRegistry(c)
+		return w, stored
+	}
+
+	// the token flag is kept if the patch doesn't set it
+	w, stored := patch(`{"password": "new-token"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, stored.TokenAuth)
+	assert.Equal(t, "new-token", stored.Password)
+
+	var response model.Registry
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.TokenAuth)
+	assert.Equal(t, "ecr.example.com", response.Address)
+
+	w, stored = patch(`{"token_auth": false}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, stored.TokenAuth)
+}
@@ -21,17 +21,20 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/securecookie"
 	"github.com/rs/zerolog/log"
 
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/utils"
 	"go.woodpecker-ci.org/woodpecker/v2/server"
 	"go.woodpecker-ci.org/woodpecker/v2/server/model"
 	"go.woodpecker-ci.org/woodpecker/v2/server/router/middleware/session"
 	"go.woodpecker-ci.org/woodpecker/v2/server/store"
 	"go.woodpecker-ci.org/woodpecker/v2/server/store/types"
+	"go.woodpecker-ci.org/woodpecker/v2/shared/constant"
 	"go.woodpecker-ci.org/woodpecker/v2/shared/token"
 )
 
@@ -229,6 +232,14 @@ func PatchRepo(c *gin.Context) {
 		c.String(http.StatusForbidden, "Insufficient privileges")
 		return
 	}
+	// the default clone step gets the netrc credentials, so only admins can choose other images
+	if in.CloneImage != nil && !user.Admin {
+		if image := strings.TrimSpace(*in.CloneImage); image != "" && !utils.MatchImage(image, constant.TrustedCloneImages...) {
+			log.Trace().Msgf("user '%s' wants to set the untrusted clone image '%s' without being an instance admin", user.Login, image)
+			c.String(http.StatusForbidden, "Only instance admins can set clone images which aren't trusted")
+			return
+		}
+	}
 
 	if in.AllowPull != nil {
 		repo.AllowPull = *in.AllowPull
@@ -254,6 +265,9 @@ func PatchRepo(c *gin.Context) {
 	if in.NetrcOnlyTrusted != nil {
 		repo.NetrcOnlyTrusted = *in.NetrcOnlyTrusted
 	}
+	if in.CloneImage != nil {
+		repo.CloneImage = strings.TrimSpace(*in.CloneImage)
+	}
 	if in.Visibility != nil {
 		switch *in.Visibility {
 		case string(model.VisibilityInternal), string(model.VisibilityPrivate), string(model.VisibilityPublic):
@@ -66,11 +66,13 @@ func PostSecret(c *gin.Context) {
 		return
 	}
 	secret := &model.Secret{
-		RepoID: repo.ID,
-		Name:   in.Name,
-		Value:  in.Value,
-		Events: in.Events,
-		Images: in.Images,
+		RepoID:   repo.ID,
+		Name:     in.Name,
+		Value:    in.Value,
+		Events:   in.Events,
+		Images:   in.Images,
+		Required: in.Required,
+		File:     in.File,
 	}
 	if err := secret.Validate(); err != nil {
 		c.String(http.StatusUnprocessableEntity, "Error inserting secret. %s", err)
@@ -92,17 +94,17 @@ func PostSecret(c *gin.Context) {
 //	@Produce	json
 //	@Success	200	{object}	Secret
 //	@Tags		Repository secrets
-//	@Param		Authorization	header	string	true	"Insert your personal access token"	default(Bearer <personal access token>)
-//	@Param		repo_id			path	int		true	"the repository id"
-//	@Param		secretName		path	string	true	"the secret name"
-//	@Param		secret			body	Secret	true	"the secret itself"
+//	@Param		Authorization	header	string		true	"Insert your personal access token"	default(Bearer <personal access token>)
+//	@Param		repo_id			path	int			true	"the repository id"
+//	@Param		secretName		path	string		true	"the secret name"
+//	@Param		secret			body	SecretPatch	true	"the secret itself"
 func PatchSecret(c *gin.Context) {
 	var (
 		repo = session.Repo(c)
 		name = c.Param("secret")
 	)
 
-	in := new(model.Secret)
+	in := new(model.SecretPatch)
 	err := c.Bind(in)
 	if err != nil {
 		c.String(http.StatusBadRequest, "Error parsing secret. %s", err)
@@ -124,13 +126,19 @@ func PatchSecret(c *gin.Context) {
 	if in.Images != nil {
 		secret.Images = in.Images
 	}
+	if in.Required != nil {
+		secret.Required = *in.Required
+	}
+	if in.File != nil {
+		secret.File = *in.File
+	}
 
 	if err := secret.Validate(); err != nil {
 		c.String(http.StatusUnprocessableEntity, "Error updating secret. %s", err)
 		return
 	}
 	if err := secretService.SecretUpdate(repo, secret); err != nil {
-		c.String(http.StatusInternalServerError, "Error updating secret %q. %s", in.Name, err)
+		c.String(http.StatusInternalServerError, "Error updating secret %q. %s", name, err)
 		return
 	}
 	c.JSON(http.StatusOK, secret.Copy())
@@ -0,0 +1,70 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"go.woodpecker-ci.org/woodpecker/v2/server"
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+	mocks_services "go.woodpecker-ci.org/woodpecker/v2/server/services/mocks"
+	"go.woodpecker-ci.org/woodpecker/v2/server/services/secret"
+	"go.woodpecker-ci.org/woodpecker/v2/server/store/mocks"
+)
+
+func TestPatchSecretFile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	_manager := server.Config.Services.Manager
+	t.Cleanup(func() { server.Config.Services.Manager = _manager })
+
+	patch := func(body string) (*httptest.ResponseRecorder, *model.Secret) {
+		repo := &model.Repo{ID: 1}
+		stored := &model.Secret{RepoID: 1, Name: "kubeconfig", Value: "old", Events: []model.WebhookEvent{model.EventPush}, File: true}
+
+		mockStore := mocks.NewStore(t)
+		mockStore.On("SecretFind", repo, "kubeconfig").Return(stored, nil)
+		mockStore.On("SecretUpdate", stored).Return(nil)
+		manager := mocks_services.NewManager(t)
+		manager.On("SecretServiceFromRepo", repo).Return(secret.NewDB(mockStore))
+		server.Config.Services.Manager = manager
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("repo", repo)
+		c.Params = gin.Params{{Key: "secret", Value: "kubeconfig"}}
+		c.Request, _ = http.NewRequest(http.MethodPatch, "/", bytes.NewBufferString(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		PatchSecret(c)
+		return w, stored
+	}
+
+	// the file flag is kept if the patch doesn't set it
+	w, stored := patch(`{"value": "new"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, stored.File)
+	assert.Equal(t, "new", stored.Value)
+
+	w, stored = patch(`{"file": false}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, stored.File)
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+	"go.woodpecker-ci.org/woodpecker/v2/server/store/mocks"
+)
+
+func TestPatchRepoCloneImage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patch := func(mockStore *mocks.Store, user *model.User, body string) (*httptest.ResponseRecorder, *model.Repo) {
+		repo := &model.Repo{ID: 1}
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("store", mockStore)
+		c.Set("repo", repo)
+		c.Set("user", user)
+		c.Request, _ = http.NewRequest(http.MethodPatch, "/", bytes.NewBufferString(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		PatchRepo(c)
+		return w, repo
+	}
+
+	g := goblin.Goblin(t)
+	g.Describe("Repo clone image", func() {
+		g.It("should not allow users to set an untrusted image", func() {
+			w, repo := patch(mocks.NewStore(t), &model.User{Login: "user"}, `{"clone_image": "evil/git"}`)
+
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			assert.Empty(t, repo.CloneImage)
+		})
+
+		g.It("should allow users to set a trusted image", func() {
+			mockStore := mocks.NewStore(t)
+			mockStore.On("UpdateRepo", mock.Anything).Return(nil)
+
+			w, repo := patch(mockStore, &model.User{Login: "user"}, `{"clone_image": "quay.io/woodpeckerci/plugin-git"}`)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "quay.io/woodpeckerci/plugin-git", repo.CloneImage)
+		})
+
+		g.It("should allow admins to set any image", func() {
+			mockStore := mocks.NewStore(t)
+			mockStore.On("UpdateRepo", mock.Anything).Return(nil)
+
+			w, repo := patch(mockStore, &model.User{Login: "admin", Admin: true}, `{"clone_image": "company/git"}`)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "company/git", repo.CloneImage)
+		})
+	})
+}
@@ -64,12 +64,23 @@ var Config = struct {
 		AuthenticatePublicRepos             bool
 		DefaultCancelPreviousPipelineEvents []model.WebhookEvent
 		DefaultCloneImage                   string
+		ArtifactImage                       string
 		Limits                              model.ResourceLimit
 		Volumes                             []string
 		Networks                            []string
 		Privileged                          []string
+		PrivilegedEvents                    []model.WebhookEvent
+		PrivilegedEventsDowngrade           bool
+		AllowedCapabilities                 []string
+		MaxTmpfsSize                        int64
+		UntrustedReadOnlyRootfs             bool
+		UntrustedUser                       string
 		DefaultTimeout                      int64
 		MaxTimeout                          int64
+		MaxParallelSteps                    int
+		AutoSkipClone                       bool
+		AutoDisambiguateNames               bool
+		MaxMatrixAxes                       int
 		Proxy                               struct {
 			No    string
 			HTTP  string
@@ -183,6 +183,8 @@ func convertPullHook(from *internal.PullRequestHook) *model.Pipeline {
 		Author:    from.Actor.Login,
 		Sender:    from.Actor.Login,
 		Timestamp: from.PullRequest.Updated.UTC().Unix(),
+		// bitbucket hooks don't list the changed files
+		ChangedFilesUnknown: true,
 	}
 }
 
@@ -198,6 +200,8 @@ func convertPushHook(hook *internal.PushHook, change *internal.Change) *model.Pi
 		Author:    hook.Actor.Login,
 		Sender:    hook.Actor.Login,
 		Timestamp: change.New.Target.Date.UTC().Unix(),
+		// bitbucket hooks don't list the changed files
+		ChangedFilesUnknown: true,
 	}
 	switch change.New.Type {
 	case "tag", "annotated_tag", "bookmark":
@@ -144,6 +144,7 @@ func Test_helper(t *testing.T) {
 			g.Assert(pipeline.Refspec).Equal("change:main")
 			g.Assert(pipeline.Message).Equal(hook.PullRequest.Desc)
 			g.Assert(pipeline.Timestamp).Equal(hook.PullRequest.Updated.Unix())
+			g.Assert(pipeline.ChangedFilesUnknown).IsTrue()
 		})
 
 		g.It("should convert push hook to pipeline", func() {
@@ -170,6 +171,7 @@ func Test_helper(t *testing.T) {
 			g.Assert(pipeline.Ref).Equal("refs/heads/main")
 			g.Assert(pipeline.Message).Equal(change.New.Target.Message)
 			g.Assert(pipeline.Timestamp).Equal(change.New.Target.Date.Unix())
+			g.Assert(pipeline.ChangedFilesUnknown).IsTrue()
 		})
 
 		g.It("should convert tag hook to pipeline", func() {
@@ -67,6 +67,7 @@ func New(opts Opts) (forge.Forge, error) {
 		SkipVerify: opts.SkipVerify,
 		MergeRef:   opts.MergeRef,
 		OnlyPublic: opts.OnlyPublic,
+		rateLimits: &rateLimits{},
 	}
 	if opts.URL != defaultURL {
 		r.url = strings.TrimSuffix(opts.URL, "/")
@@ -85,6 +86,7 @@ type client struct {
 	MergeRef   bool
 	OnlyPublic bool
 	oAuthHost  string
+	rateLimits *rateLimits
 }
 
 // Name returns the string name of this driver.
@@ -441,6 +443,7 @@ func (c *client) newClientToken(ctx context.Context, token string) *github.Clien
 			},
 		}
 	}
+	tc.Transport = &rateLimitTransport{base: tc.Transport, rateLimit: c.rateLimits.get(token)}
 	client := github.NewClient(tc)
 	client.BaseURL, _ = url.Parse(c.API)
 	return client
@@ -0,0 +1,68 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+const headerRateLimitRemaining = "X-RateLimit-Remaining"
+
+// rateLimit keeps the remaining API quota of a token reported by the last response.
+type rateLimit struct {
+	known     atomic.Bool
+	remaining atomic.Int64
+}
+
+// rateLimits keeps the rate limits per token, as GitHub counts the quota per user or app.
+type rateLimits struct {
+	tokens sync.Map
+}
+
+// get returns the rate limit of the token, creating it if it's unknown.
+func (r *rateLimits) get(token string) *rateLimit {
+	limit, _ := r.tokens.LoadOrStore(token, &rateLimit{})
+	return limit.(*rateLimit)
+}
+
+// rateLimitTransport records the remaining API quota of every response.
+type rateLimitTransport struct {
+	base      http.RoundTripper
+	rateLimit *rateLimit
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if remaining, err := strconv.ParseInt(resp.Header.Get(headerRateLimitRemaining), 10, 64); err == nil {
+		t.rateLimit.remaining.Store(remaining)
+		t.rateLimit.known.Store(true)
+	}
+	return resp, nil
+}
+
+// RateLimitRemaining returns the remaining API quota of the token reported by the last response, if known.
+func (c *client) RateLimitRemaining(token string) (int, bool) {
+	limit, ok := c.rateLimits.tokens.Load(token)
+	if !ok || !limit.(*rateLimit).known.Load() {
+		return 0, false
+	}
+	return int(limit.(*rateLimit).remaining.Load()), true
+}
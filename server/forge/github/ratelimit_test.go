@@ -0,0 +1,58 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRateLimitRemaining(t *testing.T) {
+	c := &client{rateLimits: &rateLimits{}}
+
+	respond := func(token, remaining string) {
+		transport := &rateLimitTransport{
+			base: roundTripFunc(func(*http.Request) (*http.Response, error) {
+				header := http.Header{}
+				header.Set(headerRateLimitRemaining, remaining)
+				return &http.Response{Header: header}, nil
+			}),
+			rateLimit: c.rateLimits.get(token),
+		}
+		_, err := transport.RoundTrip(&http.Request{})
+		assert.NoError(t, err)
+	}
+
+	_, known := c.RateLimitRemaining("alice")
+	assert.False(t, known)
+
+	respond("alice", "4200")
+	respond("bob", "10")
+
+	remaining, known := c.RateLimitRemaining("alice")
+	assert.True(t, known)
+	assert.Equal(t, 4200, remaining)
+	remaining, known = c.RateLimitRemaining("bob")
+	assert.True(t, known)
+	assert.Equal(t, 10, remaining)
+}
@@ -28,8 +28,10 @@ func (a fileMetaList) Len() int           { return len(a) }
 func (a fileMetaList) Less(i, j int) bool { return a[i].Name < a[j].Name }
 func (a fileMetaList) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 
+// SortByName sorts the files by name. The sort is stable, so a given set of files always
+// results in the same order, which the numbering of the workflows relies on.
 func SortByName(fm []*FileMeta) []*FileMeta {
 	l := fileMetaList(fm)
-	sort.Sort(l)
+	sort.Stable(l)
 	return l
 }
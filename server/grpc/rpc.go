@@ -279,6 +279,15 @@ func (s *RPC) Done(c context.Context, id string, state rpc.State) error {
 		logger.Error().Err(queueErr).Msg("queue.Done: cannot ack workflow")
 	}
 
+	if workflow.Failing() {
+		if err := pipeline.CancelMatrixSiblings(c, s.store, currentPipeline, workflow); err != nil {
+			logger.Error().Err(err).Msg("cannot cancel the remaining workflows of the matrix")
+		}
+		if err := pipeline.CancelRemainingWorkflows(c, s.store, currentPipeline, workflow); err != nil {
+			logger.Error().Err(err).Msg("cannot cancel the remaining workflows of the pipeline")
+		}
+	}
+
 	currentPipeline.Workflows, err = s.store.WorkflowGetTree(currentPipeline)
 	if err != nil {
 		return err
@@ -423,8 +432,8 @@ func (s *RPC) updateForgeStatus(ctx context.Context, repo *model.Repo, pipeline
 
 	forge.Refresh(ctx, _forge, s.store, user)
 
-	// only do status updates for parent steps
-	if workflow != nil {
+	// only do status updates for parent steps, experimental workflows aren't reported
+	if workflow != nil && !workflow.Experimental {
 		err = _forge.Status(ctx, user, repo, pipeline, workflow)
 		if err != nil {
 			log.Error().Err(err).Msgf("error setting commit status for %s/%d", repo.FullName, pipeline.Number)
@@ -15,6 +15,11 @@
 
 package model
 
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
 // Config represents a pipeline configuration.
 type Config struct {
 	ID     int64  `json:"-"    xorm:"pk autoincr 'config_id'"`
@@ -24,6 +29,11 @@ type Config struct {
 	Data   []byte `json:"data" xorm:"LONGBLOB 'config_data'"`
 } //	@name Config
 
+// ConfigHash returns the hash used to identify identical config contents.
+func ConfigHash(data []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
 // PipelineConfig is the n:n relation between Pipeline and Config.
 type PipelineConfig struct {
 	ConfigID   int64 `json:"-"   xorm:"UNIQUE(s) NOT NULL 'config_id'"`
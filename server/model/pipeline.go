@@ -49,9 +49,15 @@ type Pipeline struct {
 	Reviewed            int64                  `json:"reviewed_at"             xorm:"pipeline_reviewed"`
 	Workflows           []*Workflow            `json:"workflows,omitempty"     xorm:"-"`
 	ChangedFiles        []string               `json:"changed_files,omitempty" xorm:"LONGTEXT 'changed_files'"`
+	ChangedFilesUnknown bool                   `json:"changed_files_unknown,omitempty" xorm:"changed_files_unknown"` // the forge can't provide the changed files
 	AdditionalVariables map[string]string      `json:"variables,omitempty"     xorm:"json 'additional_variables'"`
 	PullRequestLabels   []string               `json:"pr_labels,omitempty"     xorm:"json 'pr_labels'"`
 	IsPrerelease        bool                   `json:"is_prerelease,omitempty"     xorm:"is_prerelease"`
+	FailFast            bool                   `json:"fail_fast,omitempty"     xorm:"pipeline_fail_fast"`
+	Timeout             int64                  `json:"timeout,omitempty"       xorm:"pipeline_timeout"` // in seconds, the pipeline gets canceled after it
+	EventHash           string                 `json:"event_hash,omitempty"    xorm:"pipeline_event_hash"`
+	TraceID             string                 `json:"trace_id,omitempty"      xorm:"pipeline_trace_id"` // to correlate spans of the steps, generated if not set by the trigger
+	MaxParallelSteps    int                    `json:"max_parallel_steps,omitempty" xorm:"pipeline_max_parallel_steps"`
 } //	@name Pipeline
 
 type PipelineFilter struct {
@@ -72,4 +78,5 @@ func (p Pipeline) IsMultiPipeline() bool {
 type PipelineOptions struct {
 	Branch    string            `json:"branch"`
 	Variables map[string]string `json:"variables"`
+	TraceID   string            `json:"trace_id"`
 } //	@name PipelineOptions
@@ -18,6 +18,8 @@ package model
 import (
 	"errors"
 	"net/url"
+	"slices"
+	"strings"
 )
 
 var (
@@ -28,13 +30,24 @@ var (
 
 // Registry represents a docker registry with credentials.
 type Registry struct {
-	ID       int64  `json:"id"       xorm:"pk autoincr 'registry_id'"`
-	RepoID   int64  `json:"-"        xorm:"UNIQUE(s) INDEX 'registry_repo_id'"`
-	Address  string `json:"address"  xorm:"UNIQUE(s) INDEX 'registry_addr'"`
-	Username string `json:"username" xorm:"varchar(2000) 'registry_username'"`
-	Password string `json:"password" xorm:"TEXT 'registry_password'"`
+	ID              int64    `json:"id"           xorm:"pk autoincr 'registry_id'"`
+	RepoID          int64    `json:"-"            xorm:"UNIQUE(s) INDEX 'registry_repo_id'"`
+	Address         string   `json:"address"      xorm:"UNIQUE(s) INDEX 'registry_addr'"`
+	Username        string   `json:"username"     xorm:"varchar(2000) 'registry_username'"`
+	Password        string   `json:"password"     xorm:"TEXT 'registry_password'"`
+	Environments    []string `json:"environments" xorm:"json 'registry_environments'"`
+	TokenAuth       bool     `json:"token_auth"   xorm:"registry_token_auth"`                         // the password is a short-lived token, refreshed for each pipeline
+	EnvironmentsKey string   `json:"-"            xorm:"UNIQUE(s) INDEX 'registry_environments_key'"` // the environments are part of the unique key, so an address can have credentials per environment
 } //	@name Registry
 
+// RegistryPatch represents the changes to a registry, unset fields are kept.
+type RegistryPatch struct {
+	Username     string   `json:"username"`
+	Password     string   `json:"password"`
+	Environments []string `json:"environments"`
+	TokenAuth    *bool    `json:"token_auth,omitempty"`
+} //	@name RegistryPatch
+
 // Validate validates the registry information.
 func (r *Registry) Validate() error {
 	switch {
@@ -53,9 +66,27 @@ func (r *Registry) Validate() error {
 // Copy makes a copy of the registry without the password.
 func (r *Registry) Copy() *Registry {
 	return &Registry{
-		ID:       r.ID,
-		RepoID:   r.RepoID,
-		Address:  r.Address,
-		Username: r.Username,
+		ID:           r.ID,
+		RepoID:       r.RepoID,
+		Address:      r.Address,
+		Username:     r.Username,
+		Environments: r.Environments,
+		TokenAuth:    r.TokenAuth,
+	}
+}
+
+// RegistryEnvironmentsKey returns the key of the environments of a registry, independent of their order.
+func RegistryEnvironmentsKey(environments []string) string {
+	environments = slices.Clone(environments)
+	slices.Sort(environments)
+	return strings.Join(slices.Compact(environments), ",")
+}
+
+// MatchEnvironment returns true if the registry is available for the deploy environment.
+// Registries without environments are available for all pipelines.
+func (r *Registry) MatchEnvironment(environment string) bool {
+	if len(r.Environments) == 0 {
+		return true
 	}
+	return slices.Contains(r.Environments, environment)
 }
@@ -51,6 +51,7 @@ type Repo struct {
 	Perm                         *Perm          `json:"-"                               xorm:"-"`
 	CancelPreviousPipelineEvents []WebhookEvent `json:"cancel_previous_pipeline_events" xorm:"json 'cancel_previous_pipeline_events'"`
 	NetrcOnlyTrusted             bool           `json:"netrc_only_trusted"              xorm:"NOT NULL DEFAULT true 'netrc_only_trusted'"`
+	CloneImage                   string         `json:"clone_image"                     xorm:"varchar(500) 'repo_clone_image'"`
 } //	@name Repo
 
 // TableName return database table name for xorm.
@@ -117,6 +118,7 @@ type RepoPatch struct {
 	AllowDeploy                  *bool           `json:"allow_deploy,omitempty"`
 	CancelPreviousPipelineEvents *[]WebhookEvent `json:"cancel_previous_pipeline_events"`
 	NetrcOnlyTrusted             *bool           `json:"netrc_only_trusted"`
+	CloneImage                   *string         `json:"clone_image,omitempty"`
 } //	@name RepoPatch
 
 type ForgeRemoteID string
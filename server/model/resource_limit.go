@@ -19,6 +19,7 @@ type ResourceLimit struct {
 	MemSwapLimit int64
 	MemLimit     int64
 	ShmSize      int64
+	MaxShmSize   int64
 	CPUQuota     int64
 	CPUShares    int64
 	CPUSet       string
@@ -45,13 +45,15 @@ type SecretStore interface {
 
 // Secret represents a secret variable, such as a password or token.
 type Secret struct {
-	ID     int64          `json:"id"              xorm:"pk autoincr 'secret_id'"`
-	OrgID  int64          `json:"org_id"          xorm:"NOT NULL DEFAULT 0 UNIQUE(s) INDEX 'secret_org_id'"`
-	RepoID int64          `json:"repo_id"         xorm:"NOT NULL DEFAULT 0 UNIQUE(s) INDEX 'secret_repo_id'"`
-	Name   string         `json:"name"            xorm:"NOT NULL UNIQUE(s) INDEX 'secret_name'"`
-	Value  string         `json:"value,omitempty" xorm:"TEXT 'secret_value'"`
-	Images []string       `json:"images"          xorm:"json 'secret_images'"`
-	Events []WebhookEvent `json:"events"          xorm:"json 'secret_events'"`
+	ID       int64          `json:"id"              xorm:"pk autoincr 'secret_id'"`
+	OrgID    int64          `json:"org_id"          xorm:"NOT NULL DEFAULT 0 UNIQUE(s) INDEX 'secret_org_id'"`
+	RepoID   int64          `json:"repo_id"         xorm:"NOT NULL DEFAULT 0 UNIQUE(s) INDEX 'secret_repo_id'"`
+	Name     string         `json:"name"            xorm:"NOT NULL UNIQUE(s) INDEX 'secret_name'"`
+	Value    string         `json:"value,omitempty" xorm:"TEXT 'secret_value'"`
+	Images   []string       `json:"images"          xorm:"json 'secret_images'"`
+	Events   []WebhookEvent `json:"events"          xorm:"json 'secret_events'"`
+	Required bool           `json:"required"        xorm:"secret_required"`
+	File     bool           `json:"file"            xorm:"secret_file"` // mount the value as file instead of passing it directly
 } //	@name Secret
 
 // TableName return database table name for xorm.
@@ -59,6 +61,15 @@ func (Secret) TableName() string {
 	return "secrets"
 }
 
+// SecretPatch represents the changes to a secret, unset fields are kept.
+type SecretPatch struct {
+	Value    string         `json:"value,omitempty"`
+	Images   []string       `json:"images"`
+	Events   []WebhookEvent `json:"events"`
+	Required *bool          `json:"required,omitempty"`
+	File     *bool          `json:"file,omitempty"`
+} //	@name SecretPatch
+
 // BeforeInsert will sort events before inserted into database.
 func (s *Secret) BeforeInsert() {
 	s.Events = sortEvents(s.Events)
@@ -79,13 +90,16 @@ func (s Secret) IsRepository() bool {
 	return s.RepoID != 0 && s.OrgID == 0
 }
 
+// validSecretFileName is used for the names of file secrets, as their name is the file name.
+var validSecretFileName = regexp.MustCompile(`^[\w\-][\w\-\.]*$`)
+
 var validDockerImageString = regexp.MustCompile(
 	`^(` +
 		`[\w\d\-_\.]+` + // hostname
 		`(:\d+)?` + // optional port
 		`/)?` + // optional hostname + port
-		`([\w\d\-_\.][\w\d\-_\.\/]*/)?` + // optional url prefix
-		`([\w\d\-_]+)` + // image name
+		`([\w\d\-_\.\*][\w\d\-_\.\/\*]*/)?` + // optional url prefix, may contain * wildcards
+		`([\w\d\-_\*]+)` + // image name, may contain * wildcards
 		`(:[\w\d\-_]+)?` + // optional image tag
 		`$`,
 )
@@ -113,6 +127,8 @@ func (s *Secret) Validate() error {
 	switch {
 	case len(s.Name) == 0:
 		return fmt.Errorf("%w: empty name", ErrSecretNameInvalid)
+	case s.File && !validSecretFileName.MatchString(s.Name):
+		return fmt.Errorf("%w: file secrets need a name matching regexp '%s'", ErrSecretNameInvalid, validSecretFileName.String())
 	case len(s.Value) == 0:
 		return fmt.Errorf("%w: empty value", ErrSecretValueInvalid)
 	default:
@@ -123,12 +139,14 @@ func (s *Secret) Validate() error {
 // Copy makes a copy of the secret without the value.
 func (s *Secret) Copy() *Secret {
 	return &Secret{
-		ID:     s.ID,
-		OrgID:  s.OrgID,
-		RepoID: s.RepoID,
-		Name:   s.Name,
-		Images: s.Images,
-		Events: sortEvents(s.Events),
+		ID:       s.ID,
+		OrgID:    s.OrgID,
+		RepoID:   s.RepoID,
+		Name:     s.Name,
+		Images:   s.Images,
+		Events:   sortEvents(s.Events),
+		Required: s.Required,
+		File:     s.File,
 	}
 }
 
@@ -33,6 +33,16 @@ func TestSecretValidate(t *testing.T) {
 			err := secret.Validate()
 			g.Assert(err).IsNil()
 		})
+		g.It("should pass validation with wildcard images", func() {
+			secret := Secret{
+				Name:   "secretname",
+				Value:  "secretvalue",
+				Events: []WebhookEvent{EventPush},
+				Images: []string{"registry.example.com/ci/*", "localregistry.test:8443/*/golang", "plugins/docker-*:latest"},
+			}
+			err := secret.Validate()
+			g.Assert(err).IsNil()
+		})
 		g.Describe("should fail validation", func() {
 			g.It("when no name", func() {
 				secret := Secret{
@@ -61,6 +71,16 @@ func TestSecretValidate(t *testing.T) {
 				err := secret.Validate()
 				g.Assert(err).IsNotNil()
 			})
+			g.It("when file secret name is no file name", func() {
+				secret := Secret{
+					Name:   "../kubeconfig",
+					Value:  "secretvalue",
+					Events: []WebhookEvent{EventPush},
+					File:   true,
+				}
+				err := secret.Validate()
+				g.Assert(err).IsNotNil()
+			})
 			g.It("wrong image: no value", func() {
 				secret := Secret{
 					Name:   "secretname",
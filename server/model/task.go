@@ -27,7 +27,9 @@ type Task struct {
 	Dependencies []string               `json:"dependencies" xorm:"json 'task_dependencies'"`
 	RunOn        []string               `json:"run_on"       xorm:"json 'task_run_on'"`
 	DepStatus    map[string]StatusValue `json:"dep_status"   xorm:"json 'task_dep_status'"`
+	DepRunOn     map[string]string      `json:"dep_run_on"   xorm:"json 'task_dep_run_on'"` // status of a dependency the task runs on (failure or always), other dependencies follow RunOn
 	AgentID      int64                  `json:"agent_id"     xorm:"'agent_id'"`
+	Priority     int                    `json:"priority"     xorm:"'task_priority'"`
 } //	@name Task
 
 // TableName return database table name for xorm.
@@ -43,13 +45,19 @@ func (t *Task) String() string {
 
 // ShouldRun tells if a task should be run or skipped, based on dependencies.
 func (t *Task) ShouldRun() bool {
+	for dep, runOn := range t.DepRunOn {
+		if status, ok := t.DepStatus[dep]; ok && runOn == string(StatusFailure) && status == StatusSuccess {
+			return false
+		}
+	}
+
 	if t.runsOnFailure() && t.runsOnSuccess() {
 		return true
 	}
 
 	if !t.runsOnFailure() && t.runsOnSuccess() {
-		for _, status := range t.DepStatus {
-			if status != StatusSuccess {
+		for dep, status := range t.DepStatus {
+			if _, ok := t.DepRunOn[dep]; !ok && status != StatusSuccess {
 				return false
 			}
 		}
@@ -57,8 +65,8 @@ func (t *Task) ShouldRun() bool {
 	}
 
 	if t.runsOnFailure() && !t.runsOnSuccess() {
-		for _, status := range t.DepStatus {
-			if status == StatusSuccess {
+		for dep, status := range t.DepStatus {
+			if _, ok := t.DepRunOn[dep]; !ok && status == StatusSuccess {
 				return false
 			}
 		}
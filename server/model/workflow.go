@@ -17,19 +17,26 @@ package model
 
 // Workflow represents a workflow in the pipeline.
 type Workflow struct {
-	ID         int64             `json:"id"                   xorm:"pk autoincr 'workflow_id'"`
-	PipelineID int64             `json:"pipeline_id"          xorm:"UNIQUE(s) INDEX 'workflow_pipeline_id'"`
-	PID        int               `json:"pid"                  xorm:"UNIQUE(s) 'workflow_pid'"`
-	Name       string            `json:"name"                 xorm:"workflow_name"`
-	State      StatusValue       `json:"state"                xorm:"workflow_state"`
-	Error      string            `json:"error,omitempty"      xorm:"TEXT 'workflow_error'"`
-	Started    int64             `json:"start_time,omitempty" xorm:"workflow_started"`
-	Stopped    int64             `json:"end_time,omitempty"   xorm:"workflow_stopped"`
-	AgentID    int64             `json:"agent_id,omitempty"   xorm:"workflow_agent_id"`
-	Platform   string            `json:"platform,omitempty"   xorm:"workflow_platform"`
-	Environ    map[string]string `json:"environ,omitempty"    xorm:"json 'workflow_environ'"`
-	AxisID     int               `json:"-"                    xorm:"workflow_axis_id"`
-	Children   []*Step           `json:"children,omitempty"   xorm:"-"`
+	ID               int64             `json:"id"                          xorm:"pk autoincr 'workflow_id'"`
+	PipelineID       int64             `json:"pipeline_id"                 xorm:"UNIQUE(s) INDEX 'workflow_pipeline_id'"`
+	PID              int               `json:"pid"                         xorm:"UNIQUE(s) 'workflow_pid'"`
+	Name             string            `json:"name"                        xorm:"workflow_name"`
+	State            StatusValue       `json:"state"                       xorm:"workflow_state"`
+	Error            string            `json:"error,omitempty"             xorm:"TEXT 'workflow_error'"`
+	Started          int64             `json:"start_time,omitempty"        xorm:"workflow_started"`
+	Stopped          int64             `json:"end_time,omitempty"          xorm:"workflow_stopped"`
+	AgentID          int64             `json:"agent_id,omitempty"          xorm:"workflow_agent_id"`
+	Platform         string            `json:"platform,omitempty"          xorm:"workflow_platform"`
+	Environ          map[string]string `json:"environ,omitempty"           xorm:"json 'workflow_environ'"`
+	AxisID           int               `json:"-"                           xorm:"workflow_axis_id"`
+	ConcurrencyGroup string            `json:"concurrency_group,omitempty" xorm:"workflow_concurrency_group"`
+	StableID         string            `json:"stable_id,omitempty"         xorm:"workflow_stable_id"`
+	Experimental     bool              `json:"experimental,omitempty"      xorm:"workflow_experimental"`
+	Notify           string            `json:"notify,omitempty"            xorm:"workflow_notify"`
+	DependencyStatus map[string]string `json:"dependency_status,omitempty" xorm:"json 'workflow_dependency_status'"`
+	MatrixFailFast   bool              `json:"matrix_fail_fast,omitempty"  xorm:"workflow_matrix_fail_fast"`
+	Timeout          int64             `json:"timeout,omitempty"           xorm:"workflow_timeout"` // in minutes, zero uses the timeout of the repo
+	Children         []*Step           `json:"children,omitempty"          xorm:"-"`
 }
 
 // TableName return database table name for xorm.
@@ -59,11 +66,12 @@ func IsThereRunningStage(workflows []*Workflow) bool {
 }
 
 // PipelineStatus determine pipeline status based on corresponding workflow list.
+// Experimental workflows don't affect the status.
 func PipelineStatus(workflows []*Workflow) StatusValue {
 	status := StatusSuccess
 
 	for _, p := range workflows {
-		if p.Failing() {
+		if p.Failing() && !p.Experimental {
 			status = p.State
 		}
 	}
@@ -17,12 +17,14 @@ package pipeline
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
 	"go.woodpecker-ci.org/woodpecker/v2/server"
 	"go.woodpecker-ci.org/woodpecker/v2/server/forge"
 	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+	"go.woodpecker-ci.org/woodpecker/v2/server/pipeline/stepbuilder"
 	"go.woodpecker-ci.org/woodpecker/v2/server/queue"
 	"go.woodpecker-ci.org/woodpecker/v2/server/store"
 )
@@ -99,6 +101,99 @@ func Cancel(ctx context.Context, _forge forge.Forge, store store.Store, repo *mo
 	return nil
 }
 
+// CancelMatrixSiblings cancels the pending workflows generated by the same matrix as the
+// failed workflow, if the matrix is marked as fail_fast. Running workflows are kept.
+// Failing experimental workflows don't cancel anything.
+func CancelMatrixSiblings(ctx context.Context, store store.Store, pipeline *model.Pipeline, failed *model.Workflow) error {
+	if !failed.MatrixFailFast || failed.Experimental {
+		return nil
+	}
+
+	workflows, err := store.WorkflowGetTree(pipeline)
+	if err != nil {
+		return err
+	}
+
+	var siblings []*model.Workflow
+	for _, workflow := range workflows {
+		if workflow.ID != failed.ID && workflow.Name == failed.Name && workflow.MatrixFailFast && workflow.State == model.StatusPending {
+			siblings = append(siblings, workflow)
+		}
+	}
+	cancelWorkflows(ctx, store, siblings)
+	return nil
+}
+
+// CancelRemainingWorkflows cancels all other pending and running workflows of the
+// pipeline once a workflow failed, if the pipeline is marked as fail_fast. Failing
+// experimental workflows don't cancel anything.
+func CancelRemainingWorkflows(ctx context.Context, store store.Store, pipeline *model.Pipeline, failed *model.Workflow) error {
+	if !pipeline.FailFast || failed.Experimental {
+		return nil
+	}
+
+	workflows, err := store.WorkflowGetTree(pipeline)
+	if err != nil {
+		return err
+	}
+
+	var remaining []*model.Workflow
+	for _, workflow := range workflows {
+		if workflow.ID != failed.ID && (workflow.State == model.StatusPending || workflow.State == model.StatusRunning) {
+			remaining = append(remaining, workflow)
+		}
+	}
+	cancelWorkflows(ctx, store, remaining)
+	return nil
+}
+
+// cancelWorkflows evicts the pending workflows from the queue and marks them as skipped,
+// running workflows are canceled and get their state once the agent stopped them.
+func cancelWorkflows(ctx context.Context, store store.Store, workflows []*model.Workflow) {
+	var (
+		workflowsToCancel []string
+		workflowsToEvict  []string
+	)
+	for _, workflow := range workflows {
+		if workflow.State == model.StatusRunning {
+			workflowsToCancel = append(workflowsToCancel, fmt.Sprint(workflow.ID))
+		}
+		if workflow.State == model.StatusPending {
+			workflowsToEvict = append(workflowsToEvict, fmt.Sprint(workflow.ID))
+		}
+	}
+
+	if len(workflowsToEvict) != 0 {
+		if err := server.Config.Services.Queue.EvictAtOnce(ctx, workflowsToEvict); err != nil {
+			log.Error().Err(err).Msgf("queue: evict_at_once: %v", workflowsToEvict)
+		}
+		if err := server.Config.Services.Queue.ErrorAtOnce(ctx, workflowsToEvict, queue.ErrCancel); err != nil {
+			log.Error().Err(err).Msgf("queue: error_at_once: %v", workflowsToEvict)
+		}
+	}
+	if len(workflowsToCancel) != 0 {
+		if err := server.Config.Services.Queue.ErrorAtOnce(ctx, workflowsToCancel, queue.ErrCancel); err != nil {
+			log.Error().Err(err).Msgf("queue: error_at_once: %v", workflowsToCancel)
+		}
+	}
+
+	for _, workflow := range workflows {
+		if workflow.State != model.StatusPending {
+			continue
+		}
+		if _, err := UpdateWorkflowToStatusSkipped(store, *workflow); err != nil {
+			log.Error().Err(err).Msgf("cannot update workflow with id %d state", workflow.ID)
+		}
+		for _, step := range workflow.Children {
+			if step.State == model.StatusPending {
+				if _, err := UpdateStepToStatusSkipped(store, *step, 0); err != nil {
+					log.Error().Err(err).Msgf("cannot update step with id %d state", step.ID)
+				}
+			}
+		}
+	}
+}
+
 func cancelPreviousPipelines(
 	ctx context.Context,
 	_forge forge.Forge,
@@ -163,3 +258,135 @@ func cancelPreviousPipelines(
 
 	return nil
 }
+
+// cancelConcurrentPipelines cancels the active workflows of other pipelines of the repo in the
+// concurrency group of a workflow of the new pipeline that asks to cancel runs in progress.
+// Pipelines without other active workflows are canceled as a whole, so they get a final status.
+func cancelConcurrentPipelines(
+	ctx context.Context,
+	_forge forge.Forge,
+	_store store.Store,
+	pipeline *model.Pipeline,
+	repo *model.Repo,
+	user *model.User,
+	pipelineItems []*stepbuilder.Item,
+) error {
+	groups := make(map[string]bool)
+	for _, item := range pipelineItems {
+		if item.Concurrency != nil && item.Concurrency.CancelInProgress {
+			groups[item.Concurrency.Group] = true
+		}
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+
+	activePipelines, err := _store.GetActivePipelineList(repo)
+	if err != nil {
+		return err
+	}
+
+	for _, active := range activePipelines {
+		if active.ID == pipeline.ID {
+			continue
+		}
+
+		workflows, err := _store.WorkflowGetTree(active)
+		if err != nil {
+			return err
+		}
+		var inGroup []*model.Workflow
+		othersActive := false
+		for _, workflow := range workflows {
+			if !workflow.Running() {
+				continue
+			}
+			if groups[workflow.ConcurrencyGroup] {
+				inGroup = append(inGroup, workflow)
+			} else {
+				othersActive = true
+			}
+		}
+		if len(inGroup) == 0 {
+			continue
+		}
+		if othersActive {
+			cancelWorkflows(ctx, _store, inGroup)
+			continue
+		}
+
+		if err = Cancel(ctx, _forge, _store, repo, user, active); err != nil {
+			log.Error().
+				Err(err).
+				Str("ref", active.Ref).
+				Int64("id", active.ID).
+				Msg("failed to cancel pipeline of the same concurrency group")
+		}
+	}
+
+	return nil
+}
+
+// CancelPipelinesAfterTimeout restores the timeout timers of all active pipelines,
+// e.g. after a restart of the server.
+func CancelPipelinesAfterTimeout(ctx context.Context, _store store.Store) error {
+	pipelines, err := _store.GetTimeoutPipelineList()
+	if err != nil {
+		return err
+	}
+
+	for _, pipeline := range pipelines {
+		repo, err := _store.GetRepo(pipeline.RepoID)
+		if err != nil {
+			log.Error().Err(err).Int64("id", pipeline.ID).Msg("failed to load repo of pipeline with timeout")
+			continue
+		}
+		_forge, err := server.Config.Services.Manager.ForgeFromRepo(repo)
+		if err != nil {
+			log.Error().Err(err).Int64("id", pipeline.ID).Msg("failed to load forge of pipeline with timeout")
+			continue
+		}
+		user, err := _store.GetUser(repo.UserID)
+		if err != nil {
+			log.Error().Err(err).Int64("id", pipeline.ID).Msg("failed to load user of pipeline with timeout")
+			continue
+		}
+		cancelPipelineAfterTimeout(ctx, _forge, _store, pipeline, repo, user)
+	}
+
+	return nil
+}
+
+// timeoutDeadline returns the time the pipeline gets canceled at. The timeout
+// starts when the pipeline is created or, if it needed an approval, when it was reviewed.
+func timeoutDeadline(pipeline *model.Pipeline) time.Time {
+	start := max(pipeline.Created, pipeline.Reviewed)
+	return time.Unix(start, 0).Add(time.Duration(pipeline.Timeout) * time.Second)
+}
+
+// cancelPipelineAfterTimeout cancels the pipeline if it is still active after its timeout.
+func cancelPipelineAfterTimeout(
+	ctx context.Context,
+	_forge forge.Forge,
+	_store store.Store,
+	pipeline *model.Pipeline,
+	repo *model.Repo,
+	user *model.User,
+) {
+	ctx = context.WithoutCancel(ctx)
+	time.AfterFunc(time.Until(timeoutDeadline(pipeline)), func() {
+		current, err := _store.GetPipeline(pipeline.ID)
+		if err != nil {
+			log.Error().Err(err).Int64("id", pipeline.ID).Msg("failed to load pipeline to check its timeout")
+			return
+		}
+		if current.Status != model.StatusRunning && current.Status != model.StatusPending {
+			return
+		}
+
+		log.Debug().Int64("id", current.ID).Msgf("pipeline exceeded its timeout of %ds", current.Timeout)
+		if err := Cancel(ctx, _forge, _store, repo, user, current); err != nil {
+			log.Error().Err(err).Int64("id", current.ID).Msg("failed to cancel pipeline after its timeout")
+		}
+	})
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	yaml_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/types"
+	"go.woodpecker-ci.org/woodpecker/v2/server"
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+	"go.woodpecker-ci.org/woodpecker/v2/server/pipeline/stepbuilder"
+	"go.woodpecker-ci.org/woodpecker/v2/server/queue"
+	mocks_store "go.woodpecker-ci.org/woodpecker/v2/server/store/mocks"
+)
+
+func TestCancelRemainingWorkflows(t *testing.T) {
+	_queue := server.Config.Services.Queue
+	t.Cleanup(func() { server.Config.Services.Queue = _queue })
+
+	ctx := context.Background()
+	failed := &model.Workflow{ID: 1, State: model.StatusFailure}
+
+	t.Run("not fail fast", func(t *testing.T) {
+		store := mocks_store.NewStore(t)
+		assert.NoError(t, CancelRemainingWorkflows(ctx, store, &model.Pipeline{ID: 1}, failed))
+	})
+
+	t.Run("experimental workflow fails with fail fast", func(t *testing.T) {
+		store := mocks_store.NewStore(t)
+		experimental := &model.Workflow{ID: 1, State: model.StatusFailure, Experimental: true, MatrixFailFast: true}
+		assert.NoError(t, CancelRemainingWorkflows(ctx, store, &model.Pipeline{ID: 1, FailFast: true}, experimental))
+		assert.NoError(t, CancelMatrixSiblings(ctx, store, &model.Pipeline{ID: 1, FailFast: true}, experimental))
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		server.Config.Services.Queue = queue.New(ctx)
+		assert.NoError(t, server.Config.Services.Queue.PushAtOnce(ctx, []*model.Task{{ID: "2"}, {ID: "3"}}))
+
+		pipeline := &model.Pipeline{ID: 1, FailFast: true}
+		pendingStep := &model.Step{ID: 21, State: model.StatusPending}
+		store := mocks_store.NewStore(t)
+		store.On("WorkflowGetTree", pipeline).Return([]*model.Workflow{
+			failed,
+			{ID: 2, State: model.StatusPending, Children: []*model.Step{pendingStep}},
+			{ID: 3, State: model.StatusRunning},
+			{ID: 4, State: model.StatusSuccess},
+		}, nil)
+		store.On("WorkflowUpdate", &model.Workflow{ID: 2, State: model.StatusSkipped, Children: []*model.Step{pendingStep}}).Once().Return(nil)
+		store.On("StepUpdate", &model.Step{ID: 21, State: model.StatusSkipped}).Once().Return(nil)
+
+		assert.NoError(t, CancelRemainingWorkflows(ctx, store, pipeline, failed))
+
+		info := server.Config.Services.Queue.Info(ctx)
+		assert.Empty(t, info.Pending)
+		assert.Empty(t, info.Running)
+	})
+}
+
+func TestCancelConcurrentPipelines(t *testing.T) {
+	_queue := server.Config.Services.Queue
+	t.Cleanup(func() { server.Config.Services.Queue = _queue })
+
+	ctx := context.Background()
+	server.Config.Services.Queue = queue.New(ctx)
+	assert.NoError(t, server.Config.Services.Queue.PushAtOnce(ctx, []*model.Task{{ID: "11"}, {ID: "12"}, {ID: "13"}}))
+
+	repo := &model.Repo{ID: 1}
+	pipeline := &model.Pipeline{ID: 2}
+	active := &model.Pipeline{ID: 1, Status: model.StatusRunning}
+	pendingStep := &model.Step{ID: 111, State: model.StatusPending}
+	store := mocks_store.NewStore(t)
+	store.On("GetActivePipelineList", repo).Return([]*model.Pipeline{pipeline, active}, nil)
+	store.On("WorkflowGetTree", active).Return([]*model.Workflow{
+		{ID: 11, State: model.StatusPending, ConcurrencyGroup: "deploy", Children: []*model.Step{pendingStep}},
+		{ID: 12, State: model.StatusRunning, ConcurrencyGroup: "deploy"},
+		{ID: 13, State: model.StatusPending, ConcurrencyGroup: "test"},
+		{ID: 14, State: model.StatusSuccess, ConcurrencyGroup: "deploy"},
+	}, nil)
+	store.On("WorkflowUpdate", &model.Workflow{ID: 11, State: model.StatusSkipped, ConcurrencyGroup: "deploy", Children: []*model.Step{pendingStep}}).Once().Return(nil)
+	store.On("StepUpdate", &model.Step{ID: 111, State: model.StatusSkipped}).Once().Return(nil)
+
+	items := []*stepbuilder.Item{{Concurrency: &yaml_types.Concurrency{Group: "deploy", CancelInProgress: true}}}
+	assert.NoError(t, cancelConcurrentPipelines(ctx, nil, store, pipeline, repo, &model.User{}, items))
+
+	// only the workflows of the group are canceled, the pipeline keeps running the other ones
+	info := server.Config.Services.Queue.Info(ctx)
+	if assert.Len(t, info.Pending, 1) {
+		assert.Equal(t, "13", info.Pending[0].ID)
+	}
+}
+
+func TestTimeoutDeadline(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, time.Unix(1100, 0), timeoutDeadline(&model.Pipeline{Created: 1000, Timeout: 100}))
+	// the timeout of pipelines needing an approval starts with the review
+	assert.Equal(t, time.Unix(2100, 0), timeoutDeadline(&model.Pipeline{Created: 1000, Reviewed: 2000, Timeout: 100}))
+}
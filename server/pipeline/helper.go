@@ -23,8 +23,13 @@ import (
 	"go.woodpecker-ci.org/woodpecker/v2/server/model"
 )
 
+// updatePipelineStatus reports the workflows of the pipeline to the forge, experimental
+// workflows are left out.
 func updatePipelineStatus(ctx context.Context, forge forge.Forge, pipeline *model.Pipeline, repo *model.Repo, user *model.User) {
 	for _, workflow := range pipeline.Workflows {
+		if workflow.Experimental {
+			continue
+		}
 		err := forge.Status(ctx, user, repo, pipeline, workflow)
 		if err != nil {
 			log.Error().Err(err).Msgf("error setting commit status for %s/%d", repo.FullName, pipeline.Number)
@@ -0,0 +1,41 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"go.woodpecker-ci.org/woodpecker/v2/server/forge/mocks"
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+)
+
+func TestUpdatePipelineStatusSkipsExperimental(t *testing.T) {
+	ctx := context.Background()
+	repo := &model.Repo{}
+	user := &model.User{}
+	build := &model.Workflow{ID: 1, State: model.StatusSuccess}
+	pipeline := &model.Pipeline{
+		Workflows: []*model.Workflow{
+			build,
+			{ID: 2, State: model.StatusFailure, Experimental: true},
+		},
+	}
+
+	forge := mocks.NewForge(t)
+	forge.On("Status", ctx, user, repo, pipeline, build).Once().Return(nil)
+
+	updatePipelineStatus(ctx, forge, pipeline, repo, user)
+}
@@ -18,9 +18,14 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"maps"
+	"math"
+	"slices"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 
+	backend_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/backend/types"
 	pipeline_errors "go.woodpecker-ci.org/woodpecker/v2/pipeline/errors"
 	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/compiler"
 	"go.woodpecker-ci.org/woodpecker/v2/server"
@@ -29,9 +34,10 @@ import (
 	"go.woodpecker-ci.org/woodpecker/v2/server/model"
 	"go.woodpecker-ci.org/woodpecker/v2/server/pipeline/stepbuilder"
 	"go.woodpecker-ci.org/woodpecker/v2/server/store"
+	"go.woodpecker-ci.org/woodpecker/v2/shared/constant"
 )
 
-func parsePipeline(forge forge.Forge, store store.Store, currentPipeline *model.Pipeline, user *model.User, repo *model.Repo, yamls []*forge_types.FileMeta, envs map[string]string) ([]*stepbuilder.Item, error) {
+func parsePipeline(ctx context.Context, forge forge.Forge, store store.Store, currentPipeline *model.Pipeline, user *model.User, repo *model.Repo, yamls []*forge_types.FileMeta, envs map[string]string) ([]*stepbuilder.Item, error) {
 	netrc, err := forge.Netrc(user, repo)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to generate netrc file")
@@ -82,20 +88,61 @@ func parsePipeline(forge forge.Forge, store store.Store, currentPipeline *model.
 		Host:  server.Config.Server.Host,
 		Yamls: yamls,
 		Forge: forge,
+		// config services can provide files of subfolders, their workflows get unique names
+		ConfigDir:             workflowConfigDir(repo),
+		AutoDisambiguateNames: server.Config.Pipeline.AutoDisambiguateNames,
 		ProxyOpts: compiler.ProxyOptions{
 			NoProxy:    server.Config.Pipeline.Proxy.No,
 			HTTPProxy:  server.Config.Pipeline.Proxy.HTTP,
 			HTTPSProxy: server.Config.Pipeline.Proxy.HTTPS,
 		},
 	}
+
+	if user != nil {
+		b.ForgeToken = user.Token
+
+		// templates are read from the repo at the commit of the pipeline, once per pipeline
+		templates := map[string][]byte{}
+		b.LoadTemplate = func(path string) ([]byte, error) {
+			if data, ok := templates[path]; ok {
+				return data, nil
+			}
+			data, err := forge.File(ctx, user, repo, currentPipeline, path)
+			if err != nil {
+				return nil, err
+			}
+			templates[path] = data
+			return data, nil
+		}
+	}
+
+	if provider := server.Config.Services.Manager.RegistryCredentialProvider(); provider != nil {
+		b.RegistryCredentials = func(registry *model.Registry) (string, string, error) {
+			return provider.RegistryCredentials(ctx, repo, registry)
+		}
+	}
+
 	return b.Build()
 }
 
+// workflowConfigDir returns the config folder of the repo the workflow names are relative to,
+// it is empty if the repo uses a single config file.
+func workflowConfigDir(repo *model.Repo) string {
+	config := strings.TrimSpace(repo.Config)
+	if config == "" {
+		config = constant.DefaultConfigOrder[0]
+	}
+	if !strings.HasSuffix(config, "/") {
+		return ""
+	}
+	return config
+}
+
 func createPipelineItems(c context.Context, forge forge.Forge, store store.Store,
 	currentPipeline *model.Pipeline, user *model.User, repo *model.Repo,
 	yamls []*forge_types.FileMeta, envs map[string]string,
 ) (*model.Pipeline, []*stepbuilder.Item, error) {
-	pipelineItems, err := parsePipeline(forge, store, currentPipeline, user, repo, yamls, envs)
+	pipelineItems, err := parsePipeline(c, forge, store, currentPipeline, user, repo, yamls, envs)
 	if pipeline_errors.HasBlockingErrors(err) {
 		currentPipeline, uErr := UpdateToStatusError(store, *currentPipeline, err)
 		if uErr != nil {
@@ -119,29 +166,36 @@ func createPipelineItems(c context.Context, forge forge.Forge, store store.Store
 // to be specific this func currently is used to convert the pipeline.Item list (crafted by StepBuilder.Build()) into
 // a pipeline that can be stored in the database by the server.
 func setPipelineStepsOnPipeline(pipeline *model.Pipeline, pipelineItems []*stepbuilder.Item) *model.Pipeline {
-	var pidSequence int
-	for _, item := range pipelineItems {
-		if pidSequence < item.Workflow.PID {
-			pidSequence = item.Workflow.PID
-		}
-	}
+	pidBases := stepPIDBases(pipelineItems)
 
 	// the workflows in the pipeline should be empty as only we do populate them,
 	// but if a pipeline was already loaded form database it might contain things, so we just clean it
 	pipeline.Workflows = nil
+	pipeline.FailFast = false
+	pipeline.Timeout = 0
 	for _, item := range pipelineItems {
-		for _, stage := range item.Config.Stages {
+		// a single workflow asking for it is enough to fail the whole pipeline fast
+		pipeline.FailFast = pipeline.FailFast || item.FailFast
+		// the shortest pipeline timeout of the workflows caps the whole pipeline
+		if timeout := int64(item.PipelineTimeout.Seconds()); timeout > 0 && (pipeline.Timeout == 0 || timeout < pipeline.Timeout) {
+			pipeline.Timeout = timeout
+		}
+		pidSequence := pidBases[item.Workflow.PID]
+		for _, stage := range itemStages(item) {
 			for _, step := range stage.Steps {
 				pidSequence++
 				step := &model.Step{
-					Name:       step.Name,
-					UUID:       step.UUID,
-					PipelineID: pipeline.ID,
-					PID:        pidSequence,
-					PPID:       item.Workflow.PID,
-					State:      model.StatusPending,
-					Failure:    step.Failure,
-					Type:       model.StepType(step.Type),
+					Name:              step.Name,
+					UUID:              step.UUID,
+					PipelineID:        pipeline.ID,
+					PID:               pidSequence,
+					PPID:              item.Workflow.PID,
+					State:             model.StatusPending,
+					Failure:           step.Failure,
+					FailureMessage:    step.FailureMessage,
+					Type:              model.StepType(step.Type),
+					EstimatedDuration: item.StepEstimates[step.Name],
+					Labels:            maps.Clone(item.Labels),
 				}
 				if item.Workflow.State == model.StatusSkipped {
 					step.State = model.StatusSkipped
@@ -155,9 +209,47 @@ func setPipelineStepsOnPipeline(pipeline *model.Pipeline, pipelineItems []*stepb
 		if pipeline.Status == model.StatusBlocked {
 			item.Workflow.State = model.StatusBlocked
 		}
+		if item.Concurrency != nil {
+			item.Workflow.ConcurrencyGroup = item.Concurrency.Group
+		}
+		item.Workflow.Experimental = item.Experimental
+		item.Workflow.Timeout = int64(math.Ceil(item.Timeout.Minutes()))
+		item.Workflow.Notify = item.Notify
+		item.Workflow.DependencyStatus = item.DependencyStatus
 		item.Workflow.PipelineID = pipeline.ID
 		pipeline.Workflows = append(pipeline.Workflows, item.Workflow)
 	}
 
 	return pipeline
 }
+
+// stepPIDBases returns the PID after which the steps of each workflow are numbered. Step PIDs continue
+// after the highest workflow PID and are assigned in the order of the workflow PIDs, so they only
+// depend on the workflows and their steps but not on the order of the items.
+func stepPIDBases(pipelineItems []*stepbuilder.Item) map[int]int {
+	sorted := slices.Clone(pipelineItems)
+	slices.SortStableFunc(sorted, func(a, b *stepbuilder.Item) int {
+		return a.Workflow.PID - b.Workflow.PID
+	})
+
+	bases := make(map[int]int, len(sorted))
+	if len(sorted) == 0 {
+		return bases
+	}
+	base := sorted[len(sorted)-1].Workflow.PID
+	for _, item := range sorted {
+		bases[item.Workflow.PID] = base
+		for _, stage := range itemStages(item) {
+			base += len(stage.Steps)
+		}
+	}
+	return bases
+}
+
+// itemStages returns the stages of the item, workflows skipped in a dry run have no config.
+func itemStages(item *stepbuilder.Item) []*backend_types.Stage {
+	if item.Config == nil {
+		return nil
+	}
+	return item.Config.Stages
+}
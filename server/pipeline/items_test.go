@@ -1,9 +1,13 @@
 package pipeline
 
 import (
+	"maps"
+	"slices"
 	"testing"
+	"time"
 
 	"go.woodpecker-ci.org/woodpecker/v2/pipeline/backend/types"
+	yaml_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/types"
 	"go.woodpecker-ci.org/woodpecker/v2/server/model"
 	sharedPipeline "go.woodpecker-ci.org/woodpecker/v2/server/pipeline/stepbuilder"
 )
@@ -20,6 +24,8 @@ func TestSetPipelineStepsOnPipeline(t *testing.T) {
 		Workflow: &model.Workflow{
 			PID: 1,
 		},
+		Labels:      map[string]string{"team": "backend"},
+		Concurrency: &yaml_types.Concurrency{Group: "deploy"},
 		Config: &types.Config{
 			Stages: []*types.Stage{
 				{
@@ -32,12 +38,21 @@ func TestSetPipelineStepsOnPipeline(t *testing.T) {
 				{
 					Steps: []*types.Step{
 						{
-							Name: "step",
+							Name:           "step",
+							FailureMessage: "check your DB migration",
 						},
 					},
 				},
 			},
 		},
+		StepEstimates:    map[string]int64{"step": 42},
+		FailFast:         true,
+		Timeout:          90 * time.Second,
+		PipelineTimeout:  time.Hour,
+		Experimental:     true,
+		Notify:           "slack:#builds",
+		DependsOn:        []string{"build"},
+		DependencyStatus: map[string]string{"build": "failure"},
 	}}
 	pipeline = setPipelineStepsOnPipeline(pipeline, pipelineItems)
 	if len(pipeline.Workflows) != 1 {
@@ -49,4 +64,110 @@ func TestSetPipelineStepsOnPipeline(t *testing.T) {
 	if pipeline.Workflows[0].Children[0].PPID != 1 {
 		t.Fatal("Should set step PPID")
 	}
+	if pipeline.Workflows[0].Children[0].EstimatedDuration != 0 {
+		t.Fatal("Should not set an estimate without history")
+	}
+	if pipeline.Workflows[0].Children[1].EstimatedDuration != 42 {
+		t.Fatal("Should set step estimated duration")
+	}
+	if pipeline.Workflows[0].Children[1].FailureMessage != "check your DB migration" {
+		t.Fatal("Should set step failure message")
+	}
+	if !pipeline.FailFast {
+		t.Fatal("Should set pipeline fail fast")
+	}
+	for _, step := range pipeline.Workflows[0].Children {
+		if step.Labels["team"] != "backend" {
+			t.Fatal("Should set workflow labels on steps")
+		}
+	}
+	if pipeline.Timeout != 3600 {
+		t.Fatal("Should set pipeline timeout")
+	}
+	if pipeline.Workflows[0].Timeout != 2 {
+		t.Fatal("Should set workflow timeout in minutes rounded up")
+	}
+	if pipeline.Workflows[0].ConcurrencyGroup != "deploy" {
+		t.Fatal("Should set workflow concurrency group")
+	}
+	if !pipeline.Workflows[0].Experimental {
+		t.Fatal("Should set workflow experimental")
+	}
+	if pipeline.Workflows[0].Notify != "slack:#builds" {
+		t.Fatal("Should set workflow notify target")
+	}
+	if pipeline.Workflows[0].DependencyStatus["build"] != "failure" {
+		t.Fatal("Should set workflow dependency status")
+	}
+}
+
+func TestSetPipelineStepsOnPipelineStepPIDs(t *testing.T) {
+	t.Parallel()
+
+	newItems := func() []*sharedPipeline.Item {
+		return []*sharedPipeline.Item{{
+			Workflow: &model.Workflow{PID: 1},
+			Config: &types.Config{Stages: []*types.Stage{
+				{Steps: []*types.Step{{Name: "clone"}}},
+				{Steps: []*types.Step{{Name: "build"}, {Name: "test"}}},
+			}},
+		}, {
+			Workflow: &model.Workflow{PID: 2},
+			Config: &types.Config{Stages: []*types.Stage{
+				{Steps: []*types.Step{{Name: "deploy"}}},
+			}},
+		}}
+	}
+	stepPIDs := func(pipeline *model.Pipeline) map[string]int {
+		pids := map[string]int{}
+		for _, workflow := range pipeline.Workflows {
+			for _, step := range workflow.Children {
+				pids[step.Name] = step.PID
+			}
+		}
+		return pids
+	}
+
+	expected := map[string]int{"clone": 3, "build": 4, "test": 5, "deploy": 6}
+	pipeline := setPipelineStepsOnPipeline(&model.Pipeline{}, newItems())
+	if pids := stepPIDs(pipeline); !maps.Equal(pids, expected) {
+		t.Fatalf("Should number steps after the workflows, got %v", pids)
+	}
+
+	// the order of the items must not change the numbering
+	items := newItems()
+	slices.Reverse(items)
+	pipeline = setPipelineStepsOnPipeline(&model.Pipeline{}, items)
+	if pids := stepPIDs(pipeline); !maps.Equal(pids, expected) {
+		t.Fatalf("Should number steps in the order of the workflow PIDs, got %v", pids)
+	}
+}
+
+func TestWorkflowConfigDir(t *testing.T) {
+	t.Parallel()
+
+	for config, want := range map[string]string{
+		"":                ".woodpecker/",
+		"ci/":             "ci/",
+		".woodpecker.yml": "",
+		"ci/build.yaml":   "",
+	} {
+		if got := workflowConfigDir(&model.Repo{Config: config}); got != want {
+			t.Errorf("config dir of '%s' is '%s', want '%s'", config, got, want)
+		}
+	}
+}
+
+func TestSetPipelineStepsOnPipelineDryRun(t *testing.T) {
+	t.Parallel()
+
+	// workflows skipped in a dry run have no config
+	pipelineItems := []*sharedPipeline.Item{{
+		Workflow:    &model.Workflow{PID: 1, State: model.StatusSkipped},
+		SkipReasons: []string{"event 'push' does not match [tag]"},
+	}}
+	pipeline := setPipelineStepsOnPipeline(&model.Pipeline{ID: 1, Event: model.EventPush}, pipelineItems)
+	if len(pipeline.Workflows) != 1 || len(pipeline.Workflows[0].Children) != 0 {
+		t.Fatal("Should keep the skipped workflow without steps")
+	}
 }
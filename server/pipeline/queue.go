@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	yaml_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/types"
 	"go.woodpecker-ci.org/woodpecker/v2/pipeline/rpc"
 	"go.woodpecker-ci.org/woodpecker/v2/server"
 	"go.woodpecker-ci.org/woodpecker/v2/server/model"
@@ -40,13 +41,15 @@ func queuePipeline(ctx context.Context, repo *model.Repo, pipelineItems []*stepb
 		task.Labels["repo"] = repo.FullName
 		task.Dependencies = taskIDs(item.DependsOn, pipelineItems)
 		task.RunOn = item.RunsOn
+		task.Priority = item.Priority
 		task.DepStatus = make(map[string]model.StatusValue)
+		task.DepRunOn = taskDepRunOn(item.DependencyStatus, pipelineItems)
 
 		var err error
 		task.Data, err = json.Marshal(rpc.Workflow{
 			ID:      fmt.Sprint(item.Workflow.ID),
 			Config:  item.Config,
-			Timeout: repo.Timeout,
+			Timeout: workflowTimeout(repo, item.Workflow),
 		})
 		if err != nil {
 			return err
@@ -57,13 +60,40 @@ func queuePipeline(ctx context.Context, repo *model.Repo, pipelineItems []*stepb
 	return server.Config.Services.Queue.PushAtOnce(ctx, tasks)
 }
 
+// workflowTimeout returns the timeout of the workflow in minutes, workflows
+// without a timeout of their own fall back to the timeout of the repo.
+func workflowTimeout(repo *model.Repo, workflow *model.Workflow) int64 {
+	if workflow.Timeout > 0 {
+		return workflow.Timeout
+	}
+	return repo.Timeout
+}
+
 func taskIDs(dependsOn []string, pipelineItems []*stepbuilder.Item) (taskIDs []string) {
 	for _, dep := range dependsOn {
 		for _, pipelineItem := range pipelineItems {
-			if pipelineItem.Workflow.Name == dep {
+			if stepbuilder.MatchesDependency(pipelineItem, dep) {
 				taskIDs = append(taskIDs, fmt.Sprint(pipelineItem.Workflow.ID))
 			}
 		}
 	}
 	return
 }
+
+// taskDepRunOn returns the status the dependencies need by their task IDs, for the dependencies
+// running on failure or always. Dependencies needing success follow the runs_on of the workflow.
+func taskDepRunOn(dependencyStatus map[string]string, pipelineItems []*stepbuilder.Item) map[string]string {
+	var depRunOn map[string]string
+	for dep, status := range dependencyStatus {
+		if status == yaml_types.DependencyStatusSuccess {
+			continue
+		}
+		for _, id := range taskIDs([]string{dep}, pipelineItems) {
+			if depRunOn == nil {
+				depRunOn = make(map[string]string)
+			}
+			depRunOn[id] = status
+		}
+	}
+	return depRunOn
+}
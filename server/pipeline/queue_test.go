@@ -0,0 +1,88 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.woodpecker-ci.org/woodpecker/v2/server"
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+	"go.woodpecker-ci.org/woodpecker/v2/server/pipeline/stepbuilder"
+	"go.woodpecker-ci.org/woodpecker/v2/server/queue"
+)
+
+func TestWorkflowTimeout(t *testing.T) {
+	t.Parallel()
+
+	repo := &model.Repo{Timeout: 60}
+	assert.EqualValues(t, 60, workflowTimeout(repo, &model.Workflow{}))
+	assert.EqualValues(t, 45, workflowTimeout(repo, &model.Workflow{Timeout: 45}))
+	assert.EqualValues(t, 120, workflowTimeout(repo, &model.Workflow{Timeout: 120}))
+}
+
+func TestTaskIDs(t *testing.T) {
+	t.Parallel()
+
+	items := []*stepbuilder.Item{
+		{Workflow: &model.Workflow{ID: 1, Name: "build"}},
+		{Workflow: &model.Workflow{ID: 2, Name: "test", Environ: map[string]string{"GO": "1.21"}}},
+		{Workflow: &model.Workflow{ID: 3, Name: "test", Environ: map[string]string{"GO": "1.22"}}},
+	}
+
+	assert.Equal(t, []string{"1", "2", "3"}, taskIDs([]string{"build", "test"}, items))
+	// an axis reference only depends on the workflow of that axis
+	assert.Equal(t, []string{"3"}, taskIDs([]string{"test[GO=1.22]"}, items))
+	assert.Empty(t, taskIDs([]string{"test[GO=1.20]"}, items))
+}
+
+func TestQueuePipelineDependencyStatus(t *testing.T) {
+	_queue := server.Config.Services.Queue
+	t.Cleanup(func() { server.Config.Services.Queue = _queue })
+
+	ctx := context.Background()
+	q := queue.New(ctx)
+	server.Config.Services.Queue = q
+
+	items := []*stepbuilder.Item{
+		{Workflow: &model.Workflow{ID: 1, Name: "build"}},
+		{Workflow: &model.Workflow{ID: 2, Name: "test"}},
+		{
+			Workflow:         &model.Workflow{ID: 3, Name: "report"},
+			DependsOn:        []string{"build", "test"},
+			DependencyStatus: map[string]string{"build": "failure", "test": "success"},
+		},
+	}
+	assert.NoError(t, queuePipeline(ctx, &model.Repo{FullName: "octo/repo"}, items))
+
+	for _, status := range []model.StatusValue{model.StatusFailure, model.StatusSuccess} {
+		task, err := q.Poll(ctx, 1, func(task *model.Task) bool { return task.ID != "3" })
+		assert.NoError(t, err)
+		assert.NoError(t, q.Done(ctx, task.ID, status))
+	}
+
+	// the report runs as the build failed and the test succeeded
+	pollCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	report, err := q.Poll(pollCtx, 1, func(*model.Task) bool { return true })
+	if assert.NoError(t, err) {
+		assert.Equal(t, "3", report.ID)
+		assert.Equal(t, map[string]string{"1": "failure"}, report.DepRunOn)
+		assert.True(t, report.ShouldRun())
+	}
+}
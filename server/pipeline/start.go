@@ -32,6 +32,12 @@ func start(ctx context.Context, forge forge.Forge, store store.Store, activePipe
 		// should be not breaking
 		log.Error().Err(err).Msg("failed to cancel previous pipelines")
 	}
+	if err := cancelConcurrentPipelines(ctx, forge, store, activePipeline, repo, user, pipelineItems); err != nil {
+		log.Error().Err(err).Msg("failed to cancel pipelines of the same concurrency group")
+	}
+	if activePipeline.Timeout > 0 {
+		cancelPipelineAfterTimeout(ctx, forge, store, activePipeline, repo, user)
+	}
 
 	publishPipeline(ctx, forge, activePipeline, repo, user)
 
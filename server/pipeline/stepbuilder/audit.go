@@ -0,0 +1,126 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/compiler"
+	yaml_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/types"
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+)
+
+// AuditSecretExposure reports which secrets the steps of the items would receive for the event.
+// The result maps "<workflow>/<step>" to the sorted secret names, steps without secrets are omitted.
+// Secrets are filtered like in the compiler: by event and, for plugin-only secrets, by the plugin image.
+// Requested secrets that are missing or not available to a step are not reported.
+func AuditSecretExposure(items []*Item, secrets []*model.Secret, event model.WebhookEvent) map[string][]string {
+	available := make(map[string]compiler.Secret)
+	for _, secret := range compilerSecrets(secrets) {
+		available[strings.ToLower(secret.Name)] = secret
+	}
+
+	exposure := make(map[string][]string)
+	for _, item := range items {
+		if item.Config == nil {
+			continue
+		}
+		for _, stage := range item.Config.Stages {
+			for _, step := range stage.Steps {
+				container := &yaml_types.Container{
+					Name:       step.Name,
+					Image:      step.Image,
+					Commands:   step.Commands,
+					Entrypoint: step.Entrypoint,
+				}
+
+				var exposed []string
+				for _, name := range item.StepSecrets[step.Name] {
+					secret, ok := available[name]
+					if !ok || secret.Available(string(event), container) != nil {
+						continue
+					}
+					exposed = append(exposed, secret.Name)
+				}
+				if len(exposed) > 0 {
+					sort.Strings(exposed)
+					exposure[fmt.Sprintf("%s/%s", item.Workflow.Name, step.Name)] = exposed
+				}
+			}
+		}
+	}
+	return exposure
+}
+
+// stepSecrets collects the names of the secrets requested by each container of the
+// workflow, including init steps. Names are lower case like in the compiler.
+func stepSecrets(parsed *yaml_types.Workflow) map[string][]string {
+	requested := make(map[string][]string)
+	collect := func(container *yaml_types.Container, detached bool) {
+		names := make(map[string]bool)
+		for _, secret := range container.Secrets.Secrets {
+			names[strings.ToLower(secret.Source)] = true
+		}
+		// settings are not passed to detached steps
+		if !detached {
+			secretReferences(container.Settings, names)
+		}
+		secretReferences(map[string]any(container.Environment), names)
+
+		for name := range names {
+			requested[container.Name] = append(requested[container.Name], name)
+		}
+		sort.Strings(requested[container.Name])
+	}
+
+	for _, container := range parsed.Clone.ContainerList {
+		collect(container, container.Detached)
+	}
+	for _, container := range parsed.Steps.ContainerList {
+		collect(container, container.Detached)
+		for i, init := range container.Init {
+			initContainer := *init
+			if initContainer.Name == "" {
+				initContainer.Name = fmt.Sprintf("%s-init-%d", container.Name, i+1)
+			}
+			collect(&initContainer, false)
+		}
+	}
+	for _, container := range parsed.Services.ContainerList {
+		collect(container, true)
+	}
+
+	return requested
+}
+
+// secretReferences adds the secrets referenced by from_secret in the params to names.
+func secretReferences(params any, names map[string]bool) {
+	switch v := params.(type) {
+	case map[string]any:
+		if name, ok := v["from_secret"].(string); ok {
+			names[strings.ToLower(name)] = true
+			return
+		}
+		for _, value := range v {
+			secretReferences(value, names)
+		}
+	case []any:
+		for _, value := range v {
+			secretReferences(value, names)
+		}
+	}
+}
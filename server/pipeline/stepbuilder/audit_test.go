@@ -0,0 +1,104 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	backend_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/backend/types"
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml"
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+)
+
+func TestAuditSecretExposure(t *testing.T) {
+	t.Parallel()
+
+	items := []*Item{{
+		Workflow: &model.Workflow{Name: "release"},
+		Config: &backend_types.Config{
+			Stages: []*backend_types.Stage{{
+				Steps: []*backend_types.Step{
+					{Name: "build", Image: "golang", Type: backend_types.StepTypeCommands, Commands: []string{"go build"}},
+					{Name: "publish", Image: "plugins/docker", Type: backend_types.StepTypePlugin},
+					{Name: "notify", Image: "plugins/slack", Type: backend_types.StepTypePlugin},
+				},
+			}},
+		},
+		StepSecrets: map[string][]string{
+			"build":   {"deploy_key", "docker_token", "missing"},
+			"publish": {"deploy_key", "docker_token"},
+			"notify":  {"docker_token"},
+		},
+	}}
+	secrets := []*model.Secret{
+		{Name: "docker_token", Value: "secret", Images: []string{"plugins/docker"}},
+		{Name: "deploy_key", Value: "secret", Events: []model.WebhookEvent{model.EventDeploy}},
+	}
+
+	// the plugin only secret only reaches the allowed plugin
+	assert.Equal(t, map[string][]string{
+		"release/publish": {"docker_token"},
+	}, AuditSecretExposure(items, secrets, model.EventPush))
+
+	assert.Equal(t, map[string][]string{
+		"release/build":   {"deploy_key"},
+		"release/publish": {"deploy_key", "docker_token"},
+	}, AuditSecretExposure(items, secrets, model.EventDeploy))
+}
+
+func TestStepSecrets(t *testing.T) {
+	t.Parallel()
+
+	parsed, err := yaml.ParseString(`
+steps:
+  build:
+    image: golang
+    environment:
+      TOKEN:
+        from_secret: API_Token
+    init:
+      - commands: echo init
+        environment:
+          KEY:
+            from_secret: deploy_key
+  publish:
+    image: plugins/docker
+    settings:
+      password:
+        from_secret: docker_token
+      tags: [latest]
+  test:
+    image: golang
+    commands: go test
+
+services:
+  database:
+    image: postgres
+    settings:
+      password:
+        from_secret: db_password
+    secrets: [ db_user ]
+`)
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string][]string{
+		"build":        {"api_token"},
+		"build-init-1": {"deploy_key"},
+		"publish":      {"docker_token"},
+		"database":     {"db_user"},
+	}, stepSecrets(parsed))
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/metadata"
+	forge_types "go.woodpecker-ci.org/woodpecker/v2/server/forge/types"
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+)
+
+type WorkflowChangeType string
+
+const (
+	WorkflowAdded   WorkflowChangeType = "added"
+	WorkflowRemoved WorkflowChangeType = "removed"
+	WorkflowChanged WorkflowChangeType = "changed"
+	WorkflowRenamed WorkflowChangeType = "renamed"
+)
+
+// WorkflowChange describes a workflow file that differs between two config versions.
+type WorkflowChange struct {
+	Type WorkflowChangeType
+	Name string
+	// OldName is the previous name of a renamed workflow.
+	OldName string
+}
+
+// ChangedWorkflows compares two versions of the workflow files by the hash of their
+// substituted content. Files are matched by name, a removed and an added file with
+// the same content are reported as a rename. Unchanged workflows are not returned.
+func (b *StepBuilder) ChangedWorkflows(oldYamls, newYamls []*forge_types.FileMeta) ([]WorkflowChange, error) {
+	oldHashes, err := b.workflowHashes(oldYamls)
+	if err != nil {
+		return nil, err
+	}
+	newHashes, err := b.workflowHashes(newYamls)
+	if err != nil {
+		return nil, err
+	}
+
+	oldYamls = forge_types.SortByName(oldYamls)
+	newYamls = forge_types.SortByName(newYamls)
+
+	var changes []WorkflowChange
+	renamedFrom := make(map[string]bool)
+	for _, y := range newYamls {
+		name := b.workflowName(y.Name)
+		oldHash, ok := oldHashes[name]
+		if ok {
+			if oldHash != newHashes[name] {
+				changes = append(changes, WorkflowChange{Type: WorkflowChanged, Name: name})
+			}
+			continue
+		}
+
+		change := WorkflowChange{Type: WorkflowAdded, Name: name}
+		for _, old := range oldYamls {
+			oldName := b.workflowName(old.Name)
+			if _, exists := newHashes[oldName]; exists || renamedFrom[oldName] {
+				continue
+			}
+			if oldHashes[oldName] == newHashes[name] {
+				change = WorkflowChange{Type: WorkflowRenamed, Name: name, OldName: oldName}
+				renamedFrom[oldName] = true
+				break
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	for _, y := range oldYamls {
+		name := b.workflowName(y.Name)
+		if _, exists := newHashes[name]; !exists && !renamedFrom[name] {
+			changes = append(changes, WorkflowChange{Type: WorkflowRemoved, Name: name})
+		}
+	}
+
+	return changes, nil
+}
+
+// workflowHashes substitutes the files without workflow specific metadata, so renaming a file keeps its hash.
+func (b *StepBuilder) workflowHashes(yamls []*forge_types.FileMeta) (map[string]string, error) {
+	hashes := make(map[string]string, len(yamls))
+	for _, y := range yamls {
+		workflowMetadata := MetadataFromStruct(b.Forge, b.Repo, b.Curr, b.Last, &model.Workflow{}, b.Host)
+		workflowMetadata.ConfigSource = b.ConfigSource
+
+		substituted, err := metadata.EnvVarSubst(string(y.Data), b.environmentVariables(workflowMetadata, nil))
+		if err != nil {
+			return nil, err
+		}
+		hashes[b.workflowName(y.Name)] = model.ConfigHash([]byte(substituted))
+	}
+	return hashes, nil
+}
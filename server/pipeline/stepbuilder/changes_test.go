@@ -0,0 +1,69 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	forge_types "go.woodpecker-ci.org/woodpecker/v2/server/forge/types"
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+)
+
+func TestChangedWorkflows(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last: &model.Pipeline{},
+		Envs: map[string]string{"IMAGE": "golang"},
+	}
+
+	lint := []byte("steps:\n  lint:\n    image: ${IMAGE}\n")
+	test := []byte("steps:\n  test:\n    image: golang\n")
+	deploy := []byte("steps:\n  deploy:\n    image: alpine\n")
+
+	oldYamls := []*forge_types.FileMeta{
+		{Name: ".woodpecker/lint.yaml", Data: lint},
+		{Name: ".woodpecker/test.yaml", Data: test},
+		{Name: ".woodpecker/deploy.yaml", Data: deploy},
+		{Name: ".woodpecker/release.yaml", Data: []byte("steps:\n  release:\n    image: plugins/release\n")},
+	}
+	newYamls := []*forge_types.FileMeta{
+		// unchanged after substitution
+		{Name: ".woodpecker/lint.yaml", Data: []byte("steps:\n  lint:\n    image: golang\n")},
+		{Name: ".woodpecker/test.yaml", Data: []byte("steps:\n  test:\n    image: golang:1.22\n")},
+		{Name: ".woodpecker/publish.yaml", Data: deploy},
+		{Name: ".woodpecker/docs.yaml", Data: []byte("steps:\n  docs:\n    image: node\n")},
+	}
+
+	changes, err := b.ChangedWorkflows(oldYamls, newYamls)
+	assert.NoError(t, err)
+	assert.Equal(t, []WorkflowChange{
+		{Type: WorkflowAdded, Name: "docs"},
+		{Type: WorkflowRenamed, Name: "publish", OldName: "deploy"},
+		{Type: WorkflowChanged, Name: "test"},
+		{Type: WorkflowRemoved, Name: "release"},
+	}, changes)
+
+	changes, err = b.ChangedWorkflows(oldYamls, oldYamls)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
@@ -0,0 +1,221 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	yaml_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/types"
+)
+
+type DependencyLevel string
+
+const (
+	DependencyLevelWorkflow DependencyLevel = "workflow"
+	DependencyLevelStep     DependencyLevel = "step"
+)
+
+// ErrDependencyCycle is returned if workflows or the steps of a workflow depend on each other.
+type ErrDependencyCycle struct {
+	Level DependencyLevel
+	// Workflow is the workflow containing the steps of a step level cycle.
+	Workflow string
+	// Members of the cycle, each one depends on the next and the last on the first.
+	Members []string
+}
+
+func (err *ErrDependencyCycle) Error() string {
+	cycle := strings.Join(append(slices.Clone(err.Members), err.Members[0]), " -> ")
+	if err.Level == DependencyLevelStep {
+		return fmt.Sprintf("steps of workflow '%s' depend on each other: %s", err.Workflow, cycle)
+	}
+	return fmt.Sprintf("workflows depend on each other: %s", cycle)
+}
+
+func (*ErrDependencyCycle) Is(target error) bool {
+	_, ok := target.(*ErrDependencyCycle)
+	return ok
+}
+
+// ErrDependencyDepth is returned if a depends_on chain of workflows or steps exceeds the max depth.
+type ErrDependencyDepth struct {
+	Level DependencyLevel
+	// Workflow is the workflow containing the steps of a step level chain.
+	Workflow string
+	MaxDepth int
+	// Chain is the deepest chain, each member depends on the next.
+	Chain []string
+}
+
+func (err *ErrDependencyDepth) Error() string {
+	chain := strings.Join(err.Chain, " -> ")
+	if err.Level == DependencyLevelStep {
+		return fmt.Sprintf("steps of workflow '%s' exceed the max dependency depth of %d: %s", err.Workflow, err.MaxDepth, chain)
+	}
+	return fmt.Sprintf("workflows exceed the max dependency depth of %d: %s", err.MaxDepth, chain)
+}
+
+func (*ErrDependencyDepth) Is(target error) bool {
+	_, ok := target.(*ErrDependencyDepth)
+	return ok
+}
+
+// workflowDependencyCycle checks the depends_on graph of all workflows.
+func workflowDependencyCycle(items []*Item) error {
+	graph := make(map[string][]string, len(items))
+	for _, item := range items {
+		graph[item.Workflow.Name] = dependencyNames(item.DependsOn)
+	}
+	if cycle := findCycle(graph); cycle != nil {
+		return &ErrDependencyCycle{Level: DependencyLevelWorkflow, Members: cycle}
+	}
+	return nil
+}
+
+// stepDependencyCycle checks the depends_on graph of the steps of a workflow.
+func stepDependencyCycle(workflowName string, steps []*yaml_types.Container) error {
+	graph := make(map[string][]string, len(steps))
+	for _, step := range steps {
+		graph[step.Name] = step.DependsOn
+	}
+	if cycle := findCycle(graph); cycle != nil {
+		return &ErrDependencyCycle{Level: DependencyLevelStep, Workflow: workflowName, Members: cycle}
+	}
+	return nil
+}
+
+// workflowDependencyDepth checks the depth of the depends_on graph of all workflows. It
+// has to be called after workflowDependencyCycle. A max depth of 0 means unlimited.
+func workflowDependencyDepth(items []*Item, maxDepth int) error {
+	if maxDepth <= 0 {
+		return nil
+	}
+	graph := make(map[string][]string, len(items))
+	for _, item := range items {
+		graph[item.Workflow.Name] = dependencyNames(item.DependsOn)
+	}
+	if chain := longestChain(graph); len(chain)-1 > maxDepth {
+		return &ErrDependencyDepth{Level: DependencyLevelWorkflow, MaxDepth: maxDepth, Chain: chain}
+	}
+	return nil
+}
+
+// stepDependencyDepth checks the depth of the depends_on graph of the steps of a workflow.
+// It has to be called after stepDependencyCycle. A max depth of 0 means unlimited.
+func stepDependencyDepth(workflowName string, steps []*yaml_types.Container, maxDepth int) error {
+	if maxDepth <= 0 {
+		return nil
+	}
+	graph := make(map[string][]string, len(steps))
+	for _, step := range steps {
+		graph[step.Name] = step.DependsOn
+	}
+	if chain := longestChain(graph); len(chain)-1 > maxDepth {
+		return &ErrDependencyDepth{Level: DependencyLevelStep, Workflow: workflowName, MaxDepth: maxDepth, Chain: chain}
+	}
+	return nil
+}
+
+// longestChain returns the longest depends_on chain of an acyclic graph, its critical path.
+// Dependencies on unknown nodes are ignored.
+func longestChain(graph map[string][]string) []string {
+	chains := make(map[string][]string, len(graph))
+
+	var chainOf func(node string) []string
+	chainOf = func(node string) []string {
+		if chain, ok := chains[node]; ok {
+			return chain
+		}
+		var longest []string
+		for _, dep := range graph[node] {
+			if _, ok := graph[dep]; !ok {
+				continue
+			}
+			if chain := chainOf(dep); len(chain) > len(longest) {
+				longest = chain
+			}
+		}
+		chain := append([]string{node}, longest...)
+		chains[node] = chain
+		return chain
+	}
+
+	// visit the nodes in a stable order to always report the same chain
+	nodes := make([]string, 0, len(graph))
+	for node := range graph {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	var longest []string
+	for _, node := range nodes {
+		if chain := chainOf(node); len(chain) > len(longest) {
+			longest = chain
+		}
+	}
+	return longest
+}
+
+// findCycle returns the members of the first cycle found in the graph. Dependencies
+// on unknown nodes are ignored, they are reported by the dependency checks.
+func findCycle(graph map[string][]string) []string {
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[string]int, len(graph))
+	var path []string
+
+	var visit func(node string) []string
+	visit = func(node string) []string {
+		switch state[node] {
+		case visiting:
+			return slices.Clone(path[slices.Index(path, node):])
+		case visited:
+			return nil
+		}
+
+		state[node] = visiting
+		path = append(path, node)
+		for _, dep := range graph[node] {
+			if _, ok := graph[dep]; !ok {
+				continue
+			}
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[node] = visited
+		return nil
+	}
+
+	// visit the nodes in a stable order to always report the same cycle
+	nodes := make([]string, 0, len(graph))
+	for node := range graph {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	for _, node := range nodes {
+		if cycle := visit(node); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
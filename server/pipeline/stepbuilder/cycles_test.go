@@ -0,0 +1,153 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	forge_types "go.woodpecker-ci.org/woodpecker/v2/server/forge/types"
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+)
+
+func TestDependencyCycles(t *testing.T) {
+	t.Parallel()
+
+	newBuilder := func(yamls ...*forge_types.FileMeta) StepBuilder {
+		return StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event: model.EventPush,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Yamls: yamls,
+		}
+	}
+
+	// workflow -> workflow
+	b := newBuilder(
+		&forge_types.FileMeta{Name: ".woodpecker/build.yaml", Data: []byte("steps:\n  build:\n    image: golang\ndepends_on: [ test ]\n")},
+		&forge_types.FileMeta{Name: ".woodpecker/lint.yaml", Data: []byte("steps:\n  lint:\n    image: golang\n")},
+		&forge_types.FileMeta{Name: ".woodpecker/test.yaml", Data: []byte("steps:\n  test:\n    image: golang\ndepends_on: [ lint, build ]\n")},
+	)
+	_, err := b.Build()
+	assert.ErrorIs(t, err, &ErrDependencyCycle{})
+	assert.EqualError(t, err, "workflows depend on each other: build -> test -> build")
+
+	// step -> step
+	b = newBuilder(&forge_types.FileMeta{Name: ".woodpecker/build.yaml", Data: []byte(`
+when:
+  event: push
+steps:
+  lint:
+    image: golang
+  build:
+    image: golang
+    depends_on: [ lint, package ]
+  package:
+    image: golang
+    depends_on: [ build ]
+`)})
+	_, err = b.Build()
+	assert.ErrorIs(t, err, &ErrDependencyCycle{})
+	assert.EqualError(t, err, "steps of workflow 'build' depend on each other: build -> package -> build")
+
+	// workflow -> itself
+	b = newBuilder(
+		&forge_types.FileMeta{Name: ".woodpecker/build.yaml", Data: []byte("steps:\n  build:\n    image: golang\ndepends_on: [ build ]\n")},
+	)
+	_, err = b.Build()
+	assert.ErrorIs(t, err, &ErrDependencyCycle{})
+	assert.EqualError(t, err, "workflows depend on each other: build -> build")
+
+	// no cycle
+	b = newBuilder(
+		&forge_types.FileMeta{Name: ".woodpecker/build.yaml", Data: []byte("when:\n  event: push\nsteps:\n  build:\n    image: golang\n")},
+		&forge_types.FileMeta{Name: ".woodpecker/test.yaml", Data: []byte("when:\n  event: push\nsteps:\n  test:\n    image: golang\ndepends_on: [ build ]\n")},
+	)
+	_, err = b.Build()
+	assert.NoError(t, err)
+}
+
+func TestFindCycle(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, findCycle(map[string][]string{"a": {"b"}, "b": {"c"}, "c": nil}))
+	// unknown dependencies are ignored
+	assert.Nil(t, findCycle(map[string][]string{"a": {"unknown"}}))
+	assert.Equal(t, []string{"a"}, findCycle(map[string][]string{"a": {"a"}}))
+	assert.Equal(t, []string{"b", "c", "d"}, findCycle(map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"d"}, "d": {"b"}}))
+}
+
+func TestDependencyDepth(t *testing.T) {
+	t.Parallel()
+
+	newBuilder := func(maxDepth int, yamls ...*forge_types.FileMeta) StepBuilder {
+		return StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event: model.EventPush,
+			},
+			Last:               &model.Pipeline{},
+			Netrc:              &model.Netrc{},
+			MaxDependencyDepth: maxDepth,
+			Yamls:              yamls,
+		}
+	}
+
+	steps := &forge_types.FileMeta{Name: ".woodpecker/build.yaml", Data: []byte(`
+when:
+  event: push
+steps:
+  lint:
+    image: golang
+    depends_on: []
+  build:
+    image: golang
+    depends_on: [ lint ]
+  package:
+    image: golang
+    depends_on: [ build ]
+  publish:
+    image: golang
+    depends_on: [ package, lint ]
+`)}
+
+	b := newBuilder(2, steps)
+	_, err := b.Build()
+	assert.ErrorIs(t, err, &ErrDependencyDepth{})
+	assert.EqualError(t, err, "steps of workflow 'build' exceed the max dependency depth of 2: publish -> package -> build -> lint")
+
+	b = newBuilder(3, steps)
+	_, err = b.Build()
+	assert.NoError(t, err)
+
+	// unlimited by default
+	b = newBuilder(0, steps)
+	_, err = b.Build()
+	assert.NoError(t, err)
+
+	b = newBuilder(1,
+		&forge_types.FileMeta{Name: ".woodpecker/a.yaml", Data: []byte("when:\n  event: push\nsteps:\n  a:\n    image: golang\n")},
+		&forge_types.FileMeta{Name: ".woodpecker/b.yaml", Data: []byte("when:\n  event: push\nsteps:\n  b:\n    image: golang\ndepends_on: [ a ]\n")},
+		&forge_types.FileMeta{Name: ".woodpecker/c.yaml", Data: []byte("when:\n  event: push\nsteps:\n  c:\n    image: golang\ndepends_on: [ b ]\n")},
+	)
+	_, err = b.Build()
+	assert.EqualError(t, err, "workflows exceed the max dependency depth of 1: c -> b -> a")
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"fmt"
+	"strings"
+
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/matrix"
+)
+
+// parseDependency splits a workflow dependency into the workflow name and the matrix axis it
+// references, e.g. "test[GO=1.21,OS=linux]". Dependencies without an axis reference all axes.
+func parseDependency(dep string) (string, matrix.Axis, error) {
+	name, rest, found := strings.Cut(dep, "[")
+	if !found {
+		return dep, nil, nil
+	}
+	values, ok := strings.CutSuffix(rest, "]")
+	if !ok || name == "" || values == "" {
+		return "", nil, fmt.Errorf("invalid dependency '%s', use the format name[KEY=value,...]", dep)
+	}
+
+	axis := matrix.Axis{}
+	for _, pair := range strings.Split(values, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return "", nil, fmt.Errorf("invalid dependency '%s', use the format name[KEY=value,...]", dep)
+		}
+		axis[key] = strings.TrimSpace(value)
+	}
+	return name, axis, nil
+}
+
+// dependencyName returns the name of the workflow a dependency references.
+func dependencyName(dep string) string {
+	name, _, _ := strings.Cut(dep, "[")
+	return name
+}
+
+// MatchesDependency returns true if the item is a workflow the dependency references.
+// Invalid dependencies don't match any item.
+func MatchesDependency(item *Item, dep string) bool {
+	name, axis, err := parseDependency(dep)
+	if err != nil || item.Workflow.Name != name {
+		return false
+	}
+	for k, v := range axis {
+		if value, ok := item.Workflow.Environ[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+// validateDependency checks that a dependency is valid and that its axis reference
+// doesn't match multiple axes of the workflow.
+func validateDependency(dep string, items []*Item) error {
+	_, axis, err := parseDependency(dep)
+	if err != nil || axis == nil {
+		return err
+	}
+	var matches int
+	for _, item := range items {
+		if MatchesDependency(item, dep) {
+			matches++
+		}
+	}
+	if matches > 1 {
+		return fmt.Errorf("dependency '%s' is ambiguous, it matches %d matrix axes", dep, matches)
+	}
+	return nil
+}
+
+// dependencyNames returns the names of the workflows the dependencies reference,
+// used by the depends_on graph of the workflows.
+func dependencyNames(deps []string) []string {
+	if deps == nil {
+		return nil
+	}
+	names := make([]string, 0, len(deps))
+	for _, dep := range deps {
+		names = append(names, dependencyName(dep))
+	}
+	return names
+}
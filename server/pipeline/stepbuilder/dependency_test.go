@@ -0,0 +1,53 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/matrix"
+)
+
+func TestParseDependency(t *testing.T) {
+	t.Parallel()
+
+	testdata := []struct {
+		dep  string
+		name string
+		axis matrix.Axis
+		err  bool
+	}{
+		{dep: "test", name: "test"},
+		{dep: "test[GO=1.21]", name: "test", axis: matrix.Axis{"GO": "1.21"}},
+		{dep: "test[GO=1.21, OS=linux]", name: "test", axis: matrix.Axis{"GO": "1.21", "OS": "linux"}},
+		{dep: "test[GO=]", name: "test", axis: matrix.Axis{"GO": ""}},
+		{dep: "test[GO=1.21", err: true},
+		{dep: "test[]", err: true},
+		{dep: "test[GO]", err: true},
+		{dep: "[GO=1.21]", err: true},
+	}
+	for _, test := range testdata {
+		name, axis, err := parseDependency(test.dep)
+		if test.err {
+			assert.Error(t, err, test.dep)
+			continue
+		}
+		assert.NoError(t, err, test.dep)
+		assert.Equal(t, test.name, name, test.dep)
+		assert.Equal(t, test.axis, axis, test.dep)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/distribution/reference"
+
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml"
+)
+
+// timeVariables matches references to the environment variables holding timestamps.
+var timeVariables = regexp.MustCompile(`\$\{?(CI_(?:PREV_)?PIPELINE_(?:CREATED|STARTED|FINISHED)|CI_STEP_(?:STARTED|FINISHED))\b`)
+
+// DeterminismReport flags sources of non-determinism in the steps of the items, to help with reproducible
+// builds: images which use the latest tag or aren't pinned by a digest and commands depending on the time.
+// The result maps "<workflow>/<step>" to the findings, steps without findings are omitted.
+func DeterminismReport(items []*Item) map[string][]string {
+	report := make(map[string][]string)
+	for _, item := range items {
+		if item.Config == nil {
+			continue
+		}
+		for _, stage := range item.Config.Stages {
+			for _, step := range stage.Steps {
+				if findings := item.Determinism[step.Name]; len(findings) > 0 {
+					report[fmt.Sprintf("%s/%s", item.Workflow.Name, step.Name)] = findings
+				}
+			}
+		}
+	}
+	return report
+}
+
+// stepDeterminism returns the findings of the steps of the config before substitution, as the
+// substitution replaces references to timestamps by their values.
+func stepDeterminism(data string) map[string][]string {
+	parsed, err := yaml.ParseString(data)
+	if err != nil {
+		// configs which only become valid yaml after the substitution can't be checked
+		return nil
+	}
+
+	findings := make(map[string][]string)
+	for _, step := range parsed.Steps.ContainerList {
+		var stepFindings []string
+		if finding := imageDeterminism(step.Image); finding != "" {
+			stepFindings = append(stepFindings, finding)
+		}
+		stepFindings = append(stepFindings, commandsDeterminism(step.Commands)...)
+		if len(stepFindings) > 0 {
+			findings[step.Name] = stepFindings
+		}
+	}
+	return findings
+}
+
+// imageDeterminism returns a finding if the image can change without changing its reference.
+func imageDeterminism(image string) string {
+	if strings.Contains(image, "${") {
+		// the reference is only known after the substitution
+		if strings.Contains(image, "@sha256:") {
+			return ""
+		}
+		return fmt.Sprintf("image '%s' depends on variables and is not pinned by a digest", image)
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return fmt.Sprintf("image '%s' is no valid reference", image)
+	}
+	if _, ok := named.(reference.Digested); ok {
+		return ""
+	}
+	if tagged, ok := named.(reference.Tagged); !ok || tagged.Tag() == "latest" {
+		return fmt.Sprintf("image '%s' uses the latest tag", image)
+	}
+	return fmt.Sprintf("image '%s' is not pinned by a digest", image)
+}
+
+// commandsDeterminism returns findings for commands which depend on the time.
+func commandsDeterminism(commands []string) []string {
+	variables := make(map[string]bool)
+	usesDate := false
+	for _, command := range commands {
+		for _, match := range timeVariables.FindAllStringSubmatch(command, -1) {
+			variables[match[1]] = true
+		}
+		usesDate = usesDate || strings.Contains(command, "$(date") || strings.Contains(command, "`date")
+	}
+
+	var findings []string
+	for variable := range variables {
+		findings = append(findings, fmt.Sprintf("commands reference the timestamp '%s'", variable))
+	}
+	sort.Strings(findings)
+	if usesDate {
+		findings = append(findings, "commands use the current date")
+	}
+	return findings
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	forge_types "go.woodpecker-ci.org/woodpecker/v2/server/forge/types"
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+)
+
+func TestDeterminismReport(t *testing.T) {
+	t.Parallel()
+
+	const digest = "sha256:2b3d1a3d1e3f1e9e2d0a5b8c7d6e5f4a3b2c1d0e9f8a7b6c5d4e3f2a1b0c9d8e"
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Yamls: []*forge_types.FileMeta{
+			{Name: "release", Data: []byte(`
+when:
+  event: [push, tag]
+skip_clone: true
+steps:
+  latest:
+    image: golang:latest
+    commands: go build
+  untagged:
+    image: golang
+  tagged:
+    image: golang:1.22
+  pinned:
+    image: golang@` + digest + `
+    commands: go build
+  pinned-tag:
+    image: golang:1.22@` + digest + `
+  variable:
+    image: golang:${GO_VERSION:-1.22}
+  stamp:
+    image: golang@` + digest + `
+    commands:
+      - go build -ldflags "-X main.built=${CI_PIPELINE_STARTED}"
+      - echo $CI_PIPELINE_STARTED $(date +%s) > stamp
+  tag-only:
+    image: golang:latest
+    when:
+      event: tag
+`)},
+		},
+	}
+
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"release/latest":   {"image 'golang:latest' uses the latest tag"},
+		"release/untagged": {"image 'golang' uses the latest tag"},
+		"release/tagged":   {"image 'golang:1.22' is not pinned by a digest"},
+		"release/variable": {"image 'golang:${GO_VERSION:-1.22}' depends on variables and is not pinned by a digest"},
+		"release/stamp": {
+			"commands reference the timestamp 'CI_PIPELINE_STARTED'",
+			"commands use the current date",
+		},
+	}, DeterminismReport(pipelineItems))
+}
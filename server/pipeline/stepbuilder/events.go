@@ -0,0 +1,41 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	pipeline_errors "go.woodpecker-ci.org/woodpecker/v2/pipeline/errors"
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+)
+
+// ValidateEvents builds the pipeline once for each of the events, to catch configs which
+// only fail for some events. It returns the blocking errors by event, events the pipeline
+// builds for map to nil. Warnings are ignored.
+func (b *StepBuilder) ValidateEvents(events ...model.WebhookEvent) map[model.WebhookEvent]error {
+	results := make(map[model.WebhookEvent]error, len(events))
+	for _, event := range events {
+		curr := *b.Curr
+		curr.Event = event
+
+		eventBuilder := *b
+		eventBuilder.Curr = &curr
+
+		_, err := eventBuilder.Build()
+		if !pipeline_errors.HasBlockingErrors(err) {
+			err = nil
+		}
+		results[event] = err
+	}
+	return results
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	forge_types "go.woodpecker-ci.org/woodpecker/v2/server/forge/types"
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+)
+
+func TestValidateEvents(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs: []*model.Secret{{
+			Name:   "docker_token",
+			Value:  "secret",
+			Events: []model.WebhookEvent{model.EventPush, model.EventManual},
+		}},
+		Regs: []*model.Registry{},
+		Host: "",
+		Yamls: []*forge_types.FileMeta{
+			{Name: "publish", Data: []byte(`
+when:
+  event: [ push, tag, manual ]
+steps:
+  publish:
+    image: plugins/docker
+    settings:
+      password:
+        from_secret: docker_token
+`)},
+		},
+	}
+
+	results := b.ValidateEvents(model.EventPush, model.EventTag, model.EventManual)
+	assert.Len(t, results, 3)
+	assert.NoError(t, results[model.EventPush])
+	assert.NoError(t, results[model.EventManual])
+	assert.ErrorContains(t, results[model.EventTag], `secret "docker_token" is not allowed to be used with pipeline event "tag"`)
+	// the builder itself is unchanged
+	assert.Equal(t, model.EventPush, b.Curr.Event)
+}
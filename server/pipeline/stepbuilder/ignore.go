@@ -0,0 +1,60 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// parseIgnoreFile returns the path patterns of an ignore file. Empty lines and lines
+// starting with # are skipped, a trailing slash matches everything inside the directory.
+func parseIgnoreFile(content string) []string {
+	var patterns []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "/")
+		if strings.HasSuffix(line, "/") {
+			line += "**"
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns
+}
+
+// changesIgnored reports whether all changed files of the pipeline match a pattern of the
+// ignore file. Pipelines without known changed files are never ignored.
+func (b *StepBuilder) changesIgnored() bool {
+	patterns := parseIgnoreFile(b.IgnoreFile)
+	if len(patterns) == 0 || len(b.Curr.ChangedFiles) == 0 {
+		return false
+	}
+
+	for _, file := range b.Curr.ChangedFiles {
+		ignored := slices.ContainsFunc(patterns, func(pattern string) bool {
+			ok, _ := doublestar.Match(pattern, file)
+			return ok
+		})
+		if !ignored {
+			return false
+		}
+	}
+	return true
+}
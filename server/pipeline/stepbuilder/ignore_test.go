@@ -0,0 +1,78 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	forge_types "go.woodpecker-ci.org/woodpecker/v2/server/forge/types"
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+)
+
+func TestIgnoreFile(t *testing.T) {
+	t.Parallel()
+
+	ignoreFile := `
+# documentation only
+docs/
+*.md
+`
+
+	// builds skipped by the ignore file don't use the forge
+	forge := getMockForge(t)
+	newBuilder := func(changedFiles ...string) StepBuilder {
+		return StepBuilder{
+			Forge: forge,
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event:        model.EventPush,
+				ChangedFiles: changedFiles,
+			},
+			Last:       &model.Pipeline{},
+			Netrc:      &model.Netrc{},
+			Secs:       []*model.Secret{},
+			Regs:       []*model.Registry{},
+			Host:       "",
+			IgnoreFile: ignoreFile,
+			Yamls: []*forge_types.FileMeta{
+				{Name: "build", Data: []byte(`
+when:
+  event: push
+steps:
+  build:
+    image: golang
+`)},
+			},
+		}
+	}
+
+	b := newBuilder("README.md", "docs/usage/intro.md", "docs/assets/logo.svg")
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	assert.Empty(t, pipelineItems)
+
+	b = newBuilder("README.md", "main.go")
+	pipelineItems, err = b.Build()
+	assert.NoError(t, err)
+	assert.Len(t, pipelineItems, 1)
+
+	// unknown changes are never ignored
+	b = newBuilder()
+	pipelineItems, err = b.Build()
+	assert.NoError(t, err)
+	assert.Len(t, pipelineItems, 1)
+}
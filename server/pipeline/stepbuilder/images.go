@@ -0,0 +1,49 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"fmt"
+	"strings"
+
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/metadata"
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml"
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/matrix"
+)
+
+// checkImageVariables makes sure the images of all steps get resolved by the substitution.
+// Substitution replaces unknown variables by an empty string, which would silently result in
+// images like "golang:" if a matrix axis doesn't match the placeholder of the image.
+func checkImageVariables(data string, environ map[string]string, axis matrix.Axis) error {
+	// configs which only become valid yaml after the substitution are checked by the parser later
+	parsed, err := yaml.ParseString(data)
+	if err != nil {
+		return nil
+	}
+
+	for _, step := range parsed.Steps.ContainerList {
+		unresolved := metadata.UnknownVariables(step.Image, environ)
+		if len(unresolved) == 0 {
+			continue
+		}
+
+		if len(axis) != 0 {
+			return fmt.Errorf("image '%s' of step '%s' uses unknown variables %s for matrix axis %s",
+				step.Image, step.Name, strings.Join(unresolved, ", "), axis.Label())
+		}
+		return fmt.Errorf("image '%s' of step '%s' uses unknown variables %s", step.Image, step.Name, strings.Join(unresolved, ", "))
+	}
+	return nil
+}
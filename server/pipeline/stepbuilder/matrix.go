@@ -0,0 +1,69 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"strconv"
+
+	yaml_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/types"
+)
+
+const (
+	matrixPositionFirst = "first"
+	matrixPositionLast  = "last"
+)
+
+// matrixEnviron returns the position of the workflow instance in its matrix,
+// the index starts at 1. Workflows without a matrix have no position.
+func matrixEnviron(axisID, axisCount int) map[string]string {
+	if axisID == 0 {
+		return nil
+	}
+	return map[string]string{
+		"CI_MATRIX_INDEX": strconv.Itoa(axisID),
+		"CI_MATRIX_TOTAL": strconv.Itoa(axisCount),
+	}
+}
+
+// matrixPosition tells if a workflow instance is the first or the last one of its matrix
+// to run, instances skipped by their when filters don't count.
+type matrixPosition struct {
+	first, last bool
+}
+
+// newMatrixPosition returns the position of the instance of the axis, matching are the
+// indexes of the axes matching their when filters.
+func newMatrixPosition(axis int, matching []int) matrixPosition {
+	if len(matching) == 0 {
+		return matrixPosition{}
+	}
+	return matrixPosition{
+		first: axis == matching[0],
+		last:  axis == matching[len(matching)-1],
+	}
+}
+
+// matrixPositionSkipped returns the names of the steps which only run in the first or last
+// instance of a matrix and are skipped in all other instances.
+func matrixPositionSkipped(steps []*yaml_types.Container, position matrixPosition) []string {
+	var skipped []string
+	for _, step := range steps {
+		if (step.MatrixPosition == matrixPositionFirst && !position.first) ||
+			(step.MatrixPosition == matrixPositionLast && !position.last) {
+			skipped = append(skipped, step.Name)
+		}
+	}
+	return skipped
+}
@@ -17,6 +17,7 @@ package stepbuilder
 import (
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/metadata"
@@ -91,6 +92,19 @@ func MetadataFromStruct(forge metadata.ServerForge, repo *model.Repo, pipeline,
 	}
 }
 
+// workflowMetadata returns the metadata of the workflow, with the remaining forge API
+// quota of the pipeline user if the forge reports it.
+func (b *StepBuilder) workflowMetadata(workflow *model.Workflow) metadata.Metadata {
+	m := MetadataFromStruct(b.Forge, b.Repo, b.Curr, b.Last, workflow, b.Host)
+	m.ConfigSource = b.ConfigSource
+	if rateLimitForge, ok := b.Forge.(metadata.RateLimitForge); ok && b.ForgeToken != "" {
+		if remaining, known := rateLimitForge.RateLimitRemaining(b.ForgeToken); known {
+			m.Forge.RateLimitRemaining = strconv.Itoa(remaining)
+		}
+	}
+	return m
+}
+
 func metadataPipelineFromModelPipeline(pipeline *model.Pipeline, includeParent bool) metadata.Pipeline {
 	if pipeline == nil {
 		return metadata.Pipeline{}
@@ -127,10 +141,13 @@ func metadataPipelineFromModelPipeline(pipeline *model.Pipeline, includeParent b
 				Email:  pipeline.Email,
 				Avatar: pipeline.Avatar,
 			},
-			ChangedFiles:      pipeline.ChangedFiles,
-			PullRequestLabels: pipeline.PullRequestLabels,
-			IsPrerelease:      pipeline.IsPrerelease,
+			ChangedFiles:        pipeline.ChangedFiles,
+			ChangedFilesUnknown: pipeline.ChangedFilesUnknown,
+			PullRequestLabels:   pipeline.PullRequestLabels,
+			IsPrerelease:        pipeline.IsPrerelease,
 		},
-		Cron: cron,
+		Cron:      cron,
+		EventHash: pipeline.EventHash,
+		TraceID:   pipeline.TraceID,
 	}
 }
@@ -46,8 +46,9 @@ func TestMetadataFromStruct(t *testing.T) {
 				"CI":               "woodpecker",
 				"CI_COMMIT_AUTHOR": "", "CI_COMMIT_AUTHOR_AVATAR": "", "CI_COMMIT_AUTHOR_EMAIL": "", "CI_COMMIT_BRANCH": "",
 				"CI_COMMIT_MESSAGE": "", "CI_COMMIT_PULL_REQUEST": "", "CI_COMMIT_PULL_REQUEST_LABELS": "", "CI_COMMIT_REF": "", "CI_COMMIT_REFSPEC": "", "CI_COMMIT_SHA": "", "CI_COMMIT_SOURCE_BRANCH": "",
-				"CI_COMMIT_TAG": "", "CI_COMMIT_TARGET_BRANCH": "", "CI_COMMIT_URL": "", "CI_FORGE_TYPE": "", "CI_FORGE_URL": "",
-				"CI_PIPELINE_CREATED": "0", "CI_PIPELINE_DEPLOY_TARGET": "", "CI_PIPELINE_DEPLOY_TASK": "", "CI_PIPELINE_EVENT": "", "CI_PIPELINE_FINISHED": "0", "CI_PIPELINE_FILES": "[]", "CI_PIPELINE_NUMBER": "0",
+				"CI_COMMIT_TAG": "", "CI_COMMIT_TARGET_BRANCH": "", "CI_COMMIT_URL": "", "CI_FORGE_TYPE": "", "CI_FORGE_URL": "", "CI_FORGE_RATELIMIT_REMAINING": "",
+				"CI_CONFIG_SOURCE_REPO": "", "CI_CONFIG_SOURCE_SHA": "",
+				"CI_PIPELINE_CREATED": "0", "CI_PIPELINE_DEPLOY_TARGET": "", "CI_PIPELINE_DEPLOY_TASK": "", "CI_PIPELINE_EVENT": "", "CI_PIPELINE_EVENT_HASH": "", "CI_PIPELINE_TRACE_ID": "", "CI_PIPELINE_FINISHED": "0", "CI_PIPELINE_FILES": "[]", "CI_PIPELINE_NUMBER": "0",
 				"CI_PIPELINE_PARENT": "0", "CI_PIPELINE_STARTED": "0", "CI_PIPELINE_STATUS": "", "CI_PIPELINE_URL": "/repos/0/pipeline/0", "CI_PIPELINE_FORGE_URL": "",
 				"CI_PREV_COMMIT_AUTHOR": "", "CI_PREV_COMMIT_AUTHOR_AVATAR": "", "CI_PREV_COMMIT_AUTHOR_EMAIL": "", "CI_PREV_COMMIT_BRANCH": "",
 				"CI_PREV_COMMIT_MESSAGE": "", "CI_PREV_COMMIT_REF": "", "CI_PREV_COMMIT_REFSPEC": "", "CI_PREV_COMMIT_SHA": "", "CI_PREV_COMMIT_URL": "", "CI_PREV_PIPELINE_CREATED": "0",
@@ -81,8 +82,9 @@ func TestMetadataFromStruct(t *testing.T) {
 				"CI":               "woodpecker",
 				"CI_COMMIT_AUTHOR": "", "CI_COMMIT_AUTHOR_AVATAR": "", "CI_COMMIT_AUTHOR_EMAIL": "", "CI_COMMIT_BRANCH": "",
 				"CI_COMMIT_MESSAGE": "", "CI_COMMIT_PULL_REQUEST": "", "CI_COMMIT_PULL_REQUEST_LABELS": "", "CI_COMMIT_REF": "", "CI_COMMIT_REFSPEC": "", "CI_COMMIT_SHA": "", "CI_COMMIT_SOURCE_BRANCH": "",
-				"CI_COMMIT_TAG": "", "CI_COMMIT_TARGET_BRANCH": "", "CI_COMMIT_URL": "", "CI_FORGE_TYPE": "gitea", "CI_FORGE_URL": "https://gitea.com",
-				"CI_PIPELINE_CREATED": "0", "CI_PIPELINE_DEPLOY_TARGET": "", "CI_PIPELINE_DEPLOY_TASK": "", "CI_PIPELINE_EVENT": "", "CI_PIPELINE_FINISHED": "0", "CI_PIPELINE_FILES": `["test.go","markdown file.md"]`,
+				"CI_COMMIT_TAG": "", "CI_COMMIT_TARGET_BRANCH": "", "CI_COMMIT_URL": "", "CI_FORGE_TYPE": "gitea", "CI_FORGE_URL": "https://gitea.com", "CI_FORGE_RATELIMIT_REMAINING": "",
+				"CI_CONFIG_SOURCE_REPO": "testUser/testRepo", "CI_CONFIG_SOURCE_SHA": "",
+				"CI_PIPELINE_CREATED": "0", "CI_PIPELINE_DEPLOY_TARGET": "", "CI_PIPELINE_DEPLOY_TASK": "", "CI_PIPELINE_EVENT": "", "CI_PIPELINE_EVENT_HASH": "", "CI_PIPELINE_TRACE_ID": "", "CI_PIPELINE_FINISHED": "0", "CI_PIPELINE_FILES": `["test.go","markdown file.md"]`,
 				"CI_PIPELINE_NUMBER": "3", "CI_PIPELINE_PARENT": "0", "CI_PIPELINE_STARTED": "0", "CI_PIPELINE_STATUS": "", "CI_PIPELINE_URL": "https://example.com/repos/0/pipeline/3", "CI_PIPELINE_FORGE_URL": "",
 				"CI_PREV_COMMIT_AUTHOR": "", "CI_PREV_COMMIT_AUTHOR_AVATAR": "", "CI_PREV_COMMIT_AUTHOR_EMAIL": "", "CI_PREV_COMMIT_BRANCH": "",
 				"CI_PREV_COMMIT_MESSAGE": "", "CI_PREV_COMMIT_REF": "", "CI_PREV_COMMIT_REFSPEC": "", "CI_PREV_COMMIT_SHA": "", "CI_PREV_COMMIT_URL": "", "CI_PREV_PIPELINE_CREATED": "0",
@@ -104,3 +106,70 @@ func TestMetadataFromStruct(t *testing.T) {
 		})
 	}
 }
+
+func TestEventHash(t *testing.T) {
+	payload := `{"ref":"refs/heads/main","after":"6fb5e9a1"}`
+
+	hash := EventHash([]byte(payload))
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, EventHash([]byte(payload)))
+	assert.NotEqual(t, hash, EventHash([]byte(`{"ref":"refs/heads/main","after":"0ab5c21d"}`)))
+	assert.Empty(t, EventHash(nil))
+
+	m := MetadataFromStruct(nil, &model.Repo{}, &model.Pipeline{EventHash: hash}, nil, &model.Workflow{}, "")
+	assert.Equal(t, hash, m.Environ()["CI_PIPELINE_EVENT_HASH"])
+}
+
+func TestWorkflowMatrixEnviron(t *testing.T) {
+	m := MetadataFromStruct(nil, &model.Repo{}, &model.Pipeline{}, nil, &model.Workflow{
+		Environ: map[string]string{"GO": "1.21", "db-version": "16"},
+	}, "")
+	environ := m.Environ()
+	assert.Equal(t, `{"GO":"1.21","db-version":"16"}`, environ["CI_WORKFLOW_MATRIX"])
+	assert.Equal(t, "1.21", environ["CI_WORKFLOW_MATRIX_GO"])
+	assert.Equal(t, "16", environ["CI_WORKFLOW_MATRIX_DB_VERSION"])
+
+	m = MetadataFromStruct(nil, &model.Repo{}, &model.Pipeline{}, nil, &model.Workflow{}, "")
+	environ = m.Environ()
+	assert.NotContains(t, environ, "CI_WORKFLOW_MATRIX")
+}
+
+type rateLimitForge struct {
+	remaining map[string]int
+}
+
+func (f *rateLimitForge) Name() string { return "github" }
+
+func (f *rateLimitForge) URL() string { return "https://github.com" }
+
+func (f *rateLimitForge) RateLimitRemaining(token string) (int, bool) {
+	remaining, ok := f.remaining[token]
+	return remaining, ok
+}
+
+func TestMetadataRateLimit(t *testing.T) {
+	forge := &rateLimitForge{remaining: map[string]int{"alice": 4200, "bob": 10}}
+
+	b := &StepBuilder{Forge: forge, ForgeToken: "alice"}
+	result := b.workflowMetadata(nil)
+	assert.Equal(t, metadata.Forge{Type: "github", URL: "https://github.com", RateLimitRemaining: "4200"}, result.Forge)
+	assert.Equal(t, "4200", result.Environ()["CI_FORGE_RATELIMIT_REMAINING"])
+
+	// the quota is reported per token
+	b.ForgeToken = "bob"
+	result = b.workflowMetadata(nil)
+	assert.Equal(t, "10", result.Environ()["CI_FORGE_RATELIMIT_REMAINING"])
+
+	// unknown for tokens without a response yet
+	b.ForgeToken = "carol"
+	result = b.workflowMetadata(nil)
+	assert.Empty(t, result.Environ()["CI_FORGE_RATELIMIT_REMAINING"])
+
+	// unknown if the forge doesn't report it
+	mockForge := mocks.NewForge(t)
+	mockForge.On("Name").Return("gitea")
+	mockForge.On("URL").Return("https://gitea.com")
+	b = &StepBuilder{Forge: mockForge, ForgeToken: "alice"}
+	result = b.workflowMetadata(nil)
+	assert.Empty(t, result.Environ()["CI_FORGE_RATELIMIT_REMAINING"])
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"fmt"
+	"slices"
+
+	yaml_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/types"
+)
+
+// validateStepOutputs makes sure the outputs steps run depending on are declared by a step running before them.
+func validateStepOutputs(parsed *yaml_types.Workflow) error {
+	steps := parsed.Steps.ContainerList
+	dag := slices.ContainsFunc(steps, func(step *yaml_types.Container) bool { return step.DependsOn != nil })
+
+	for i, step := range steps {
+		for _, output := range step.When.StepOutputs() {
+			j := slices.IndexFunc(steps, func(s *yaml_types.Container) bool { return s.Name == output.Step })
+			if j < 0 {
+				return fmt.Errorf("step '%s' depends on output '%s' of unknown step '%s'", step.Name, output.Key, output.Step)
+			}
+			if !slices.Contains(steps[j].Outputs, output.Key) {
+				return fmt.Errorf("step '%s' depends on output '%s' which step '%s' doesn't declare", step.Name, output.Key, output.Step)
+			}
+
+			runsBefore := j < i && (steps[j].Group == "" || steps[j].Group != step.Group)
+			if dag {
+				runsBefore = stepDependsOn(steps, step.Name, output.Step, map[string]bool{})
+			}
+			if !runsBefore {
+				return fmt.Errorf("step '%s' depends on output '%s' of step '%s' which doesn't run before it", step.Name, output.Key, output.Step)
+			}
+		}
+	}
+	return nil
+}
+
+// stepDependsOn returns whether the step depends directly or indirectly on the other step.
+func stepDependsOn(steps []*yaml_types.Container, name, other string, visited map[string]bool) bool {
+	if visited[name] {
+		return false
+	}
+	visited[name] = true
+
+	i := slices.IndexFunc(steps, func(s *yaml_types.Container) bool { return s.Name == name })
+	if i < 0 {
+		return false
+	}
+	for _, dep := range steps[i].DependsOn {
+		if dep == other || stepDependsOn(steps, dep, other, visited) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"fmt"
+
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/metadata"
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml"
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/compiler"
+	yaml_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/types"
+)
+
+// ReproductionConfig returns a standalone config of the workflow of the item containing only
+// the given step and the steps it depends on. Secrets are kept as references, so the config
+// can be shared.
+func (b *StepBuilder) ReproductionConfig(item *Item, stepName string) (string, error) {
+	var data string
+	found := false
+	for _, y := range b.Yamls {
+		if b.workflowName(y.Name) == item.Workflow.Name {
+			data, found = string(y.Data), true
+			break
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("config of workflow '%s' not found", item.Workflow.Name)
+	}
+
+	workflowMetadata := b.workflowMetadata(item.Workflow)
+	// the environment variables of the server are not part of the reproduction
+	environ := workflowMetadata.Environ()
+	for k, v := range item.MatrixAxis {
+		environ[k] = v
+	}
+	if err := addWorkflowEnvironment(environ, data); err != nil {
+		return "", err
+	}
+
+	substituted, err := metadata.EnvVarSubst(data, environ)
+	if err != nil {
+		return "", err
+	}
+	parsed, err := yaml.ParseString(substituted)
+	if err != nil {
+		return "", err
+	}
+	if err := b.inlineTemplates(parsed, environ); err != nil {
+		return "", err
+	}
+
+	needed, err := compiler.StepDependencies(parsed.Steps.ContainerList, stepName)
+	if err != nil {
+		return "", fmt.Errorf("%w in workflow '%s'", err, item.Workflow.Name)
+	}
+
+	reproduction := &yaml_types.Workflow{
+		Version:   parsed.Version,
+		Workspace: parsed.Workspace,
+		Clone:     parsed.Clone,
+		SkipClone: parsed.SkipClone,
+		Labels:    parsed.Labels,
+		Pull:      parsed.Pull,
+	}
+	for _, step := range parsed.Steps.ContainerList {
+		if needed[step.Name] {
+			reproduction.Steps.ContainerList = append(reproduction.Steps.ContainerList, step)
+		}
+	}
+
+	return yaml.EmitString(reproduction)
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml"
+	forge_types "go.woodpecker-ci.org/woodpecker/v2/server/forge/types"
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+)
+
+func TestReproductionConfig(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{{Name: "token", Value: "secret-value", Events: []model.WebhookEvent{model.EventPush}}},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Envs:  map[string]string{"SERVER_SECRET": "server-value"},
+		Yamls: []*forge_types.FileMeta{
+			{Name: ".woodpecker/ci.yaml", Data: []byte(`
+when:
+  event: push
+steps:
+  deps:
+    image: golang
+    environment:
+      PROXY: ${SERVER_SECRET}
+    commands:
+      - go mod download
+  build:
+    image: golang
+    environment:
+      TOKEN:
+        from_secret: token
+    commands:
+      - go build
+    depends_on: [deps]
+  lint:
+    image: golangci/golangci-lint
+    commands:
+      - golangci-lint run
+    depends_on: []
+`)},
+		},
+	}
+
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	if !assert.Len(t, pipelineItems, 1) {
+		return
+	}
+
+	config, err := b.ReproductionConfig(pipelineItems[0], "build")
+	assert.NoError(t, err)
+	assert.NotContains(t, config, "secret-value")
+	assert.NotContains(t, config, "server-value")
+
+	reproduction, err := yaml.ParseString(config)
+	assert.NoError(t, err)
+	var names []string
+	for _, step := range reproduction.Steps.ContainerList {
+		names = append(names, step.Name)
+	}
+	assert.Equal(t, []string{"deps", "build"}, names)
+	assert.Equal(t, map[string]any{"from_secret": "token"}, reproduction.Steps.ContainerList[1].Environment["TOKEN"])
+
+	_, err = b.ReproductionConfig(pipelineItems[0], "deploy")
+	assert.ErrorContains(t, err, "step 'deploy' not found in workflow 'ci'")
+}
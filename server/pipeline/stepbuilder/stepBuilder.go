@@ -16,9 +16,17 @@
 package stepbuilder
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"maps"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/oklog/ulid/v2"
 	"github.com/rs/zerolog/log"
@@ -31,11 +39,20 @@ import (
 	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml"
 	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/compiler"
 	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/linter"
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/linter/schema"
 	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/matrix"
 	yaml_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/types"
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/utils"
 	"go.woodpecker-ci.org/woodpecker/v2/server"
 	forge_types "go.woodpecker-ci.org/woodpecker/v2/server/forge/types"
 	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+	"go.woodpecker-ci.org/woodpecker/v2/server/queue"
+)
+
+const (
+	defaultPriority = 0
+	minPriority     = -10
+	maxPriority     = 10
 )
 
 // StepBuilder Takes the hook data and the yaml and returns in internal data model.
@@ -51,43 +68,156 @@ type StepBuilder struct {
 	Envs      map[string]string
 	Forge     metadata.ServerForge
 	ProxyOpts compiler.ProxyOptions
+	// ForgeToken is the forge token of the pipeline user, the remaining forge API quota is looked up for it
+	ForgeToken string
+	// StepDurations maps step names to their historical average duration in seconds
+	StepDurations map[string]int64
+	// ConfigSource is the repo and commit the config was loaded from, if it differs from the pipeline commit
+	ConfigSource metadata.ConfigSource
+	// DisabledLintRules are lint rules skipped for the repo
+	DisabledLintRules []linter.Rule
+	// AgentLabels are the label sets of the available agents, if set Build fails for workflows no agent can run
+	AgentLabels []map[string]string
+	// ReportEnvCollisions adds warnings for environment variables set by several layers with different values
+	ReportEnvCollisions bool
+	// Templates are the step templates workflows can inline with `use`, matched by their name
+	Templates []*forge_types.FileMeta
+	// LoadTemplate returns the repo file a step uses as template if none of the Templates matches,
+	// without it only the Templates can be used
+	LoadTemplate func(path string) ([]byte, error)
+	// ImagePlatforms are the platforms supported by images, if known steps are checked against the workflow platform
+	ImagePlatforms map[string][]string
+	// IgnoreFile is the content of the repo wide ignore file, pipelines only changing ignored paths are skipped
+	IgnoreFile string
+	// MaxDependencyDepth limits the depth of depends_on chains of workflows and steps, 0 means unlimited
+	MaxDependencyDepth int
+	// DefaultRunsOn is the runs_on of the repo used by workflows which don't set it
+	DefaultRunsOn []string
+	// EnvironmentNetrcs are clone credentials for deployments matched by the deploy target, others use Netrc.
+	// The server doesn't set them, so all its pipelines use Netrc; it's a hook for users of this package only.
+	EnvironmentNetrcs map[string]*model.Netrc
+	// ConfigDir is the config folder of the repo, if set workflow names keep the subdirectories below it like "backend/test"
+	ConfigDir string
+	// AutoDisambiguateNames appends the parent folder to the names of workflows which would have the same name
+	// like "test-backend", instead of failing the pipeline
+	AutoDisambiguateNames bool
+	// DryRun keeps workflows skipped by their when filters as items with StatusSkipped and the SkipReasons,
+	// they are appended after the items to run
+	DryRun bool
+	// RegistryCredentials returns fresh credentials for registries flagged as token, without it
+	// their stored password is used
+	RegistryCredentials RegistryCredentialFunc
 }
 
+// RegistryCredentialFunc returns the credentials of a registry using short-lived tokens like ECR or GCR.
+type RegistryCredentialFunc func(registry *model.Registry) (username, password string, err error)
+
 type Item struct {
 	Workflow  *model.Workflow
 	Labels    map[string]string
 	DependsOn []string
-	RunsOn    []string
-	Config    *backend_types.Config
+	// DependencyStatus maps the DependsOn workflows to the result this workflow runs on: success, failure or always
+	DependencyStatus map[string]string
+	RunsOn           []string
+	Config           *backend_types.Config
+	// StepEstimates maps step names of this workflow to their estimated duration in seconds
+	StepEstimates map[string]int64
+	FailFast      bool
+	// Artifacts are the workspace paths this workflow saves for dependent workflows
+	Artifacts []string
+	// Consumes are the artifacts of other workflows restored before the steps
+	Consumes []*yaml_types.ArtifactDependency
+	// Priority orders queued workflows, higher values are scheduled first
+	Priority int
+	// MatrixAxis are the matrix values of this workflow instance, MatrixLabel shows them for display
+	MatrixAxis  matrix.Axis
+	MatrixLabel string
+	// Concurrency is the group of the workflow, running workflows of the group may get canceled by it
+	Concurrency *yaml_types.Concurrency
+	// Timeout caps the runtime of the workflow, zero uses the timeout of the repo
+	Timeout time.Duration
+	// PipelineTimeout caps the runtime of the whole pipeline, zero means no cap
+	PipelineTimeout time.Duration
+	// MaxParallelSteps is the cap of running steps of the whole pipeline, zero means unlimited.
+	// It's only recorded with the pipeline, nothing enforces it yet.
+	MaxParallelSteps int
+	// Experimental workflows are reported but excluded from the pipeline status
+	Experimental bool
+	// Notify is the notification target of the workflow
+	Notify string
+	// StepSecrets maps step names of this workflow to the names of the secrets they request
+	StepSecrets map[string][]string
+	// Variables are the default environment variables of the steps resolved for the pipeline event
+	Variables map[string]string
+	// OptionalDependsOn are the DependsOn workflows this workflow runs without if they don't run
+	OptionalDependsOn []string
+	// SkipReasons explain why the when filters of a skipped workflow don't match, only set by dry runs
+	SkipReasons []string
+	// Determinism maps step names to the sources of non-determinism found in the config before substitution
+	Determinism map[string][]string
 }
 
+// Build generates the items of the pipeline. Workflows are numbered in the order of their
+// file names and matrix axes, so the same set of files always results in the same PIDs.
 func (b *StepBuilder) Build() (items []*Item, errorsAndWarnings error) {
 	b.Yamls = forge_types.SortByName(b.Yamls)
 
+	if b.changesIgnored() {
+		log.Debug().Str("repo", b.Repo.FullName).Msg("all changed files are ignored, skipping pipeline")
+		return nil, nil
+	}
+
+	if err := b.checkRequiredSecrets(); err != nil {
+		return nil, err
+	}
+
+	if err := b.validateWorkflowNames(); err != nil {
+		return nil, err
+	}
+
+	// all steps share the trace id, so it has to be set before the first workflow is built
+	if b.Curr.TraceID == "" {
+		b.Curr.TraceID = NewTraceID()
+	}
+
 	pidSequence := 1
+	var skipped []*Item
 
 	for _, y := range b.Yamls {
 		// matrix axes
-		axes, err := matrix.ParseString(string(y.Data))
+		axes, err := matrix.ParseWithLimit(y.Data, server.Config.Pipeline.MaxMatrixAxes)
 		if err != nil {
 			return nil, err
 		}
 		if len(axes) == 0 {
 			axes = append(axes, matrix.Axis{})
 		}
+		failFast, err := matrix.ParseFailFast(y.Data)
+		if err != nil {
+			return nil, err
+		}
+
+		// steps running only in the first or last instance of the matrix depend on the axes matching their when filters
+		matching := b.matchingAxes(b.workflowName(y.Name), axes, string(y.Data))
 
 		for i, axis := range axes {
 			workflow := &model.Workflow{
-				PID:     pidSequence,
-				State:   model.StatusPending,
-				Environ: axis,
-				Name:    SanitizePath(y.Name),
+				PID:      pidSequence,
+				State:    model.StatusPending,
+				Environ:  axis,
+				Name:     b.workflowName(y.Name),
+				StableID: StableWorkflowID(b.workflowName(y.Name), axis),
 			}
 			if len(axes) > 1 {
 				workflow.AxisID = i + 1
+				workflow.MatrixFailFast = failFast
 			}
-			item, err := b.genItemForWorkflow(workflow, axis, string(y.Data))
-			if err != nil && pipeline_errors.HasBlockingErrors(err) {
+			item, err := b.genItemForWorkflow(workflow, axis, len(axes), newMatrixPosition(i, matching), string(y.Data))
+			var substErr *metadata.SubstitutionError
+			if errors.As(err, &substErr) {
+				substErr.File = y.Name
+				return nil, &errorTypes.PipelineError{Message: substErr.Error(), Type: errorTypes.PipelineErrorTypeCompiler, Data: substErr}
+			} else if err != nil && pipeline_errors.HasBlockingErrors(err) {
 				return nil, err
 			} else if err != nil {
 				errorsAndWarnings = multierr.Append(errorsAndWarnings, err)
@@ -96,6 +226,10 @@ func (b *StepBuilder) Build() (items []*Item, errorsAndWarnings error) {
 			if item == nil {
 				continue
 			}
+			if item.Workflow.State == model.StatusSkipped {
+				skipped = append(skipped, item)
+				continue
+			}
 			items = append(items, item)
 			pidSequence++
 		}
@@ -104,44 +238,139 @@ func (b *StepBuilder) Build() (items []*Item, errorsAndWarnings error) {
 		// depend on https://github.com/woodpecker-ci/woodpecker/issues/778
 	}
 
-	items = filterItemsWithMissingDependencies(items)
+	items, missing, err := filterItemsWithMissingDependencies(items)
+	if err != nil {
+		return nil, err
+	}
+	errorsAndWarnings = multierr.Append(errorsAndWarnings, b.missingDependencyWarnings(missing))
+
+	if err := workflowDependencyCycle(items); err != nil {
+		return nil, err
+	}
+	if err := workflowDependencyDepth(items, b.MaxDependencyDepth); err != nil {
+		return nil, err
+	}
+
+	if err := validateArtifacts(items); err != nil {
+		return nil, err
+	}
+
+	if b.AgentLabels != nil {
+		for _, item := range items {
+			if !b.isSchedulable(item) {
+				return nil, fmt.Errorf("workflow '%s' can't be scheduled, no agent matches the labels %v", item.Workflow.Name, item.Labels)
+			}
+		}
+	}
 
 	// check if at least one step can start if slice is not empty
 	if len(items) > 0 && !stepListContainsItemsToRun(items) {
-		return nil, fmt.Errorf("pipeline has no steps to run")
+		return nil, multierr.Append(errorsAndWarnings, fmt.Errorf("pipeline has no steps to run"))
+	}
+
+	// the scheduler enforces the cap across all workflows of the pipeline
+	b.Curr.MaxParallelSteps = pipelineMaxParallelSteps(items)
+
+	for _, item := range skipped {
+		item.Workflow.PID = pidSequence
+		items = append(items, item)
+		pidSequence++
 	}
 
 	return items, errorsAndWarnings
 }
 
-func (b *StepBuilder) genItemForWorkflow(workflow *model.Workflow, axis matrix.Axis, data string) (item *Item, errorsAndWarnings error) {
-	workflowMetadata := MetadataFromStruct(b.Forge, b.Repo, b.Curr, b.Last, workflow, b.Host)
-	environ := b.environmentVariables(workflowMetadata, axis)
-
-	// add global environment variables for substituting
-	for k, v := range b.Envs {
-		if _, exists := environ[k]; exists {
-			// don't override existing values
-			continue
-		}
+// parseWorkflow substitutes the variables of the workflow config and parses it. The environment
+// of the steps is returned separately, as it doesn't contain the workflow environment.
+func (b *StepBuilder) parseWorkflow(workflow *model.Workflow, axis matrix.Axis, axisCount int, data string) (parsed *yaml_types.Workflow, workflowMetadata metadata.Metadata, environ, stepEnviron map[string]string, err error) {
+	workflowMetadata = b.workflowMetadata(workflow)
+	environ = b.environmentVariables(workflowMetadata, axis)
+	for k, v := range matrixEnviron(workflow.AxisID, axisCount) {
 		environ[k] = v
 	}
+	// the workflow environment is only used for substituting, steps don't get it
+	stepEnviron = maps.Clone(environ)
+	if err := addWorkflowEnvironment(environ, data); err != nil {
+		return nil, workflowMetadata, nil, nil, &errorTypes.PipelineError{Message: err.Error(), Type: errorTypes.PipelineErrorTypeCompiler}
+	}
+
+	if err := checkImageVariables(data, environ, axis); err != nil {
+		return nil, workflowMetadata, nil, nil, &errorTypes.PipelineError{Message: err.Error(), Type: errorTypes.PipelineErrorTypeCompiler}
+	}
 
 	// substitute vars
 	substituted, err := metadata.EnvVarSubst(data, environ)
 	if err != nil {
-		return nil, multierr.Append(errorsAndWarnings, err)
+		return nil, workflowMetadata, nil, nil, err
 	}
 
 	// parse yaml pipeline
-	parsed, err := yaml.ParseString(substituted)
+	parsed, err = yaml.ParseString(substituted)
+	if err != nil {
+		return nil, workflowMetadata, nil, nil, &errorTypes.PipelineError{Message: err.Error(), Type: errorTypes.PipelineErrorTypeCompiler}
+	}
+	if !schema.IsSupportedVersion(parsed.Version) {
+		return nil, workflowMetadata, nil, nil, &errorTypes.PipelineError{
+			Message: fmt.Sprintf("unknown schema version %d, supported up to %d", parsed.Version, schema.LatestVersion),
+			Type:    errorTypes.PipelineErrorTypeCompiler,
+		}
+	}
+
+	return parsed, workflowMetadata, environ, stepEnviron, nil
+}
+
+// matchingAxes returns the indexes of the matrix axes whose workflow matches its when filters.
+// Workflows failing to parse are counted as matching, building them reports the error.
+func (b *StepBuilder) matchingAxes(name string, axes []matrix.Axis, data string) []int {
+	if len(axes) == 1 {
+		// the only instance is the first and the last one
+		return []int{0}
+	}
+
+	var matching []int
+	for i, axis := range axes {
+		workflow := &model.Workflow{Name: name, Environ: axis, AxisID: i + 1}
+		parsed, workflowMetadata, environ, _, err := b.parseWorkflow(workflow, axis, len(axes), data)
+		if err != nil {
+			matching = append(matching, i)
+			continue
+		}
+		if match, err := parsed.When.Match(workflowMetadata, true, environ); match || err != nil {
+			matching = append(matching, i)
+		}
+	}
+	return matching
+}
+
+func (b *StepBuilder) genItemForWorkflow(workflow *model.Workflow, axis matrix.Axis, axisCount int, position matrixPosition, data string) (item *Item, errorsAndWarnings error) {
+	parsed, workflowMetadata, environ, stepEnviron, err := b.parseWorkflow(workflow, axis, axisCount, data)
 	if err != nil {
+		return nil, err
+	}
+
+	// inline step templates
+	if err := b.inlineTemplates(parsed, environ); err != nil {
+		return nil, &errorTypes.PipelineError{Message: err.Error(), Type: errorTypes.PipelineErrorTypeCompiler}
+	}
+
+	if err := validateStepOutputs(parsed); err != nil {
 		return nil, &errorTypes.PipelineError{Message: err.Error(), Type: errorTypes.PipelineErrorTypeCompiler}
 	}
 
 	// lint pipeline
+	disabledRules := b.DisabledLintRules
+	if workflow.AxisID > 1 {
+		// all axes share the matrix, it is only reported for the first one
+		disabledRules = append(slices.Clone(disabledRules), linter.RuleMatrixValues)
+	}
 	errorsAndWarnings = multierr.Append(errorsAndWarnings, linter.New(
 		linter.WithTrusted(b.Repo.IsTrusted),
+		linter.WithDisabledLintRules(disabledRules...),
+		linter.WithImagePlatformData(b.ImagePlatforms),
+		linter.WithVariables(environ),
+		linter.WithAllowedCapabilities(server.Config.Pipeline.AllowedCapabilities),
+		linter.WithMaxTmpfsSize(server.Config.Pipeline.MaxTmpfsSize),
+		linter.WithResourceLimit(server.Config.Pipeline.Limits.MemSwapLimit, server.Config.Pipeline.Limits.MemLimit, server.Config.Pipeline.Limits.CPUQuota, server.Config.Pipeline.Limits.CPUShares, server.Config.Pipeline.Limits.CPUSet),
 	).Lint([]*linter.WorkflowConfig{{
 		Workflow:  parsed,
 		File:      workflow.Name,
@@ -156,6 +385,9 @@ func (b *StepBuilder) genItemForWorkflow(workflow *model.Workflow, axis matrix.A
 		log.Debug().Str("pipeline", workflow.Name).Msg(
 			"marked as skipped, does not match metadata",
 		)
+		if b.DryRun {
+			return skippedItem(workflow, parsed, workflowMetadata, environ)
+		}
 		return nil, nil
 	} else if err != nil {
 		log.Debug().Str("pipeline", workflow.Name).Msg(
@@ -164,7 +396,42 @@ func (b *StepBuilder) genItemForWorkflow(workflow *model.Workflow, axis matrix.A
 		return nil, multierr.Append(errorsAndWarnings, err)
 	}
 
-	ir, err := b.toInternalRepresentation(parsed, environ, workflowMetadata, workflow.ID)
+	if err := stepDependencyCycle(workflow.Name, parsed.Steps.ContainerList); err != nil {
+		return nil, multierr.Append(errorsAndWarnings, err)
+	}
+	if err := stepDependencyDepth(workflow.Name, parsed.Steps.ContainerList, b.MaxDependencyDepth); err != nil {
+		return nil, multierr.Append(errorsAndWarnings, err)
+	}
+
+	priority, err := b.workflowPriority(parsed.Priority)
+	if err != nil {
+		return nil, multierr.Append(errorsAndWarnings, err)
+	}
+
+	if parsed.Concurrency != nil && strings.TrimSpace(parsed.Concurrency.Group) == "" {
+		return nil, multierr.Append(errorsAndWarnings, fmt.Errorf("concurrency group of workflow '%s' is empty", workflow.Name))
+	}
+
+	timeout, err := b.timeout(parsed.Timeout)
+	if err != nil {
+		return nil, multierr.Append(errorsAndWarnings, err)
+	}
+
+	pipelineTimeout, err := b.timeout(parsed.PipelineTimeout)
+	if err != nil {
+		return nil, multierr.Append(errorsAndWarnings, err)
+	}
+
+	maxParallelSteps, err := b.maxParallelSteps(parsed.MaxParallelSteps)
+	if err != nil {
+		return nil, multierr.Append(errorsAndWarnings, err)
+	}
+
+	variables := parsed.EnvDefaults.Resolve(string(b.Curr.Event))
+
+	skippedSteps := matrixPositionSkipped(parsed.Steps.ContainerList, position)
+
+	ir, err := b.toInternalRepresentation(parsed, stepEnviron, variables, skippedSteps, workflowMetadata, workflow.ID)
 	if err != nil {
 		return nil, multierr.Append(errorsAndWarnings, err)
 	}
@@ -173,20 +440,202 @@ func (b *StepBuilder) genItemForWorkflow(workflow *model.Workflow, axis matrix.A
 		return nil, nil
 	}
 
+	if b.ReportEnvCollisions {
+		errorsAndWarnings = multierr.Append(errorsAndWarnings, b.envCollisionWarnings(workflow, variables, workflowMetadata, ir))
+	}
+
+	runsOn := parsed.RunsOn
+	if len(runsOn) == 0 {
+		runsOn = b.DefaultRunsOn
+	}
+
 	item = &Item{
-		Workflow:  workflow,
-		Config:    ir,
-		Labels:    parsed.Labels,
-		DependsOn: parsed.DependsOn,
-		RunsOn:    parsed.RunsOn,
+		Workflow:         workflow,
+		Config:           ir,
+		Labels:           parsed.Labels,
+		DependsOn:        parsed.DependsOn.Names(),
+		DependencyStatus: parsed.DependsOn.Statuses(),
+		RunsOn:           runsOn,
+		FailFast:         parsed.FailFast,
+		Artifacts:        parsed.Artifacts,
+		Consumes:         parsed.Consumes,
+		Priority:         priority,
+		Concurrency:      parsed.Concurrency,
+		Timeout:          timeout,
+		PipelineTimeout:  pipelineTimeout,
+		MaxParallelSteps: maxParallelSteps,
+		Experimental:     parsed.Experimental,
+		Notify:           parsed.Notify,
+		StepSecrets:      stepSecrets(parsed),
+		Variables:        variables,
+		Determinism:      stepDeterminism(data),
 	}
 	if item.Labels == nil {
 		item.Labels = map[string]string{}
 	}
+	// agents report their backend as label, so only agents with the required backend pick up the workflow
+	if parsed.Backend != "" {
+		item.Labels["backend"] = parsed.Backend
+	}
+	if len(axis) > 0 {
+		item.MatrixAxis = axis
+		item.MatrixLabel = axis.Label()
+	}
+	item.StepEstimates = b.stepEstimates(ir)
+	item.OptionalDependsOn = parsed.DependsOn.OptionalNames()
 
 	return item, errorsAndWarnings
 }
 
+// checkRequiredSecrets fails if a secret marked as required is not available for the
+// event of the pipeline, regardless of steps using it.
+func (b *StepBuilder) checkRequiredSecrets() error {
+	for _, sec := range b.Secs {
+		if !sec.Required {
+			continue
+		}
+		secret := compiler.Secret{Name: sec.Name}
+		for _, event := range sec.Events {
+			secret.Events = append(secret.Events, string(event))
+		}
+		if !secret.Match(string(b.Curr.Event)) {
+			return fmt.Errorf("required secret '%s' is not available for event '%s'", sec.Name, b.Curr.Event)
+		}
+	}
+	return nil
+}
+
+// workflowPriority validates the configured priority. Untrusted repos can only lower it,
+// so they can't jump the queue.
+func (b *StepBuilder) workflowPriority(priority int) (int, error) {
+	if priority < minPriority || priority > maxPriority {
+		return 0, fmt.Errorf("priority %d is out of range [%d, %d]", priority, minPriority, maxPriority)
+	}
+	if !b.Repo.IsTrusted && priority > defaultPriority {
+		log.Debug().Str("repo", b.Repo.FullName).Msgf("untrusted repo can't raise priority to %d", priority)
+		return defaultPriority, nil
+	}
+	return priority, nil
+}
+
+// timeout validates a configured workflow or pipeline timeout. The timeouts of all repos are
+// capped by the max timeout of the server.
+func (b *StepBuilder) timeout(timeout string) (time.Duration, error) {
+	if timeout == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout '%s': %w", timeout, err)
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("timeout '%s' is not a positive duration", timeout)
+	}
+	maxTimeout := time.Duration(server.Config.Pipeline.MaxTimeout) * time.Minute
+	if maxTimeout > 0 && duration > maxTimeout {
+		log.Debug().Str("repo", b.Repo.FullName).Msgf("timeout %s is capped by the max timeout of the server", duration)
+		return maxTimeout, nil
+	}
+	return duration, nil
+}
+
+// maxParallelSteps validates the configured cap of running steps. Untrusted repos are capped
+// by the max parallel steps of the server, even if they don't set any.
+func (b *StepBuilder) maxParallelSteps(maxSteps int) (int, error) {
+	if maxSteps < 0 {
+		return 0, fmt.Errorf("max_parallel_steps %d has to be positive", maxSteps)
+	}
+	serverMax := server.Config.Pipeline.MaxParallelSteps
+	if !b.Repo.IsTrusted && serverMax > 0 && (maxSteps == 0 || maxSteps > serverMax) {
+		log.Debug().Str("repo", b.Repo.FullName).Msgf("untrusted repo can't raise max parallel steps to %d", maxSteps)
+		return serverMax, nil
+	}
+	return maxSteps, nil
+}
+
+// pipelineMaxParallelSteps returns the smallest cap of running steps of the workflows, zero if none has one.
+func pipelineMaxParallelSteps(items []*Item) int {
+	maxSteps := 0
+	for _, item := range items {
+		if item.MaxParallelSteps > 0 && (maxSteps == 0 || item.MaxParallelSteps < maxSteps) {
+			maxSteps = item.MaxParallelSteps
+		}
+	}
+	return maxSteps
+}
+
+// stepEstimates returns the historical durations of all steps in the config that have one.
+func (b *StepBuilder) stepEstimates(config *backend_types.Config) map[string]int64 {
+	estimates := map[string]int64{}
+	for _, stage := range config.Stages {
+		for _, step := range stage.Steps {
+			if duration, ok := b.StepDurations[step.Name]; ok {
+				estimates[step.Name] = duration
+			}
+		}
+	}
+	return estimates
+}
+
+// ExplainEnv returns the environment of a step of the item together with the layer each value originates from.
+func (b *StepBuilder) ExplainEnv(item *Item, stepName string) ([]compiler.ExplainedEnv, error) {
+	for _, stage := range item.Config.Stages {
+		for _, step := range stage.Steps {
+			if step.Name != stepName {
+				continue
+			}
+
+			workflowMetadata := b.workflowMetadata(item.Workflow)
+
+			return compiler.ExplainEnv(step, b.envLayers(item.Workflow, item.Variables, workflowMetadata)...), nil
+		}
+	}
+	return nil, fmt.Errorf("step '%s' not found in workflow '%s'", stepName, item.Workflow.Name)
+}
+
+// envLayers returns the environment layers in the same order as they are passed
+// to the compiler in toInternalRepresentation.
+func (b *StepBuilder) envLayers(workflow *model.Workflow, variables map[string]string, workflowMetadata metadata.Metadata) []compiler.EnvLayer {
+	return []compiler.EnvLayer{
+		{Source: compiler.EnvSourceMatrix, Env: workflow.Environ},
+		{Source: compiler.EnvSourceGlobal, Env: b.Envs},
+		{Source: compiler.EnvSourceVariable, Env: variables},
+		{Source: compiler.EnvSourceMetadata, Env: workflowMetadata.Environ()},
+	}
+}
+
+// envCollisionWarnings returns a warning for every environment variable of a step set by several layers with different values.
+func (b *StepBuilder) envCollisionWarnings(workflow *model.Workflow, variables map[string]string, workflowMetadata metadata.Metadata, config *backend_types.Config) (warnings error) {
+	layers := b.envLayers(workflow, variables, workflowMetadata)
+	for _, stage := range config.Stages {
+		for _, step := range stage.Steps {
+			for _, collision := range compiler.EnvCollisions(step, layers...) {
+				sources := make([]string, 0, len(collision.Sources))
+				for _, source := range collision.Sources {
+					sources = append(sources, string(source))
+				}
+				warnings = multierr.Append(warnings, &errorTypes.PipelineError{
+					Type:      errorTypes.PipelineErrorTypeCompiler,
+					Message:   fmt.Sprintf("Environment variable '%s' of step '%s' is set with different values by %s", collision.Key, step.Name, strings.Join(sources, ", ")),
+					IsWarning: true,
+				})
+			}
+		}
+	}
+	return warnings
+}
+
+// isSchedulable checks if at least one agent matches the labels of the item,
+// using the label matching of the queue.
+func (b *StepBuilder) isSchedulable(item *Item) bool {
+	for _, agentLabels := range b.AgentLabels {
+		if queue.LabelsMatch(item.Labels, agentLabels) {
+			return true
+		}
+	}
+	return false
+}
+
 func stepListContainsItemsToRun(items []*Item) bool {
 	for i := range items {
 		if items[i].Workflow.State == model.StatusPending {
@@ -196,15 +645,37 @@ func stepListContainsItemsToRun(items []*Item) bool {
 	return false
 }
 
-func filterItemsWithMissingDependencies(items []*Item) []*Item {
+// MissingDependency is a workflow dropped from the pipeline because a workflow it depends on doesn't run.
+type MissingDependency struct {
+	Workflow   string `json:"workflow"`
+	Dependency string `json:"dependency"`
+}
+
+func filterItemsWithMissingDependencies(items []*Item) ([]*Item, []MissingDependency, error) {
 	itemsToRemove := make([]*Item, 0)
+	var missing []MissingDependency
 
 	for _, item := range items {
+		dependsOn := make([]string, 0, len(item.DependsOn))
 		for _, dep := range item.DependsOn {
-			if !containsItemWithName(dep, items) {
+			if err := validateDependency(dep, items); err != nil {
+				return nil, nil, fmt.Errorf("workflow '%s': %w", item.Workflow.Name, err)
+			}
+			switch {
+			case containsItemWithName(dep, items):
+				dependsOn = append(dependsOn, dep)
+			case slices.Contains(item.OptionalDependsOn, dep):
+				// the workflow runs without its optional dependency
+				delete(item.DependencyStatus, dep)
+			default:
+				dependsOn = append(dependsOn, dep)
 				itemsToRemove = append(itemsToRemove, item)
+				missing = append(missing, MissingDependency{Workflow: item.Workflow.Name, Dependency: dep})
 			}
 		}
+		if len(dependsOn) != len(item.DependsOn) {
+			item.DependsOn = dependsOn
+		}
 	}
 
 	if len(itemsToRemove) > 0 {
@@ -215,15 +686,137 @@ func filterItemsWithMissingDependencies(items []*Item) []*Item {
 			}
 		}
 		// Recursive to handle transitive deps
-		return filterItemsWithMissingDependencies(filtered)
+		filtered, transitive, err := filterItemsWithMissingDependencies(filtered)
+		if err != nil {
+			return nil, nil, err
+		}
+		return filtered, append(missing, transitive...), nil
+	}
+
+	return items, nil, nil
+}
+
+// missingDependencyWarnings returns a warning for every workflow dropped because of a missing dependency.
+// Dependencies which aren't the name of any workflow of the config are reported as unknown.
+func (b *StepBuilder) missingDependencyWarnings(missing []MissingDependency) (warnings error) {
+	known := make(map[string]bool, len(b.Yamls))
+	for _, y := range b.Yamls {
+		known[b.workflowName(y.Name)] = true
+	}
+	seen := make(map[MissingDependency]bool, len(missing))
+	for _, m := range missing {
+		// matrix workflows share their name
+		if seen[m] {
+			continue
+		}
+		seen[m] = true
+
+		message := fmt.Sprintf("workflow %s dropped: depends on workflow '%s' which doesn't run", m.Workflow, m.Dependency)
+		if !known[dependencyName(m.Dependency)] {
+			message = fmt.Sprintf("workflow %s dropped: depends on unknown workflow '%s'", m.Workflow, m.Dependency)
+		}
+		warnings = multierr.Append(warnings, &errorTypes.PipelineError{
+			Type:      errorTypes.PipelineErrorTypeCompiler,
+			Message:   message,
+			IsWarning: true,
+			Data:      m,
+		})
+	}
+	return warnings
+}
+
+// validateWorkflowNames checks that no two config files result in workflows with the same name,
+// as dependencies between workflows would be ambiguous.
+func (b *StepBuilder) validateWorkflowNames() error {
+	files := make(map[string]string, len(b.Yamls))
+	for _, y := range b.Yamls {
+		name := b.workflowName(y.Name)
+		if name == "" {
+			// unnamed configs can't be referenced by depends_on
+			continue
+		}
+		if file, ok := files[name]; ok {
+			return fmt.Errorf("config files '%s' and '%s' both result in workflow '%s'", file, y.Name, name)
+		}
+		files[name] = y.Name
+	}
+	return nil
+}
+
+// privilegedSteps returns the names of the steps and services which run privileged, either by
+// the privileged option or as plugin with an escalated image. Steps skipped by their when filters are left out.
+func privilegedSteps(parsed *yaml_types.Workflow, escalated []string, workflowMetadata metadata.Metadata, environ map[string]string) ([]string, error) {
+	var names []string
+	isPrivileged := func(c *yaml_types.Container) bool {
+		return c.Privileged || (utils.MatchImage(c.Image, escalated...) && c.IsPlugin())
+	}
+	containers := slices.Concat(parsed.Clone.ContainerList, parsed.Steps.ContainerList, parsed.Services.ContainerList)
+	for _, c := range containers {
+		if !isPrivileged(c) && !slices.ContainsFunc(c.Init, isPrivileged) {
+			continue
+		}
+		match, err := c.When.Match(workflowMetadata, false, environ)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			names = append(names, c.Name)
+		}
+	}
+	return names, nil
+}
+
+// skippedItem returns the item of a workflow skipped by its when filters with the reasons, used by dry runs.
+func skippedItem(workflow *model.Workflow, parsed *yaml_types.Workflow, workflowMetadata metadata.Metadata, environ map[string]string) (*Item, error) {
+	reasons, err := parsed.When.Mismatches(workflowMetadata, true, environ)
+	if err != nil {
+		return nil, err
+	}
+	workflow.State = model.StatusSkipped
+	return &Item{
+		Workflow:    workflow,
+		Labels:      parsed.Labels,
+		DependsOn:   parsed.DependsOn.Names(),
+		SkipReasons: reasons,
+	}, nil
+}
+
+// validateArtifacts checks that every consumed artifact is declared by a workflow the consumer depends on.
+func validateArtifacts(items []*Item) error {
+	for _, item := range items {
+		for _, dep := range item.Consumes {
+			if !slices.ContainsFunc(item.DependsOn, func(name string) bool { return dependencyName(name) == dep.Workflow }) {
+				return fmt.Errorf("workflow '%s' consumes artifacts of '%s' but does not depend on it", item.Workflow.Name, dep.Workflow)
+			}
+			for _, p := range dep.Paths {
+				if !producesArtifact(items, dep.Workflow, p) {
+					return fmt.Errorf("workflow '%s' consumes artifact '%s' which is not declared by workflow '%s'", item.Workflow.Name, p, dep.Workflow)
+				}
+			}
+		}
 	}
+	return nil
+}
 
-	return items
+func producesArtifact(items []*Item, name, artifact string) bool {
+	for _, item := range items {
+		if item.Workflow.Name != name {
+			continue
+		}
+		for _, p := range item.Artifacts {
+			if filepath.Clean(p) == filepath.Clean(artifact) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
+// containsItemWithName returns true if an item is a workflow the dependency references,
+// names with an axis reference like "test[GO=1.21]" only match that axis.
 func containsItemWithName(name string, items []*Item) bool {
 	for _, item := range items {
-		if name == item.Workflow.Name {
+		if MatchesDependency(item, name) {
 			return true
 		}
 	}
@@ -235,12 +828,76 @@ func (b *StepBuilder) environmentVariables(metadata metadata.Metadata, axis matr
 	for k, v := range axis {
 		environ[k] = v
 	}
+
+	// add global environment variables for substituting
+	for k, v := range b.Envs {
+		if _, exists := environ[k]; exists {
+			// don't override existing values
+			continue
+		}
+		environ[k] = v
+	}
 	return environ
 }
 
-func (b *StepBuilder) toInternalRepresentation(parsed *yaml_types.Workflow, environ map[string]string, metadata metadata.Metadata, workflowID int64) (*backend_types.Config, error) {
+// defaultCloneImage returns the image of the clone step used by workflows without clone steps.
+// The clone image of the workflow takes precedence over the one of the repo settings, which
+// takes precedence over the one of the server.
+func (b *StepBuilder) defaultCloneImage(parsed *yaml_types.Workflow) string {
+	if parsed.Clone.Image != "" {
+		return parsed.Clone.Image
+	}
+	if b.Repo.CloneImage != "" {
+		return b.Repo.CloneImage
+	}
+	return server.Config.Pipeline.DefaultCloneImage
+}
+
+// addWorkflowEnvironment adds the environment block of the workflow config to the variables used for substituting.
+func addWorkflowEnvironment(environ map[string]string, data string) error {
+	workflowEnviron, err := yaml.ParseEnvironment(data)
+	if err != nil {
+		return err
+	}
+	for k, v := range workflowEnviron {
+		if _, exists := environ[k]; exists {
+			// don't override existing values
+			continue
+		}
+		environ[k] = v
+	}
+	return nil
+}
+
+// compilerRegistries converts the registries available for the deploy target to the representation
+// of the compiler. Registries using short-lived tokens get fresh credentials from RegistryCredentials.
+func (b *StepBuilder) compilerRegistries(target string) ([]compiler.Registry, error) {
+	var registries []compiler.Registry
+	for _, reg := range b.Regs {
+		if !reg.MatchEnvironment(target) {
+			continue
+		}
+		username, password := reg.Username, reg.Password
+		if reg.TokenAuth && b.RegistryCredentials != nil {
+			var err error
+			username, password, err = b.RegistryCredentials(reg)
+			if err != nil {
+				return nil, fmt.Errorf("could not refresh the credentials of registry '%s': %w", reg.Address, err)
+			}
+		}
+		registries = append(registries, compiler.Registry{
+			Hostname: reg.Address,
+			Username: username,
+			Password: password,
+		})
+	}
+	return registries, nil
+}
+
+// compilerSecrets converts the secrets to the representation of the compiler.
+func compilerSecrets(secs []*model.Secret) []compiler.Secret {
 	var secrets []compiler.Secret
-	for _, sec := range b.Secs {
+	for _, sec := range secs {
 		var events []string
 		for _, event := range sec.Events {
 			events = append(events, string(event))
@@ -251,36 +908,66 @@ func (b *StepBuilder) toInternalRepresentation(parsed *yaml_types.Workflow, envi
 			Value:          sec.Value,
 			AllowedPlugins: sec.Images,
 			Events:         events,
+			File:           sec.File,
 		})
 	}
+	return secrets
+}
 
-	var registries []compiler.Registry
-	for _, reg := range b.Regs {
-		registries = append(registries, compiler.Registry{
-			Hostname: reg.Address,
-			Username: reg.Username,
-			Password: reg.Password,
-		})
+func (b *StepBuilder) toInternalRepresentation(parsed *yaml_types.Workflow, environ, variables map[string]string, skippedSteps []string, metadata metadata.Metadata, workflowID int64) (*backend_types.Config, error) {
+	secrets := compilerSecrets(b.Secs)
+
+	registries, err := b.compilerRegistries(metadata.Curr.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	netrc := b.Netrc
+	if envNetrc, ok := b.EnvironmentNetrcs[metadata.Curr.Target]; ok && metadata.Curr.Target != "" {
+		netrc = envNetrc
+	}
+
+	privilegedDisabled := false
+	if events := server.Config.Pipeline.PrivilegedEvents; len(events) != 0 && !slices.Contains(events, b.Curr.Event) {
+		steps, err := privilegedSteps(parsed, server.Config.Pipeline.Privileged, metadata, environ)
+		if err != nil {
+			return nil, err
+		}
+		if len(steps) != 0 && !server.Config.Pipeline.PrivilegedEventsDowngrade {
+			return nil, &errorTypes.PipelineError{
+				Message: fmt.Sprintf("privileged mode is not allowed for %s events, used by: %s", b.Curr.Event, strings.Join(steps, ", ")),
+				Type:    errorTypes.PipelineErrorTypeCompiler,
+			}
+		}
+		privilegedDisabled = true
 	}
 
 	return compiler.New(
 		compiler.WithEnviron(environ),
 		compiler.WithEnviron(b.Envs),
+		compiler.WithEnviron(variables),
 		// TODO: server deps should be moved into StepBuilder fields and set on StepBuilder creation
 		compiler.WithEscalated(server.Config.Pipeline.Privileged...),
+		compiler.WithPrivilegedDisabled(privilegedDisabled),
 		compiler.WithResourceLimit(server.Config.Pipeline.Limits.MemSwapLimit, server.Config.Pipeline.Limits.MemLimit, server.Config.Pipeline.Limits.ShmSize, server.Config.Pipeline.Limits.CPUQuota, server.Config.Pipeline.Limits.CPUShares, server.Config.Pipeline.Limits.CPUSet),
+		compiler.WithMaxShmSize(server.Config.Pipeline.Limits.MaxShmSize),
 		compiler.WithVolumes(server.Config.Pipeline.Volumes...),
 		compiler.WithNetworks(server.Config.Pipeline.Networks...),
 		compiler.WithLocal(false),
 		compiler.WithOption(
 			compiler.WithNetrc(
-				b.Netrc.Login,
-				b.Netrc.Password,
-				b.Netrc.Machine,
+				netrc.Login,
+				netrc.Password,
+				netrc.Machine,
 			),
 			b.Repo.IsSCMPrivate || server.Config.Pipeline.AuthenticatePublicRepos,
 		),
-		compiler.WithDefaultCloneImage(server.Config.Pipeline.DefaultCloneImage),
+		compiler.WithDefaultCloneImage(b.defaultCloneImage(parsed)),
+		compiler.WithOption(
+			compiler.WithAutoSkipClone(),
+			server.Config.Pipeline.AutoSkipClone,
+		),
+		compiler.WithArtifactImage(server.Config.Pipeline.ArtifactImage),
 		compiler.WithRegistry(registries...),
 		compiler.WithSecret(secrets...),
 		compiler.WithPrefix(
@@ -295,9 +982,39 @@ func (b *StepBuilder) toInternalRepresentation(parsed *yaml_types.Workflow, envi
 		compiler.WithMetadata(metadata),
 		compiler.WithTrusted(b.Repo.IsTrusted),
 		compiler.WithNetrcOnlyTrusted(b.Repo.NetrcOnlyTrusted),
+		compiler.WithReadOnlyRootfs(server.Config.Pipeline.UntrustedReadOnlyRootfs && !b.Repo.IsTrusted),
+		compiler.WithOption(
+			compiler.WithForcedUser(server.Config.Pipeline.UntrustedUser),
+			!b.Repo.IsTrusted,
+		),
+		compiler.WithSkippedSteps(skippedSteps...),
 	).Compile(parsed)
 }
 
+// StableWorkflowID returns an ID of the workflow derived from its name and matrix axis.
+// Unlike the PID it doesn't change if other workflow files are added or removed.
+func StableWorkflowID(name string, axis matrix.Axis) string {
+	hash := sha256.Sum256([]byte(name + "\x00" + axis.Label()))
+	return hex.EncodeToString(hash[:8])
+}
+
+// EventHash returns a hash of the webhook payload a pipeline was created from, steps can use it to
+// correlate pipelines of the same event. It is empty if there is no payload.
+func EventHash(payload []byte) string {
+	if len(payload) == 0 {
+		return ""
+	}
+	hash := sha256.Sum256(payload)
+	return hex.EncodeToString(hash[:])
+}
+
+// NewTraceID returns a random trace id in the format of the W3C trace context.
+func NewTraceID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
 func SanitizePath(path string) string {
 	path = filepath.Base(path)
 	path = strings.TrimSuffix(path, ".yml")
@@ -305,3 +1022,43 @@ func SanitizePath(path string) string {
 	path = strings.TrimPrefix(path, ".")
 	return path
 }
+
+// SanitizeRelativePath is like SanitizePath but keeps the subdirectories of the file below
+// the config folder, so ".woodpecker/backend/test.yml" becomes "backend/test". Files outside
+// of the config folder only keep their base name.
+func SanitizeRelativePath(file, configDir string) string {
+	configDir = strings.Trim(path.Clean("/"+configDir), "/")
+	file = strings.TrimPrefix(path.Clean("/"+file), "/")
+
+	dir, name := path.Split(file)
+	dir = strings.TrimSuffix(dir, "/")
+	if configDir == "" || dir == configDir || !strings.HasPrefix(dir, configDir+"/") {
+		return SanitizePath(file)
+	}
+	return path.Join(strings.TrimPrefix(dir, configDir+"/"), SanitizePath(name))
+}
+
+// workflowName returns the name of the workflow defined by the file.
+func (b *StepBuilder) workflowName(file string) string {
+	name := b.sanitizeWorkflowName(file)
+	if !b.AutoDisambiguateNames {
+		return name
+	}
+	for _, y := range b.Yamls {
+		if y.Name == file || b.sanitizeWorkflowName(y.Name) != name {
+			continue
+		}
+		if dir := SanitizePath(filepath.Dir(file)); dir != "" {
+			return name + "-" + dir
+		}
+		break
+	}
+	return name
+}
+
+func (b *StepBuilder) sanitizeWorkflowName(file string) string {
+	if b.ConfigDir != "" {
+		return SanitizeRelativePath(file, b.ConfigDir)
+	}
+	return SanitizePath(file)
+}
@@ -17,11 +17,19 @@ package stepbuilder
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	backend_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/backend/types"
 	"go.woodpecker-ci.org/woodpecker/v2/pipeline/errors"
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/metadata"
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/compiler"
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/matrix"
+	yaml_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/types"
+	"go.woodpecker-ci.org/woodpecker/v2/server"
 	"go.woodpecker-ci.org/woodpecker/v2/server/forge"
 	"go.woodpecker-ci.org/woodpecker/v2/server/forge/mocks"
 	forge_types "go.woodpecker-ci.org/woodpecker/v2/server/forge/types"
@@ -507,9 +515,10 @@ depends_on: [ zerostep ]
 	}
 
 	pipelineItems, err := b.Build()
-	if err != nil {
+	if errors.HasBlockingErrors(err) {
 		t.Fatal(err)
 	}
+	assert.ErrorContains(t, err, "workflow shouldbefiltered dropped: depends on workflow 'zerostep' which doesn't run")
 	if len(pipelineItems) != 1 {
 		t.Fatal("Zerostep and the step that depends on it should not generate a pipeline item")
 	}
@@ -573,9 +582,11 @@ depends_on: [ shouldbefiltered ]
 	}
 
 	pipelineItems, err := b.Build()
-	if err != nil {
+	if errors.HasBlockingErrors(err) {
 		t.Fatal(err)
 	}
+	assert.ErrorContains(t, err, "workflow shouldbefiltered dropped: depends on workflow 'zerostep' which doesn't run")
+	assert.ErrorContains(t, err, "workflow shouldbefilteredtoo dropped: depends on workflow 'shouldbefiltered' which doesn't run")
 	if len(pipelineItems) != 1 {
 		t.Fatal("Zerostep and the step that depends on it, and the one depending on it should not generate a pipeline item")
 	}
@@ -584,6 +595,52 @@ depends_on: [ shouldbefiltered ]
 	}
 }
 
+func TestMissingDependencies(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Yamls: []*forge_types.FileMeta{
+			{Name: "build", Data: []byte(`
+when:
+  event: push
+steps:
+  build:
+    image: scratch
+`)},
+			{Name: "deploy", Data: []byte(`
+when:
+  event: push
+steps:
+  deploy:
+    image: scratch
+depends_on: [ buld ]
+`)},
+		},
+	}
+
+	pipelineItems, err := b.Build()
+	assert.False(t, errors.HasBlockingErrors(err))
+	if assert.Len(t, pipelineItems, 1) {
+		assert.Equal(t, "build", pipelineItems[0].Workflow.Name)
+	}
+	pipelineErrors := errors.GetPipelineErrors(err)
+	if assert.Len(t, pipelineErrors, 1) {
+		assert.Equal(t, "workflow deploy dropped: depends on unknown workflow 'buld'", pipelineErrors[0].Message)
+		assert.True(t, pipelineErrors[0].IsWarning)
+		assert.Equal(t, MissingDependency{Workflow: "deploy", Dependency: "buld"}, pipelineErrors[0].Data)
+	}
+}
+
 func TestSanitizePath(t *testing.T) {
 	t.Parallel()
 
@@ -624,6 +681,2511 @@ func TestSanitizePath(t *testing.T) {
 	}
 }
 
+func TestSanitizeRelativePath(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "backend/test", SanitizeRelativePath(".woodpecker/backend/test.yml", ".woodpecker/"))
+	assert.Equal(t, "frontend/test", SanitizeRelativePath(".woodpecker/frontend/test.yaml", ".woodpecker"))
+	assert.Equal(t, "a/b/.test", SanitizeRelativePath("ci/a/b/..test.yml", "ci"))
+	assert.Equal(t, "test", SanitizeRelativePath(".woodpecker/test.yml", ".woodpecker/"))
+	// files outside of the config folder keep their base name
+	assert.Equal(t, "woodpecker", SanitizeRelativePath(".woodpecker.yml", ".woodpecker/"))
+	assert.Equal(t, "test", SanitizeRelativePath("folder/sub-folder/test.yml", ".woodpecker/"))
+	assert.Equal(t, "test", SanitizeRelativePath(".woodpecker-old/sub/test.yml", ".woodpecker/"))
+	assert.Equal(t, "test", SanitizeRelativePath(".woodpecker/backend/test.yml", ""))
+}
+
+func TestConfigDirWorkflowNames(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:      &model.Pipeline{},
+		Netrc:     &model.Netrc{},
+		Secs:      []*model.Secret{},
+		Regs:      []*model.Registry{},
+		Host:      "",
+		ConfigDir: ".woodpecker/",
+		Yamls: []*forge_types.FileMeta{
+			{Name: ".woodpecker/backend/test.yml", Data: []byte(`
+when:
+  event: push
+steps:
+  test:
+    image: golang
+    commands: go test
+`)},
+			{Name: ".woodpecker/frontend/test.yml", Data: []byte(`
+when:
+  event: push
+depends_on: [backend/test]
+steps:
+  test:
+    image: node
+    commands: npm test
+`)},
+		},
+	}
+
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	if assert.Len(t, pipelineItems, 2) {
+		assert.Equal(t, "backend/test", pipelineItems[0].Workflow.Name)
+		assert.Equal(t, "frontend/test", pipelineItems[1].Workflow.Name)
+		assert.Equal(t, []string{"backend/test"}, pipelineItems[1].DependsOn)
+		assert.NotEqual(t, pipelineItems[0].Workflow.StableID, pipelineItems[1].Workflow.StableID)
+	}
+}
+
+func TestStepEstimates(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Yamls: []*forge_types.FileMeta{
+			{Data: []byte(`
+when:
+  event: push
+skip_clone: true
+steps:
+  build:
+    image: scratch
+  test:
+    image: scratch
+`)},
+		},
+		StepDurations: map[string]int64{
+			"build":   120,
+			"unknown": 30,
+		},
+	}
+
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	assert.Len(t, pipelineItems, 1)
+	assert.Equal(t, map[string]int64{"build": 120}, pipelineItems[0].StepEstimates)
+}
+
+func TestConfigSource(t *testing.T) {
+	t.Parallel()
+
+	newBuilder := func(source metadata.ConfigSource) StepBuilder {
+		return StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{FullName: "org/app"},
+			Curr: &model.Pipeline{
+				Event:  model.EventPush,
+				Commit: "0123456789",
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  []*model.Registry{},
+			Host:  "",
+			Yamls: []*forge_types.FileMeta{
+				{Data: []byte(`
+when:
+  event: push
+skip_clone: true
+steps:
+  build:
+    image: scratch
+`)},
+			},
+			ConfigSource: source,
+		}
+	}
+
+	b := newBuilder(metadata.ConfigSource{})
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	assert.Len(t, pipelineItems, 1)
+	env := pipelineItems[0].Config.Stages[0].Steps[0].Environment
+	assert.Equal(t, "org/app", env["CI_CONFIG_SOURCE_REPO"])
+	assert.Equal(t, "0123456789", env["CI_CONFIG_SOURCE_SHA"])
+
+	b = newBuilder(metadata.ConfigSource{Repo: "org/config", Sha: "abcdef"})
+	pipelineItems, err = b.Build()
+	assert.NoError(t, err)
+	assert.Len(t, pipelineItems, 1)
+	env = pipelineItems[0].Config.Stages[0].Steps[0].Environment
+	assert.Equal(t, "org/config", env["CI_CONFIG_SOURCE_REPO"])
+	assert.Equal(t, "abcdef", env["CI_CONFIG_SOURCE_SHA"])
+}
+
+func TestExplainEnv(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Envs: map[string]string{
+			"DEPLOY_ENV": "staging",
+			"GO_VERSION": "1.21",
+		},
+		Yamls: []*forge_types.FileMeta{
+			{Data: []byte(`
+when:
+  event: push
+skip_clone: true
+matrix:
+  GO_VERSION:
+    - 1.22
+steps:
+  build:
+    image: golang:${GO_VERSION}
+    environment:
+      DEPLOY_ENV: production
+`)},
+		},
+	}
+
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	assert.Len(t, pipelineItems, 1)
+
+	explained, err := b.ExplainEnv(pipelineItems[0], "build")
+	assert.NoError(t, err)
+	sources := make(map[string]compiler.EnvSource, len(explained))
+	for _, env := range explained {
+		sources[env.Key] = env.Source
+	}
+	assert.Equal(t, compiler.EnvSourceStep, sources["DEPLOY_ENV"])
+	assert.Equal(t, compiler.EnvSourceGlobal, sources["GO_VERSION"])
+	assert.Equal(t, compiler.EnvSourceMetadata, sources["CI_PIPELINE_EVENT"])
+
+	_, err = b.ExplainEnv(pipelineItems[0], "unknown")
+	assert.Error(t, err)
+}
+
+func TestPluginSettingsSecret(t *testing.T) {
+	t.Parallel()
+
+	newBuilder := func(event model.WebhookEvent, image string) StepBuilder {
+		return StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event: event,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs: []*model.Secret{{
+				Name:   "deploy_token",
+				Value:  "s3cr3t",
+				Images: []string{"plugins/deploy"},
+				Events: []model.WebhookEvent{model.EventPush},
+			}},
+			Regs: []*model.Registry{},
+			Host: "",
+			Yamls: []*forge_types.FileMeta{
+				{Data: []byte(fmt.Sprintf(`
+when:
+  event: [push, pull_request]
+skip_clone: true
+steps:
+  deploy:
+    image: %s
+    settings:
+      token:
+        from_secret: deploy_token
+`, image))},
+			},
+		}
+	}
+
+	b := newBuilder(model.EventPush, "plugins/deploy")
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	assert.Len(t, pipelineItems, 1)
+	assert.Equal(t, "s3cr3t", pipelineItems[0].Config.Stages[0].Steps[0].Environment["PLUGIN_TOKEN"])
+
+	// secret is not available for this event
+	b = newBuilder(model.EventPull, "plugins/deploy")
+	_, err = b.Build()
+	assert.ErrorContains(t, err, "not allowed to be used with pipeline event")
+
+	// secret is only available for other plugins
+	b = newBuilder(model.EventPush, "plugins/other")
+	_, err = b.Build()
+	assert.ErrorContains(t, err, "is not allowed to be used with image")
+}
+
+func TestEnvironmentScopedRegistries(t *testing.T) {
+	t.Parallel()
+
+	build := func(deployTo string) *Item {
+		b := StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event:  model.EventDeploy,
+				Deploy: deployTo,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs: []*model.Registry{{
+				Address:      "registry.example.com",
+				Username:     "prod",
+				Password:     "prod-password",
+				Environments: []string{"production"},
+			}, {
+				Address:      "staging.example.com",
+				Username:     "staging",
+				Password:     "staging-password",
+				Environments: []string{"staging"},
+			}, {
+				Address:  "docker.io",
+				Username: "hub",
+				Password: "hub-password",
+			}},
+			Host: "",
+			Yamls: []*forge_types.FileMeta{
+				{Data: []byte(`
+when:
+  event: deployment
+skip_clone: true
+steps:
+  prod:
+    image: registry.example.com/app
+  staging:
+    image: staging.example.com/app
+  hub:
+    image: golang
+`)},
+			},
+		}
+
+		pipelineItems, err := b.Build()
+		assert.NoError(t, err)
+		assert.Len(t, pipelineItems, 1)
+		return pipelineItems[0]
+	}
+
+	authUsers := func(item *Item) map[string]string {
+		users := map[string]string{}
+		for _, stage := range item.Config.Stages {
+			for _, step := range stage.Steps {
+				users[step.Name] = step.AuthConfig.Username
+			}
+		}
+		return users
+	}
+
+	assert.Equal(t, map[string]string{"prod": "prod", "staging": "", "hub": "hub"}, authUsers(build("production")))
+	assert.Equal(t, map[string]string{"prod": "", "staging": "staging", "hub": "hub"}, authUsers(build("staging")))
+}
+
+func TestStepCache(t *testing.T) {
+	t.Parallel()
+
+	newBuilder := func(path string) StepBuilder {
+		return StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event:  model.EventPush,
+				Branch: "main",
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  []*model.Registry{},
+			Host:  "",
+			Yamls: []*forge_types.FileMeta{
+				{Data: []byte(fmt.Sprintf(`
+when:
+  event: push
+skip_clone: true
+steps:
+  build:
+    image: golang
+    commands: go build
+    cache:
+      key: go-${CI_COMMIT_BRANCH}
+      paths: %s
+`, path))},
+			},
+		}
+	}
+
+	b := newBuilder(".cache/go-build")
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	assert.Len(t, pipelineItems, 1)
+	assert.Equal(t, &backend_types.StepCache{
+		Key:   "go-main",
+		Paths: []string{".cache/go-build"},
+	}, pipelineItems[0].Config.Stages[0].Steps[0].Cache)
+
+	b = newBuilder("/root/.cache")
+	_, err = b.Build()
+	assert.True(t, errors.HasBlockingErrors(err))
+}
+
+func TestSchedulability(t *testing.T) {
+	t.Parallel()
+
+	newBuilder := func(agentLabels []map[string]string) StepBuilder {
+		return StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event: model.EventPush,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  []*model.Registry{},
+			Host:  "",
+			Yamls: []*forge_types.FileMeta{
+				{Name: "train", Data: []byte(`
+when:
+  event: push
+labels:
+  gpu: nvidia
+  platform: linux/amd64
+steps:
+  train:
+    image: scratch
+`)},
+			},
+			AgentLabels: agentLabels,
+		}
+	}
+
+	// check disabled
+	b := newBuilder(nil)
+	_, err := b.Build()
+	assert.NoError(t, err)
+
+	b = newBuilder([]map[string]string{
+		{"platform": "linux/amd64"},
+		{"platform": "linux/arm64", "gpu": "nvidia"},
+	})
+	_, err = b.Build()
+	assert.ErrorContains(t, err, "workflow 'train' can't be scheduled")
+
+	b = newBuilder([]map[string]string{
+		{"platform": "linux/amd64"},
+		{"platform": "linux/amd64", "gpu": "*"},
+	})
+	_, err = b.Build()
+	assert.NoError(t, err)
+}
+
+func TestDNSAndExtraHostsTrusted(t *testing.T) {
+	t.Parallel()
+
+	newBuilder := func(trusted bool) StepBuilder {
+		return StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{IsTrusted: trusted},
+			Curr: &model.Pipeline{
+				Event: model.EventPush,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  []*model.Registry{},
+			Host:  "",
+			Yamls: []*forge_types.FileMeta{
+				{Data: []byte(`
+when:
+  event: push
+skip_clone: true
+steps:
+  integration:
+    image: golang
+    commands: go test
+    dns: 10.0.0.53
+    extra_hosts:
+      - db.internal:10.0.0.10
+`)},
+			},
+		}
+	}
+
+	b := newBuilder(true)
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	assert.Len(t, pipelineItems, 1)
+	step := pipelineItems[0].Config.Stages[0].Steps[0]
+	assert.Equal(t, []string{"10.0.0.53"}, step.DNS)
+	assert.Equal(t, []backend_types.HostAlias{{Name: "db.internal", IP: "10.0.0.10"}}, step.ExtraHosts)
+
+	b = newBuilder(false)
+	_, err = b.Build()
+	assert.True(t, errors.HasBlockingErrors(err))
+	assert.ErrorContains(t, err, "Insufficient privileges to use extra_hosts")
+}
+
+func TestFailFast(t *testing.T) {
+	t.Parallel()
+
+	newBuilder := func(failFast string) StepBuilder {
+		return StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event: model.EventPush,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  []*model.Registry{},
+			Host:  "",
+			Yamls: []*forge_types.FileMeta{
+				{Name: "test", Data: []byte(fmt.Sprintf(`
+when:
+  event: push
+fail_fast: %s
+steps:
+  test:
+    image: scratch
+`, failFast))},
+			},
+		}
+	}
+
+	b := newBuilder("true")
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	assert.Len(t, pipelineItems, 1)
+	assert.True(t, pipelineItems[0].FailFast)
+
+	b = newBuilder("sometimes")
+	_, err = b.Build()
+	assert.Error(t, err)
+}
+
+func TestArtifacts(t *testing.T) {
+	artifactImage := server.Config.Pipeline.ArtifactImage
+	server.Config.Pipeline.ArtifactImage = "woodpeckerci/plugin-artifact"
+	t.Cleanup(func() { server.Config.Pipeline.ArtifactImage = artifactImage })
+
+	newBuilder := func(build, test string) StepBuilder {
+		return StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event: model.EventPush,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  []*model.Registry{},
+			Host:  "",
+			Yamls: []*forge_types.FileMeta{
+				{Name: ".woodpecker/build.yml", Data: []byte(build)},
+				{Name: ".woodpecker/test.yml", Data: []byte(test)},
+			},
+		}
+	}
+
+	build := `
+when:
+  event: push
+steps:
+  build:
+    image: golang
+    commands: go build -o dist/app
+artifacts: [ dist/ ]
+`
+	test := `
+when:
+  event: push
+steps:
+  test:
+    image: golang
+    commands: ./dist/app
+depends_on: [ build ]
+consumes:
+  - workflow: build
+    paths: dist
+`
+
+	b := newBuilder(build, test)
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	assert.Len(t, pipelineItems, 2)
+	assert.Equal(t, []string{"dist/"}, pipelineItems[0].Artifacts)
+	assert.Equal(t, "save-artifacts", pipelineItems[0].Config.Stages[len(pipelineItems[0].Config.Stages)-1].Steps[0].Name)
+	assert.Len(t, pipelineItems[1].Consumes, 1)
+	assert.Equal(t, "restore-artifacts-build", pipelineItems[1].Config.Stages[1].Steps[0].Name)
+
+	// producer does not declare the artifact
+	b = newBuilder(strings.Replace(build, "artifacts: [ dist/ ]", "artifacts: [ bin ]", 1), test)
+	_, err = b.Build()
+	assert.EqualError(t, err, "workflow 'test' consumes artifact 'dist' which is not declared by workflow 'build'")
+
+	// consumer does not depend on the producer
+	b = newBuilder(build, strings.Replace(test, "depends_on: [ build ]", "", 1))
+	_, err = b.Build()
+	assert.EqualError(t, err, "workflow 'test' consumes artifacts of 'build' but does not depend on it")
+
+	// no artifact image configured
+	server.Config.Pipeline.ArtifactImage = ""
+	b = newBuilder(build, test)
+	_, err = b.Build()
+	assert.ErrorIs(t, err, &compiler.ErrArtifactImageMissing{})
+}
+
+func TestPriority(t *testing.T) {
+	t.Parallel()
+
+	newBuilder := func(trusted bool, priority int) StepBuilder {
+		return StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{IsTrusted: trusted},
+			Curr: &model.Pipeline{
+				Event: model.EventPush,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  []*model.Registry{},
+			Host:  "",
+			Yamls: []*forge_types.FileMeta{
+				{Name: "deploy", Data: []byte(fmt.Sprintf(`
+when:
+  event: push
+priority: %d
+steps:
+  deploy:
+    image: scratch
+`, priority))},
+			},
+		}
+	}
+
+	b := newBuilder(true, 5)
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	assert.Equal(t, 5, pipelineItems[0].Priority)
+
+	// untrusted repos can lower but not raise the priority
+	b = newBuilder(false, 5)
+	pipelineItems, err = b.Build()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pipelineItems[0].Priority)
+
+	b = newBuilder(false, -3)
+	pipelineItems, err = b.Build()
+	assert.NoError(t, err)
+	assert.Equal(t, -3, pipelineItems[0].Priority)
+
+	b = newBuilder(true, 11)
+	_, err = b.Build()
+	assert.Error(t, err)
+}
+
+func TestMatrixInclude(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Yamls: []*forge_types.FileMeta{
+			{Name: "test", Data: []byte(`
+when:
+  event: push
+matrix:
+  include:
+    - GO: 1.21
+      OS: linux
+    - GO: 1.20
+      OS: windows
+steps:
+  test:
+    image: golang:${GO}
+`)},
+		},
+	}
+
+	pipelineItems, err := b.Build()
+	// the unquoted 1.20 is kept as written, but the linter warns about it
+	if pipelineErrors := errors.GetPipelineErrors(err); assert.Len(t, pipelineErrors, 1) {
+		assert.True(t, pipelineErrors[0].IsWarning)
+		assert.Equal(t, "Matrix value 1.20 of 'GO' is the number 1.2 in YAML, quote it to keep it as written", pipelineErrors[0].Message)
+	}
+	assert.Len(t, pipelineItems, 2)
+	assert.Equal(t, map[string]string{"GO": "1.21", "OS": "linux"}, pipelineItems[0].Workflow.Environ)
+	assert.Equal(t, map[string]string{"GO": "1.20", "OS": "windows"}, pipelineItems[1].Workflow.Environ)
+	assert.Equal(t, 1, pipelineItems[0].Workflow.AxisID)
+	assert.Equal(t, 2, pipelineItems[1].Workflow.AxisID)
+	assert.Equal(t, "GO=1.21, OS=linux", pipelineItems[0].MatrixLabel)
+	assert.Equal(t, "GO=1.20, OS=windows", pipelineItems[1].MatrixLabel)
+	assert.Equal(t, matrix.Axis{"GO": "1.20", "OS": "windows"}, pipelineItems[1].MatrixAxis)
+}
+
+func TestRequiredSecrets(t *testing.T) {
+	t.Parallel()
+
+	// builds failing because of missing secrets don't use the forge
+	forge := getMockForge(t)
+	newBuilder := func(event model.WebhookEvent) StepBuilder {
+		return StepBuilder{
+			Forge: forge,
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event: event,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs: []*model.Secret{{
+				Name:     "deploy_token",
+				Value:    "secret",
+				Events:   []model.WebhookEvent{model.EventPush, model.EventDeploy},
+				Required: true,
+			}, {
+				Name:   "docker_password",
+				Value:  "secret",
+				Events: []model.WebhookEvent{model.EventTag},
+			}},
+			Regs: []*model.Registry{},
+			Host: "",
+			Yamls: []*forge_types.FileMeta{
+				{Data: []byte(`
+when:
+  event: push
+steps:
+  build:
+    image: scratch
+`)},
+			},
+		}
+	}
+
+	b := newBuilder(model.EventPush)
+	_, err := b.Build()
+	assert.NoError(t, err)
+
+	// no step uses the secret, but it is required
+	b = newBuilder(model.EventPull)
+	_, err = b.Build()
+	assert.EqualError(t, err, "required secret 'deploy_token' is not available for event 'pull_request'")
+}
+
+func TestEnvCollisionWarnings(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Envs: map[string]string{
+			"DEPLOY_ENV": "staging",
+			"GOOS":       "linux",
+		},
+		ReportEnvCollisions: true,
+		Yamls: []*forge_types.FileMeta{
+			{Name: "deploy", Data: []byte(`
+when:
+  event: push
+skip_clone: true
+steps:
+  deploy:
+    image: alpine
+    environment:
+      DEPLOY_ENV: production
+      GOOS: linux
+`)},
+		},
+	}
+
+	pipelineItems, err := b.Build()
+	assert.Len(t, pipelineItems, 1)
+	assert.False(t, errors.HasBlockingErrors(err))
+	pipelineErrors := errors.GetPipelineErrors(err)
+	assert.Len(t, pipelineErrors, 1)
+	assert.True(t, pipelineErrors[0].IsWarning)
+	assert.Equal(t, "Environment variable 'DEPLOY_ENV' of step 'deploy' is set with different values by global, step", pipelineErrors[0].Message)
+
+	b.ReportEnvCollisions = false
+	_, err = b.Build()
+	assert.NoError(t, err)
+}
+
+func TestSchemaVersion(t *testing.T) {
+	t.Parallel()
+
+	newBuilder := func(version int) StepBuilder {
+		return StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event: model.EventPush,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  []*model.Registry{},
+			Host:  "",
+			Yamls: []*forge_types.FileMeta{
+				{Name: "build", Data: []byte(fmt.Sprintf(`
+version: %d
+when:
+  event: push
+steps:
+  build:
+    image: scratch
+`, version))},
+			},
+		}
+	}
+
+	b := newBuilder(1)
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	assert.Len(t, pipelineItems, 1)
+
+	b = newBuilder(99)
+	_, err = b.Build()
+	assert.ErrorContains(t, err, "unknown schema version 99")
+}
+
+func TestConcurrency(t *testing.T) {
+	t.Parallel()
+
+	newBuilder := func(group string) StepBuilder {
+		return StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event:  model.EventPush,
+				Branch: "main",
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  []*model.Registry{},
+			Host:  "",
+			Yamls: []*forge_types.FileMeta{
+				{Name: "deploy", Data: []byte(fmt.Sprintf(`
+when:
+  event: push
+concurrency:
+  group: "%s"
+  cancel_in_progress: true
+steps:
+  deploy:
+    image: scratch
+`, group))},
+			},
+		}
+	}
+
+	b := newBuilder("deploy-${CI_COMMIT_BRANCH}")
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	if assert.Len(t, pipelineItems, 1) {
+		assert.Equal(t, &yaml_types.Concurrency{Group: "deploy-main", CancelInProgress: true}, pipelineItems[0].Concurrency)
+	}
+
+	// empty after substitution
+	b = newBuilder("${UNKNOWN}")
+	_, err = b.Build()
+	assert.ErrorContains(t, err, "concurrency group of workflow 'deploy' is empty")
+}
+
+func TestPipelineTimeout(t *testing.T) {
+	maxTimeout := server.Config.Pipeline.MaxTimeout
+	server.Config.Pipeline.MaxTimeout = 60
+	t.Cleanup(func() { server.Config.Pipeline.MaxTimeout = maxTimeout })
+
+	newBuilder := func(trusted bool, timeouts string) StepBuilder {
+		return StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{IsTrusted: trusted},
+			Curr: &model.Pipeline{
+				Event: model.EventPush,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  []*model.Registry{},
+			Host:  "",
+			Yamls: []*forge_types.FileMeta{
+				{Name: "build", Data: []byte(fmt.Sprintf(`
+when:
+  event: push
+%s
+steps:
+  build:
+    image: scratch
+`, timeouts))},
+			},
+		}
+	}
+
+	b := newBuilder(false, "timeout: 30m")
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Minute, pipelineItems[0].Timeout)
+	assert.Zero(t, pipelineItems[0].PipelineTimeout)
+
+	// all repos are capped by the max timeout of the server
+	for _, trusted := range []bool{false, true} {
+		b = newBuilder(trusted, "timeout: 2h\npipeline_timeout: 3h")
+		pipelineItems, err = b.Build()
+		assert.NoError(t, err)
+		assert.Equal(t, time.Hour, pipelineItems[0].Timeout)
+		assert.Equal(t, time.Hour, pipelineItems[0].PipelineTimeout)
+	}
+
+	// the timeouts of the workflow and the pipeline are independent
+	b = newBuilder(true, "timeout: 45m\npipeline_timeout: 50m")
+	pipelineItems, err = b.Build()
+	assert.NoError(t, err)
+	assert.Equal(t, 45*time.Minute, pipelineItems[0].Timeout)
+	assert.Equal(t, 50*time.Minute, pipelineItems[0].PipelineTimeout)
+
+	b = newBuilder(true, "timeout: -5m")
+	_, err = b.Build()
+	assert.ErrorContains(t, err, "Invalid timeout '-5m', expected a positive duration")
+
+	b = newBuilder(true, "pipeline_timeout: -5m")
+	_, err = b.Build()
+	assert.ErrorContains(t, err, "Invalid pipeline timeout '-5m', expected a positive duration")
+}
+
+func TestImagePlatforms(t *testing.T) {
+	t.Parallel()
+
+	newBuilder := func(imagePlatforms map[string][]string) StepBuilder {
+		return StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event: model.EventPush,
+			},
+			Last:           &model.Pipeline{},
+			Netrc:          &model.Netrc{},
+			Secs:           []*model.Secret{},
+			Regs:           []*model.Registry{},
+			Host:           "",
+			ImagePlatforms: imagePlatforms,
+			Yamls: []*forge_types.FileMeta{
+				{Name: "build", Data: []byte(`
+when:
+  event: push
+labels:
+  platform: linux/arm64
+steps:
+  build:
+    image: golang:1.22
+  scan:
+    image: amd64-only/scanner
+`)},
+			},
+		}
+	}
+
+	b := newBuilder(map[string][]string{
+		"golang:1.22":        {"linux/amd64", "linux/arm64/v8"},
+		"amd64-only/scanner": {"linux/amd64"},
+	})
+	pipelineItems, err := b.Build()
+	assert.Len(t, pipelineItems, 1)
+	assert.False(t, errors.HasBlockingErrors(err))
+	var messages []string
+	for _, pipelineErr := range errors.GetPipelineErrors(err) {
+		messages = append(messages, pipelineErr.Message)
+	}
+	assert.Equal(t, []string{"Image 'amd64-only/scanner' does not support the workflow platform 'linux/arm64'"}, messages)
+
+	// skipped without platform data
+	b = newBuilder(nil)
+	_, err = b.Build()
+	assert.NoError(t, err)
+}
+
+func TestStableWorkflowID(t *testing.T) {
+	t.Parallel()
+
+	newBuilder := func(yamls ...*forge_types.FileMeta) StepBuilder {
+		return StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event: model.EventPush,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  []*model.Registry{},
+			Host:  "",
+			Yamls: yamls,
+		}
+	}
+
+	test := &forge_types.FileMeta{Name: ".woodpecker/test.yaml", Data: []byte(`
+when:
+  event: push
+matrix:
+  GO_VERSION:
+    - "1.21"
+    - "1.22"
+steps:
+  test:
+    image: golang:${GO_VERSION}
+`)}
+	build := &forge_types.FileMeta{Name: ".woodpecker/build.yaml", Data: []byte(`
+when:
+  event: push
+steps:
+  build:
+    image: golang
+`)}
+
+	stableIDs := func(items []*Item) map[string]int {
+		ids := make(map[string]int, len(items))
+		for _, item := range items {
+			ids[item.Workflow.StableID] = item.Workflow.PID
+		}
+		return ids
+	}
+
+	b := newBuilder(test)
+	before, err := b.Build()
+	assert.NoError(t, err)
+	assert.Len(t, stableIDs(before), 2, "matrix instances have different IDs")
+
+	// build.yaml is sorted before test.yaml and shifts the PIDs
+	b = newBuilder(test, build)
+	after, err := b.Build()
+	assert.NoError(t, err)
+	afterIDs := stableIDs(after)
+	for _, item := range before {
+		pid, ok := afterIDs[item.Workflow.StableID]
+		assert.True(t, ok)
+		assert.NotEqual(t, item.Workflow.PID, pid)
+	}
+}
+
+func TestMatrixImageVariables(t *testing.T) {
+	t.Parallel()
+
+	newBuilder := func(image string) StepBuilder {
+		return StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event: model.EventPush,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  []*model.Registry{},
+			Host:  "",
+			Yamls: []*forge_types.FileMeta{
+				{Name: "test", Data: []byte(fmt.Sprintf(`
+when:
+  event: push
+matrix:
+  GO_VERSION:
+    - "1.21"
+    - "1.22"
+steps:
+  test:
+    image: %s
+`, image))},
+			},
+		}
+	}
+
+	b := newBuilder("golang:${GO_VERSION}")
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	if assert.Len(t, pipelineItems, 2) {
+		assert.Equal(t, "golang:1.21", pipelineItems[0].Config.Stages[1].Steps[0].Image)
+		assert.Equal(t, "golang:1.22", pipelineItems[1].Config.Stages[1].Steps[0].Image)
+	}
+
+	// variables with a default value are resolved
+	b = newBuilder("golang:${GO:-1.22}")
+	_, err = b.Build()
+	assert.NoError(t, err)
+
+	b = newBuilder("golang:${GO}")
+	_, err = b.Build()
+	assert.ErrorContains(t, err, "image 'golang:${GO}' of step 'test' uses unknown variables GO for matrix axis GO_VERSION=1.21")
+}
+
+func TestMatrixPosition(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Yamls: []*forge_types.FileMeta{
+			{Name: "test", Data: []byte(`
+when:
+  event: push
+skip_clone: true
+matrix:
+  GO_VERSION:
+    - "1.21"
+    - "1.22"
+    - "1.23"
+steps:
+  setup:
+    image: alpine
+    matrix_position: first
+  test:
+    image: golang:${GO_VERSION}
+    depends_on: [setup]
+`)},
+		},
+	}
+
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	if !assert.Len(t, pipelineItems, 3) {
+		return
+	}
+
+	// steps of other positions are kept as skipped steps
+	skippedSteps := func(item *Item) (names []string) {
+		for _, stage := range item.Config.Stages {
+			for _, step := range stage.Steps {
+				if step.Condition == "false" {
+					names = append(names, step.Name)
+				}
+			}
+		}
+		return names
+	}
+	assert.Empty(t, skippedSteps(pipelineItems[0]))
+	assert.Equal(t, []string{"setup"}, skippedSteps(pipelineItems[1]))
+	assert.Equal(t, []string{"setup"}, skippedSteps(pipelineItems[2]))
+	assert.Equal(t, "1", pipelineItems[0].Config.Stages[0].Steps[0].Environment["CI_MATRIX_INDEX"])
+	assert.Equal(t, "3", pipelineItems[0].Config.Stages[0].Steps[0].Environment["CI_MATRIX_TOTAL"])
+}
+
+func TestMatrixPositionFiltered(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Yamls: []*forge_types.FileMeta{
+			{Name: "test", Data: []byte(`
+when:
+  event: push
+  evaluate: 'OS == "linux" || OS == "freebsd"'
+skip_clone: true
+matrix:
+  OS:
+    - windows
+    - linux
+    - freebsd
+    - darwin
+steps:
+  setup:
+    image: alpine
+    matrix_position: first
+  report:
+    image: alpine
+    matrix_position: last
+`)},
+		},
+	}
+
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	if !assert.Len(t, pipelineItems, 2) {
+		return
+	}
+
+	// the first and the last axis are filtered out, so the remaining ones take their positions
+	skippedSteps := func(item *Item) (names []string) {
+		for _, stage := range item.Config.Stages {
+			for _, step := range stage.Steps {
+				if step.Condition == "false" {
+					names = append(names, step.Name)
+				}
+			}
+		}
+		return names
+	}
+	assert.Equal(t, 2, pipelineItems[0].Workflow.AxisID)
+	assert.Equal(t, []string{"report"}, skippedSteps(pipelineItems[0]))
+	assert.Equal(t, 3, pipelineItems[1].Workflow.AxisID)
+	assert.Equal(t, []string{"setup"}, skippedSteps(pipelineItems[1]))
+}
+
+func TestDefaultRunsOn(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:          &model.Pipeline{},
+		Netrc:         &model.Netrc{},
+		Secs:          []*model.Secret{},
+		Regs:          []*model.Registry{},
+		Host:          "",
+		DefaultRunsOn: []string{"success", "failure"},
+		Yamls: []*forge_types.FileMeta{
+			{Name: "inherit", Data: []byte(`
+when:
+  event: push
+steps:
+  build:
+    image: scratch
+`)},
+			{Name: "override", Data: []byte(`
+when:
+  event: push
+runs_on: [success]
+steps:
+  build:
+    image: scratch
+`)},
+		},
+	}
+
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	if assert.Len(t, pipelineItems, 2) {
+		assert.Equal(t, []string{"success", "failure"}, pipelineItems[0].RunsOn)
+		assert.Equal(t, []string{"success"}, pipelineItems[1].RunsOn)
+	}
+}
+
+func TestEnvironmentNetrc(t *testing.T) {
+	t.Parallel()
+
+	newBuilder := func(target string) StepBuilder {
+		return StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{IsSCMPrivate: true},
+			Curr: &model.Pipeline{
+				Event:  model.EventDeploy,
+				Deploy: target,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{Login: "default", Password: "default-token", Machine: "codeberg.org"},
+			EnvironmentNetrcs: map[string]*model.Netrc{
+				"prod":    {Login: "prod", Password: "prod-token", Machine: "codeberg.org"},
+				"staging": {Login: "staging", Password: "staging-token", Machine: "codeberg.org"},
+			},
+			Secs: []*model.Secret{},
+			Regs: []*model.Registry{},
+			Host: "",
+			Yamls: []*forge_types.FileMeta{
+				{Name: "deploy", Data: []byte(`
+when:
+  event: deployment
+steps:
+  deploy:
+    image: scratch
+`)},
+			},
+		}
+	}
+
+	for target, login := range map[string]string{"prod": "prod", "staging": "staging", "dev": "default"} {
+		b := newBuilder(target)
+		pipelineItems, err := b.Build()
+		assert.NoError(t, err)
+		if assert.Len(t, pipelineItems, 1) {
+			clone := pipelineItems[0].Config.Stages[0].Steps[0]
+			assert.Equal(t, login, clone.Environment["CI_NETRC_USERNAME"], target)
+			assert.Equal(t, login+"-token", clone.Environment["CI_NETRC_PASSWORD"], target)
+		}
+	}
+}
+
+func TestExperimentalWorkflow(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Yamls: []*forge_types.FileMeta{
+			{Name: "build", Data: []byte(`
+when:
+  event: push
+steps:
+  build:
+    image: scratch
+`)},
+			{Name: "try", Data: []byte(`
+when:
+  event: push
+experimental: true
+steps:
+  build:
+    image: scratch
+`)},
+		},
+	}
+
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	if assert.Len(t, pipelineItems, 2) {
+		assert.False(t, pipelineItems[0].Experimental)
+		assert.True(t, pipelineItems[1].Experimental)
+	}
+
+	// failures of experimental workflows don't fail the pipeline
+	assert.Equal(t, model.StatusSuccess, model.PipelineStatus([]*model.Workflow{
+		{State: model.StatusSuccess},
+		{State: model.StatusFailure, Experimental: true},
+	}))
+}
+
+func TestMatrixUndeclaredVariable(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Yamls: []*forge_types.FileMeta{
+			{Name: "test", Data: []byte(`
+when:
+  event: push
+matrix:
+  GO_VERSION:
+    - "1.22"
+steps:
+  test:
+    image: golang:${GO_VERSION}
+    commands:
+      - GOOS=${GO_OS} go test ./...
+      - echo ${CI_COMMIT_BRANCH}
+`)},
+		},
+	}
+
+	pipelineItems, err := b.Build()
+	assert.Len(t, pipelineItems, 1)
+	assert.False(t, errors.HasBlockingErrors(err))
+	warnings := errors.GetPipelineErrors(err)
+	if assert.Len(t, warnings, 1) {
+		assert.Equal(t, "Variable 'GO_OS' is neither declared by the matrix nor a known variable and resolves to an empty value", warnings[0].Message)
+		assert.True(t, warnings[0].IsWarning)
+	}
+}
+
+func TestNotifyTarget(t *testing.T) {
+	t.Parallel()
+
+	newBuilder := func(target string) StepBuilder {
+		return StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event: model.EventPush,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  []*model.Registry{},
+			Host:  "",
+			Yamls: []*forge_types.FileMeta{
+				{Name: "deploy", Data: []byte(fmt.Sprintf(`
+when:
+  event: push
+notify: "%s"
+steps:
+  deploy:
+    image: scratch
+`, target))},
+			},
+		}
+	}
+
+	b := newBuilder("slack:#deployments")
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	if assert.Len(t, pipelineItems, 1) {
+		assert.Equal(t, "slack:#deployments", pipelineItems[0].Notify)
+	}
+
+	b = newBuilder("#deployments")
+	_, err = b.Build()
+	assert.True(t, errors.HasBlockingErrors(err))
+	assert.ErrorContains(t, err, "Invalid notify target '#deployments'")
+}
+
+func TestDependencyStatus(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Yamls: []*forge_types.FileMeta{
+			{Name: "build", Data: []byte("when:\n  event: push\nsteps:\n  build:\n    image: scratch\n")},
+			{Name: "lint", Data: []byte("when:\n  event: push\nsteps:\n  lint:\n    image: scratch\n")},
+			{Name: "notify", Data: []byte(`
+when:
+  event: push
+depends_on:
+  - lint
+  - name: build
+    status: failure
+steps:
+  notify:
+    image: scratch
+`)},
+		},
+	}
+
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	if assert.Len(t, pipelineItems, 3) {
+		assert.Nil(t, pipelineItems[0].DependencyStatus)
+		assert.Equal(t, []string{"lint", "build"}, pipelineItems[2].DependsOn)
+		// dependencies without a status have to succeed
+		assert.Equal(t, map[string]string{"lint": "success", "build": "failure"}, pipelineItems[2].DependencyStatus)
+	}
+}
+
+func TestEventVariables(t *testing.T) {
+	t.Parallel()
+
+	newBuilder := func(event model.WebhookEvent) StepBuilder {
+		return StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event: event,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  []*model.Registry{},
+			Host:  "",
+			Yamls: []*forge_types.FileMeta{
+				{Name: "deploy", Data: []byte(`
+when:
+  event: [push, tag, manual]
+skip_clone: true
+variables:
+  - &alpine_image alpine
+env_defaults:
+  GOFLAGS: -mod=vendor
+  DEPLOY_ENV:
+    default: staging
+    tag: production
+  RELEASE:
+    tag: "true"
+steps:
+  deploy:
+    image: *alpine_image
+    commands: ./deploy.sh
+  check:
+    image: alpine
+    commands: ./check.sh
+    environment:
+      DEPLOY_ENV: local
+`)},
+			},
+		}
+	}
+
+	for _, test := range []struct {
+		event model.WebhookEvent
+		want  map[string]string
+	}{
+		{event: model.EventPush, want: map[string]string{"GOFLAGS": "-mod=vendor", "DEPLOY_ENV": "staging"}},
+		{event: model.EventManual, want: map[string]string{"GOFLAGS": "-mod=vendor", "DEPLOY_ENV": "staging"}},
+		{event: model.EventTag, want: map[string]string{"GOFLAGS": "-mod=vendor", "DEPLOY_ENV": "production", "RELEASE": "true"}},
+	} {
+		b := newBuilder(test.event)
+		pipelineItems, err := b.Build()
+		assert.NoError(t, err)
+		if !assert.Len(t, pipelineItems, 1) {
+			continue
+		}
+		assert.Equal(t, test.want, pipelineItems[0].Variables)
+
+		stages := pipelineItems[0].Config.Stages
+		for k, v := range test.want {
+			assert.Equal(t, v, stages[0].Steps[0].Environment[k])
+		}
+		_, hasRelease := stages[0].Steps[0].Environment["RELEASE"]
+		assert.Equal(t, test.event == model.EventTag, hasRelease)
+		// steps override the variables
+		assert.Equal(t, "local", stages[1].Steps[0].Environment["DEPLOY_ENV"])
+	}
+}
+
+func TestMatrixIncludeExclude(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Yamls: []*forge_types.FileMeta{
+			{Name: "test", Data: []byte(`
+when:
+  event: push
+matrix:
+  GO_VERSION: [ "1.21", "1.22" ]
+  PLATFORM: [ linux, windows ]
+  exclude:
+    - GO_VERSION: "1.21"
+      PLATFORM: windows
+  include:
+    - GO_VERSION: "1.22"
+      PLATFORM: darwin
+steps:
+  test:
+    image: golang:${GO_VERSION}
+    commands: go test
+`)},
+		},
+	}
+
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	if !assert.Len(t, pipelineItems, 4) {
+		return
+	}
+	var environs []map[string]string
+	for i, item := range pipelineItems {
+		assert.Equal(t, i+1, item.Workflow.AxisID)
+		assert.Equal(t, item.Workflow.Environ["PLATFORM"], item.Config.Stages[1].Steps[0].Environment["PLATFORM"])
+		environs = append(environs, item.Workflow.Environ)
+	}
+	assert.ElementsMatch(t, []map[string]string{
+		{"GO_VERSION": "1.21", "PLATFORM": "linux"},
+		{"GO_VERSION": "1.22", "PLATFORM": "linux"},
+		{"GO_VERSION": "1.22", "PLATFORM": "windows"},
+		{"GO_VERSION": "1.22", "PLATFORM": "darwin"},
+	}, environs)
+}
+
+func TestOptionalDependencies(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Yamls: []*forge_types.FileMeta{
+			{Name: "build", Data: []byte(`
+when:
+  event: push
+steps:
+  build:
+    image: scratch
+`)},
+			{Name: "docs", Data: []byte(`
+when:
+  event: tag
+steps:
+  docs:
+    image: scratch
+`)},
+			{Name: "deploy", Data: []byte(`
+when:
+  event: push
+depends_on:
+  - build
+  - '?docs'
+  - name: lint
+    optional: true
+    status: always
+steps:
+  deploy:
+    image: scratch
+`)},
+		},
+	}
+
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	if assert.Len(t, pipelineItems, 2) {
+		assert.Equal(t, "build", pipelineItems[0].Workflow.Name)
+		assert.Equal(t, "deploy", pipelineItems[1].Workflow.Name)
+		// the optional dependencies that don't run are dropped
+		assert.Equal(t, []string{"build"}, pipelineItems[1].DependsOn)
+		assert.Equal(t, map[string]string{"build": "success"}, pipelineItems[1].DependencyStatus)
+	}
+}
+
+func TestMatrixAxisDependencies(t *testing.T) {
+	t.Parallel()
+
+	build := func(dependency string) ([]*Item, error) {
+		b := StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event: model.EventPush,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  []*model.Registry{},
+			Host:  "",
+			Yamls: []*forge_types.FileMeta{
+				{Name: "deploy", Data: []byte(`
+when:
+  event: push
+depends_on: [ '` + dependency + `' ]
+steps:
+  deploy:
+    image: scratch
+`)},
+				{Name: "test", Data: []byte(`
+when:
+  event: push
+matrix:
+  GO: [ "1.21", "1.22" ]
+  OS: [ linux, windows ]
+steps:
+  test:
+    image: golang:${GO}
+`)},
+			},
+		}
+		return b.Build()
+	}
+
+	pipelineItems, err := build("test[GO=1.21,OS=linux]")
+	assert.NoError(t, err)
+	if assert.Len(t, pipelineItems, 5) {
+		assert.Equal(t, "deploy", pipelineItems[0].Workflow.Name)
+		assert.Equal(t, []string{"test[GO=1.21,OS=linux]"}, pipelineItems[0].DependsOn)
+
+		var matches []matrix.Axis
+		for _, item := range pipelineItems[1:] {
+			if MatchesDependency(item, pipelineItems[0].DependsOn[0]) {
+				matches = append(matches, item.Workflow.Environ)
+			}
+		}
+		assert.Equal(t, []matrix.Axis{{"GO": "1.21", "OS": "linux"}}, matches)
+	}
+
+	_, err = build("test[OS=linux]")
+	assert.ErrorContains(t, err, "dependency 'test[OS=linux]' is ambiguous, it matches 2 matrix axes")
+
+	_, err = build("test[GO=1.21")
+	assert.ErrorContains(t, err, "invalid dependency 'test[GO=1.21'")
+
+	// dependencies on axes which don't exist drop the workflow
+	pipelineItems, err = build("test[GO=1.20]")
+	assert.Len(t, pipelineItems, 4)
+	assert.ErrorContains(t, err, "workflow deploy dropped: depends on workflow 'test[GO=1.20]' which doesn't run")
+}
+
+func TestMatrixFailFast(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Yamls: []*forge_types.FileMeta{
+			{Name: "lint", Data: []byte(`
+when:
+  event: push
+steps:
+  lint:
+    image: golang
+    commands: go vet
+`)},
+			{Name: "test", Data: []byte(`
+when:
+  event: push
+matrix:
+  fail_fast: true
+  GO_VERSION: [ "1.21", "1.22" ]
+steps:
+  test:
+    image: golang:${GO_VERSION}
+    commands: go test
+`)},
+		},
+	}
+
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	if assert.Len(t, pipelineItems, 3) {
+		assert.False(t, pipelineItems[0].Workflow.MatrixFailFast)
+		assert.True(t, pipelineItems[1].Workflow.MatrixFailFast)
+		assert.True(t, pipelineItems[2].Workflow.MatrixFailFast)
+		assert.Equal(t, "1.21", pipelineItems[1].Workflow.Environ["GO_VERSION"])
+	}
+}
+
+func TestDuplicateWorkflowNames(t *testing.T) {
+	t.Parallel()
+
+	config := []byte(`
+when:
+  event: push
+steps:
+  test:
+    image: golang
+    commands: go test
+`)
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Yamls: []*forge_types.FileMeta{
+			{Name: ".woodpecker/backend/test.yml", Data: config},
+			{Name: ".woodpecker/frontend/test.yml", Data: config},
+		},
+	}
+
+	_, err := b.Build()
+	assert.EqualError(t, err, "config files '.woodpecker/backend/test.yml' and '.woodpecker/frontend/test.yml' both result in workflow 'test'")
+
+	// keeping the subdirectories makes the names unique
+	b.ConfigDir = ".woodpecker"
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	assert.Len(t, pipelineItems, 2)
+}
+
+func TestAutoDisambiguateNames(t *testing.T) {
+	t.Parallel()
+
+	config := []byte(`
+when:
+  event: push
+steps:
+  test:
+    image: golang
+    commands: go test
+`)
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Yamls: []*forge_types.FileMeta{
+			{Name: ".woodpecker/backend/test.yml", Data: config},
+			{Name: ".woodpecker/deploy.yml", Data: []byte(`
+when:
+  event: push
+depends_on: [ test-backend ]
+steps:
+  deploy:
+    image: alpine
+    commands: echo deploy
+`)},
+			{Name: ".woodpecker/frontend/test.yml", Data: config},
+		},
+		AutoDisambiguateNames: true,
+	}
+
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	if assert.Len(t, pipelineItems, 3) {
+		assert.Equal(t, "test-backend", pipelineItems[0].Workflow.Name)
+		assert.Equal(t, "deploy", pipelineItems[1].Workflow.Name)
+		assert.Equal(t, []string{"test-backend"}, pipelineItems[1].DependsOn)
+		assert.Equal(t, "test-frontend", pipelineItems[2].Workflow.Name)
+	}
+}
+
+func TestSubstitutionErrors(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Yamls: []*forge_types.FileMeta{
+			{Name: ".woodpecker/deploy.yml", Data: []byte(`
+when:
+  event: push
+steps:
+  deploy:
+    image: alpine
+    commands: echo ${DEPLOY_TARGET:?}
+`)},
+		},
+	}
+
+	_, err := b.Build()
+	pipelineErrors := errors.GetPipelineErrors(err)
+	if assert.Len(t, pipelineErrors, 1) {
+		assert.Equal(t, "[compiler] .woodpecker/deploy.yml: variable 'DEPLOY_TARGET' is not set", pipelineErrors[0].Error())
+		substErr, ok := pipelineErrors[0].Data.(*metadata.SubstitutionError)
+		if assert.True(t, ok) {
+			assert.Equal(t, ".woodpecker/deploy.yml", substErr.File)
+			assert.Equal(t, "DEPLOY_TARGET", substErr.Variable)
+		}
+	}
+}
+
+func TestDryRunSkipReasons(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event:  model.EventPush,
+			Branch: "feature/x",
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Yamls: []*forge_types.FileMeta{
+			{Name: "deploy", Data: []byte(`
+when:
+  event: push
+  branch: [ main, release/* ]
+steps:
+  deploy:
+    image: alpine
+    commands: echo deploy
+`)},
+			{Name: "test", Data: []byte(`
+when:
+  event: push
+steps:
+  test:
+    image: golang
+    commands: go test
+`)},
+		},
+	}
+
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	if assert.Len(t, pipelineItems, 1) {
+		assert.Equal(t, "test", pipelineItems[0].Workflow.Name)
+	}
+
+	b.DryRun = true
+	pipelineItems, err = b.Build()
+	assert.NoError(t, err)
+	if assert.Len(t, pipelineItems, 2) {
+		assert.Equal(t, "test", pipelineItems[0].Workflow.Name)
+		assert.Empty(t, pipelineItems[0].SkipReasons)
+
+		assert.Equal(t, "deploy", pipelineItems[1].Workflow.Name)
+		assert.Equal(t, model.StatusSkipped, pipelineItems[1].Workflow.State)
+		assert.Equal(t, 2, pipelineItems[1].Workflow.PID)
+		assert.Nil(t, pipelineItems[1].Config)
+		assert.Equal(t, []string{"branch 'feature/x' does not match [main, release/*]"}, pipelineItems[1].SkipReasons)
+	}
+}
+
+func TestPrivilegedEvents(t *testing.T) {
+	privilegedEvents := server.Config.Pipeline.PrivilegedEvents
+	server.Config.Pipeline.PrivilegedEvents = []model.WebhookEvent{model.EventPush, model.EventTag}
+	t.Cleanup(func() {
+		server.Config.Pipeline.PrivilegedEvents = privilegedEvents
+		server.Config.Pipeline.PrivilegedEventsDowngrade = false
+	})
+
+	newBuilder := func(event model.WebhookEvent) StepBuilder {
+		return StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{IsTrusted: true},
+			Curr: &model.Pipeline{
+				Event: event,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  []*model.Registry{},
+			Host:  "",
+			Yamls: []*forge_types.FileMeta{
+				{Name: "build", Data: []byte(`
+when:
+  event: [ push, pull_request ]
+steps:
+  docker:
+    image: docker
+    commands: docker build .
+    privileged: true
+`)},
+			},
+		}
+	}
+
+	b := newBuilder(model.EventPush)
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	if assert.Len(t, pipelineItems, 1) {
+		assert.True(t, pipelineItems[0].Config.Stages[1].Steps[0].Privileged)
+	}
+
+	b = newBuilder(model.EventPull)
+	_, err = b.Build()
+	assert.ErrorContains(t, err, "privileged mode is not allowed for pull_request events, used by: docker")
+
+	server.Config.Pipeline.PrivilegedEventsDowngrade = true
+	b = newBuilder(model.EventPull)
+	pipelineItems, err = b.Build()
+	assert.NoError(t, err)
+	if assert.Len(t, pipelineItems, 1) {
+		assert.False(t, pipelineItems[0].Config.Stages[1].Steps[0].Privileged)
+	}
+}
+
+func TestPrivilegedEventsStepFilter(t *testing.T) {
+	privilegedEvents := server.Config.Pipeline.PrivilegedEvents
+	server.Config.Pipeline.PrivilegedEvents = []model.WebhookEvent{model.EventPush}
+	t.Cleanup(func() { server.Config.Pipeline.PrivilegedEvents = privilegedEvents })
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{IsTrusted: true},
+		Curr: &model.Pipeline{
+			Event: model.EventPull,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Yamls: []*forge_types.FileMeta{
+			{Name: "build", Data: []byte(`
+when:
+  event: [ push, pull_request ]
+steps:
+  test:
+    image: golang
+    commands: go test ./...
+  docker:
+    image: docker
+    commands: docker build .
+    privileged: true
+    when:
+      event: push
+`)},
+		},
+	}
+
+	// the privileged step doesn't run for pull requests
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	assert.Len(t, pipelineItems, 1)
+}
+
+func TestWorkflowEnvironment(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Yamls: []*forge_types.FileMeta{
+			{Name: "build", Data: []byte(`
+when:
+  event: push
+skip_clone: true
+environment:
+  GO_VERSION: "1.21"
+  CI_PIPELINE_EVENT: tag
+steps:
+  build:
+    image: golang:${GO_VERSION}
+    commands: echo ${CI_PIPELINE_EVENT}
+`)},
+			{Name: "test", Data: []byte(`
+when:
+  event: push
+skip_clone: true
+matrix:
+  GO_VERSION:
+    - "1.22"
+environment:
+  GO_VERSION: "1.21"
+steps:
+  test:
+    image: golang:${GO_VERSION}
+    commands: go test
+`)},
+		},
+	}
+
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	if assert.Len(t, pipelineItems, 2) {
+		step := pipelineItems[0].Config.Stages[0].Steps[0]
+		assert.Equal(t, "golang:1.21", step.Image)
+		assert.Equal(t, []string{"echo push"}, step.Commands)
+		assert.NotContains(t, step.Environment, "GO_VERSION")
+		assert.Equal(t, "golang:1.22", pipelineItems[1].Config.Stages[0].Steps[0].Image)
+	}
+}
+
+func TestWorkflowPIDsIndependentOfFileOrder(t *testing.T) {
+	t.Parallel()
+
+	config := []byte(`
+when:
+  event: push
+steps:
+  build:
+    image: scratch
+`)
+	build := func(names ...string) map[string]int {
+		b := StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event: model.EventPush,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  []*model.Registry{},
+			Host:  "",
+		}
+		for _, name := range names {
+			b.Yamls = append(b.Yamls, &forge_types.FileMeta{Name: name, Data: config})
+		}
+
+		pipelineItems, err := b.Build()
+		assert.NoError(t, err)
+		pids := map[string]int{}
+		for _, item := range pipelineItems {
+			pids[item.Workflow.Name] = item.Workflow.PID
+		}
+		return pids
+	}
+
+	expected := map[string]int{"a": 1, "b": 2, "c": 3}
+	assert.Equal(t, expected, build("a", "b", "c"))
+	assert.Equal(t, expected, build("c", "a", "b"))
+	assert.Equal(t, expected, build("b", "c", "a"))
+}
+
+func TestRepoCloneImage(t *testing.T) {
+	defaultCloneImage := server.Config.Pipeline.DefaultCloneImage
+	server.Config.Pipeline.DefaultCloneImage = "woodpeckerci/plugin-git:server"
+	t.Cleanup(func() {
+		server.Config.Pipeline.DefaultCloneImage = defaultCloneImage
+	})
+
+	build := func(repo *model.Repo, config string) string {
+		b := StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  repo,
+			Curr: &model.Pipeline{
+				Event: model.EventPush,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  []*model.Registry{},
+			Host:  "",
+			Yamls: []*forge_types.FileMeta{
+				{Name: "build", Data: []byte(config)},
+			},
+		}
+		pipelineItems, err := b.Build()
+		assert.NoError(t, err)
+		if !assert.Len(t, pipelineItems, 1) {
+			return ""
+		}
+		return pipelineItems[0].Config.Stages[0].Steps[0].Image
+	}
+
+	config := `
+when:
+  event: push
+steps:
+  build:
+    image: golang
+    commands: go build
+`
+	assert.Equal(t, "woodpeckerci/plugin-git:server", build(&model.Repo{}, config))
+	assert.Equal(t, "example.com/git:repo", build(&model.Repo{CloneImage: "example.com/git:repo"}, config))
+
+	// the clone section of the workflow still wins
+	assert.Equal(t, "example.com/git:workflow", build(&model.Repo{CloneImage: "example.com/git:repo"}, `
+when:
+  event: push
+clone:
+  git:
+    image: example.com/git:workflow
+steps:
+  build:
+    image: golang
+    commands: go build
+`))
+
+	// the clone image of the workflow takes precedence over the repo setting
+	assert.Equal(t, "example.com/git:mirror", build(&model.Repo{IsTrusted: true, CloneImage: "example.com/git:repo"}, `
+when:
+  event: push
+clone:
+  image: example.com/git:mirror
+steps:
+  build:
+    image: golang
+    commands: go build
+`))
+}
+
+func TestWorkflowBackendLabel(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Yamls: []*forge_types.FileMeta{
+			{Name: "k8s", Data: []byte(`
+when:
+  event: push
+backend: kubernetes
+steps:
+  build:
+    image: golang
+    commands: go build
+`)},
+			{Name: "any", Data: []byte(`
+when:
+  event: push
+steps:
+  build:
+    image: golang
+    commands: go build
+`)},
+		},
+	}
+
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	if assert.Len(t, pipelineItems, 2) {
+		// workflows are sorted by name
+		assert.NotContains(t, pipelineItems[0].Labels, "backend")
+		assert.Equal(t, "kubernetes", pipelineItems[1].Labels["backend"])
+	}
+}
+
+func TestPipelineTraceID(t *testing.T) {
+	t.Parallel()
+
+	build := func(traceID string) []*Item {
+		b := StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event:   model.EventPush,
+				TraceID: traceID,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  []*model.Registry{},
+			Host:  "",
+			Yamls: []*forge_types.FileMeta{
+				{Name: "build", Data: []byte(`
+when:
+  event: push
+skip_clone: true
+steps:
+  build:
+    image: golang
+    commands: go build
+  test:
+    image: golang
+    commands: go test
+`)},
+				{Name: "lint", Data: []byte(`
+when:
+  event: push
+skip_clone: true
+steps:
+  lint:
+    image: golang
+    commands: go vet
+`)},
+			},
+		}
+		pipelineItems, err := b.Build()
+		assert.NoError(t, err)
+		return pipelineItems
+	}
+
+	traceIDs := func(items []*Item) []string {
+		var ids []string
+		for _, item := range items {
+			for _, stage := range item.Config.Stages {
+				for _, step := range stage.Steps {
+					ids = append(ids, step.Environment["CI_PIPELINE_TRACE_ID"])
+				}
+			}
+		}
+		return ids
+	}
+
+	generated := traceIDs(build(""))
+	if assert.Len(t, generated, 3) {
+		assert.Len(t, generated[0], 32)
+		assert.Equal(t, []string{generated[0], generated[0], generated[0]}, generated)
+	}
+
+	provided := "4bf92f3577b34da6a3ce929d0e0e4736"
+	assert.Equal(t, []string{provided, provided, provided}, traceIDs(build(provided)))
+}
+
+func TestMaxParallelSteps(t *testing.T) {
+	maxParallelSteps := server.Config.Pipeline.MaxParallelSteps
+	server.Config.Pipeline.MaxParallelSteps = 3
+	t.Cleanup(func() {
+		server.Config.Pipeline.MaxParallelSteps = maxParallelSteps
+	})
+
+	workflow := func(maxSteps string) []byte {
+		return []byte(`
+when:
+  event: push
+` + maxSteps + `
+steps:
+  build:
+    image: golang
+    commands: go build
+`)
+	}
+	build := func(repo *model.Repo, yamls ...[]byte) (*model.Pipeline, error) {
+		var files []*forge_types.FileMeta
+		for i, data := range yamls {
+			files = append(files, &forge_types.FileMeta{Name: fmt.Sprintf("workflow-%d", i), Data: data})
+		}
+		b := StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  repo,
+			Curr: &model.Pipeline{
+				Event: model.EventPush,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  []*model.Registry{},
+			Host:  "",
+			Yamls: files,
+		}
+		_, err := b.Build()
+		return b.Curr, err
+	}
+
+	// the smallest cap of the workflows applies to the pipeline
+	pipeline, err := build(&model.Repo{IsTrusted: true}, workflow("max_parallel_steps: 5"), workflow("max_parallel_steps: 4"), workflow(""))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, pipeline.MaxParallelSteps)
+
+	pipeline, err = build(&model.Repo{IsTrusted: true}, workflow(""))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pipeline.MaxParallelSteps)
+
+	// untrusted repos are capped by the server
+	pipeline, err = build(&model.Repo{}, workflow("max_parallel_steps: 5"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, pipeline.MaxParallelSteps)
+
+	pipeline, err = build(&model.Repo{}, workflow(""))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, pipeline.MaxParallelSteps)
+
+	pipeline, err = build(&model.Repo{}, workflow("max_parallel_steps: 2"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, pipeline.MaxParallelSteps)
+
+	_, err = build(&model.Repo{IsTrusted: true}, workflow("max_parallel_steps: -1"))
+	assert.ErrorContains(t, err, "max_parallel_steps -1 has to be positive")
+}
+
+func TestRegistryCredentials(t *testing.T) {
+	t.Parallel()
+
+	var refreshed []string
+	fakeCredentials := func(registry *model.Registry) (string, string, error) {
+		refreshed = append(refreshed, registry.Address)
+		if registry.Address == "broken.example.com" {
+			return "", "", fmt.Errorf("token expired")
+		}
+		return "AWS", "fresh-token", nil
+	}
+
+	build := func(regs ...*model.Registry) ([]*Item, error) {
+		b := StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event: model.EventPush,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  regs,
+			Host:  "",
+			Yamls: []*forge_types.FileMeta{
+				{Name: "build", Data: []byte(`
+when:
+  event: push
+skip_clone: true
+steps:
+  ecr:
+    image: ecr.example.com/app/build
+    commands: make
+  static:
+    image: static.example.com/app/build
+    commands: make
+`)},
+			},
+			RegistryCredentials: fakeCredentials,
+		}
+		return b.Build()
+	}
+
+	pipelineItems, err := build(
+		&model.Registry{Address: "ecr.example.com", Username: "AWS", Password: "stale-token", TokenAuth: true},
+		&model.Registry{Address: "static.example.com", Username: "user", Password: "password"},
+	)
+	assert.NoError(t, err)
+	if assert.Len(t, pipelineItems, 1) {
+		stages := pipelineItems[0].Config.Stages
+		assert.Equal(t, "fresh-token", stages[0].Steps[0].AuthConfig.Password)
+		assert.Equal(t, "password", stages[1].Steps[0].AuthConfig.Password)
+	}
+	assert.Equal(t, []string{"ecr.example.com"}, refreshed)
+
+	_, err = build(&model.Registry{Address: "broken.example.com", Username: "AWS", Password: "stale-token", TokenAuth: true})
+	assert.ErrorContains(t, err, "could not refresh the credentials of registry 'broken.example.com': token expired")
+}
+
+func TestStepOutputs(t *testing.T) {
+	t.Parallel()
+
+	build := func(data string) ([]*Item, error) {
+		b := StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{},
+			Curr:  &model.Pipeline{Event: model.EventPush},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  []*model.Registry{},
+			Host:  "",
+			Yamls: []*forge_types.FileMeta{{Name: "deploy", Data: []byte(data)}},
+		}
+		return b.Build()
+	}
+
+	items, err := build(`
+when:
+  event: push
+skip_clone: true
+steps:
+  detect:
+    image: alpine
+    commands: echo "::set-output name=changed::true"
+    outputs: [changed]
+  deploy:
+    image: alpine
+    commands: ./deploy.sh
+    when:
+      evaluate: "steps.detect.outputs.changed == 'true'"
+`)
+	assert.NoError(t, err)
+	if assert.Len(t, items, 1) && assert.Len(t, items[0].Config.Stages, 2) {
+		detect := items[0].Config.Stages[0].Steps[0]
+		assert.Equal(t, []string{"changed"}, detect.Outputs)
+		assert.Empty(t, detect.Condition)
+		deploy := items[0].Config.Stages[1].Steps[0]
+		assert.Equal(t, "(steps.detect.outputs.changed == 'true')", deploy.Condition)
+	}
+
+	_, err = build(`
+when:
+  event: push
+skip_clone: true
+steps:
+  detect:
+    image: alpine
+    commands: echo "::set-output name=changed::true"
+    outputs: [changed]
+  deploy:
+    image: alpine
+    commands: ./deploy.sh
+    when:
+      evaluate: "steps.detect.outputs.version != ''"
+`)
+	assert.ErrorContains(t, err, "step 'deploy' depends on output 'version' which step 'detect' doesn't declare")
+
+	_, err = build(`
+when:
+  event: push
+skip_clone: true
+steps:
+  deploy:
+    image: alpine
+    commands: ./deploy.sh
+    when:
+      evaluate: "steps.detect.outputs.changed == 'true'"
+`)
+	assert.ErrorContains(t, err, "step 'deploy' depends on output 'changed' of unknown step 'detect'")
+
+	_, err = build(`
+when:
+  event: push
+skip_clone: true
+steps:
+  deploy:
+    image: alpine
+    commands: ./deploy.sh
+    when:
+      evaluate: "steps.detect.outputs.changed == 'true'"
+  detect:
+    image: alpine
+    commands: echo "::set-output name=changed::true"
+    outputs: [changed]
+`)
+	assert.ErrorContains(t, err, "step 'deploy' depends on output 'changed' of step 'detect' which doesn't run before it")
+}
+
 func getMockForge(t *testing.T) forge.Forge {
 	forge := mocks.NewForge(t)
 	forge.On("Name").Return("mock")
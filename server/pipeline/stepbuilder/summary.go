@@ -0,0 +1,76 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"sort"
+
+	pipeline_errors "go.woodpecker-ci.org/woodpecker/v2/pipeline/errors"
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+)
+
+// Summary is an overview of the pipeline built by the StepBuilder.
+type Summary struct {
+	Workflows        int `json:"workflows"`
+	PendingWorkflows int `json:"pending_workflows"`
+	SkippedWorkflows int `json:"skipped_workflows"`
+	Steps            int `json:"steps"`
+	// MatrixExpansions is the number of workflows generated by a matrix
+	MatrixExpansions int      `json:"matrix_expansions"`
+	Images           []string `json:"images"`
+	Warnings         []string `json:"warnings,omitempty"`
+}
+
+// BuildSummary summarizes the items and the warnings returned by Build.
+func BuildSummary(items []*Item, warnings error) Summary {
+	summary := Summary{
+		Workflows: len(items),
+		Images:    []string{},
+	}
+
+	images := make(map[string]bool)
+	for _, item := range items {
+		switch item.Workflow.State {
+		case model.StatusPending:
+			summary.PendingWorkflows++
+		case model.StatusSkipped:
+			summary.SkippedWorkflows++
+		}
+		if item.Workflow.AxisID > 0 {
+			summary.MatrixExpansions++
+		}
+		if item.Config == nil {
+			continue
+		}
+		for _, stage := range item.Config.Stages {
+			for _, step := range stage.Steps {
+				summary.Steps++
+				if !images[step.Image] {
+					images[step.Image] = true
+					summary.Images = append(summary.Images, step.Image)
+				}
+			}
+		}
+	}
+	sort.Strings(summary.Images)
+
+	for _, err := range pipeline_errors.GetPipelineErrors(warnings) {
+		if err.IsWarning {
+			summary.Warnings = append(summary.Warnings, err.Message)
+		}
+	}
+
+	return summary
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	forge_types "go.woodpecker-ci.org/woodpecker/v2/server/forge/types"
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+	"go.woodpecker-ci.org/woodpecker/v2/shared/constant"
+)
+
+func TestBuildSummary(t *testing.T) {
+	t.Parallel()
+
+	b := StepBuilder{
+		Forge: getMockForge(t),
+		Repo:  &model.Repo{},
+		Curr: &model.Pipeline{
+			Event: model.EventPush,
+		},
+		Last:  &model.Pipeline{},
+		Netrc: &model.Netrc{},
+		Secs:  []*model.Secret{},
+		Regs:  []*model.Registry{},
+		Host:  "",
+		Yamls: []*forge_types.FileMeta{
+			{Name: "docs", Data: []byte(`
+when:
+  event: push
+skip_clone: true
+steps:
+  build:
+    image: node
+    commands: npm run docs
+`)},
+			{Name: "test", Data: []byte(`
+when:
+  event: push
+matrix:
+  GO_VERSION: [ "1.21", "1.22" ]
+steps:
+  test:
+    image: golang:${GO_VERSION}
+    commands: go test
+  lint:
+    image: golangci/golangci-lint
+    commands: golangci-lint run
+`)},
+			{Name: "deploy", Data: []byte(`
+when:
+  event: push
+depends_on: [ buld ]
+steps:
+  deploy:
+    image: alpine
+    commands: ./deploy.sh
+`)},
+		},
+	}
+
+	items, warnings := b.Build()
+	if !assert.Len(t, items, 3) {
+		return
+	}
+	items[0].Workflow.State = model.StatusSkipped
+
+	summary := BuildSummary(items, warnings)
+	assert.Equal(t, Summary{
+		Workflows:        3,
+		PendingWorkflows: 2,
+		SkippedWorkflows: 1,
+		// clone and two steps for every matrix axis
+		Steps:            7,
+		MatrixExpansions: 2,
+		Images:           []string{constant.DefaultCloneImage, "golang:1.21", "golang:1.22", "golangci/golangci-lint", "node"},
+		Warnings:         []string{"workflow deploy dropped: depends on unknown workflow 'buld'"},
+	}, summary)
+
+	_, err := json.Marshal(summary)
+	assert.NoError(t, err)
+}
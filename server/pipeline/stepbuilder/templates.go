@@ -0,0 +1,121 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"sort"
+	"strings"
+
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/metadata"
+	"go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml"
+	yaml_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/frontend/yaml/types"
+)
+
+// inlineTemplates replaces the steps using a template by the steps of the template.
+func (b *StepBuilder) inlineTemplates(workflow *yaml_types.Workflow, environ map[string]string) error {
+	steps := make([]*yaml_types.Container, 0, len(workflow.Steps.ContainerList))
+	for _, step := range workflow.Steps.ContainerList {
+		if step.Use == "" {
+			steps = append(steps, step)
+			continue
+		}
+
+		templateSteps, err := b.templateSteps(step, environ)
+		if err != nil {
+			return err
+		}
+		steps = append(steps, templateSteps...)
+	}
+	workflow.Steps.ContainerList = steps
+	return nil
+}
+
+// templateSteps substitutes the parameters of the step into its template and returns the resulting steps.
+// Template parameters are referenced like environment variables and take precedence over them.
+func (b *StepBuilder) templateSteps(step *yaml_types.Container, environ map[string]string) ([]*yaml_types.Container, error) {
+	var data string
+	found := false
+	for _, t := range b.Templates {
+		if t.Name == step.Use {
+			data, found = string(t.Data), true
+			break
+		}
+	}
+	if !found && b.LoadTemplate != nil {
+		file, ok := templatePath(step.Use)
+		if !ok {
+			return nil, fmt.Errorf("step '%s' uses template '%s' which is outside of the repo", step.Name, step.Use)
+		}
+		content, err := b.LoadTemplate(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not load template '%s' of step '%s': %w", step.Use, step.Name, err)
+		}
+		data, found = string(content), true
+	}
+	if !found {
+		return nil, fmt.Errorf("step '%s' uses unknown template '%s'", step.Name, step.Use)
+	}
+
+	template, err := yaml.ParseTemplateString(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse template '%s': %w", step.Use, err)
+	}
+
+	for name := range step.With {
+		if _, ok := template.Parameters[name]; !ok {
+			return nil, fmt.Errorf("step '%s' sets parameter '%s' which is not defined by template '%s'", step.Name, name, step.Use)
+		}
+	}
+
+	names := make([]string, 0, len(template.Parameters))
+	for name := range template.Parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := maps.Clone(environ)
+	for _, name := range names {
+		value, ok := step.With[name]
+		if param := template.Parameters[name]; !ok && param != nil {
+			if param.Required {
+				return nil, fmt.Errorf("step '%s' misses required parameter '%s' of template '%s'", step.Name, name, step.Use)
+			}
+			value = param.Default
+		}
+		params[name] = value
+	}
+
+	substituted, err := metadata.EnvVarSubst(data, params)
+	if err != nil {
+		return nil, err
+	}
+	template, err = yaml.ParseTemplateString(substituted)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse template '%s': %w", step.Use, err)
+	}
+	return template.Steps.ContainerList, nil
+}
+
+// templatePath returns the repo path of a template, it fails for paths leaving the repo.
+func templatePath(use string) (string, bool) {
+	file := path.Clean(use)
+	if path.IsAbs(file) || file == ".." || strings.HasPrefix(file, "../") {
+		return "", false
+	}
+	return file, true
+}
@@ -0,0 +1,164 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	forge_types "go.woodpecker-ci.org/woodpecker/v2/server/forge/types"
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+)
+
+func TestTemplates(t *testing.T) {
+	t.Parallel()
+
+	template := &forge_types.FileMeta{Name: "go-test", Data: []byte(`
+parameters:
+  version:
+    required: true
+  flags:
+    default: -short
+steps:
+  test:
+    image: golang:${version}
+    commands:
+      - go test ${flags} ./...
+`)}
+
+	newBuilder := func(with string) StepBuilder {
+		return StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event: model.EventPush,
+			},
+			Last:      &model.Pipeline{},
+			Netrc:     &model.Netrc{},
+			Secs:      []*model.Secret{},
+			Regs:      []*model.Registry{},
+			Host:      "",
+			Templates: []*forge_types.FileMeta{template},
+			Yamls: []*forge_types.FileMeta{
+				{Name: "ci", Data: []byte(`
+when:
+  event: push
+skip_clone: true
+steps:
+  build:
+    image: golang
+    commands:
+      - go build
+  go:
+    use: go-test
+    with:` + with + `
+`)},
+			},
+		}
+	}
+
+	b := newBuilder(`
+      version: "1.22"`)
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	if assert.Len(t, pipelineItems, 1) {
+		var names []string
+		for _, stage := range pipelineItems[0].Config.Stages {
+			for _, step := range stage.Steps {
+				names = append(names, step.Name)
+				if step.Name == "test" {
+					assert.Equal(t, "golang:1.22", step.Image)
+					assert.Contains(t, step.Commands, "go test -short ./...")
+				}
+			}
+		}
+		assert.Equal(t, []string{"build", "test"}, names)
+	}
+
+	b = newBuilder(`
+      flags: -race`)
+	_, err = b.Build()
+	assert.ErrorContains(t, err, "step 'go' misses required parameter 'version' of template 'go-test'")
+
+	b = newBuilder(`
+      version: "1.22"
+      os: linux`)
+	_, err = b.Build()
+	assert.ErrorContains(t, err, "step 'go' sets parameter 'os' which is not defined by template 'go-test'")
+}
+
+func TestLoadTemplate(t *testing.T) {
+	t.Parallel()
+
+	newBuilder := func(use string) StepBuilder {
+		return StepBuilder{
+			Forge: getMockForge(t),
+			Repo:  &model.Repo{},
+			Curr: &model.Pipeline{
+				Event: model.EventPush,
+			},
+			Last:  &model.Pipeline{},
+			Netrc: &model.Netrc{},
+			Secs:  []*model.Secret{},
+			Regs:  []*model.Registry{},
+			Host:  "",
+			LoadTemplate: func(path string) ([]byte, error) {
+				if path != "ci/templates/go-test.yaml" {
+					return nil, fmt.Errorf("file '%s' not found", path)
+				}
+				return []byte(`
+steps:
+  test:
+    image: golang
+    commands:
+      - go test ./...
+`), nil
+			},
+			Yamls: []*forge_types.FileMeta{
+				{Name: "ci", Data: []byte(`
+when:
+  event: push
+skip_clone: true
+steps:
+  go:
+    use: ` + use + `
+`)},
+			},
+		}
+	}
+
+	b := newBuilder("./ci/templates/go-test.yaml")
+	pipelineItems, err := b.Build()
+	assert.NoError(t, err)
+	if assert.Len(t, pipelineItems, 1) {
+		var names []string
+		for _, stage := range pipelineItems[0].Config.Stages {
+			for _, step := range stage.Steps {
+				names = append(names, step.Name)
+			}
+		}
+		assert.Equal(t, []string{"test"}, names)
+	}
+
+	b = newBuilder("ci/templates/missing.yaml")
+	_, err = b.Build()
+	assert.ErrorContains(t, err, "could not load template 'ci/templates/missing.yaml' of step 'go': file 'ci/templates/missing.yaml' not found")
+
+	b = newBuilder("../other/go-test.yaml")
+	_, err = b.Build()
+	assert.ErrorContains(t, err, "step 'go' uses template '../other/go-test.yaml' which is outside of the repo")
+}
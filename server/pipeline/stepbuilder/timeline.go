@@ -0,0 +1,85 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import "sort"
+
+// defaultStepEstimate is the estimated duration in seconds of steps without historical duration.
+const defaultStepEstimate int64 = 60
+
+// WorkflowSchedule is the predicted start and end of a workflow in seconds after the start of the pipeline.
+type WorkflowSchedule struct {
+	Workflow string `json:"workflow"`
+	Start    int64  `json:"start"`
+	End      int64  `json:"end"`
+}
+
+// PredictTimeline estimates when the workflows start and end, assuming there are enough agents
+// to run all workflows in parallel. The durations map step names to their historical duration
+// in seconds like StepDurations, steps without one count with a default estimate. Workflows
+// start once all their dependencies ended. The schedule is ordered by the start of the workflows.
+func PredictTimeline(items []*Item, durations map[string]int64) ([]WorkflowSchedule, error) {
+	waves, err := ExecutionWaves(items)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*Item, len(items))
+	for _, item := range items {
+		byName[item.Workflow.Name] = item
+	}
+
+	ends := make(map[string]int64, len(items))
+	var schedule []WorkflowSchedule
+	for _, wave := range waves {
+		for _, name := range wave {
+			item := byName[name]
+			var start int64
+			for _, dep := range item.DependsOn {
+				if end, ok := ends[dependencyName(dep)]; ok && end > start {
+					start = end
+				}
+			}
+			ends[name] = start + workflowEstimate(item, durations)
+			schedule = append(schedule, WorkflowSchedule{Workflow: name, Start: start, End: ends[name]})
+		}
+	}
+
+	sort.SliceStable(schedule, func(i, j int) bool {
+		return schedule[i].Start < schedule[j].Start
+	})
+	return schedule, nil
+}
+
+// workflowEstimate returns the estimated duration of a workflow, its stages run one after another
+// and take as long as their slowest step.
+func workflowEstimate(item *Item, durations map[string]int64) int64 {
+	if item.Config == nil {
+		return 0
+	}
+	var total int64
+	for _, stage := range item.Config.Stages {
+		var slowest int64
+		for _, step := range stage.Steps {
+			duration, ok := durations[step.Name]
+			if !ok {
+				duration = defaultStepEstimate
+			}
+			slowest = max(slowest, duration)
+		}
+		total += slowest
+	}
+	return total
+}
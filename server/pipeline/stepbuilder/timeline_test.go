@@ -0,0 +1,75 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	backend_types "go.woodpecker-ci.org/woodpecker/v2/pipeline/backend/types"
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+)
+
+func TestPredictTimeline(t *testing.T) {
+	t.Parallel()
+
+	newItem := func(name string, steps []string, dependsOn ...string) *Item {
+		config := &backend_types.Config{}
+		for _, step := range steps {
+			config.Stages = append(config.Stages, &backend_types.Stage{Steps: []*backend_types.Step{{Name: step}}})
+		}
+		return &Item{
+			Workflow:  &model.Workflow{Name: name, State: model.StatusPending},
+			DependsOn: dependsOn,
+			Config:    config,
+		}
+	}
+	durations := map[string]int64{
+		"clone":   10,
+		"compile": 100,
+		"unit":    200,
+		"vet":     30,
+		"push":    40,
+	}
+
+	// diamond
+	schedule, err := PredictTimeline([]*Item{
+		newItem("deploy", []string{"clone", "push"}, "test", "lint"),
+		newItem("test", []string{"clone", "unit"}, "build"),
+		newItem("lint", []string{"clone", "vet"}, "build"),
+		newItem("build", []string{"clone", "compile"}),
+	}, durations)
+	assert.NoError(t, err)
+	assert.Equal(t, []WorkflowSchedule{
+		{Workflow: "build", Start: 0, End: 110},
+		{Workflow: "lint", Start: 110, End: 150},
+		{Workflow: "test", Start: 110, End: 320},
+		{Workflow: "deploy", Start: 320, End: 370},
+	}, schedule)
+
+	// steps without history use the default estimate
+	schedule, err = PredictTimeline([]*Item{
+		newItem("docs", []string{"clone", "mkdocs"}),
+	}, durations)
+	assert.NoError(t, err)
+	assert.Equal(t, []WorkflowSchedule{{Workflow: "docs", Start: 0, End: 10 + defaultStepEstimate}}, schedule)
+
+	_, err = PredictTimeline([]*Item{
+		newItem("build", nil, "test"),
+		newItem("test", nil, "build"),
+	}, durations)
+	assert.ErrorIs(t, err, &ErrDependencyCycle{})
+}
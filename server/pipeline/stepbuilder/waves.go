@@ -0,0 +1,64 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"sort"
+
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+)
+
+// ExecutionWaves groups the workflows into waves by their depends_on graph. The workflows
+// of a wave can run in parallel once all previous waves finished. Skipped workflows are
+// left out and dependencies on them or on unknown workflows don't delay a workflow.
+func ExecutionWaves(items []*Item) ([][]string, error) {
+	graph := make(map[string][]string, len(items))
+	for _, item := range items {
+		if item.Workflow.State == model.StatusSkipped {
+			continue
+		}
+		graph[item.Workflow.Name] = dependencyNames(item.DependsOn)
+	}
+	if cycle := findCycle(graph); cycle != nil {
+		return nil, &ErrDependencyCycle{Level: DependencyLevelWorkflow, Members: cycle}
+	}
+
+	var waves [][]string
+	done := make(map[string]bool, len(graph))
+	for len(done) < len(graph) {
+		var wave []string
+		for name, deps := range graph {
+			if done[name] {
+				continue
+			}
+			ready := true
+			for _, dep := range deps {
+				if _, ok := graph[dep]; ok && !done[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				wave = append(wave, name)
+			}
+		}
+		sort.Strings(wave)
+		for _, name := range wave {
+			done[name] = true
+		}
+		waves = append(waves, wave)
+	}
+	return waves, nil
+}
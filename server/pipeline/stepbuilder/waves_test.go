@@ -0,0 +1,59 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stepbuilder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+)
+
+func TestExecutionWaves(t *testing.T) {
+	t.Parallel()
+
+	newItem := func(name string, state model.StatusValue, dependsOn ...string) *Item {
+		return &Item{
+			Workflow:  &model.Workflow{Name: name, State: state},
+			DependsOn: dependsOn,
+		}
+	}
+
+	// diamond
+	waves, err := ExecutionWaves([]*Item{
+		newItem("deploy", model.StatusPending, "test", "lint"),
+		newItem("test", model.StatusPending, "build"),
+		newItem("lint", model.StatusPending, "build"),
+		newItem("build", model.StatusPending),
+		newItem("docs", model.StatusSkipped, "build"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"build"}, {"lint", "test"}, {"deploy"}}, waves)
+
+	// dependencies on skipped workflows are satisfied
+	waves, err = ExecutionWaves([]*Item{
+		newItem("build", model.StatusSkipped),
+		newItem("test", model.StatusPending, "build"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"test"}}, waves)
+
+	_, err = ExecutionWaves([]*Item{
+		newItem("build", model.StatusPending, "test"),
+		newItem("test", model.StatusPending, "build"),
+	})
+	assert.ErrorIs(t, err, &ErrDependencyCycle{})
+}
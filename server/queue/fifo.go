@@ -65,20 +65,20 @@ func New(_ context.Context) Queue {
 	}
 }
 
-// Push pushes a task to the tail of this queue.
+// Push pushes a task to the tail of the tasks with the same priority in this queue.
 func (q *fifo) Push(_ context.Context, task *model.Task) error {
 	q.Lock()
-	q.pending.PushBack(task)
+	q.pushPending(task)
 	q.Unlock()
 	go q.process()
 	return nil
 }
 
-// PushAtOnce pushes multiple tasks to the tail of this queue.
+// PushAtOnce pushes multiple tasks to the tail of the tasks with the same priority in this queue.
 func (q *fifo) PushAtOnce(_ context.Context, tasks []*model.Task) error {
 	q.Lock()
 	for _, task := range tasks {
-		q.pending.PushBack(task)
+		q.pushPending(task)
 	}
 	q.Unlock()
 	go q.process()
@@ -285,7 +285,7 @@ func (q *fifo) filterWaiting() {
 	for e := q.waitingOnDeps.Front(); e != nil; e = nextWaiting {
 		nextWaiting = e.Next()
 		task, _ := e.Value.(*model.Task)
-		q.pending.PushBack(task)
+		q.pushPending(task)
 	}
 
 	// rebuild waitingDeps
@@ -326,6 +326,18 @@ func (q *fifo) assignToWorker() (*list.Element, *worker) {
 	return nil, nil
 }
 
+// pushPending inserts the task behind all pending tasks with the same or a higher priority,
+// so tasks are ordered by priority and in order of arrival within the same priority.
+func (q *fifo) pushPending(task *model.Task) {
+	for e := q.pending.Back(); e != nil; e = e.Prev() {
+		if pending, _ := e.Value.(*model.Task); pending.Priority >= task.Priority {
+			q.pending.InsertAfter(task, e)
+			return
+		}
+	}
+	q.pending.PushFront(task)
+}
+
 func (q *fifo) resubmitExpiredPipelines() {
 	for id, state := range q.running {
 		if time.Now().After(state.deadline) {
@@ -429,6 +429,27 @@ func TestWaitingVsPending(t *testing.T) {
 	assert.Equal(t, 1, info.Stats.Pending)
 }
 
+func TestFifoPriority(t *testing.T) {
+	low := &model.Task{ID: "1", Priority: -5}
+	normal := &model.Task{ID: "2"}
+	high := &model.Task{ID: "3", Priority: 5}
+	normal2 := &model.Task{ID: "4"}
+
+	q, _ := New(context.Background()).(*fifo)
+	assert.NoError(t, q.PushAtOnce(noContext, []*model.Task{low, normal, high}))
+	assert.NoError(t, q.Push(noContext, normal2))
+
+	info := q.Info(noContext)
+	assert.Equal(t, []*model.Task{high, normal, normal2, low}, info.Pending)
+
+	for _, want := range []*model.Task{high, normal, normal2, low} {
+		got, err := q.Poll(noContext, 1, func(*model.Task) bool { return true })
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+		assert.NoError(t, q.Done(noContext, got.ID, model.StatusSuccess))
+	}
+}
+
 func TestShouldRun(t *testing.T) {
 	task := &model.Task{
 		ID:           "2",
@@ -497,4 +518,22 @@ func TestShouldRun(t *testing.T) {
 		RunOn: []string{"failure"},
 	}
 	assert.True(t, task.ShouldRun(), "on failure, tasks should run on skipped deps, something failed higher up the chain")
+
+	task = &model.Task{
+		ID:           "3",
+		Dependencies: []string{"1", "2"},
+		DepStatus: map[string]model.StatusValue{
+			"1": model.StatusFailure,
+			"2": model.StatusSuccess,
+		},
+		DepRunOn: map[string]string{"1": "failure"},
+	}
+	assert.True(t, task.ShouldRun(), "task should run if the dependency it runs on failure of failed")
+
+	task.DepStatus["1"] = model.StatusSuccess
+	assert.False(t, task.ShouldRun(), "task should not run if the dependency it runs on failure of succeeded")
+
+	task.DepRunOn["1"] = "always"
+	task.DepStatus["2"] = model.StatusFailure
+	assert.False(t, task.ShouldRun(), "dependencies without a status follow run on")
 }
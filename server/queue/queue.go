@@ -66,6 +66,33 @@ func (t *InfoT) String() string {
 // the Task is skipped and not returned to the subscriber.
 type FilterFn func(*model.Task) bool
 
+// LabelsMatch returns true if an agent with the given labels is allowed to
+// run a task with the given labels.
+func LabelsMatch(taskLabels, agentLabels map[string]string) bool {
+	for taskLabel, taskLabelValue := range taskLabels {
+		// if a task label is empty it will be ignored
+		if taskLabelValue == "" {
+			continue
+		}
+
+		agentLabelValue, ok := agentLabels[taskLabel]
+
+		if !ok {
+			return false
+		}
+
+		// if agent label has a wildcard
+		if agentLabelValue == "*" {
+			continue
+		}
+
+		if taskLabelValue != agentLabelValue {
+			return false
+		}
+	}
+	return true
+}
+
 // Queue defines a task queue for scheduling tasks among
 // a pool of workers.
 type Queue interface {
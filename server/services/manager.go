@@ -42,6 +42,7 @@ type Manager interface {
 	SecretService() secret.Service
 	RegistryServiceFromRepo(repo *model.Repo) registry.Service
 	RegistryService() registry.Service
+	RegistryCredentialProvider() registry.CredentialProvider
 	ConfigServiceFromRepo(repo *model.Repo) config.Service
 	EnvironmentService() environment.Service
 	ForgeFromRepo(repo *model.Repo) (forge.Forge, error)
@@ -55,6 +56,7 @@ type manager struct {
 	store               store.Store
 	secret              secret.Service
 	registry            registry.Service
+	registryCredentials registry.CredentialProvider
 	config              config.Service
 	environment         environment.Service
 	forgeCache          *ttlcache.Cache[int64, forge.Forge]
@@ -83,6 +85,7 @@ func NewManager(c *cli.Context, store store.Store, setupForge SetupForge) (Manag
 		store:               store,
 		secret:              setupSecretService(store),
 		registry:            setupRegistryService(store, c.String("docker-config")),
+		registryCredentials: setupRegistryCredentialProvider(c, signaturePrivateKey),
 		config:              configService,
 		environment:         environment.Parse(c.StringSlice("environment")),
 		forgeCache:          ttlcache.New(ttlcache.WithDisableTouchOnHit[int64, forge.Forge]()),
@@ -110,6 +113,11 @@ func (m *manager) RegistryService() registry.Service {
 	return m.registry
 }
 
+// RegistryCredentialProvider returns the provider of fresh credentials for token registries, nil if none is configured.
+func (m *manager) RegistryCredentialProvider() registry.CredentialProvider {
+	return m.registryCredentials
+}
+
 func (m *manager) ConfigServiceFromRepo(_ *model.Repo) config.Service {
 	// TODO: decide based on repo property which config service to use
 	return m.config
@@ -158,6 +158,26 @@ func (_m *Manager) ForgeMain() (forge.Forge, error) {
 	return r0, r1
 }
 
+// RegistryCredentialProvider provides a mock function with given fields:
+func (_m *Manager) RegistryCredentialProvider() registry.CredentialProvider {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for RegistryCredentialProvider")
+	}
+
+	var r0 registry.CredentialProvider
+	if rf, ok := ret.Get(0).(func() registry.CredentialProvider); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(registry.CredentialProvider)
+		}
+	}
+
+	return r0
+}
+
 // RegistryService provides a mock function with given fields:
 func (_m *Manager) RegistryService() registry.Service {
 	ret := _m.Called()
@@ -31,9 +31,9 @@ func NewCombined(dbRegistry Service, registries ...ReadOnlyService) Service {
 	}
 }
 
-func (c *combined) RegistryFind(repo *model.Repo, name string) (*model.Registry, error) {
+func (c *combined) RegistryFind(repo *model.Repo, name string, environments []string) (*model.Registry, error) {
 	for _, registry := range c.registries {
-		res, err := registry.RegistryFind(repo, name)
+		res, err := registry.RegistryFind(repo, name, environments)
 		if err != nil {
 			return nil, err
 		}
@@ -64,6 +64,6 @@ func (c *combined) RegistryUpdate(repo *model.Repo, registry *model.Registry) er
 	return c.dbRegistry.RegistryUpdate(repo, registry)
 }
 
-func (c *combined) RegistryDelete(repo *model.Repo, name string) error {
-	return c.dbRegistry.RegistryDelete(repo, name)
+func (c *combined) RegistryDelete(repo *model.Repo, name string, environments []string) error {
+	return c.dbRegistry.RegistryDelete(repo, name, environments)
 }
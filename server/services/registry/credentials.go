@@ -0,0 +1,70 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	net_http "net/http"
+
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+	"go.woodpecker-ci.org/woodpecker/v2/server/services/utils"
+)
+
+// CredentialProvider returns fresh credentials for registries using short-lived tokens like ECR or GCR.
+type CredentialProvider interface {
+	RegistryCredentials(ctx context.Context, repo *model.Repo, registry *model.Registry) (username, password string, err error)
+}
+
+type httpCredentials struct {
+	endpoint   string
+	privateKey crypto.PrivateKey
+}
+
+type credentialsRequest struct {
+	Repo     *model.Repo     `json:"repo"`
+	Registry *model.Registry `json:"registry"`
+}
+
+type credentialsResponse struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// NewHTTPCredentials returns a credential provider requesting the credentials from an external service.
+func NewHTTPCredentials(endpoint string, privateKey crypto.PrivateKey) CredentialProvider {
+	return &httpCredentials{endpoint, privateKey}
+}
+
+func (h *httpCredentials) RegistryCredentials(ctx context.Context, repo *model.Repo, registry *model.Registry) (string, string, error) {
+	response := new(credentialsResponse)
+	body := credentialsRequest{
+		Repo: repo,
+		// the stored password is not sent, the service has to know how to get a token on its own
+		Registry: registry.Copy(),
+	}
+
+	status, err := utils.Send(ctx, net_http.MethodPost, h.endpoint, h.privateKey, body, response)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to fetch registry credentials via http (%d) %w", status, err)
+	}
+
+	if response.Password == "" {
+		return "", "", fmt.Errorf("registry credential service returned no password for '%s'", registry.Address)
+	}
+
+	return response.Username, response.Password, nil
+}
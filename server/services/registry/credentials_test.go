@@ -0,0 +1,67 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+	"go.woodpecker-ci.org/woodpecker/v2/server/services/registry"
+)
+
+func TestHTTPCredentials(t *testing.T) {
+	_, privEd25519Key, err := ed25519.GenerateKey(rand.Reader)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var requested map[string]map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&requested))
+		if requested["registry"]["address"] == "unknown.example.com" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"username": "AWS", "password": "fresh-token"}`))
+	}))
+	defer server.Close()
+
+	provider := registry.NewHTTPCredentials(server.URL, privEd25519Key)
+	repo := &model.Repo{ID: 1, FullName: "octocat/hello-world"}
+
+	username, password, err := provider.RegistryCredentials(context.Background(), repo, &model.Registry{
+		Address:   "123456789.dkr.ecr.eu-central-1.amazonaws.com",
+		Username:  "AWS",
+		Password:  "stale-token",
+		TokenAuth: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "AWS", username)
+	assert.Equal(t, "fresh-token", password)
+	assert.Equal(t, "octocat/hello-world", requested["repo"]["full_name"])
+	assert.Equal(t, "123456789.dkr.ecr.eu-central-1.amazonaws.com", requested["registry"]["address"])
+	assert.Empty(t, requested["registry"]["password"])
+
+	_, _, err = provider.RegistryCredentials(context.Background(), repo, &model.Registry{Address: "unknown.example.com"})
+	assert.Error(t, err)
+}
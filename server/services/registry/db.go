@@ -28,8 +28,8 @@ func NewDB(store store.Store) Service {
 	return &db{store}
 }
 
-func (d *db) RegistryFind(repo *model.Repo, name string) (*model.Registry, error) {
-	return d.store.RegistryFind(repo, name)
+func (d *db) RegistryFind(repo *model.Repo, name string, environments []string) (*model.Registry, error) {
+	return d.store.RegistryFind(repo, name, environments)
 }
 
 func (d *db) RegistryList(repo *model.Repo, p *model.ListOptions) ([]*model.Registry, error) {
@@ -44,6 +44,6 @@ func (d *db) RegistryUpdate(_ *model.Repo, in *model.Registry) error {
 	return d.store.RegistryUpdate(in)
 }
 
-func (d *db) RegistryDelete(repo *model.Repo, addr string) error {
-	return d.store.RegistryDelete(repo, addr)
+func (d *db) RegistryDelete(repo *model.Repo, addr string, environments []string) error {
+	return d.store.RegistryDelete(repo, addr, environments)
 }
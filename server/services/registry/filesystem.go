@@ -85,7 +85,7 @@ func parseDockerConfig(path string) ([]*model.Registry, error) {
 	return registries, nil
 }
 
-func (f *filesystem) RegistryFind(*model.Repo, string) (*model.Registry, error) {
+func (f *filesystem) RegistryFind(*model.Repo, string, []string) (*model.Registry, error) {
 	return nil, nil
 }
 
@@ -57,6 +57,13 @@ func setupSecretService(store store.Store) secret.Service {
 	return secret.NewDB(store)
 }
 
+func setupRegistryCredentialProvider(c *cli.Context, privateSignatureKey crypto.PrivateKey) registry.CredentialProvider {
+	if endpoint := c.String("registry-credentials-endpoint"); endpoint != "" {
+		return registry.NewHTTPCredentials(endpoint, privateSignatureKey)
+	}
+	return nil
+}
+
 func setupConfigService(c *cli.Context, privateSignatureKey crypto.PrivateKey) (config.Service, error) {
 	timeout := c.Duration("forge-timeout")
 	retries := c.Uint("forge-retry")
@@ -15,7 +15,6 @@
 package datastore
 
 import (
-	"crypto/sha256"
 	"errors"
 	"fmt"
 
@@ -46,7 +45,7 @@ func (s storage) configFindIdentical(sess *xorm.Session, repoID int64, hash, nam
 }
 
 func (s storage) ConfigPersist(conf *model.Config) (*model.Config, error) {
-	conf.Hash = fmt.Sprintf("%x", sha256.Sum256(conf.Data))
+	conf.Hash = model.ConfigHash(conf.Data)
 
 	sess := s.engine.NewSession()
 	defer sess.Close()
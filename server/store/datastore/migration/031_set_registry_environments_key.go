@@ -0,0 +1,57 @@
+// Copyright 2024 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+
+	"go.woodpecker-ci.org/woodpecker/v2/server/model"
+)
+
+type registry031 struct {
+	ID              int64    `xorm:"pk autoincr 'registry_id'"`
+	RepoID          int64    `xorm:"UNIQUE(s) INDEX 'registry_repo_id'"`
+	Address         string   `xorm:"UNIQUE(s) INDEX 'registry_addr'"`
+	Environments    []string `xorm:"json 'registry_environments'"`
+	EnvironmentsKey string   `xorm:"UNIQUE(s) INDEX 'registry_environments_key'"`
+}
+
+func (registry031) TableName() string {
+	return "registry"
+}
+
+var setRegistryEnvironmentsKey = xormigrate.Migration{
+	ID: "set-registry-environments-key",
+	MigrateSession: func(sess *xorm.Session) (err error) {
+		if err := sess.Sync(new(registry031)); err != nil {
+			return err
+		}
+
+		var registries []*registry031
+		if err := sess.Find(&registries); err != nil {
+			return err
+		}
+
+		for _, registry := range registries {
+			registry.EnvironmentsKey = model.RegistryEnvironmentsKey(registry.Environments)
+			if _, err := sess.ID(registry.ID).Cols("registry_environments_key").Update(registry); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+}
@@ -60,6 +60,7 @@ var migrationTasks = []*xormigrate.Migration{
 	&renameLinkToURL,
 	&cleanRegistryPipeline,
 	&setForgeID,
+	&setRegistryEnvironmentsKey,
 }
 
 var allBeans = []any{
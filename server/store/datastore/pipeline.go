@@ -82,6 +82,15 @@ func (s storage) GetActivePipelineList(repo *model.Repo) ([]*model.Pipeline, err
 	return pipelines, query.Find(&pipelines)
 }
 
+// GetTimeoutPipelineList get all pipelines with a timeout that are pending or running.
+func (s storage) GetTimeoutPipelineList() ([]*model.Pipeline, error) {
+	pipelines := make([]*model.Pipeline, 0)
+	query := s.engine.
+		Where("pipeline_timeout > 0").
+		In("pipeline_status", model.StatusPending, model.StatusRunning)
+	return pipelines, query.Find(&pipelines)
+}
+
 func (s storage) GetPipelineCount() (int64, error) {
 	return s.engine.Count(new(model.Pipeline))
 }
@@ -20,10 +20,10 @@ import (
 	"go.woodpecker-ci.org/woodpecker/v2/server/model"
 )
 
-func (s storage) RegistryFind(repo *model.Repo, addr string) (*model.Registry, error) {
+func (s storage) RegistryFind(repo *model.Repo, addr string, environments []string) (*model.Registry, error) {
 	reg := new(model.Registry)
 	return reg, wrapGet(s.engine.Where(
-		builder.Eq{"registry_repo_id": repo.ID, "registry_addr": addr},
+		builder.Eq{"registry_repo_id": repo.ID, "registry_addr": addr, "registry_environments_key": model.RegistryEnvironmentsKey(environments)},
 	).Get(reg))
 }
 
@@ -33,18 +33,20 @@ func (s storage) RegistryList(repo *model.Repo, p *model.ListOptions) ([]*model.
 }
 
 func (s storage) RegistryCreate(registry *model.Registry) error {
+	registry.EnvironmentsKey = model.RegistryEnvironmentsKey(registry.Environments)
 	// only Insert set auto created ID back to object
 	_, err := s.engine.Insert(registry)
 	return err
 }
 
 func (s storage) RegistryUpdate(registry *model.Registry) error {
+	registry.EnvironmentsKey = model.RegistryEnvironmentsKey(registry.Environments)
 	_, err := s.engine.ID(registry.ID).AllCols().Update(registry)
 	return err
 }
 
-func (s storage) RegistryDelete(repo *model.Repo, addr string) error {
-	registry, err := s.RegistryFind(repo, addr)
+func (s storage) RegistryDelete(repo *model.Repo, addr string, environments []string) error {
+	registry, err := s.RegistryFind(repo, addr, environments)
 	if err != nil {
 		return err
 	}
@@ -35,7 +35,7 @@ func TestRegistryFind(t *testing.T) {
 	})
 	assert.NoError(t, err)
 
-	registry, err := store.RegistryFind(&model.Repo{ID: 1}, "index.docker.io")
+	registry, err := store.RegistryFind(&model.Repo{ID: 1}, "index.docker.io", nil)
 	assert.NoError(t, err)
 	assert.EqualValues(t, 1, registry.RepoID)
 	assert.Equal(t, "index.docker.io", registry.Address)
@@ -78,7 +78,7 @@ func TestRegistryUpdate(t *testing.T) {
 	assert.NoError(t, store.RegistryCreate(registry))
 	registry.Password = "qux"
 	assert.NoError(t, store.RegistryUpdate(registry))
-	updated, err := store.RegistryFind(&model.Repo{ID: 1}, "index.docker.io")
+	updated, err := store.RegistryFind(&model.Repo{ID: 1}, "index.docker.io", nil)
 	assert.NoError(t, err)
 	assert.Equal(t, "qux", updated.Password)
 }
@@ -101,6 +101,28 @@ func TestRegistryIndexes(t *testing.T) {
 		Username: "baz",
 		Password: "qux",
 	}))
+
+	// succeed for the same addr limited to environments
+	assert.NoError(t, store.RegistryCreate(&model.Registry{
+		RepoID:       1,
+		Address:      "index.docker.io",
+		Username:     "baz",
+		Password:     "qux",
+		Environments: []string{"production", "staging"},
+	}))
+
+	// fail due to duplicate addr and environments
+	assert.Error(t, store.RegistryCreate(&model.Registry{
+		RepoID:       1,
+		Address:      "index.docker.io",
+		Username:     "baz",
+		Password:     "qux",
+		Environments: []string{"staging", "production"},
+	}))
+
+	registry, err := store.RegistryFind(&model.Repo{ID: 1}, "index.docker.io", []string{"staging", "production"})
+	assert.NoError(t, err)
+	assert.Equal(t, "baz", registry.Username)
 }
 
 func TestRegistryDelete(t *testing.T) {
@@ -117,6 +139,6 @@ func TestRegistryDelete(t *testing.T) {
 		return
 	}
 
-	assert.NoError(t, store.RegistryDelete(&model.Repo{ID: 1}, "index.docker.io"))
-	assert.ErrorIs(t, store.RegistryDelete(&model.Repo{ID: 1}, "index.docker.io"), types.RecordNotExist)
+	assert.NoError(t, store.RegistryDelete(&model.Repo{ID: 1}, "index.docker.io", nil))
+	assert.ErrorIs(t, store.RegistryDelete(&model.Repo{ID: 1}, "index.docker.io", nil), types.RecordNotExist)
 }
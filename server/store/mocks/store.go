@@ -1042,6 +1042,36 @@ func (_m *Store) GetRepoNameFallback(remoteID model.ForgeRemoteID, fullName stri
 	return r0, r1
 }
 
+// GetTimeoutPipelineList provides a mock function with given fields:
+func (_m *Store) GetTimeoutPipelineList() ([]*model.Pipeline, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTimeoutPipelineList")
+	}
+
+	var r0 []*model.Pipeline
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]*model.Pipeline, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []*model.Pipeline); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Pipeline)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUser provides a mock function with given fields: _a0
 func (_m *Store) GetUser(_a0 int64) (*model.User, error) {
 	ret := _m.Called(_a0)
@@ -1698,17 +1728,17 @@ func (_m *Store) RegistryCreate(_a0 *model.Registry) error {
 	return r0
 }
 
-// RegistryDelete provides a mock function with given fields: repo, addr
-func (_m *Store) RegistryDelete(repo *model.Repo, addr string) error {
-	ret := _m.Called(repo, addr)
+// RegistryDelete provides a mock function with given fields: repo, addr, environments
+func (_m *Store) RegistryDelete(repo *model.Repo, addr string, environments []string) error {
+	ret := _m.Called(repo, addr, environments)
 
 	if len(ret) == 0 {
 		panic("no return value specified for RegistryDelete")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*model.Repo, string) error); ok {
-		r0 = rf(repo, addr)
+	if rf, ok := ret.Get(0).(func(*model.Repo, string, []string) error); ok {
+		r0 = rf(repo, addr, environments)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -1716,9 +1746,9 @@ func (_m *Store) RegistryDelete(repo *model.Repo, addr string) error {
 	return r0
 }
 
-// RegistryFind provides a mock function with given fields: _a0, _a1
-func (_m *Store) RegistryFind(_a0 *model.Repo, _a1 string) (*model.Registry, error) {
-	ret := _m.Called(_a0, _a1)
+// RegistryFind provides a mock function with given fields: repo, addr, environments
+func (_m *Store) RegistryFind(repo *model.Repo, addr string, environments []string) (*model.Registry, error) {
+	ret := _m.Called(repo, addr, environments)
 
 	if len(ret) == 0 {
 		panic("no return value specified for RegistryFind")
@@ -1726,19 +1756,19 @@ func (_m *Store) RegistryFind(_a0 *model.Repo, _a1 string) (*model.Registry, err
 
 	var r0 *model.Registry
 	var r1 error
-	if rf, ok := ret.Get(0).(func(*model.Repo, string) (*model.Registry, error)); ok {
-		return rf(_a0, _a1)
+	if rf, ok := ret.Get(0).(func(*model.Repo, string, []string) (*model.Registry, error)); ok {
+		return rf(repo, addr, environments)
 	}
-	if rf, ok := ret.Get(0).(func(*model.Repo, string) *model.Registry); ok {
-		r0 = rf(_a0, _a1)
+	if rf, ok := ret.Get(0).(func(*model.Repo, string, []string) *model.Registry); ok {
+		r0 = rf(repo, addr, environments)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.Registry)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(*model.Repo, string) error); ok {
-		r1 = rf(_a0, _a1)
+	if rf, ok := ret.Get(1).(func(*model.Repo, string, []string) error); ok {
+		r1 = rf(repo, addr, environments)
 	} else {
 		r1 = ret.Error(1)
 	}
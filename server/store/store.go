@@ -78,6 +78,8 @@ type Store interface {
 	GetPipelineList(*model.Repo, *model.ListOptions, *model.PipelineFilter) ([]*model.Pipeline, error)
 	// GetActivePipelineList gets a list of the active pipelines for the repository
 	GetActivePipelineList(repo *model.Repo) ([]*model.Pipeline, error)
+	// GetTimeoutPipelineList gets the pending and running pipelines with a timeout of all repositories
+	GetTimeoutPipelineList() ([]*model.Pipeline, error)
 	// GetPipelineQueue gets a list of pipelines in queue.
 	GetPipelineQueue() ([]*model.Feed, error)
 	// GetPipelineCount gets a count of all pipelines in the system.
@@ -119,11 +121,11 @@ type Store interface {
 	GlobalSecretList(*model.ListOptions) ([]*model.Secret, error)
 
 	// Registries
-	RegistryFind(*model.Repo, string) (*model.Registry, error)
+	RegistryFind(repo *model.Repo, addr string, environments []string) (*model.Registry, error)
 	RegistryList(*model.Repo, *model.ListOptions) ([]*model.Registry, error)
 	RegistryCreate(*model.Registry) error
 	RegistryUpdate(*model.Registry) error
-	RegistryDelete(repo *model.Repo, addr string) error
+	RegistryDelete(repo *model.Repo, addr string, environments []string) error
 
 	// Steps
 	StepLoad(int64) (*model.Step, error)
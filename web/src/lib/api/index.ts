@@ -178,11 +178,13 @@ export default class WoodpeckerClient extends ApiClient {
   }
 
   updateRegistry(repoId: number, registry: Partial<Registry>): Promise<unknown> {
-    return this._patch(`/api/repos/${repoId}/registry/${registry.address}`, registry);
+    const query = encodeRegistryEnvironments(registry.environments);
+    return this._patch(`/api/repos/${repoId}/registry/${registry.address}?${query}`, registry);
   }
 
-  deleteRegistry(repoId: number, registryAddress: string): Promise<unknown> {
-    return this._delete(`/api/repos/${repoId}/registry/${registryAddress}`);
+  deleteRegistry(repoId: number, registryAddress: string, environments?: string[]): Promise<unknown> {
+    const query = encodeRegistryEnvironments(environments);
+    return this._delete(`/api/repos/${repoId}/registry/${registryAddress}?${query}`);
   }
 
   getCronList(repoId: number, opts?: PaginationOptions): Promise<Cron[] | null> {
@@ -380,3 +382,8 @@ export default class WoodpeckerClient extends ApiClient {
     });
   }
 }
+
+// registries are identified by their address and the environments they are limited to
+function encodeRegistryEnvironments(environments: string[] = []): string {
+  return new URLSearchParams(environments.map((environment) => ['environments', environment])).toString();
+}
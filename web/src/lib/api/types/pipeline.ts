@@ -89,6 +89,9 @@ export interface Pipeline {
   workflows?: PipelineWorkflow[];
 
   changed_files?: string[];
+
+  // Whether the forge can't provide the changed files
+  changed_files_unknown?: boolean;
 }
 
 export type PipelineStatus =
@@ -129,6 +132,7 @@ export interface PipelineStep {
   start_time?: number;
   end_time?: number;
   error?: string;
+  failure_message?: string;
   type?: StepType;
 }
 
@@ -73,6 +73,9 @@ export interface Repo {
   cancel_previous_pipeline_events: string[];
 
   netrc_only_trusted: boolean;
+
+  // Image of the default clone step, overrides the default of the server.
+  clone_image: string;
 }
 
 /* eslint-disable no-unused-vars */
@@ -94,6 +97,7 @@ export type RepoSettings = Pick<
   | 'allow_deploy'
   | 'cancel_previous_pipeline_events'
   | 'netrc_only_trusted'
+  | 'clone_image'
 >;
 
 export interface RepoPermissions {
@@ -89,6 +89,10 @@ type Client interface {
 	// the specified repository.
 	PipelineList(repoID int64) ([]*Pipeline, error)
 
+	// PipelineListOpts returns a page of the pipelines of the specified
+	// repository, created within the optional time range.
+	PipelineListOpts(repoID int64, opt PipelineListOptions) ([]*Pipeline, error)
+
 	// PipelineQueue returns a list of enqueued pipelines.
 	PipelineQueue() ([]*Feed, error)
 
@@ -123,8 +127,9 @@ type Client interface {
 	// StepLogsPurge purges the pipeline logs for the specified step.
 	StepLogsPurge(repoID, pipelineNumber, stepID int64) error
 
-	// Registry returns a registry by hostname.
-	Registry(repoID int64, hostname string) (*Registry, error)
+	// Registry returns a registry by hostname and the environments it is
+	// limited to.
+	Registry(repoID int64, hostname string, environments ...string) (*Registry, error)
 
 	// RegistryList returns a list of all repository registries.
 	RegistryList(repoID int64) ([]*Registry, error)
@@ -135,8 +140,9 @@ type Client interface {
 	// RegistryUpdate updates a registry.
 	RegistryUpdate(repoID int64, registry *Registry) (*Registry, error)
 
-	// RegistryDelete deletes a registry.
-	RegistryDelete(repoID int64, hostname string) error
+	// RegistryDelete deletes a registry by hostname and the environments it is
+	// limited to.
+	RegistryDelete(repoID int64, hostname string, environments ...string) error
 
 	// Secret returns a secret by name.
 	Secret(repoID int64, secret string) (*Secret, error)
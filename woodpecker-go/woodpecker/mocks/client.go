@@ -935,6 +935,36 @@ func (_m *Client) PipelineList(repoID int64) ([]*woodpecker.Pipeline, error) {
 	return r0, r1
 }
 
+// PipelineListOpts provides a mock function with given fields: repoID, opt
+func (_m *Client) PipelineListOpts(repoID int64, opt woodpecker.PipelineListOptions) ([]*woodpecker.Pipeline, error) {
+	ret := _m.Called(repoID, opt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PipelineListOpts")
+	}
+
+	var r0 []*woodpecker.Pipeline
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64, woodpecker.PipelineListOptions) ([]*woodpecker.Pipeline, error)); ok {
+		return rf(repoID, opt)
+	}
+	if rf, ok := ret.Get(0).(func(int64, woodpecker.PipelineListOptions) []*woodpecker.Pipeline); ok {
+		r0 = rf(repoID, opt)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*woodpecker.Pipeline)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, woodpecker.PipelineListOptions) error); ok {
+		r1 = rf(repoID, opt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // PipelineQueue provides a mock function with given fields:
 func (_m *Client) PipelineQueue() ([]*woodpecker.Feed, error) {
 	ret := _m.Called()
@@ -1043,9 +1073,16 @@ func (_m *Client) QueueInfo() (*woodpecker.Info, error) {
 	return r0, r1
 }
 
-// Registry provides a mock function with given fields: repoID, hostname
-func (_m *Client) Registry(repoID int64, hostname string) (*woodpecker.Registry, error) {
-	ret := _m.Called(repoID, hostname)
+// Registry provides a mock function with given fields: repoID, hostname, environments
+func (_m *Client) Registry(repoID int64, hostname string, environments ...string) (*woodpecker.Registry, error) {
+	_va := make([]interface{}, len(environments))
+	for _i := range environments {
+		_va[_i] = environments[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, repoID, hostname)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Registry")
@@ -1053,19 +1090,19 @@ func (_m *Client) Registry(repoID int64, hostname string) (*woodpecker.Registry,
 
 	var r0 *woodpecker.Registry
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64, string) (*woodpecker.Registry, error)); ok {
-		return rf(repoID, hostname)
+	if rf, ok := ret.Get(0).(func(int64, string, ...string) (*woodpecker.Registry, error)); ok {
+		return rf(repoID, hostname, environments...)
 	}
-	if rf, ok := ret.Get(0).(func(int64, string) *woodpecker.Registry); ok {
-		r0 = rf(repoID, hostname)
+	if rf, ok := ret.Get(0).(func(int64, string, ...string) *woodpecker.Registry); ok {
+		r0 = rf(repoID, hostname, environments...)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*woodpecker.Registry)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int64, string) error); ok {
-		r1 = rf(repoID, hostname)
+	if rf, ok := ret.Get(1).(func(int64, string, ...string) error); ok {
+		r1 = rf(repoID, hostname, environments...)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1103,17 +1140,24 @@ func (_m *Client) RegistryCreate(repoID int64, registry *woodpecker.Registry) (*
 	return r0, r1
 }
 
-// RegistryDelete provides a mock function with given fields: repoID, hostname
-func (_m *Client) RegistryDelete(repoID int64, hostname string) error {
-	ret := _m.Called(repoID, hostname)
+// RegistryDelete provides a mock function with given fields: repoID, hostname, environments
+func (_m *Client) RegistryDelete(repoID int64, hostname string, environments ...string) error {
+	_va := make([]interface{}, len(environments))
+	for _i := range environments {
+		_va[_i] = environments[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, repoID, hostname)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	if len(ret) == 0 {
 		panic("no return value specified for RegistryDelete")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int64, string) error); ok {
-		r0 = rf(repoID, hostname)
+	if rf, ok := ret.Get(0).(func(int64, string, ...string) error); ok {
+		r0 = rf(repoID, hostname, environments...)
 	} else {
 		r0 = ret.Error(0)
 	}
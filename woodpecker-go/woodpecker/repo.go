@@ -1,6 +1,11 @@
 package woodpecker
 
-import "fmt"
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
 
 const (
 	pathRepoPost       = "%s/api/repos?forge_remote_id=%d"
@@ -84,9 +89,9 @@ func (c *client) RepoMove(repoID int64, newFullName string) error {
 }
 
 // Registry returns a registry by hostname.
-func (c *client) Registry(repoID int64, hostname string) (*Registry, error) {
+func (c *client) Registry(repoID int64, hostname string, environments ...string) (*Registry, error) {
 	out := new(Registry)
-	uri := fmt.Sprintf(pathRepoRegistry, c.addr, repoID, hostname)
+	uri := c.registryURI(repoID, hostname, environments)
 	err := c.get(uri, out)
 	return out, err
 }
@@ -110,17 +115,27 @@ func (c *client) RegistryCreate(repoID int64, in *Registry) (*Registry, error) {
 // RegistryUpdate updates a registry.
 func (c *client) RegistryUpdate(repoID int64, in *Registry) (*Registry, error) {
 	out := new(Registry)
-	uri := fmt.Sprintf(pathRepoRegistry, c.addr, repoID, in.Address)
+	uri := c.registryURI(repoID, in.Address, in.Environments)
 	err := c.patch(uri, in, out)
 	return out, err
 }
 
 // RegistryDelete deletes a registry.
-func (c *client) RegistryDelete(repoID int64, hostname string) error {
-	uri := fmt.Sprintf(pathRepoRegistry, c.addr, repoID, hostname)
+func (c *client) RegistryDelete(repoID int64, hostname string, environments ...string) error {
+	uri := c.registryURI(repoID, hostname, environments)
 	return c.delete(uri)
 }
 
+// registryURI returns the path of a registry, selecting the entry limited to
+// the given environments when there are any.
+func (c *client) registryURI(repoID int64, hostname string, environments []string) string {
+	uri := fmt.Sprintf(pathRepoRegistry, c.addr, repoID, hostname)
+	if len(environments) > 0 {
+		uri += "?" + url.Values{"environments": environments}.Encode()
+	}
+	return uri
+}
+
 // Secret returns a secret by name.
 func (c *client) Secret(repoID int64, secret string) (*Secret, error) {
 	out := new(Secret)
@@ -222,6 +237,31 @@ func (c *client) PipelineList(repoID int64) ([]*Pipeline, error) {
 	return out, err
 }
 
+// PipelineListOpts returns a page of the pipelines of the specified
+// repository, created within the optional time range.
+func (c *client) PipelineListOpts(repoID int64, opt PipelineListOptions) ([]*Pipeline, error) {
+	var out []*Pipeline
+	val := url.Values{}
+	if opt.Page > 0 {
+		val.Set("page", strconv.Itoa(opt.Page))
+	}
+	if opt.PerPage > 0 {
+		val.Set("perPage", strconv.Itoa(opt.PerPage))
+	}
+	if !opt.Before.IsZero() {
+		val.Set("before", opt.Before.Format(time.RFC3339))
+	}
+	if !opt.After.IsZero() {
+		val.Set("after", opt.After.Format(time.RFC3339))
+	}
+	uri := fmt.Sprintf(pathPipelines, c.addr, repoID)
+	if len(val) > 0 {
+		uri += "?" + val.Encode()
+	}
+	err := c.get(uri, &out)
+	return out, err
+}
+
 // PipelineCreate creates a new pipeline for the specified repository.
 func (c *client) PipelineCreate(repoID int64, options *PipelineOptions) (*Pipeline, error) {
 	var out *Pipeline
@@ -0,0 +1,71 @@
+package woodpecker
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_PipelineListOpts(t *testing.T) {
+	tests := []struct {
+		name     string
+		opt      PipelineListOptions
+		query    string
+		expected []*Pipeline
+		wantErr  bool
+	}{
+		{
+			name:     "no options",
+			opt:      PipelineListOptions{},
+			query:    "",
+			expected: []*Pipeline{{ID: 2, Number: 2}, {ID: 1, Number: 1}},
+		},
+		{
+			name: "page and time range",
+			opt: PipelineListOptions{
+				Page:    2,
+				PerPage: 10,
+				Before:  time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+				After:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+			query:    "after=2024-01-01T00%3A00%3A00Z&before=2024-02-01T00%3A00%3A00Z&page=2&perPage=10",
+			expected: []*Pipeline{{ID: 2, Number: 2}, {ID: 1, Number: 1}},
+		},
+		{
+			name:    "server error",
+			opt:     PipelineListOptions{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.wantErr {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				assert.Equal(t, "/api/repos/1/pipelines", r.URL.Path)
+				assert.Equal(t, tt.query, r.URL.RawQuery)
+				_, err := fmt.Fprint(w, `[{"id":2,"number":2},{"id":1,"number":1}]`)
+				assert.NoError(t, err)
+			}))
+			defer ts.Close()
+
+			client := NewClient(ts.URL, http.DefaultClient)
+			pipelines, err := client.PipelineListOpts(1, tt.opt)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, pipelines)
+		})
+	}
+}
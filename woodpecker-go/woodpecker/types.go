@@ -14,6 +14,8 @@
 
 package woodpecker
 
+import "time"
+
 type (
 	// User represents a user account.
 	User struct {
@@ -47,6 +49,7 @@ type (
 		Config                       string   `json:"config_file"`
 		CancelPreviousPipelineEvents []string `json:"cancel_previous_pipeline_events"`
 		NetrcOnlyTrusted             bool     `json:"netrc_only_trusted"`
+		CloneImage                   string   `json:"clone_image"`
 	}
 
 	// RepoPatch defines a repository patch request.
@@ -58,6 +61,7 @@ type (
 		Visibility      *string `json:"visibility"`
 		AllowPull       *bool   `json:"allow_pr,omitempty"`
 		PipelineCounter *int    `json:"pipeline_counter,omitempty"`
+		CloneImage      *string `json:"clone_image,omitempty"`
 	}
 
 	PipelineError struct {
@@ -117,24 +121,27 @@ type (
 
 	// Step represents a process in the pipeline.
 	Step struct {
-		ID       int64    `json:"id"`
-		PID      int      `json:"pid"`
-		PPID     int      `json:"ppid"`
-		Name     string   `json:"name"`
-		State    string   `json:"state"`
-		Error    string   `json:"error,omitempty"`
-		ExitCode int      `json:"exit_code"`
-		Started  int64    `json:"start_time,omitempty"`
-		Stopped  int64    `json:"end_time,omitempty"`
-		Type     StepType `json:"type,omitempty"`
+		ID             int64    `json:"id"`
+		PID            int      `json:"pid"`
+		PPID           int      `json:"ppid"`
+		Name           string   `json:"name"`
+		State          string   `json:"state"`
+		Error          string   `json:"error,omitempty"`
+		ExitCode       int      `json:"exit_code"`
+		Started        int64    `json:"start_time,omitempty"`
+		Stopped        int64    `json:"end_time,omitempty"`
+		Type           StepType `json:"type,omitempty"`
+		FailureMessage string   `json:"failure_message,omitempty"`
 	}
 
 	// Registry represents a docker registry with credentials.
 	Registry struct {
-		ID       int64  `json:"id"`
-		Address  string `json:"address"`
-		Username string `json:"username"`
-		Password string `json:"password,omitempty"`
+		ID           int64    `json:"id"`
+		Address      string   `json:"address"`
+		Username     string   `json:"username"`
+		Password     string   `json:"password,omitempty"`
+		Environments []string `json:"environments,omitempty"`
+		TokenAuth    *bool    `json:"token_auth,omitempty"`
 		// Deprecated
 		Email string `json:"email"` // TODO: remove in 3.x
 		// Deprecated
@@ -143,13 +150,15 @@ type (
 
 	// Secret represents a secret variable, such as a password or token.
 	Secret struct {
-		ID     int64    `json:"id"`
-		OrgID  int64    `json:"org_id"`
-		RepoID int64    `json:"repo_id"`
-		Name   string   `json:"name"`
-		Value  string   `json:"value,omitempty"`
-		Images []string `json:"images"`
-		Events []string `json:"events"`
+		ID       int64    `json:"id"`
+		OrgID    int64    `json:"org_id"`
+		RepoID   int64    `json:"repo_id"`
+		Name     string   `json:"name"`
+		Value    string   `json:"value,omitempty"`
+		Images   []string `json:"images"`
+		Events   []string `json:"events"`
+		Required *bool    `json:"required,omitempty"`
+		File     *bool    `json:"file,omitempty"`
 	}
 
 	// Feed represents an item in the user's feed or timeline.
@@ -239,6 +248,15 @@ type (
 	PipelineOptions struct {
 		Branch    string            `json:"branch"`
 		Variables map[string]string `json:"variables"`
+		TraceID   string            `json:"trace_id,omitempty"`
+	}
+
+	// PipelineListOptions selects the page and filters of a pipeline list.
+	PipelineListOptions struct {
+		Page    int
+		PerPage int
+		Before  time.Time
+		After   time.Time
 	}
 
 	// Agent is the JSON data for an agent.